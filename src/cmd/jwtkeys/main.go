@@ -0,0 +1,207 @@
+// Comando jwtkeys gera e rotaciona as chaves de assinatura dos tokens JWT.
+//
+// Uso:
+//
+//	jwtkeys generate [-file arquivo] [-alg EdDSA|RS256] [-force]
+//	jwtkeys rotate   [-file arquivo] [-alg EdDSA|RS256] [-stage]
+//	jwtkeys promote  [-file arquivo] -kid ID
+//	jwtkeys prune    [-file arquivo] [-retention duração]
+//	jwtkeys list     [-file arquivo]
+//
+// Em implantações com várias instâncias, a rotação segura é feita em duas etapas:
+// "rotate -stage" publica a nova chave no JWKS sem usá-la para assinar e, depois que
+// todas as instâncias e verificadores a conhecem, "promote" passa a assinar com ela.
+// As chaves aposentadas continuam válidas para verificação até o "prune".
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"time"
+
+	"github.com/Barba2k2/aurora_backend/src/utils"
+)
+
+func main() {
+	log.SetFlags(0)
+
+	if len(os.Args) < 2 {
+		usage()
+		os.Exit(2)
+	}
+
+	var err error
+	switch os.Args[1] {
+	case "generate":
+		err = generate(os.Args[2:])
+	case "rotate":
+		err = rotate(os.Args[2:])
+	case "promote":
+		err = promote(os.Args[2:])
+	case "prune":
+		err = prune(os.Args[2:])
+	case "list":
+		err = list(os.Args[2:])
+	default:
+		usage()
+		os.Exit(2)
+	}
+
+	if err != nil {
+		log.Fatalf("jwtkeys %s: %v", os.Args[1], err)
+	}
+}
+
+// usage exibe os subcomandos disponiveis
+func usage() {
+	fmt.Fprintln(os.Stderr, "uso: jwtkeys <generate|rotate|promote|prune|list> [opções]")
+}
+
+// newFlagSet cria o conjunto de opcoes de um subcomando com a opcao comum do arquivo
+func newFlagSet(name string) (*flag.FlagSet, *string) {
+	fs := flag.NewFlagSet(name, flag.ExitOnError)
+	defaultFile := os.Getenv("JWT_KEYS_FILE")
+	if defaultFile == "" {
+		defaultFile = "jwt_keys.json"
+	}
+	file := fs.String("file", defaultFile, "arquivo do chaveiro (padrão: $JWT_KEYS_FILE)")
+	return fs, file
+}
+
+// generate cria um novo chaveiro com uma unica chave de assinatura
+func generate(args []string) error {
+	fs, file := newFlagSet("generate")
+	alg := fs.String("alg", utils.JWTAlgorithmEdDSA, "algoritmo da chave (EdDSA ou RS256)")
+	force := fs.Bool("force", false, "sobrescreve um chaveiro existente")
+	fs.Parse(args)
+
+	if _, err := os.Stat(*file); err == nil && !*force {
+		return fmt.Errorf("%s já existe; use rotate para adicionar uma chave ou -force para sobrescrever", *file)
+	}
+
+	ring, err := utils.NewJWTKeyRing(*alg)
+	if err != nil {
+		return err
+	}
+
+	if err := ring.Save(*file); err != nil {
+		return err
+	}
+
+	log.Printf("Chaveiro criado em %s com a chave %s (%s)", *file, ring.SigningKeyID, *alg)
+	return nil
+}
+
+// rotate adiciona uma nova chave e, a menos que -stage seja usado, passa a assinar com ela
+func rotate(args []string) error {
+	fs, file := newFlagSet("rotate")
+	alg := fs.String("alg", utils.JWTAlgorithmEdDSA, "algoritmo da nova chave (EdDSA ou RS256)")
+	stage := fs.Bool("stage", false, "apenas publica a nova chave, sem usá-la para assinar")
+	fs.Parse(args)
+
+	ring, err := utils.LoadJWTKeyRing(*file)
+	if err != nil {
+		return err
+	}
+
+	key, err := utils.GenerateJWTKey(*alg)
+	if err != nil {
+		return err
+	}
+	ring.Add(key)
+
+	if !*stage {
+		if err := ring.Promote(key.ID); err != nil {
+			return err
+		}
+	}
+
+	if err := ring.Save(*file); err != nil {
+		return err
+	}
+
+	if *stage {
+		log.Printf("Chave %s (%s) publicada; use promote -kid %s para assinar com ela", key.ID, *alg, key.ID)
+	} else {
+		log.Printf("Chave %s (%s) passou a assinar os tokens", key.ID, *alg)
+	}
+	return nil
+}
+
+// promote passa a assinar com uma chave ja publicada
+func promote(args []string) error {
+	fs, file := newFlagSet("promote")
+	kid := fs.String("kid", "", "ID da chave")
+	fs.Parse(args)
+
+	if *kid == "" {
+		return errors.New("-kid é obrigatório")
+	}
+
+	ring, err := utils.LoadJWTKeyRing(*file)
+	if err != nil {
+		return err
+	}
+
+	if err := ring.Promote(*kid); err != nil {
+		return err
+	}
+
+	if err := ring.Save(*file); err != nil {
+		return err
+	}
+
+	log.Printf("Chave %s passou a assinar os tokens", *kid)
+	return nil
+}
+
+// prune remove as chaves aposentadas ha mais tempo que a retencao
+func prune(args []string) error {
+	fs, file := newFlagSet("prune")
+	// Por padrao mantemos as chaves enquanto houver refresh tokens assinados com elas
+	retention := fs.Duration("retention", utils.TokenExpirationRefresh, "tempo mínimo que uma chave aposentada continua válida")
+	fs.Parse(args)
+
+	ring, err := utils.LoadJWTKeyRing(*file)
+	if err != nil {
+		return err
+	}
+
+	removed := ring.Prune(*retention)
+	if removed == 0 {
+		log.Println("Nenhuma chave removida")
+		return nil
+	}
+
+	if err := ring.Save(*file); err != nil {
+		return err
+	}
+
+	log.Printf("%d chave(s) removida(s)", removed)
+	return nil
+}
+
+// list exibe as chaves do chaveiro
+func list(args []string) error {
+	fs, file := newFlagSet("list")
+	fs.Parse(args)
+
+	ring, err := utils.LoadJWTKeyRing(*file)
+	if err != nil {
+		return err
+	}
+
+	for _, key := range ring.Keys {
+		status := "publicada"
+		if key.ID == ring.SigningKeyID {
+			status = "assinando"
+		} else if key.RetiredAt != nil {
+			status = "aposentada em " + key.RetiredAt.Format(time.RFC3339)
+		}
+		fmt.Printf("%s\t%s\t%s\t%s\n", key.ID, key.Algorithm, key.CreatedAt.Format(time.RFC3339), status)
+	}
+	return nil
+}
@@ -0,0 +1,386 @@
+package controllers
+
+import (
+	"net/http"
+
+	"github.com/Barba2k2/aurora_backend/src/middlewares"
+	"github.com/Barba2k2/aurora_backend/src/services"
+	"github.com/Barba2k2/aurora_backend/src/utils"
+	"github.com/gin-gonic/gin"
+)
+
+// AccountController manipula as alterações de credenciais do usuário autenticado
+type AccountController struct {
+	AuthService *services.AuthService
+}
+
+// NewAccountController cria uma nova instância de AccountController
+func NewAccountController(authService *services.AuthService) *AccountController {
+	return &AccountController{
+		AuthService: authService,
+	}
+}
+
+// ChangePassword troca a senha do usuário autenticado
+// @Summary Troca a senha
+// @Description Troca a senha mediante a senha atual e encerra as demais sessões
+// @Tags account
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param request body services.ChangePasswordRequest true "Senha atual e nova senha"
+// @Success 200 {object} SuccessResponse "Senha alterada com sucesso"
+// @Failure 400 {object} ErrorResponse "Dados inválidos"
+// @Failure 401 {object} ErrorResponse "Senha atual inválida"
+// @Failure 422 {object} ErrorResponse "Validação falhou"
+// @Failure 500 {object} ErrorResponse "Erro interno do servidor"
+// @Router /api/v1/client/me/password [post]
+// @Router /api/v1/professional/me/password [post]
+func (c *AccountController) ChangePassword(ctx *gin.Context) {
+	var req services.ChangePasswordRequest
+
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		utils.SendErrorResponse(ctx, http.StatusBadRequest, "INVALID_REQUEST", "Formato da requisição inválido", nil)
+		return
+	}
+
+	// Validamos os dados
+	if req.CurrentPassword == "" || req.Password == "" {
+		utils.SendErrorResponse(ctx, http.StatusUnprocessableEntity, "VALIDATION_ERROR", "Campos obrigatórios não preenchidos", map[string]interface{}{
+			"current_password": "Senha atual é obrigatória",
+			"password":         "Nova senha é obrigatória",
+		})
+		return
+	}
+
+	// Adicionamos informações do cliente para auditoria
+	req.ClientIP = ctx.ClientIP()
+	req.UserAgent = ctx.GetHeader("User-Agent")
+
+	user := currentUser(ctx)
+
+	if err := c.AuthService.ChangePassword(user, currentSessionID(ctx), req); err != nil {
+		switch err {
+		case services.ErrInvalidLogin:
+			utils.SendErrorResponse(ctx, http.StatusUnauthorized, "INVALID_CREDENTIALS", "Senha atual inválida", nil)
+		case services.ErrPasswordTooWeak, services.ErrPasswordRepeatedChars, services.ErrPasswordCommon,
+			services.ErrPasswordPersonalInfo, services.ErrPasswordBreached:
+			sendPasswordPolicyError(ctx, err)
+		case services.ErrPasswordReused:
+			utils.SendErrorResponse(ctx, http.StatusUnprocessableEntity, "VALIDATION_ERROR", "Senha usada recentemente", map[string]interface{}{
+				"password": "A nova senha não pode repetir as últimas senhas da conta",
+			})
+		case services.ErrPasswordConfirmation:
+			utils.SendErrorResponse(ctx, http.StatusUnprocessableEntity, "VALIDATION_ERROR", "Senhas não conferem", map[string]interface{}{
+				"confirm_password": "Senhas não conferem",
+			})
+		default:
+			utils.SendErrorResponse(ctx, http.StatusInternalServerError, "SERVER_ERROR", "Erro ao alterar senha", nil)
+		}
+		return
+	}
+
+	utils.SendSuccessResponse(ctx, http.StatusOK, nil, map[string]interface{}{
+		"message": "Senha alterada com sucesso, as demais sessões foram encerradas",
+	})
+}
+
+// ChangeEmail solicita a troca do email do usuário autenticado
+// @Summary Solicita a troca de email
+// @Description Envia um link de confirmação para o novo email e um aviso para o email atual
+// @Tags account
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param request body services.ChangeEmailRequest true "Novo email e senha atual"
+// @Success 200 {object} SuccessResponse "Link de confirmação enviado"
+// @Failure 400 {object} ErrorResponse "Dados inválidos"
+// @Failure 401 {object} ErrorResponse "Senha inválida"
+// @Failure 409 {object} ErrorResponse "Email já cadastrado"
+// @Failure 429 {object} ErrorResponse "Muitas requisições"
+// @Failure 500 {object} ErrorResponse "Erro interno do servidor"
+// @Router /api/v1/client/me/email [post]
+// @Router /api/v1/professional/me/email [post]
+func (c *AccountController) ChangeEmail(ctx *gin.Context) {
+	var req services.ChangeEmailRequest
+
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		utils.SendErrorResponse(ctx, http.StatusBadRequest, "INVALID_REQUEST", "Formato da requisição inválido", nil)
+		return
+	}
+
+	// Validamos os dados
+	if req.NewEmail == "" || req.Password == "" {
+		utils.SendErrorResponse(ctx, http.StatusUnprocessableEntity, "VALIDATION_ERROR", "Campos obrigatórios não preenchidos", map[string]interface{}{
+			"new_email": "Novo email é obrigatório",
+			"password":  "Senha é obrigatória",
+		})
+		return
+	}
+
+	// Adicionamos informações do cliente para auditoria
+	req.ClientIP = ctx.ClientIP()
+	req.UserAgent = ctx.GetHeader("User-Agent")
+
+	user := currentUser(ctx)
+
+	if err := c.AuthService.RequestEmailChange(user, req); err != nil {
+		switch err {
+		case services.ErrInvalidLogin:
+			utils.SendErrorResponse(ctx, http.StatusUnauthorized, "INVALID_CREDENTIALS", "Senha inválida", nil)
+		case services.ErrEmailUnchanged:
+			utils.SendErrorResponse(ctx, http.StatusUnprocessableEntity, "VALIDATION_ERROR", "O novo email é igual ao atual", map[string]interface{}{
+				"new_email": "Informe um email diferente do atual",
+			})
+		case services.ErrEmailAlreadyInUse:
+			utils.SendErrorResponse(ctx, http.StatusConflict, "EMAIL_IN_USE", "Email já cadastrado em outra conta", nil)
+		case services.ErrTooManyRequests:
+			utils.SendErrorResponse(ctx, http.StatusTooManyRequests, "TOO_MANY_REQUESTS", "Muitas solicitações em um curto período", nil)
+		default:
+			utils.SendErrorResponse(ctx, http.StatusInternalServerError, "SERVER_ERROR", "Erro ao solicitar troca de email", nil)
+		}
+		return
+	}
+
+	utils.SendSuccessResponse(ctx, http.StatusOK, nil, map[string]interface{}{
+		"message": "Enviamos um link de confirmação para o novo email",
+	})
+}
+
+// ConfirmEmailChange confirma o novo email com o link recebido
+// @Summary Confirma a troca de email
+// @Description Valida o link enviado ao novo email e o torna o email da conta
+// @Tags account
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param request body services.ConfirmEmailChangeRequest true "Token do link"
+// @Success 200 {object} models.User "Email alterado com sucesso"
+// @Failure 400 {object} ErrorResponse "Link inválido ou expirado"
+// @Failure 409 {object} ErrorResponse "Email já cadastrado"
+// @Failure 500 {object} ErrorResponse "Erro interno do servidor"
+// @Router /api/v1/client/me/email/confirm [post]
+// @Router /api/v1/professional/me/email/confirm [post]
+func (c *AccountController) ConfirmEmailChange(ctx *gin.Context) {
+	var req services.ConfirmEmailChangeRequest
+
+	if err := ctx.ShouldBindJSON(&req); err != nil || req.Token == "" {
+		utils.SendErrorResponse(ctx, http.StatusBadRequest, "INVALID_REQUEST", "Token não fornecido", nil)
+		return
+	}
+
+	// Adicionamos informações do cliente para auditoria
+	req.ClientIP = ctx.ClientIP()
+	req.UserAgent = ctx.GetHeader("User-Agent")
+
+	user, err := c.AuthService.ConfirmEmailChange(currentUser(ctx), req)
+	if err != nil {
+		switch err {
+		case services.ErrInvalidToken:
+			utils.SendErrorResponse(ctx, http.StatusBadRequest, "INVALID_TOKEN", "Link inválido ou expirado", nil)
+		case services.ErrEmailAlreadyInUse:
+			utils.SendErrorResponse(ctx, http.StatusConflict, "EMAIL_IN_USE", "Email já cadastrado em outra conta", nil)
+		default:
+			utils.SendErrorResponse(ctx, http.StatusInternalServerError, "SERVER_ERROR", "Erro ao confirmar troca de email", nil)
+		}
+		return
+	}
+
+	utils.SendSuccessResponse(ctx, http.StatusOK, user, nil)
+}
+
+// UpdatePreferredChannel troca o canal dos avisos de segurança do usuário autenticado
+// @Summary Troca o canal dos avisos de segurança
+// @Description Define se avisos como o de login em novo dispositivo chegam por email ou WhatsApp
+// @Tags account
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param request body services.UpdatePreferredChannelRequest true "Canal preferido"
+// @Success 200 {object} models.User "Canal alterado com sucesso"
+// @Failure 400 {object} ErrorResponse "Dados inválidos"
+// @Failure 422 {object} ErrorResponse "Canal inválido ou telefone não verificado"
+// @Failure 500 {object} ErrorResponse "Erro interno do servidor"
+// @Router /api/v1/client/me/notification-channel [put]
+// @Router /api/v1/professional/me/notification-channel [put]
+func (c *AccountController) UpdatePreferredChannel(ctx *gin.Context) {
+	var req services.UpdatePreferredChannelRequest
+
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		utils.SendErrorResponse(ctx, http.StatusBadRequest, "INVALID_REQUEST", "Formato da requisição inválido", nil)
+		return
+	}
+
+	user, err := c.AuthService.UpdatePreferredChannel(currentUser(ctx), req)
+	if err != nil {
+		switch err {
+		case services.ErrInvalidChannel:
+			utils.SendErrorResponse(ctx, http.StatusUnprocessableEntity, "VALIDATION_ERROR", "Canal inválido", map[string]interface{}{
+				"channel": "Canal deve ser EMAIL ou WHATSAPP",
+			})
+		case services.ErrPhoneNotVerified:
+			utils.SendErrorResponse(ctx, http.StatusUnprocessableEntity, "PHONE_NOT_VERIFIED", "Verifique o telefone antes de receber avisos pelo WhatsApp", nil)
+		default:
+			utils.SendErrorResponse(ctx, http.StatusInternalServerError, "SERVER_ERROR", "Erro ao alterar canal dos avisos", nil)
+		}
+		return
+	}
+
+	utils.SendSuccessResponse(ctx, http.StatusOK, user, nil)
+}
+
+// DeleteAccount exclui a conta do usuário autenticado
+// @Summary Exclui a conta
+// @Description Exclui a conta mediante a senha atual e encerra todas as sessões. Contas sem senha, criadas pelo login social, enviam no lugar dela um ID token recente do provedor vinculado, com o provedor e o nonce. A conta pode ser restaurada até a data informada; depois os dados pessoais são anonimizados.
+// @Description A exclusão da conta de um profissional desativa também o estabelecimento, e a equipe perde o acesso a ele.
+// @Tags account
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param request body services.DeleteAccountRequest true "Senha atual ou ID token do provedor"
+// @Success 200 {object} SuccessResponse "Conta excluída com sucesso"
+// @Failure 400 {object} ErrorResponse "Dados inválidos"
+// @Failure 401 {object} ErrorResponse "Senha ou ID token inválido"
+// @Failure 403 {object} ErrorResponse "Indisponível durante acesso assistido"
+// @Failure 422 {object} ErrorResponse "Validação falhou"
+// @Failure 500 {object} ErrorResponse "Erro interno do servidor"
+// @Router /api/v1/client/me [delete]
+// @Router /api/v1/professional/me [delete]
+func (c *AccountController) DeleteAccount(ctx *gin.Context) {
+	var req services.DeleteAccountRequest
+
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		utils.SendErrorResponse(ctx, http.StatusBadRequest, "INVALID_REQUEST", "Formato da requisição inválido", nil)
+		return
+	}
+
+	// Validamos os dados
+	if req.Password == "" && (req.IDToken == "" || req.Provider == "" || req.Nonce == "") {
+		utils.SendErrorResponse(ctx, http.StatusUnprocessableEntity, "VALIDATION_ERROR", "Campos obrigatórios não preenchidos", map[string]interface{}{
+			"password": "Senha ou ID token do provedor, com provedor e nonce, é obrigatório",
+		})
+		return
+	}
+
+	// Adicionamos informações do cliente para auditoria
+	req.ClientIP = ctx.ClientIP()
+	req.UserAgent = ctx.GetHeader("User-Agent")
+
+	restoreUntil, err := c.AuthService.DeleteAccount(currentUser(ctx), req)
+	if err != nil {
+		switch err {
+		case services.ErrInvalidLogin:
+			utils.SendErrorResponse(ctx, http.StatusUnauthorized, "INVALID_CREDENTIALS", "Senha ou ID token inválido", nil)
+		default:
+			utils.SendErrorResponse(ctx, http.StatusInternalServerError, "SERVER_ERROR", "Erro ao excluir conta", nil)
+		}
+		return
+	}
+
+	utils.SendSuccessResponse(ctx, http.StatusOK, map[string]interface{}{
+		"restore_until": restoreUntil,
+	}, map[string]interface{}{
+		"message": "Conta excluída. Ela pode ser restaurada até a data informada",
+	})
+}
+
+// RestoreAccount restaura uma conta excluída pelo próprio usuário
+// @Summary Restaura a conta
+// @Description Restaura, com email e senha, uma conta excluída pelo próprio usuário dentro do prazo para restauração. Depois é preciso fazer login.
+// @Tags auth
+// @Accept json
+// @Produce json
+// @Param request body services.RestoreAccountRequest true "Email e senha"
+// @Success 200 {object} models.User "Conta restaurada com sucesso"
+// @Failure 400 {object} ErrorResponse "Dados inválidos"
+// @Failure 401 {object} ErrorResponse "Credenciais inválidas"
+// @Failure 403 {object} ErrorResponse "Conta excluída por um administrador"
+// @Failure 410 {object} ErrorResponse "Prazo para restauração encerrado"
+// @Failure 422 {object} ErrorResponse "Validação falhou"
+// @Failure 500 {object} ErrorResponse "Erro interno do servidor"
+// @Router /api/v1/client/auth/restore [post]
+// @Router /api/v1/professional/auth/restore [post]
+func (c *AccountController) RestoreAccount(ctx *gin.Context) {
+	var req services.RestoreAccountRequest
+
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		utils.SendErrorResponse(ctx, http.StatusBadRequest, "INVALID_REQUEST", "Formato da requisição inválido", nil)
+		return
+	}
+
+	// Validamos os dados
+	if req.Email == "" || req.Password == "" {
+		utils.SendErrorResponse(ctx, http.StatusUnprocessableEntity, "VALIDATION_ERROR", "Campos obrigatórios não preenchidos", map[string]interface{}{
+			"email":    "Email é obrigatório",
+			"password": "Senha é obrigatória",
+		})
+		return
+	}
+
+	// Adicionamos informações do cliente para auditoria
+	req.ClientIP = ctx.ClientIP()
+	req.UserAgent = ctx.GetHeader("User-Agent")
+
+	user, err := c.AuthService.RestoreAccount(req)
+	if err != nil {
+		switch err {
+		case services.ErrInvalidLogin:
+			utils.SendErrorResponse(ctx, http.StatusUnauthorized, "INVALID_CREDENTIALS", "Credenciais inválidas", nil)
+		case services.ErrUserInactive:
+			utils.SendErrorResponse(ctx, http.StatusForbidden, "USER_INACTIVE", "Conta excluída por um administrador, entre em contato com o suporte", nil)
+		case services.ErrAccountNotRestorable:
+			utils.SendErrorResponse(ctx, http.StatusGone, "RESTORE_WINDOW_EXPIRED", "O prazo para restaurar a conta terminou", nil)
+		default:
+			utils.SendErrorResponse(ctx, http.StatusInternalServerError, "SERVER_ERROR", "Erro ao restaurar conta", nil)
+		}
+		return
+	}
+
+	utils.SendSuccessResponse(ctx, http.StatusOK, user, map[string]interface{}{
+		"message": "Conta restaurada com sucesso",
+	})
+}
+
+// RegisterPublicRoutes registra as rotas que não exigem autenticação
+func (c *AccountController) RegisterPublicRoutes(router *gin.RouterGroup) {
+	router.POST("/auth/restore", c.RestoreAccount)
+}
+
+// RegisterRoutes registra as rotas do controlador em um grupo já autenticado
+func (c *AccountController) RegisterRoutes(router *gin.RouterGroup, authMiddleware *middlewares.AuthMiddleware) {
+	router.DELETE("/me", authMiddleware.ForbidImpersonation(), c.DeleteAccount)
+
+	me := router.Group("/me")
+	{
+		me.POST("/password", authMiddleware.ForbidImpersonation(), c.ChangePassword)
+		me.POST("/email", authMiddleware.ForbidImpersonation(), c.ChangeEmail)
+		me.POST("/email/confirm", authMiddleware.ForbidImpersonation(), c.ConfirmEmailChange)
+		me.PUT("/notification-channel", authMiddleware.ForbidImpersonation(), c.UpdatePreferredChannel)
+	}
+}
+
+// sendPasswordPolicyError informa qual regra da política de senhas a nova senha descumpriu
+func sendPasswordPolicyError(ctx *gin.Context, err error) {
+	switch err {
+	case services.ErrPasswordRepeatedChars:
+		utils.SendErrorResponse(ctx, http.StatusUnprocessableEntity, "VALIDATION_ERROR", "Senha com caracteres repetidos", map[string]interface{}{
+			"password": "A senha não pode repetir o mesmo caractere muitas vezes seguidas",
+		})
+	case services.ErrPasswordCommon:
+		utils.SendErrorResponse(ctx, http.StatusUnprocessableEntity, "VALIDATION_ERROR", "Senha muito comum", map[string]interface{}{
+			"password": "A senha não pode ser uma palavra ou senha comum, mesmo com números ou símbolos",
+		})
+	case services.ErrPasswordPersonalInfo:
+		utils.SendErrorResponse(ctx, http.StatusUnprocessableEntity, "VALIDATION_ERROR", "Senha contém dados pessoais", map[string]interface{}{
+			"password": "A senha não pode conter o nome, o email ou o telefone da conta",
+		})
+	case services.ErrPasswordBreached:
+		utils.SendErrorResponse(ctx, http.StatusUnprocessableEntity, "VALIDATION_ERROR", "Senha exposta em vazamento de dados", map[string]interface{}{
+			"password": "Esta senha aparece em vazamentos conhecidos, escolha outra",
+		})
+	default:
+		utils.SendErrorResponse(ctx, http.StatusUnprocessableEntity, "VALIDATION_ERROR", "Senha muito fraca", map[string]interface{}{
+			"password": "A senha deve ter o tamanho mínimo e combinar letras maiúsculas, minúsculas, números e caracteres especiais",
+		})
+	}
+}
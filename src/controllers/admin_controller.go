@@ -0,0 +1,337 @@
+package controllers
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/Barba2k2/aurora_backend/src/services"
+	"github.com/Barba2k2/aurora_backend/src/utils"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// AdminController manipula as operações administrativas sobre contas de usuários
+type AdminController struct {
+	AuthService *services.AuthService
+}
+
+// NewAdminController cria uma nova instância de AdminController
+func NewAdminController(authService *services.AuthService) *AdminController {
+	return &AdminController{
+		AuthService: authService,
+	}
+}
+
+// UnlockUser remove o bloqueio por tentativas de login de um usuário
+// @Summary Desbloqueia um usuário
+// @Description Remove o bloqueio por tentativas de login e zera o histórico de bloqueios do usuário
+// @Tags admin
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "ID do usuário"
+// @Success 200 {object} models.User "Usuário desbloqueado com sucesso"
+// @Failure 400 {object} ErrorResponse "ID inválido"
+// @Failure 403 {object} ErrorResponse "Acesso restrito a administradores"
+// @Failure 404 {object} ErrorResponse "Usuário não encontrado"
+// @Failure 500 {object} ErrorResponse "Erro interno do servidor"
+// @Router /api/v1/admin/users/{id}/unlock [post]
+func (c *AdminController) UnlockUser(ctx *gin.Context) {
+	userID, err := uuid.Parse(ctx.Param("id"))
+	if err != nil {
+		utils.SendErrorResponse(ctx, http.StatusBadRequest, "INVALID_REQUEST", "ID de usuário inválido", nil)
+		return
+	}
+
+	user, err := c.AuthService.AdminUnlockUser(currentUser(ctx).ID, userID, ctx.ClientIP(), ctx.GetHeader("User-Agent"))
+	if err != nil {
+		switch err {
+		case services.ErrUserNotFound:
+			utils.SendErrorResponse(ctx, http.StatusNotFound, "USER_NOT_FOUND", "Usuário não encontrado", nil)
+		default:
+			utils.SendErrorResponse(ctx, http.StatusInternalServerError, "SERVER_ERROR", "Erro ao desbloquear usuário", nil)
+		}
+		return
+	}
+
+	utils.SendSuccessResponse(ctx, http.StatusOK, user, nil)
+}
+
+// ChangeUserRole troca o papel de um usuário
+// @Summary Troca o papel de um usuário
+// @Description Troca o papel do usuário e encerra as sessões dele; a troca fica registrada no log de autenticação
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "ID do usuário"
+// @Param request body services.ChangeUserRoleRequest true "Novo papel"
+// @Success 200 {object} models.User "Papel alterado com sucesso"
+// @Failure 400 {object} ErrorResponse "Dados inválidos"
+// @Failure 403 {object} ErrorResponse "Acesso restrito a administradores"
+// @Failure 404 {object} ErrorResponse "Usuário não encontrado"
+// @Failure 422 {object} ErrorResponse "Papel inválido"
+// @Failure 500 {object} ErrorResponse "Erro interno do servidor"
+// @Router /api/v1/admin/users/{id}/role [put]
+func (c *AdminController) ChangeUserRole(ctx *gin.Context) {
+	userID, err := uuid.Parse(ctx.Param("id"))
+	if err != nil {
+		utils.SendErrorResponse(ctx, http.StatusBadRequest, "INVALID_REQUEST", "ID de usuário inválido", nil)
+		return
+	}
+
+	var req services.ChangeUserRoleRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		utils.SendErrorResponse(ctx, http.StatusBadRequest, "INVALID_REQUEST", "Formato da requisição inválido", nil)
+		return
+	}
+
+	// Adicionamos informações do cliente para auditoria
+	req.ClientIP = ctx.ClientIP()
+	req.UserAgent = ctx.GetHeader("User-Agent")
+
+	user, err := c.AuthService.AdminChangeUserRole(currentUser(ctx).ID, userID, req)
+	if err != nil {
+		switch err {
+		case services.ErrInvalidRole:
+			utils.SendErrorResponse(ctx, http.StatusUnprocessableEntity, "VALIDATION_ERROR", "Papel inválido", map[string]interface{}{
+				"role": "Papel deve ser CLIENT, PROFESSIONAL, STAFF ou ADMIN",
+			})
+		case services.ErrCannotChangeOwnRole:
+			utils.SendErrorResponse(ctx, http.StatusUnprocessableEntity, "VALIDATION_ERROR", "Não é possível alterar o próprio papel", nil)
+		case services.ErrUserNotFound:
+			utils.SendErrorResponse(ctx, http.StatusNotFound, "USER_NOT_FOUND", "Usuário não encontrado", nil)
+		default:
+			utils.SendErrorResponse(ctx, http.StatusInternalServerError, "SERVER_ERROR", "Erro ao alterar papel do usuário", nil)
+		}
+		return
+	}
+
+	utils.SendSuccessResponse(ctx, http.StatusOK, user, nil)
+}
+
+// Impersonate inicia a personificação de um usuário
+// @Summary Age como outro usuário
+// @Description Emite um token de acesso curto, sem refresh token, para o suporte ver o sistema como o usuário.
+// @Description Troca de senha, de email e de fatores de autenticação ficam bloqueadas durante a personificação.
+// @Description O início e o fim ficam registrados no log de autenticação.
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "ID do usuário"
+// @Param request body services.ImpersonationRequest false "Motivo do atendimento"
+// @Success 200 {object} services.ImpersonationResponse "Token de personificação"
+// @Failure 400 {object} ErrorResponse "ID inválido"
+// @Failure 403 {object} ErrorResponse "Administradores não podem ser personificados"
+// @Failure 404 {object} ErrorResponse "Usuário não encontrado"
+// @Failure 500 {object} ErrorResponse "Erro interno do servidor"
+// @Router /api/v1/admin/users/{id}/impersonate [post]
+func (c *AdminController) Impersonate(ctx *gin.Context) {
+	userID, err := uuid.Parse(ctx.Param("id"))
+	if err != nil {
+		utils.SendErrorResponse(ctx, http.StatusBadRequest, "INVALID_REQUEST", "ID de usuário inválido", nil)
+		return
+	}
+
+	// O motivo e opcional, entao o corpo pode vir vazio
+	var req services.ImpersonationRequest
+	if ctx.Request.ContentLength > 0 {
+		if err := ctx.ShouldBindJSON(&req); err != nil {
+			utils.SendErrorResponse(ctx, http.StatusBadRequest, "INVALID_REQUEST", "Formato da requisição inválido", nil)
+			return
+		}
+	}
+
+	// Adicionamos informações do cliente para auditoria
+	req.ClientIP = ctx.ClientIP()
+	req.UserAgent = ctx.GetHeader("User-Agent")
+
+	response, err := c.AuthService.StartImpersonation(currentUser(ctx).ID, userID, req)
+	if err != nil {
+		switch err {
+		case services.ErrImpersonationDenied:
+			utils.SendErrorResponse(ctx, http.StatusForbidden, "IMPERSONATION_DENIED", "Administradores não podem ser personificados", nil)
+		case services.ErrUserInactive:
+			utils.SendErrorResponse(ctx, http.StatusForbidden, "USER_INACTIVE", "Usuário inativo", nil)
+		case services.ErrUserNotFound:
+			utils.SendErrorResponse(ctx, http.StatusNotFound, "USER_NOT_FOUND", "Usuário não encontrado", nil)
+		default:
+			utils.SendErrorResponse(ctx, http.StatusInternalServerError, "SERVER_ERROR", "Erro ao iniciar personificação", nil)
+		}
+		return
+	}
+
+	utils.SendSuccessResponse(ctx, http.StatusOK, response, nil)
+}
+
+// StopImpersonation encerra uma personificação iniciada pelo administrador
+// @Summary Encerra uma personificação
+// @Tags admin
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "ID da sessão de personificação"
+// @Success 200 {object} SuccessResponse "Personificação encerrada com sucesso"
+// @Failure 400 {object} ErrorResponse "ID inválido"
+// @Failure 404 {object} ErrorResponse "Sessão não encontrada"
+// @Failure 500 {object} ErrorResponse "Erro interno do servidor"
+// @Router /api/v1/admin/impersonations/{id} [delete]
+func (c *AdminController) StopImpersonation(ctx *gin.Context) {
+	sessionID, err := uuid.Parse(ctx.Param("id"))
+	if err != nil {
+		utils.SendErrorResponse(ctx, http.StatusBadRequest, "INVALID_REQUEST", "ID de sessão inválido", nil)
+		return
+	}
+
+	if err := c.AuthService.StopImpersonation(currentUser(ctx).ID, sessionID, ctx.ClientIP(), ctx.GetHeader("User-Agent")); err != nil {
+		switch err {
+		case services.ErrSessionNotFound:
+			utils.SendErrorResponse(ctx, http.StatusNotFound, "SESSION_NOT_FOUND", "Sessão de personificação não encontrada", nil)
+		default:
+			utils.SendErrorResponse(ctx, http.StatusInternalServerError, "SERVER_ERROR", "Erro ao encerrar personificação", nil)
+		}
+		return
+	}
+
+	utils.SendSuccessResponse(ctx, http.StatusOK, nil, map[string]interface{}{
+		"message": "Personificação encerrada com sucesso",
+	})
+}
+
+// ListDeletedUsers lista as contas excluídas que ainda podem ser restauradas
+// @Summary Lista contas excluídas
+// @Description Lista as contas excluídas cujos dados pessoais ainda não foram anonimizados, da exclusão mais recente para a mais antiga
+// @Tags admin
+// @Produce json
+// @Security BearerAuth
+// @Param page query int false "Página" default(1)
+// @Param limit query int false "Itens por página" default(20)
+// @Success 200 {array} services.DeletedAccountResponse "Contas excluídas"
+// @Failure 400 {object} ErrorResponse "Paginação inválida"
+// @Failure 403 {object} ErrorResponse "Acesso restrito a administradores"
+// @Failure 500 {object} ErrorResponse "Erro interno do servidor"
+// @Router /api/v1/admin/users/deleted [get]
+func (c *AdminController) ListDeletedUsers(ctx *gin.Context) {
+	page, limit := 1, 20
+	details := map[string]interface{}{}
+
+	if value := ctx.Query("page"); value != "" {
+		parsed, err := strconv.Atoi(value)
+		if err != nil || parsed < 1 {
+			details["page"] = "Página inválida"
+		}
+		page = parsed
+	}
+
+	if value := ctx.Query("limit"); value != "" {
+		parsed, err := strconv.Atoi(value)
+		if err != nil || parsed < 1 || parsed > services.MaxDeletedUsersPageSize {
+			details["limit"] = "Limite deve estar entre 1 e " + strconv.Itoa(services.MaxDeletedUsersPageSize)
+		}
+		limit = parsed
+	}
+
+	if len(details) > 0 {
+		utils.SendErrorResponse(ctx, http.StatusBadRequest, "INVALID_REQUEST", "Paginação inválida", details)
+		return
+	}
+
+	accounts, total, err := c.AuthService.AdminListDeletedUsers(page, limit)
+	if err != nil {
+		utils.SendErrorResponse(ctx, http.StatusInternalServerError, "SERVER_ERROR", "Erro ao listar contas excluídas", nil)
+		return
+	}
+
+	utils.SendSuccessResponseWithPagination(ctx, accounts, int(total), page, limit)
+}
+
+// DeleteUser exclui a conta de um usuário
+// @Summary Exclui um usuário
+// @Description Exclui a conta e encerra as sessões do usuário. Apenas administradores podem restaurá-la, até o fim do prazo para restauração.
+// @Tags admin
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "ID do usuário"
+// @Success 200 {object} SuccessResponse "Usuário excluído com sucesso"
+// @Failure 400 {object} ErrorResponse "ID inválido"
+// @Failure 403 {object} ErrorResponse "Acesso restrito a administradores"
+// @Failure 404 {object} ErrorResponse "Usuário não encontrado"
+// @Failure 500 {object} ErrorResponse "Erro interno do servidor"
+// @Router /api/v1/admin/users/{id} [delete]
+func (c *AdminController) DeleteUser(ctx *gin.Context) {
+	userID, err := uuid.Parse(ctx.Param("id"))
+	if err != nil {
+		utils.SendErrorResponse(ctx, http.StatusBadRequest, "INVALID_REQUEST", "ID de usuário inválido", nil)
+		return
+	}
+
+	restoreUntil, err := c.AuthService.AdminDeleteUser(currentUser(ctx).ID, userID, ctx.ClientIP(), ctx.GetHeader("User-Agent"))
+	if err != nil {
+		switch err {
+		case services.ErrCannotDeleteSelf:
+			utils.SendErrorResponse(ctx, http.StatusForbidden, "FORBIDDEN", "Não é possível excluir a própria conta", nil)
+		case services.ErrUserNotFound:
+			utils.SendErrorResponse(ctx, http.StatusNotFound, "USER_NOT_FOUND", "Usuário não encontrado", nil)
+		default:
+			utils.SendErrorResponse(ctx, http.StatusInternalServerError, "SERVER_ERROR", "Erro ao excluir usuário", nil)
+		}
+		return
+	}
+
+	utils.SendSuccessResponse(ctx, http.StatusOK, map[string]interface{}{
+		"restore_until": restoreUntil,
+	}, map[string]interface{}{
+		"message": "Usuário excluído com sucesso",
+	})
+}
+
+// RestoreUser restaura a conta excluída de um usuário
+// @Summary Restaura um usuário
+// @Description Restaura a conta e, para profissionais, o estabelecimento, cancelando a anonimização dos dados pessoais
+// @Tags admin
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "ID do usuário"
+// @Success 200 {object} models.User "Usuário restaurado com sucesso"
+// @Failure 400 {object} ErrorResponse "ID inválido"
+// @Failure 403 {object} ErrorResponse "Acesso restrito a administradores"
+// @Failure 404 {object} ErrorResponse "Conta excluída não encontrada"
+// @Failure 410 {object} ErrorResponse "Prazo para restauração encerrado"
+// @Failure 500 {object} ErrorResponse "Erro interno do servidor"
+// @Router /api/v1/admin/users/{id}/restore [post]
+func (c *AdminController) RestoreUser(ctx *gin.Context) {
+	userID, err := uuid.Parse(ctx.Param("id"))
+	if err != nil {
+		utils.SendErrorResponse(ctx, http.StatusBadRequest, "INVALID_REQUEST", "ID de usuário inválido", nil)
+		return
+	}
+
+	user, err := c.AuthService.AdminRestoreUser(currentUser(ctx).ID, userID, ctx.ClientIP(), ctx.GetHeader("User-Agent"))
+	if err != nil {
+		switch err {
+		case services.ErrUserNotFound:
+			utils.SendErrorResponse(ctx, http.StatusNotFound, "USER_NOT_FOUND", "Conta excluída não encontrada", nil)
+		case services.ErrAccountNotRestorable:
+			utils.SendErrorResponse(ctx, http.StatusGone, "RESTORE_WINDOW_EXPIRED", "O prazo para restaurar a conta terminou", nil)
+		default:
+			utils.SendErrorResponse(ctx, http.StatusInternalServerError, "SERVER_ERROR", "Erro ao restaurar usuário", nil)
+		}
+		return
+	}
+
+	utils.SendSuccessResponse(ctx, http.StatusOK, user, nil)
+}
+
+// RegisterRoutes registra as rotas do controlador em um grupo já autenticado
+func (c *AdminController) RegisterRoutes(router *gin.RouterGroup) {
+	users := router.Group("/users")
+	{
+		users.GET("/deleted", c.ListDeletedUsers)
+		users.DELETE("/:id", c.DeleteUser)
+		users.POST("/:id/restore", c.RestoreUser)
+		users.POST("/:id/unlock", c.UnlockUser)
+		users.PUT("/:id/role", c.ChangeUserRole)
+		users.POST("/:id/impersonate", c.Impersonate)
+	}
+
+	router.DELETE("/impersonations/:id", c.StopImpersonation)
+}
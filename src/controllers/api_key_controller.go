@@ -0,0 +1,200 @@
+package controllers
+
+import (
+	"net/http"
+
+	"github.com/Barba2k2/aurora_backend/src/middlewares"
+	"github.com/Barba2k2/aurora_backend/src/models"
+	"github.com/Barba2k2/aurora_backend/src/services"
+	"github.com/Barba2k2/aurora_backend/src/utils"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// APIKeyController manipula as chaves de API usadas nas integrações dos estabelecimentos
+type APIKeyController struct {
+	AuthService *services.AuthService
+}
+
+// NewAPIKeyController cria uma nova instância de APIKeyController
+func NewAPIKeyController(authService *services.AuthService) *APIKeyController {
+	return &APIKeyController{
+		AuthService: authService,
+	}
+}
+
+// ListKeys lista as chaves de API ativas do estabelecimento
+// @Summary Lista as chaves de API
+// @Tags api-keys
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {array} models.APIKey "Chaves de API"
+// @Failure 403 {object} ErrorResponse "Acesso negado"
+// @Failure 500 {object} ErrorResponse "Erro interno do servidor"
+// @Router /api/v1/professional/establishment/api-keys [get]
+func (c *APIKeyController) ListKeys(ctx *gin.Context) {
+	keys, err := c.AuthService.ListAPIKeys(currentPermissions(ctx))
+	if err != nil {
+		c.sendAPIKeyError(ctx, err, "Erro ao listar chaves de API")
+		return
+	}
+
+	utils.SendSuccessResponse(ctx, http.StatusOK, keys, nil)
+}
+
+// CreateKey cria uma chave de API
+// @Summary Cria uma chave de API
+// @Description Cria uma chave para o backend do estabelecimento. O valor da chave é exibido apenas nesta resposta.
+// @Tags api-keys
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param request body services.CreateAPIKeyRequest true "Nome, escopos e validade"
+// @Success 201 {object} services.APIKeyResponse "Chave criada com sucesso"
+// @Failure 400 {object} ErrorResponse "Dados inválidos"
+// @Failure 403 {object} ErrorResponse "Acesso negado"
+// @Failure 422 {object} ErrorResponse "Erro de validação"
+// @Failure 500 {object} ErrorResponse "Erro interno do servidor"
+// @Router /api/v1/professional/establishment/api-keys [post]
+func (c *APIKeyController) CreateKey(ctx *gin.Context) {
+	var req services.CreateAPIKeyRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		utils.SendErrorResponse(ctx, http.StatusBadRequest, "INVALID_REQUEST", "Formato da requisição inválido", nil)
+		return
+	}
+
+	if req.Name == "" {
+		utils.SendErrorResponse(ctx, http.StatusUnprocessableEntity, "VALIDATION_ERROR", "Nome da chave é obrigatório", map[string]interface{}{
+			"name": "Nome é obrigatório",
+		})
+		return
+	}
+
+	// Adicionamos informações do cliente para auditoria
+	req.ClientIP = ctx.ClientIP()
+	req.UserAgent = ctx.Request.UserAgent()
+
+	key, err := c.AuthService.CreateAPIKey(currentUser(ctx).ID, currentPermissions(ctx), req)
+	if err != nil {
+		c.sendAPIKeyError(ctx, err, "Erro ao criar chave de API")
+		return
+	}
+
+	utils.SendSuccessResponse(ctx, http.StatusCreated, key, nil)
+}
+
+// RotateKey gera um novo valor para uma chave de API
+// @Summary Rotaciona uma chave de API
+// @Description Gera um novo valor mantendo nome, escopos e validade. O valor anterior deixa de valer imediatamente.
+// @Tags api-keys
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "ID da chave"
+// @Success 200 {object} services.APIKeyResponse "Chave rotacionada com sucesso"
+// @Failure 400 {object} ErrorResponse "ID inválido"
+// @Failure 403 {object} ErrorResponse "Acesso negado"
+// @Failure 404 {object} ErrorResponse "Chave não encontrada"
+// @Failure 500 {object} ErrorResponse "Erro interno do servidor"
+// @Router /api/v1/professional/establishment/api-keys/{id}/rotate [post]
+func (c *APIKeyController) RotateKey(ctx *gin.Context) {
+	keyID, err := uuid.Parse(ctx.Param("id"))
+	if err != nil {
+		utils.SendErrorResponse(ctx, http.StatusBadRequest, "INVALID_REQUEST", "ID de chave inválido", nil)
+		return
+	}
+
+	key, err := c.AuthService.RotateAPIKey(currentUser(ctx).ID, currentPermissions(ctx), keyID, ctx.ClientIP(), ctx.Request.UserAgent())
+	if err != nil {
+		c.sendAPIKeyError(ctx, err, "Erro ao rotacionar chave de API")
+		return
+	}
+
+	utils.SendSuccessResponse(ctx, http.StatusOK, key, nil)
+}
+
+// RevokeKey revoga uma chave de API
+// @Summary Revoga uma chave de API
+// @Tags api-keys
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "ID da chave"
+// @Success 200 {object} SuccessResponse "Chave revogada com sucesso"
+// @Failure 400 {object} ErrorResponse "ID inválido"
+// @Failure 403 {object} ErrorResponse "Acesso negado"
+// @Failure 404 {object} ErrorResponse "Chave não encontrada"
+// @Failure 500 {object} ErrorResponse "Erro interno do servidor"
+// @Router /api/v1/professional/establishment/api-keys/{id} [delete]
+func (c *APIKeyController) RevokeKey(ctx *gin.Context) {
+	keyID, err := uuid.Parse(ctx.Param("id"))
+	if err != nil {
+		utils.SendErrorResponse(ctx, http.StatusBadRequest, "INVALID_REQUEST", "ID de chave inválido", nil)
+		return
+	}
+
+	if err := c.AuthService.RevokeAPIKey(currentUser(ctx).ID, currentPermissions(ctx), keyID, ctx.ClientIP(), ctx.Request.UserAgent()); err != nil {
+		c.sendAPIKeyError(ctx, err, "Erro ao revogar chave de API")
+		return
+	}
+
+	utils.SendSuccessResponse(ctx, http.StatusOK, nil, map[string]interface{}{
+		"message": "Chave revogada com sucesso",
+	})
+}
+
+// GetCurrentKey retorna o estabelecimento e os escopos da chave usada na requisição
+// @Summary Dados da chave de API
+// @Description Permite à integração conferir a chave configurada
+// @Tags integrations
+// @Produce json
+// @Param X-API-Key header string true "Chave de API"
+// @Success 200 {object} SuccessResponse "Estabelecimento e escopos da chave"
+// @Failure 401 {object} ErrorResponse "Chave inválida"
+// @Router /api/v1/integrations/me [get]
+func (c *APIKeyController) GetCurrentKey(ctx *gin.Context) {
+	permissions := currentPermissions(ctx)
+
+	utils.SendSuccessResponse(ctx, http.StatusOK, map[string]interface{}{
+		"api_key_id":       ctx.GetString("api_key_id"),
+		"establishment_id": permissions.EstablishmentID,
+		"scopes":           permissions.List(),
+	}, nil)
+}
+
+// sendAPIKeyError traduz os erros de chaves de API em respostas HTTP
+func (c *APIKeyController) sendAPIKeyError(ctx *gin.Context, err error, fallback string) {
+	switch err {
+	case services.ErrEstablishmentNotFound:
+		utils.SendErrorResponse(ctx, http.StatusNotFound, "ESTABLISHMENT_NOT_FOUND", "Usuário não pertence a um estabelecimento", nil)
+	case services.ErrAPIKeyNotFound:
+		utils.SendErrorResponse(ctx, http.StatusNotFound, "API_KEY_NOT_FOUND", "Chave de API não encontrada", nil)
+	case services.ErrInvalidPermission:
+		utils.SendErrorResponse(ctx, http.StatusUnprocessableEntity, "VALIDATION_ERROR", "Escopo inválido", map[string]interface{}{
+			"scopes": "Informe ao menos um escopo, usando as permissões listadas em /establishment/permissions",
+		})
+	case services.ErrInvalidExpiration:
+		utils.SendErrorResponse(ctx, http.StatusUnprocessableEntity, "VALIDATION_ERROR", "Validade inválida", map[string]interface{}{
+			"expires_at": "A validade deve ser uma data futura",
+		})
+	case services.ErrPermissionEscalation:
+		utils.SendErrorResponse(ctx, http.StatusForbidden, "FORBIDDEN", "Não é possível conceder permissões que você não tem", nil)
+	default:
+		utils.SendErrorResponse(ctx, http.StatusInternalServerError, "SERVER_ERROR", fallback, nil)
+	}
+}
+
+// RegisterRoutes registra as rotas de gerenciamento das chaves em um grupo já autenticado
+func (c *APIKeyController) RegisterRoutes(router *gin.RouterGroup, authMiddleware *middlewares.AuthMiddleware) {
+	keys := router.Group("/establishment/api-keys")
+	keys.Use(authMiddleware.ForbidImpersonation(), authMiddleware.RequirePermission(models.PermissionEstablishmentManage))
+	{
+		keys.GET("", c.ListKeys)
+		keys.POST("", c.CreateKey)
+		keys.POST("/:id/rotate", c.RotateKey)
+		keys.DELETE("/:id", c.RevokeKey)
+	}
+}
+
+// RegisterIntegrationRoutes registra as rotas das integrações em um grupo autenticado por chave de API
+func (c *APIKeyController) RegisterIntegrationRoutes(router *gin.RouterGroup) {
+	router.GET("/me", c.GetCurrentKey)
+}
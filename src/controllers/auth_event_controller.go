@@ -0,0 +1,150 @@
+package controllers
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Barba2k2/aurora_backend/src/models"
+	"github.com/Barba2k2/aurora_backend/src/services"
+	"github.com/Barba2k2/aurora_backend/src/utils"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// Formato aceito para datas sem horário nos filtros
+const dateOnlyLayout = "2006-01-02"
+
+// AuthEventController manipula as consultas ao log de eventos de autenticação
+type AuthEventController struct {
+	AuthService *services.AuthService
+}
+
+// NewAuthEventController cria uma nova instância de AuthEventController
+func NewAuthEventController(authService *services.AuthService) *AuthEventController {
+	return &AuthEventController{
+		AuthService: authService,
+	}
+}
+
+// ListEvents lista os eventos de autenticação
+// @Summary Lista eventos de autenticação
+// @Description Lista logins, bloqueios, recuperações de senha, renovações de token e trocas de papel, do mais recente para o mais antigo.
+// @Description Administradores podem consultar qualquer usuário; os demais veem apenas os próprios eventos.
+// @Tags audit
+// @Produce json
+// @Security BearerAuth
+// @Param user_id query string false "ID do usuário (apenas administradores)"
+// @Param type query string false "Tipos de evento separados por vírgula"
+// @Param from query string false "Data inicial (RFC3339 ou AAAA-MM-DD)"
+// @Param to query string false "Data final (RFC3339 ou AAAA-MM-DD, inclusiva)"
+// @Param page query int false "Página" default(1)
+// @Param limit query int false "Itens por página" default(20)
+// @Success 200 {array} models.AuthEvent "Eventos de autenticação"
+// @Failure 400 {object} ErrorResponse "Filtros inválidos"
+// @Failure 401 {object} ErrorResponse "Não autenticado"
+// @Failure 500 {object} ErrorResponse "Erro interno do servidor"
+// @Router /api/v1/professional/auth-events [get]
+// @Router /api/v1/admin/auth-events [get]
+func (c *AuthEventController) ListEvents(ctx *gin.Context) {
+	user := currentUser(ctx)
+
+	query, details := parseAuthEventQuery(ctx)
+	if len(details) > 0 {
+		utils.SendErrorResponse(ctx, http.StatusBadRequest, "INVALID_REQUEST", "Filtros inválidos", details)
+		return
+	}
+
+	// Somente administradores consultam eventos de outros usuários
+	if user.Role != models.UserRoleAdmin {
+		query.UserID = &user.ID
+	}
+
+	events, total, err := c.AuthService.ListAuthEvents(query)
+	if err != nil {
+		utils.SendErrorResponse(ctx, http.StatusInternalServerError, "SERVER_ERROR", "Erro ao consultar eventos de autenticação", nil)
+		return
+	}
+
+	utils.SendSuccessResponseWithPagination(ctx, events, int(total), query.Page, query.Limit)
+}
+
+// parseAuthEventQuery lê os filtros da query string, retornando os erros por campo
+func parseAuthEventQuery(ctx *gin.Context) (services.AuthEventQuery, map[string]interface{}) {
+	query := services.AuthEventQuery{Page: 1, Limit: 20}
+	details := map[string]interface{}{}
+
+	if value := ctx.Query("user_id"); value != "" {
+		userID, err := uuid.Parse(value)
+		if err != nil {
+			details["user_id"] = "ID de usuário inválido"
+		} else {
+			query.UserID = &userID
+		}
+	}
+
+	if value := ctx.Query("type"); value != "" {
+		for _, name := range strings.Split(value, ",") {
+			eventType := models.AuthEventType(strings.ToUpper(strings.TrimSpace(name)))
+			if !eventType.IsValid() {
+				details["type"] = "Tipo de evento inválido: " + name
+				break
+			}
+			query.Types = append(query.Types, eventType)
+		}
+	}
+
+	if value := ctx.Query("from"); value != "" {
+		from, _, err := parseDateFilter(value)
+		if err != nil {
+			details["from"] = "Data inválida, use RFC3339 ou AAAA-MM-DD"
+		}
+		query.From = from
+	}
+
+	if value := ctx.Query("to"); value != "" {
+		to, dateOnly, err := parseDateFilter(value)
+		if err != nil {
+			details["to"] = "Data inválida, use RFC3339 ou AAAA-MM-DD"
+		}
+		// Uma data sem horário inclui o dia inteiro
+		if dateOnly {
+			to = to.AddDate(0, 0, 1)
+		}
+		query.To = to
+	}
+
+	if value := ctx.Query("page"); value != "" {
+		page, err := strconv.Atoi(value)
+		if err != nil || page < 1 {
+			details["page"] = "Página inválida"
+		}
+		query.Page = page
+	}
+
+	if value := ctx.Query("limit"); value != "" {
+		limit, err := strconv.Atoi(value)
+		if err != nil || limit < 1 || limit > services.MaxAuthEventsPageSize {
+			details["limit"] = "Limite deve estar entre 1 e " + strconv.Itoa(services.MaxAuthEventsPageSize)
+		}
+		query.Limit = limit
+	}
+
+	return query, details
+}
+
+// parseDateFilter aceita datas em RFC3339 ou apenas a data, informando qual formato foi usado
+func parseDateFilter(value string) (time.Time, bool, error) {
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return t, false, nil
+	}
+
+	t, err := time.Parse(dateOnlyLayout, value)
+	return t, true, err
+}
+
+// RegisterRoutes registra as rotas do controlador em um grupo já autenticado
+func (c *AuthEventController) RegisterRoutes(router *gin.RouterGroup) {
+	router.GET("/auth-events", c.ListEvents)
+}
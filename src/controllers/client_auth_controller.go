@@ -1,6 +1,7 @@
 package controllers
 
 import (
+	"errors"
 	"net/http"
 	"strings"
 
@@ -30,6 +31,7 @@ func NewClientAuthController(authService *services.AuthService) *ClientAuthContr
 // @Produce json
 // @Param request body services.RegisterRequest true "Dados de registro"
 // @Success 201 {object} models.User "Usuário criado com sucesso"
+// @Success 202 {object} SuccessResponse "Cadastro em andamento (modo de privacidade)"
 // @Failure 400 {object} ErrorResponse "Dados inválidos"
 // @Failure 409 {object} ErrorResponse "Usuário já existe"
 // @Failure 422 {object} ErrorResponse "Validação falhou"
@@ -68,10 +70,9 @@ func (c *ClientAuthController) Register(ctx *gin.Context) {
 	user, err := c.AuthService.Register(req)
 	if err != nil {
 		switch err {
-		case services.ErrPasswordTooWeak:
-			utils.SendErrorResponse(ctx, http.StatusUnprocessableEntity, "VALIDATION_ERROR", "Senha muito fraca", map[string]interface{}{
-				"password": "A senha deve conter letras maiúsculas, minúsculas, números e caracteres especiais",
-			})
+		case services.ErrPasswordTooWeak, services.ErrPasswordRepeatedChars, services.ErrPasswordCommon,
+			services.ErrPasswordPersonalInfo, services.ErrPasswordBreached:
+			sendPasswordPolicyError(ctx, err)
 		case services.ErrPasswordConfirmation:
 			utils.SendErrorResponse(ctx, http.StatusUnprocessableEntity, "VALIDATION_ERROR", "Senhas não conferem", map[string]interface{}{
 				"confirm_password": "Senhas não conferem",
@@ -86,6 +87,14 @@ func (c *ClientAuthController) Register(ctx *gin.Context) {
 		return
 	}
 
+	// No modo de privacidade a resposta é a mesma para contas novas e existentes
+	if c.AuthService.Config.PrivacyMode {
+		utils.SendSuccessResponse(ctx, http.StatusAccepted, nil, map[string]interface{}{
+			"message": "Enviamos um email com as instruções para concluir o cadastro",
+		})
+		return
+	}
+
 	// Retornamos o usuário criado
 	utils.SendSuccessResponse(ctx, http.StatusCreated, user, nil)
 }
@@ -121,25 +130,39 @@ func (c *ClientAuthController) Login(ctx *gin.Context) {
 		return
 	}
 
+	// Adicionamos informações do cliente para auditoria
+	req.ClientIP = ctx.ClientIP()
+	req.UserAgent = ctx.GetHeader("User-Agent")
+
+	// So os papeis do portal podem entrar; a conferencia acontece antes de abrir a sessao
+	req.AllowedRoles = []models.UserRole{models.UserRoleClient}
+
 	// Realizamos o login
-	user, tokens, err := c.AuthService.Login(req)
-	if err != nil {
+	_, tokens, err := c.AuthService.Login(req)
+	var mfaRequired *services.MFARequiredError
+	if err != nil && !errors.As(err, &mfaRequired) {
 		switch err {
 		case services.ErrInvalidLogin:
 			utils.SendErrorResponse(ctx, http.StatusUnauthorized, "INVALID_CREDENTIALS", "Email ou senha inválidos", nil)
 		case services.ErrUserBlocked:
 			utils.SendErrorResponse(ctx, http.StatusForbidden, "USER_BLOCKED", "Usuário bloqueado por excesso de tentativas de login", nil)
+		case services.ErrPasswordResetRequired:
+			utils.SendErrorResponse(ctx, http.StatusForbidden, "PASSWORD_RESET_REQUIRED", "Redefina a senha para voltar a acessar a conta", nil)
 		case services.ErrUserInactive:
 			utils.SendErrorResponse(ctx, http.StatusForbidden, "USER_INACTIVE", "Usuário inativo", nil)
+		case services.ErrRoleNotAllowed:
+			utils.SendErrorResponse(ctx, http.StatusForbidden, "INVALID_ROLE", "Este usuário não é um cliente", nil)
+		case services.ErrEmailNotVerified:
+			utils.SendErrorResponse(ctx, http.StatusForbidden, "EMAIL_NOT_VERIFIED", "Email ainda não verificado", nil)
 		default:
 			utils.SendErrorResponse(ctx, http.StatusInternalServerError, "SERVER_ERROR", "Erro ao realizar login", nil)
 		}
 		return
 	}
 
-	// Verificamos se o usuário é cliente
-	if user.Role != models.UserRoleClient {
-		utils.SendErrorResponse(ctx, http.StatusForbidden, "INVALID_ROLE", "Este usuário não é um cliente", nil)
+	// Com o segundo fator habilitado, retornamos o desafio em vez dos tokens
+	if mfaRequired != nil {
+		utils.SendSuccessResponse(ctx, http.StatusOK, mfaRequired.Challenge, nil)
 		return
 	}
 
@@ -175,12 +198,18 @@ func (c *ClientAuthController) RefreshToken(ctx *gin.Context) {
 		return
 	}
 
+	// Adicionamos informações do cliente para auditoria
+	req.ClientIP = ctx.ClientIP()
+	req.UserAgent = ctx.GetHeader("User-Agent")
+
 	// Renovamos o token
 	tokens, err := c.AuthService.RefreshToken(req)
 	if err != nil {
 		switch err {
 		case services.ErrInvalidToken:
 			utils.SendErrorResponse(ctx, http.StatusUnauthorized, "INVALID_TOKEN", "Token inválido ou expirado", nil)
+		case services.ErrRefreshTokenReused:
+			utils.SendErrorResponse(ctx, http.StatusUnauthorized, "TOKEN_REUSED", "Refresh token reutilizado, sessão encerrada por segurança", nil)
 		case services.ErrUserInactive:
 			utils.SendErrorResponse(ctx, http.StatusForbidden, "USER_INACTIVE", "Usuário inativo", nil)
 		default:
@@ -193,6 +222,51 @@ func (c *ClientAuthController) RefreshToken(ctx *gin.Context) {
 	utils.SendSuccessResponse(ctx, http.StatusOK, tokens, nil)
 }
 
+// Logout manipula o encerramento da sessão
+// @Summary Logout
+// @Description Encerra a sessão associada ao refresh token informado
+// @Tags client-auth
+// @Accept json
+// @Produce json
+// @Param request body services.LogoutRequest true "Refresh token"
+// @Success 200 {object} SuccessResponse "Sessão encerrada com sucesso"
+// @Failure 400 {object} ErrorResponse "Dados inválidos"
+// @Failure 401 {object} ErrorResponse "Token inválido"
+// @Failure 500 {object} ErrorResponse "Erro interno do servidor"
+// @Router /api/v1/client/auth/logout [post]
+func (c *ClientAuthController) Logout(ctx *gin.Context) {
+	var req services.LogoutRequest
+
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		utils.SendErrorResponse(ctx, http.StatusBadRequest, "INVALID_REQUEST", "Formato da requisição inválido", nil)
+		return
+	}
+
+	// Validamos os dados
+	if req.RefreshToken == "" {
+		utils.SendErrorResponse(ctx, http.StatusUnprocessableEntity, "VALIDATION_ERROR", "Refresh token não fornecido", map[string]interface{}{
+			"refresh_token": "Refresh token é obrigatório",
+		})
+		return
+	}
+
+	// Encerramos a sessão
+	if err := c.AuthService.Logout(req); err != nil {
+		switch err {
+		case services.ErrInvalidToken:
+			utils.SendErrorResponse(ctx, http.StatusUnauthorized, "INVALID_TOKEN", "Token inválido ou expirado", nil)
+		default:
+			utils.SendErrorResponse(ctx, http.StatusInternalServerError, "SERVER_ERROR", "Erro ao encerrar sessão", nil)
+		}
+		return
+	}
+
+	// Retornamos sucesso
+	utils.SendSuccessResponse(ctx, http.StatusOK, nil, map[string]interface{}{
+		"message": "Sessão encerrada com sucesso",
+	})
+}
+
 // ForgotPasswordEmail manipula a solicitação de recuperação de senha via email
 // @Summary Recuperação de senha via email
 // @Description Envia um email com token para recuperação de senha
@@ -242,9 +316,13 @@ func (c *ClientAuthController) ForgotPasswordEmail(ctx *gin.Context) {
 		return
 	}
 
-	// Retornamos sucesso
+	// Retornamos sucesso; no modo de privacidade a mensagem não confirma a existência da conta
+	message := "Email de recuperação enviado com sucesso"
+	if c.AuthService.Config.PrivacyMode {
+		message = "Se o email estiver cadastrado, enviaremos as instruções de recuperação"
+	}
 	utils.SendSuccessResponse(ctx, http.StatusOK, nil, map[string]interface{}{
-		"message": "Email de recuperação enviado com sucesso",
+		"message": message,
 	})
 }
 
@@ -257,6 +335,7 @@ func (c *ClientAuthController) ForgotPasswordEmail(ctx *gin.Context) {
 // @Param request body services.ForgotPasswordRequest true "Telefone do usuário"
 // @Success 200 {object} SuccessResponse "SMS enviado com sucesso"
 // @Failure 400 {object} ErrorResponse "Dados inválidos"
+// @Failure 403 {object} ErrorResponse "Telefone não verificado"
 // @Failure 404 {object} ErrorResponse "Telefone não encontrado"
 // @Failure 429 {object} ErrorResponse "Muitas requisições"
 // @Failure 500 {object} ErrorResponse "Erro interno do servidor"
@@ -287,6 +366,8 @@ func (c *ClientAuthController) ForgotPasswordSMS(ctx *gin.Context) {
 		switch err {
 		case services.ErrPhoneNotFound:
 			utils.SendErrorResponse(ctx, http.StatusNotFound, "PHONE_NOT_FOUND", "Não existe usuário com este telefone", nil)
+		case services.ErrPhoneNotVerified:
+			utils.SendErrorResponse(ctx, http.StatusForbidden, "PHONE_NOT_VERIFIED", "Telefone não verificado, use a recuperação por email", nil)
 		case services.ErrUserInactive:
 			utils.SendErrorResponse(ctx, http.StatusForbidden, "USER_INACTIVE", "Usuário inativo", nil)
 		case services.ErrTooManyRequests:
@@ -297,9 +378,13 @@ func (c *ClientAuthController) ForgotPasswordSMS(ctx *gin.Context) {
 		return
 	}
 
-	// Retornamos sucesso
+	// Retornamos sucesso; no modo de privacidade a mensagem não confirma a existência da conta
+	message := "SMS de recuperação enviado com sucesso"
+	if c.AuthService.Config.PrivacyMode {
+		message = "Se o telefone estiver cadastrado e verificado, enviaremos o código de recuperação"
+	}
 	utils.SendSuccessResponse(ctx, http.StatusOK, nil, map[string]interface{}{
-		"message": "SMS de recuperação enviado com sucesso",
+		"message": message,
 	})
 }
 
@@ -312,6 +397,7 @@ func (c *ClientAuthController) ForgotPasswordSMS(ctx *gin.Context) {
 // @Param request body services.ForgotPasswordRequest true "Telefone do usuário"
 // @Success 200 {object} SuccessResponse "Mensagem enviada com sucesso"
 // @Failure 400 {object} ErrorResponse "Dados inválidos"
+// @Failure 403 {object} ErrorResponse "Telefone não verificado"
 // @Failure 404 {object} ErrorResponse "Telefone não encontrado"
 // @Failure 429 {object} ErrorResponse "Muitas requisições"
 // @Failure 500 {object} ErrorResponse "Erro interno do servidor"
@@ -342,6 +428,8 @@ func (c *ClientAuthController) ForgotPasswordWhatsApp(ctx *gin.Context) {
 		switch err {
 		case services.ErrPhoneNotFound:
 			utils.SendErrorResponse(ctx, http.StatusNotFound, "PHONE_NOT_FOUND", "Não existe usuário com este telefone", nil)
+		case services.ErrPhoneNotVerified:
+			utils.SendErrorResponse(ctx, http.StatusForbidden, "PHONE_NOT_VERIFIED", "Telefone não verificado, use a recuperação por email", nil)
 		case services.ErrUserInactive:
 			utils.SendErrorResponse(ctx, http.StatusForbidden, "USER_INACTIVE", "Usuário inativo", nil)
 		case services.ErrTooManyRequests:
@@ -352,9 +440,13 @@ func (c *ClientAuthController) ForgotPasswordWhatsApp(ctx *gin.Context) {
 		return
 	}
 
-	// Retornamos sucesso
+	// Retornamos sucesso; no modo de privacidade a mensagem não confirma a existência da conta
+	message := "Mensagem de WhatsApp enviada com sucesso"
+	if c.AuthService.Config.PrivacyMode {
+		message = "Se o telefone estiver cadastrado e verificado, enviaremos o código de recuperação"
+	}
 	utils.SendSuccessResponse(ctx, http.StatusOK, nil, map[string]interface{}{
-		"message": "Mensagem de WhatsApp enviada com sucesso",
+		"message": message,
 	})
 }
 
@@ -389,9 +481,43 @@ func (c *ClientAuthController) ValidateResetToken(ctx *gin.Context) {
 	})
 }
 
+// VerifyResetCode confere um código de recuperação recebido por SMS ou WhatsApp
+// @Summary Conferência de código de recuperação
+// @Description Confere o código enviado ao telefone sem consumi-lo. Após 5 códigos errados o código é revogado
+// @Tags client-auth
+// @Accept json
+// @Produce json
+// @Param request body services.VerifyResetCodeRequest true "Telefone e código recebido"
+// @Success 200 {object} SuccessResponse "Código válido"
+// @Failure 400 {object} ErrorResponse "Código inválido, expirado ou revogado"
+// @Failure 500 {object} ErrorResponse "Erro interno do servidor"
+// @Router /api/v1/client/auth/reset-password/verify-code [post]
+func (c *ClientAuthController) VerifyResetCode(ctx *gin.Context) {
+	var req services.VerifyResetCodeRequest
+
+	if err := ctx.ShouldBindJSON(&req); err != nil || req.Phone == "" || req.Code == "" {
+		utils.SendErrorResponse(ctx, http.StatusBadRequest, "INVALID_REQUEST", "Telefone e código são obrigatórios", nil)
+		return
+	}
+
+	if err := c.AuthService.VerifyResetCode(req); err != nil {
+		switch err {
+		case services.ErrInvalidToken:
+			utils.SendErrorResponse(ctx, http.StatusBadRequest, "INVALID_CODE", "Código inválido ou expirado", nil)
+		default:
+			utils.SendErrorResponse(ctx, http.StatusInternalServerError, "SERVER_ERROR", "Erro ao verificar código", nil)
+		}
+		return
+	}
+
+	utils.SendSuccessResponse(ctx, http.StatusOK, nil, map[string]interface{}{
+		"valid": true,
+	})
+}
+
 // ResetPassword redefine a senha de um usuário
 // @Summary Redefinição de senha
-// @Description Redefine a senha de um usuário usando um token de recuperação
+// @Description Redefine a senha de um usuário usando um token de recuperação. Códigos recebidos por SMS ou WhatsApp devem ser enviados junto com o telefone
 // @Tags client-auth
 // @Accept json
 // @Produce json
@@ -428,15 +554,22 @@ func (c *ClientAuthController) ResetPassword(ctx *gin.Context) {
 		return
 	}
 
+	// Adicionamos informações do cliente para auditoria
+	req.ClientIP = ctx.ClientIP()
+	req.UserAgent = ctx.GetHeader("User-Agent")
+
 	// Redefinimos a senha
 	err := c.AuthService.ResetPassword(req)
 	if err != nil {
 		switch err {
 		case services.ErrInvalidToken:
 			utils.SendErrorResponse(ctx, http.StatusUnauthorized, "INVALID_TOKEN", "Token inválido ou expirado", nil)
-		case services.ErrPasswordTooWeak:
-			utils.SendErrorResponse(ctx, http.StatusUnprocessableEntity, "VALIDATION_ERROR", "Senha muito fraca", map[string]interface{}{
-				"password": "A senha deve conter letras maiúsculas, minúsculas, números e caracteres especiais",
+		case services.ErrPasswordTooWeak, services.ErrPasswordRepeatedChars, services.ErrPasswordCommon,
+			services.ErrPasswordPersonalInfo, services.ErrPasswordBreached:
+			sendPasswordPolicyError(ctx, err)
+		case services.ErrPasswordReused:
+			utils.SendErrorResponse(ctx, http.StatusUnprocessableEntity, "VALIDATION_ERROR", "Senha usada recentemente", map[string]interface{}{
+				"password": "A nova senha não pode repetir as últimas senhas da conta",
 			})
 		case services.ErrPasswordConfirmation:
 			utils.SendErrorResponse(ctx, http.StatusUnprocessableEntity, "VALIDATION_ERROR", "Senhas não conferem", map[string]interface{}{
@@ -456,17 +589,528 @@ func (c *ClientAuthController) ResetPassword(ctx *gin.Context) {
 	})
 }
 
+// BeginWebAuthnLogin inicia o login de clientes com passkey
+// @Summary Inicia o login com passkey
+// @Description Gera o desafio e as opções para navigator.credentials.get()
+// @Tags client-auth
+// @Accept json
+// @Produce json
+// @Param request body services.WebAuthnLoginBeginRequest false "Email opcional para limitar as passkeys oferecidas"
+// @Success 200 {object} services.WebAuthnLoginOptions "Opções geradas com sucesso"
+// @Failure 400 {object} ErrorResponse "Dados inválidos"
+// @Failure 500 {object} ErrorResponse "Erro interno do servidor"
+// @Router /api/v1/client/auth/webauthn/login/begin [post]
+func (c *ClientAuthController) BeginWebAuthnLogin(ctx *gin.Context) {
+	var req services.WebAuthnLoginBeginRequest
+
+	// O corpo é opcional para passkeys descobríveis
+	if ctx.Request.ContentLength > 0 {
+		if err := ctx.ShouldBindJSON(&req); err != nil {
+			utils.SendErrorResponse(ctx, http.StatusBadRequest, "INVALID_REQUEST", "Formato da requisição inválido", nil)
+			return
+		}
+	}
+
+	options, err := c.AuthService.BeginWebAuthnLogin(req)
+	if err != nil {
+		utils.SendErrorResponse(ctx, http.StatusInternalServerError, "SERVER_ERROR", "Erro ao iniciar login com passkey", nil)
+		return
+	}
+
+	utils.SendSuccessResponse(ctx, http.StatusOK, options, nil)
+}
+
+// FinishWebAuthnLogin conclui o login de clientes com passkey
+// @Summary Conclui o login com passkey
+// @Description Valida a asserção do autenticador e retorna tokens de acesso, ou o desafio do segundo fator quando a passkey não verificou o usuário e o TOTP está habilitado
+// @Tags client-auth
+// @Accept json
+// @Produce json
+// @Param request body services.WebAuthnLoginFinishRequest true "Resposta do autenticador"
+// @Success 200 {object} services.TokenResponse "Tokens gerados com sucesso"
+// @Failure 400 {object} ErrorResponse "Dados inválidos"
+// @Failure 401 {object} ErrorResponse "Passkey inválida"
+// @Failure 403 {object} ErrorResponse "Usuário bloqueado"
+// @Failure 500 {object} ErrorResponse "Erro interno do servidor"
+// @Router /api/v1/client/auth/webauthn/login/finish [post]
+func (c *ClientAuthController) FinishWebAuthnLogin(ctx *gin.Context) {
+	var req services.WebAuthnLoginFinishRequest
+
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		utils.SendErrorResponse(ctx, http.StatusBadRequest, "INVALID_REQUEST", "Formato da requisição inválido", nil)
+		return
+	}
+
+	// Validamos os dados
+	if req.Credential.RawID == "" || req.Credential.Response.ClientDataJSON == "" {
+		utils.SendErrorResponse(ctx, http.StatusUnprocessableEntity, "VALIDATION_ERROR", "Resposta do autenticador não fornecida", map[string]interface{}{
+			"credential": "Resposta do autenticador é obrigatória",
+		})
+		return
+	}
+
+	// Adicionamos informações do cliente para auditoria
+	req.ClientIP = ctx.ClientIP()
+	req.UserAgent = ctx.GetHeader("User-Agent")
+
+	req.AllowedRoles = []models.UserRole{models.UserRoleClient}
+
+	_, tokens, err := c.AuthService.FinishWebAuthnLogin(req)
+	var mfaRequired *services.MFARequiredError
+	if err != nil && !errors.As(err, &mfaRequired) {
+		switch err {
+		case services.ErrWebAuthnFailed:
+			utils.SendErrorResponse(ctx, http.StatusUnauthorized, "INVALID_PASSKEY", "Passkey inválida", nil)
+		case services.ErrUserBlocked:
+			utils.SendErrorResponse(ctx, http.StatusForbidden, "USER_BLOCKED", "Usuário bloqueado por excesso de tentativas de login", nil)
+		case services.ErrPasswordResetRequired:
+			utils.SendErrorResponse(ctx, http.StatusForbidden, "PASSWORD_RESET_REQUIRED", "Redefina a senha para voltar a acessar a conta", nil)
+		case services.ErrUserInactive:
+			utils.SendErrorResponse(ctx, http.StatusForbidden, "USER_INACTIVE", "Usuário inativo", nil)
+		case services.ErrRoleNotAllowed:
+			utils.SendErrorResponse(ctx, http.StatusForbidden, "INVALID_ROLE", "Este usuário não é um cliente", nil)
+		default:
+			utils.SendErrorResponse(ctx, http.StatusInternalServerError, "SERVER_ERROR", "Erro ao realizar login", nil)
+		}
+		return
+	}
+
+	// Sem a verificacao do usuario na passkey, quem tem TOTP ainda informa o codigo
+	if mfaRequired != nil {
+		utils.SendSuccessResponse(ctx, http.StatusOK, mfaRequired.Challenge, nil)
+		return
+	}
+
+	// Retornamos os tokens
+	utils.SendSuccessResponse(ctx, http.StatusOK, tokens, nil)
+}
+
+// VerifyEmail confirma o email de um novo cliente
+// @Summary Verificação de email
+// @Description Confirma o email usando o token do link enviado no cadastro e ativa a conta
+// @Tags client-auth
+// @Accept json
+// @Produce json
+// @Param request body services.VerifyEmailRequest true "Token de verificação"
+// @Success 200 {object} models.User "Email verificado com sucesso"
+// @Failure 400 {object} ErrorResponse "Token inválido"
+// @Failure 403 {object} ErrorResponse "Usuário inativo"
+// @Failure 500 {object} ErrorResponse "Erro interno do servidor"
+// @Router /api/v1/client/auth/verify-email [post]
+func (c *ClientAuthController) VerifyEmail(ctx *gin.Context) {
+	var req services.VerifyEmailRequest
+
+	if err := ctx.ShouldBindJSON(&req); err != nil || req.Token == "" {
+		utils.SendErrorResponse(ctx, http.StatusBadRequest, "INVALID_TOKEN", "Token não fornecido", nil)
+		return
+	}
+
+	user, err := c.AuthService.VerifyEmail(req)
+	if err != nil {
+		switch err {
+		case services.ErrInvalidToken:
+			utils.SendErrorResponse(ctx, http.StatusBadRequest, "INVALID_TOKEN", "Token inválido ou expirado", nil)
+		case services.ErrUserInactive:
+			utils.SendErrorResponse(ctx, http.StatusForbidden, "USER_INACTIVE", "Usuário inativo", nil)
+		default:
+			utils.SendErrorResponse(ctx, http.StatusInternalServerError, "SERVER_ERROR", "Erro ao verificar email", nil)
+		}
+		return
+	}
+
+	utils.SendSuccessResponse(ctx, http.StatusOK, user, nil)
+}
+
+// ResendVerificationEmail reenvia o link de verificação de email
+// @Summary Reenvio da verificação de email
+// @Description Envia um novo link de verificação, invalidando os anteriores
+// @Tags client-auth
+// @Accept json
+// @Produce json
+// @Param request body services.ResendVerificationRequest true "Email do usuário"
+// @Success 200 {object} SuccessResponse "Email enviado com sucesso"
+// @Failure 400 {object} ErrorResponse "Dados inválidos"
+// @Failure 404 {object} ErrorResponse "Email não encontrado"
+// @Failure 409 {object} ErrorResponse "Email já verificado"
+// @Failure 429 {object} ErrorResponse "Muitas requisições"
+// @Failure 500 {object} ErrorResponse "Erro interno do servidor"
+// @Router /api/v1/client/auth/verify-email/resend [post]
+func (c *ClientAuthController) ResendVerificationEmail(ctx *gin.Context) {
+	var req services.ResendVerificationRequest
+
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		utils.SendErrorResponse(ctx, http.StatusBadRequest, "INVALID_REQUEST", "Formato da requisição inválido", nil)
+		return
+	}
+
+	// Validamos os dados
+	if req.Email == "" {
+		utils.SendErrorResponse(ctx, http.StatusUnprocessableEntity, "VALIDATION_ERROR", "Email não fornecido", map[string]interface{}{
+			"email": "Email é obrigatório",
+		})
+		return
+	}
+
+	// Adicionamos informações do cliente para auditoria
+	req.ClientIP = ctx.ClientIP()
+	req.UserAgent = ctx.GetHeader("User-Agent")
+
+	if err := c.AuthService.ResendVerificationEmail(req); err != nil {
+		switch err {
+		case services.ErrEmailNotFound:
+			utils.SendErrorResponse(ctx, http.StatusNotFound, "EMAIL_NOT_FOUND", "Não existe usuário com este email", nil)
+		case services.ErrEmailAlreadyVerified:
+			utils.SendErrorResponse(ctx, http.StatusConflict, "EMAIL_ALREADY_VERIFIED", "Email já verificado", nil)
+		case services.ErrUserInactive:
+			utils.SendErrorResponse(ctx, http.StatusForbidden, "USER_INACTIVE", "Usuário inativo", nil)
+		case services.ErrTooManyRequests:
+			utils.SendErrorResponse(ctx, http.StatusTooManyRequests, "TOO_MANY_REQUESTS", "Muitas solicitações em um curto período", nil)
+		default:
+			utils.SendErrorResponse(ctx, http.StatusInternalServerError, "SERVER_ERROR", "Erro ao enviar email de verificação", nil)
+		}
+		return
+	}
+
+	utils.SendSuccessResponse(ctx, http.StatusOK, nil, map[string]interface{}{
+		"message": "Email de verificação enviado com sucesso",
+	})
+}
+
+// UnlockAccount desbloqueia a conta pelo link enviado no aviso de bloqueio
+// @Summary Desbloqueio de conta
+// @Description Remove o bloqueio por tentativas de login usando o token do link enviado por email
+// @Tags client-auth
+// @Accept json
+// @Produce json
+// @Param request body services.UnlockAccountRequest true "Token de desbloqueio"
+// @Success 200 {object} SuccessResponse "Conta desbloqueada com sucesso"
+// @Failure 400 {object} ErrorResponse "Token inválido"
+// @Failure 500 {object} ErrorResponse "Erro interno do servidor"
+// @Router /api/v1/client/auth/unlock [post]
+func (c *ClientAuthController) UnlockAccount(ctx *gin.Context) {
+	var req services.UnlockAccountRequest
+
+	if err := ctx.ShouldBindJSON(&req); err != nil || req.Token == "" {
+		utils.SendErrorResponse(ctx, http.StatusBadRequest, "INVALID_TOKEN", "Token não fornecido", nil)
+		return
+	}
+
+	// Adicionamos informações do cliente para auditoria
+	req.ClientIP = ctx.ClientIP()
+	req.UserAgent = ctx.GetHeader("User-Agent")
+
+	if err := c.AuthService.UnlockAccount(req); err != nil {
+		switch err {
+		case services.ErrInvalidToken:
+			utils.SendErrorResponse(ctx, http.StatusBadRequest, "INVALID_TOKEN", "Token inválido ou expirado", nil)
+		default:
+			utils.SendErrorResponse(ctx, http.StatusInternalServerError, "SERVER_ERROR", "Erro ao desbloquear conta", nil)
+		}
+		return
+	}
+
+	utils.SendSuccessResponse(ctx, http.StatusOK, nil, map[string]interface{}{
+		"message": "Conta desbloqueada com sucesso",
+	})
+}
+
+// SecureAccount atende o link "não fui eu" enviado no aviso de login em novo dispositivo
+// @Summary Protege a conta após um login não reconhecido
+// @Description Encerra todas as sessões, remove passkeys, provedores vinculados e chaves de API criados desde o login avisado, exige a redefinição da senha e envia um link de recuperação para o email da conta
+// @Tags client-auth
+// @Accept json
+// @Produce json
+// @Param request body services.SecureAccountRequest true "Token do link"
+// @Success 200 {object} SuccessResponse "Conta protegida com sucesso"
+// @Failure 400 {object} ErrorResponse "Token inválido"
+// @Failure 500 {object} ErrorResponse "Erro interno do servidor"
+// @Router /api/v1/client/auth/secure-account [post]
+func (c *ClientAuthController) SecureAccount(ctx *gin.Context) {
+	var req services.SecureAccountRequest
+
+	if err := ctx.ShouldBindJSON(&req); err != nil || req.Token == "" {
+		utils.SendErrorResponse(ctx, http.StatusBadRequest, "INVALID_TOKEN", "Token não fornecido", nil)
+		return
+	}
+
+	// Adicionamos informações do cliente para auditoria
+	req.ClientIP = ctx.ClientIP()
+	req.UserAgent = ctx.GetHeader("User-Agent")
+
+	if err := c.AuthService.SecureAccount(req); err != nil {
+		switch err {
+		case services.ErrInvalidToken:
+			utils.SendErrorResponse(ctx, http.StatusBadRequest, "INVALID_TOKEN", "Token inválido ou expirado", nil)
+		default:
+			utils.SendErrorResponse(ctx, http.StatusInternalServerError, "SERVER_ERROR", "Erro ao proteger a conta", nil)
+		}
+		return
+	}
+
+	utils.SendSuccessResponse(ctx, http.StatusOK, nil, map[string]interface{}{
+		"message": "Sessões encerradas. Enviamos um link para redefinir a senha",
+	})
+}
+
+// RequestMagicLink envia um link mágico de login por email
+// @Summary Solicita link mágico de login
+// @Description Envia por email um link de uso único que permite entrar sem senha
+// @Tags client-auth
+// @Accept json
+// @Produce json
+// @Param request body services.PasswordlessRequest true "Email do cliente"
+// @Success 202 {object} SuccessResponse "Solicitação recebida"
+// @Failure 400 {object} ErrorResponse "Dados inválidos"
+// @Failure 422 {object} ErrorResponse "Validação falhou"
+// @Failure 429 {object} ErrorResponse "Muitas requisições"
+// @Failure 500 {object} ErrorResponse "Erro interno do servidor"
+// @Router /api/v1/client/auth/passwordless/email [post]
+func (c *ClientAuthController) RequestMagicLink(ctx *gin.Context) {
+	var req services.PasswordlessRequest
+
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		utils.SendErrorResponse(ctx, http.StatusBadRequest, "INVALID_REQUEST", "Formato da requisição inválido", nil)
+		return
+	}
+
+	// Validamos os dados
+	if req.Email == "" {
+		utils.SendErrorResponse(ctx, http.StatusUnprocessableEntity, "VALIDATION_ERROR", "Email não fornecido", map[string]interface{}{
+			"email": "Email é obrigatório",
+		})
+		return
+	}
+
+	// Adicionamos informações do cliente para auditoria
+	req.ClientIP = ctx.ClientIP()
+	req.UserAgent = ctx.GetHeader("User-Agent")
+
+	// A resposta e a mesma para qualquer email, exista ou nao a conta
+	c.AuthService.SendMagicLink(req)
+
+	utils.SendSuccessResponse(ctx, http.StatusAccepted, nil, map[string]interface{}{
+		"message": "Se o email pertencer a um cliente, enviaremos o link de acesso",
+	})
+}
+
+// RequestLoginCodeSMS envia um código de login por SMS
+// @Summary Solicita código de login via SMS
+// @Description Envia por SMS um código de uso único que permite entrar sem senha
+// @Tags client-auth
+// @Accept json
+// @Produce json
+// @Param request body services.PasswordlessRequest true "Telefone do cliente"
+// @Success 202 {object} SuccessResponse "Solicitação recebida"
+// @Failure 400 {object} ErrorResponse "Dados inválidos"
+// @Failure 422 {object} ErrorResponse "Validação falhou"
+// @Failure 429 {object} ErrorResponse "Muitas requisições"
+// @Failure 500 {object} ErrorResponse "Erro interno do servidor"
+// @Router /api/v1/client/auth/passwordless/sms [post]
+func (c *ClientAuthController) RequestLoginCodeSMS(ctx *gin.Context) {
+	c.requestLoginCode(ctx, models.TokenChannelSMS)
+}
+
+// RequestLoginCodeWhatsApp envia um código de login por WhatsApp
+// @Summary Solicita código de login via WhatsApp
+// @Description Envia por WhatsApp um código de uso único que permite entrar sem senha
+// @Tags client-auth
+// @Accept json
+// @Produce json
+// @Param request body services.PasswordlessRequest true "Telefone do cliente"
+// @Success 202 {object} SuccessResponse "Solicitação recebida"
+// @Failure 400 {object} ErrorResponse "Dados inválidos"
+// @Failure 422 {object} ErrorResponse "Validação falhou"
+// @Failure 429 {object} ErrorResponse "Muitas requisições"
+// @Failure 500 {object} ErrorResponse "Erro interno do servidor"
+// @Router /api/v1/client/auth/passwordless/whatsapp [post]
+func (c *ClientAuthController) RequestLoginCodeWhatsApp(ctx *gin.Context) {
+	c.requestLoginCode(ctx, models.TokenChannelWhatsApp)
+}
+
+// requestLoginCode envia o código de login pelo canal informado
+func (c *ClientAuthController) requestLoginCode(ctx *gin.Context, channel models.TokenChannel) {
+	var req services.PasswordlessRequest
+
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		utils.SendErrorResponse(ctx, http.StatusBadRequest, "INVALID_REQUEST", "Formato da requisição inválido", nil)
+		return
+	}
+
+	// Validamos os dados
+	if req.Phone == "" {
+		utils.SendErrorResponse(ctx, http.StatusUnprocessableEntity, "VALIDATION_ERROR", "Telefone não fornecido", map[string]interface{}{
+			"phone": "Telefone é obrigatório",
+		})
+		return
+	}
+
+	// Adicionamos informações do cliente para auditoria
+	req.ClientIP = ctx.ClientIP()
+	req.UserAgent = ctx.GetHeader("User-Agent")
+
+	// A resposta e a mesma para qualquer telefone, exista ou nao a conta
+	c.AuthService.SendLoginCode(req, channel)
+
+	utils.SendSuccessResponse(ctx, http.StatusAccepted, nil, map[string]interface{}{
+		"message": "Se o telefone verificado pertencer a um cliente, enviaremos o código de acesso",
+	})
+}
+
+// LoginPasswordless troca um link mágico ou código de login por tokens
+// @Summary Login sem senha
+// @Description Troca o token do link mágico, ou o telefone e o código recebido, por tokens de acesso
+// @Tags client-auth
+// @Accept json
+// @Produce json
+// @Param request body services.PasswordlessLoginRequest true "Token do link ou telefone e código"
+// @Success 200 {object} services.TokenResponse "Tokens gerados com sucesso"
+// @Failure 400 {object} ErrorResponse "Dados inválidos"
+// @Failure 401 {object} ErrorResponse "Link ou código inválido"
+// @Failure 403 {object} ErrorResponse "Usuário bloqueado"
+// @Failure 422 {object} ErrorResponse "Validação falhou"
+// @Failure 500 {object} ErrorResponse "Erro interno do servidor"
+// @Router /api/v1/client/auth/passwordless/login [post]
+func (c *ClientAuthController) LoginPasswordless(ctx *gin.Context) {
+	var req services.PasswordlessLoginRequest
+
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		utils.SendErrorResponse(ctx, http.StatusBadRequest, "INVALID_REQUEST", "Formato da requisição inválido", nil)
+		return
+	}
+
+	// Validamos os dados
+	if req.Token == "" && (req.Phone == "" || req.Code == "") {
+		utils.SendErrorResponse(ctx, http.StatusUnprocessableEntity, "VALIDATION_ERROR", "Campos obrigatórios não preenchidos", map[string]interface{}{
+			"token": "Token do link ou telefone e código são obrigatórios",
+		})
+		return
+	}
+
+	// Adicionamos informações do cliente para auditoria
+	req.ClientIP = ctx.ClientIP()
+	req.UserAgent = ctx.GetHeader("User-Agent")
+
+	_, tokens, err := c.AuthService.LoginPasswordless(req)
+	var mfaRequired *services.MFARequiredError
+	if err != nil && !errors.As(err, &mfaRequired) {
+		switch err {
+		case services.ErrInvalidToken:
+			utils.SendErrorResponse(ctx, http.StatusUnauthorized, "INVALID_TOKEN", "Link ou código inválido ou expirado", nil)
+		case services.ErrPasswordlessDenied:
+			utils.SendErrorResponse(ctx, http.StatusForbidden, "INVALID_ROLE", "Este usuário não é um cliente", nil)
+		case services.ErrUserBlocked:
+			utils.SendErrorResponse(ctx, http.StatusForbidden, "USER_BLOCKED", "Usuário bloqueado por excesso de tentativas de login", nil)
+		case services.ErrPasswordResetRequired:
+			utils.SendErrorResponse(ctx, http.StatusForbidden, "PASSWORD_RESET_REQUIRED", "Redefina a senha para voltar a acessar a conta", nil)
+		case services.ErrUserInactive:
+			utils.SendErrorResponse(ctx, http.StatusForbidden, "USER_INACTIVE", "Usuário inativo", nil)
+		default:
+			utils.SendErrorResponse(ctx, http.StatusInternalServerError, "SERVER_ERROR", "Erro ao realizar login", nil)
+		}
+		return
+	}
+
+	// Com o segundo fator habilitado, retornamos o desafio em vez dos tokens
+	if mfaRequired != nil {
+		utils.SendSuccessResponse(ctx, http.StatusOK, mfaRequired.Challenge, nil)
+		return
+	}
+
+	// Retornamos os tokens
+	utils.SendSuccessResponse(ctx, http.StatusOK, tokens, nil)
+}
+
+// SocialLogin autentica um cliente com Google ou Apple
+// @Summary Login social
+// @Description Valida o ID token do provedor, vincula a identidade pelo email verificado ou cria um novo cliente, e retorna tokens de acesso
+// @Tags client-auth
+// @Accept json
+// @Produce json
+// @Param provider path string true "Provedor (google ou apple)"
+// @Param request body services.SocialLoginRequest true "ID token do provedor"
+// @Success 200 {object} services.TokenResponse "Tokens gerados com sucesso"
+// @Failure 400 {object} ErrorResponse "Dados inválidos ou provedor não suportado"
+// @Failure 401 {object} ErrorResponse "ID token inválido"
+// @Failure 403 {object} ErrorResponse "Usuário bloqueado ou inativo"
+// @Failure 409 {object} ErrorResponse "Conta do provedor já vinculada a outro usuário"
+// @Failure 500 {object} ErrorResponse "Erro interno do servidor"
+// @Router /api/v1/client/auth/social/{provider} [post]
+func (c *ClientAuthController) SocialLogin(ctx *gin.Context) {
+	var req services.SocialLoginRequest
+
+	if err := ctx.ShouldBindJSON(&req); err != nil || req.IDToken == "" || req.Nonce == "" {
+		utils.SendErrorResponse(ctx, http.StatusBadRequest, "INVALID_REQUEST", "ID token e nonce são obrigatórios", nil)
+		return
+	}
+
+	// Adicionamos informações do cliente para auditoria
+	req.ClientIP = ctx.ClientIP()
+	req.UserAgent = ctx.GetHeader("User-Agent")
+
+	user, tokens, err := c.AuthService.LoginWithProvider(ctx.Param("provider"), req)
+	var mfaRequired *services.MFARequiredError
+	if err != nil && !errors.As(err, &mfaRequired) {
+		switch err {
+		case services.ErrProviderNotSupported:
+			utils.SendErrorResponse(ctx, http.StatusBadRequest, "PROVIDER_NOT_SUPPORTED", "Provedor de identidade não suportado", nil)
+		case services.ErrInvalidToken:
+			utils.SendErrorResponse(ctx, http.StatusUnauthorized, "INVALID_TOKEN", "ID token inválido ou expirado", nil)
+		case services.ErrProviderEmailMissing:
+			utils.SendErrorResponse(ctx, http.StatusUnauthorized, "EMAIL_NOT_VERIFIED", "O provedor não informou um email verificado", nil)
+		case services.ErrProviderAlreadyLinked:
+			utils.SendErrorResponse(ctx, http.StatusConflict, "PROVIDER_ALREADY_LINKED", "Já existe outra conta deste provedor vinculada a este usuário", nil)
+		case services.ErrUserBlocked:
+			utils.SendErrorResponse(ctx, http.StatusForbidden, "USER_BLOCKED", "Usuário bloqueado por excesso de tentativas de login", nil)
+		case services.ErrPasswordResetRequired:
+			utils.SendErrorResponse(ctx, http.StatusForbidden, "PASSWORD_RESET_REQUIRED", "Redefina a senha para voltar a acessar a conta", nil)
+		case services.ErrUserInactive:
+			utils.SendErrorResponse(ctx, http.StatusForbidden, "USER_INACTIVE", "Usuário inativo", nil)
+		default:
+			utils.SendErrorResponse(ctx, http.StatusInternalServerError, "SERVER_ERROR", "Erro ao realizar login", nil)
+		}
+		return
+	}
+
+	// Verificamos se o usuário é cliente
+	if user.Role != models.UserRoleClient {
+		utils.SendErrorResponse(ctx, http.StatusForbidden, "INVALID_ROLE", "Este usuário não é um cliente", nil)
+		return
+	}
+
+	// Com o segundo fator habilitado, retornamos o desafio em vez dos tokens
+	if mfaRequired != nil {
+		utils.SendSuccessResponse(ctx, http.StatusOK, mfaRequired.Challenge, nil)
+		return
+	}
+
+	// Retornamos os tokens
+	utils.SendSuccessResponse(ctx, http.StatusOK, tokens, nil)
+}
+
 // RegisterRoutes registra as rotas do controlador
 func (c *ClientAuthController) RegisterRoutes(router *gin.RouterGroup) {
 	auth := router.Group("/auth")
 	{
 		auth.POST("/register", c.Register)
 		auth.POST("/login", c.Login)
+		auth.POST("/verify-email", c.VerifyEmail)
+		auth.POST("/verify-email/resend", c.ResendVerificationEmail)
+		auth.POST("/unlock", c.UnlockAccount)
+		auth.POST("/secure-account", c.SecureAccount)
 		auth.POST("/refresh", c.RefreshToken)
+		auth.POST("/logout", c.Logout)
+		auth.POST("/webauthn/login/begin", c.BeginWebAuthnLogin)
+		auth.POST("/webauthn/login/finish", c.FinishWebAuthnLogin)
+		auth.POST("/passwordless/email", c.RequestMagicLink)
+		auth.POST("/passwordless/sms", c.RequestLoginCodeSMS)
+		auth.POST("/passwordless/whatsapp", c.RequestLoginCodeWhatsApp)
+		auth.POST("/passwordless/login", c.LoginPasswordless)
+		auth.POST("/social/:provider", c.SocialLogin)
 		auth.POST("/forgot-password/email", c.ForgotPasswordEmail)
 		auth.POST("/forgot-password/sms", c.ForgotPasswordSMS)
 		auth.POST("/forgot-password/whatsapp", c.ForgotPasswordWhatsApp)
 		auth.GET("/reset-password/validate/:token", c.ValidateResetToken)
+		auth.POST("/reset-password/verify-code", c.VerifyResetCode)
 		auth.POST("/reset-password", c.ResetPassword)
 	}
 }
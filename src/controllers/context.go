@@ -0,0 +1,55 @@
+package controllers
+
+import (
+	"github.com/Barba2k2/aurora_backend/src/models"
+	"github.com/Barba2k2/aurora_backend/src/services"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// currentUser obtém o usuário autenticado armazenado no contexto pelo AuthMiddleware
+func currentUser(ctx *gin.Context) *models.User {
+	user, exists := ctx.Get("user")
+	if !exists {
+		return nil
+	}
+	userObj, _ := user.(*models.User)
+	return userObj
+}
+
+// currentSessionID obtém o ID da sessão do token de acesso usado na requisição
+func currentSessionID(ctx *gin.Context) uuid.UUID {
+	sessionID, err := uuid.Parse(ctx.GetString("session_id"))
+	if err != nil {
+		return uuid.Nil
+	}
+	return sessionID
+}
+
+// currentEstablishmentID obtém o estabelecimento escolhido na sessão, presente no token da equipe
+func currentEstablishmentID(ctx *gin.Context) *uuid.UUID {
+	establishmentID, err := uuid.Parse(ctx.GetString("establishment_id"))
+	if err != nil {
+		return nil
+	}
+	return &establishmentID
+}
+
+// currentImpersonatorID obtém o administrador que está agindo como o usuário, presente nos tokens de personificação
+func currentImpersonatorID(ctx *gin.Context) *uuid.UUID {
+	impersonatorID, err := uuid.Parse(ctx.GetString("impersonator_id"))
+	if err != nil {
+		return nil
+	}
+	return &impersonatorID
+}
+
+// currentPermissions obtém as permissões calculadas pelo middleware RequirePermission na requisição
+func currentPermissions(ctx *gin.Context) *services.PermissionSet {
+	permissions, exists := ctx.Get("permissions")
+	if !exists {
+		return nil
+	}
+	permissionSet, _ := permissions.(*services.PermissionSet)
+	return permissionSet
+}
@@ -0,0 +1,111 @@
+package controllers
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/Barba2k2/aurora_backend/src/middlewares"
+	"github.com/Barba2k2/aurora_backend/src/services"
+	"github.com/Barba2k2/aurora_backend/src/utils"
+	"github.com/gin-gonic/gin"
+)
+
+// DataRightsController manipula os direitos do titular previstos na LGPD: portabilidade e eliminação dos dados
+type DataRightsController struct {
+	AuthService *services.AuthService
+}
+
+// NewDataRightsController cria uma nova instância de DataRightsController
+func NewDataRightsController(authService *services.AuthService) *DataRightsController {
+	return &DataRightsController{
+		AuthService: authService,
+	}
+}
+
+// ExportData baixa os dados pessoais do usuário autenticado
+// @Summary Exporta os dados pessoais
+// @Description Gera um arquivo zip com um JSON por assunto: perfil, vínculos, sessões, dispositivos, contas vinculadas, passkeys, avisos enviados e eventos de autenticação
+// @Tags account
+// @Produce application/zip
+// @Security BearerAuth
+// @Success 200 {file} file "Arquivo com os dados pessoais"
+// @Failure 403 {object} ErrorResponse "Indisponível durante acesso assistido"
+// @Failure 500 {object} ErrorResponse "Erro interno do servidor"
+// @Router /api/v1/client/me/data-export [get]
+// @Router /api/v1/professional/me/data-export [get]
+func (c *DataRightsController) ExportData(ctx *gin.Context) {
+	archive, err := c.AuthService.ExportPersonalData(currentUser(ctx), ctx.ClientIP(), ctx.GetHeader("User-Agent"))
+	if err != nil {
+		utils.SendErrorResponse(ctx, http.StatusInternalServerError, "SERVER_ERROR", "Erro ao exportar dados pessoais", nil)
+		return
+	}
+
+	filename := "aurora-dados-" + time.Now().Format("2006-01-02") + ".zip"
+	ctx.Header("Content-Disposition", `attachment; filename="`+filename+`"`)
+	ctx.Data(http.StatusOK, "application/zip", archive)
+}
+
+// RequestErasure solicita a eliminação dos dados pessoais do usuário autenticado
+// @Summary Solicita a eliminação dos dados pessoais
+// @Description Desativa a conta imediatamente e anonimiza os dados ao fim do prazo de carência. Registros financeiros são mantidos de forma anonimizada.
+// @Description Exige a senha atual; contas sem senha, criadas pelo login social, enviam no lugar dela um ID token recente do provedor vinculado, com o provedor e o nonce.
+// @Tags account
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param request body services.RequestErasureRequest true "Senha atual ou ID token do provedor"
+// @Success 200 {object} SuccessResponse "Eliminação agendada"
+// @Failure 400 {object} ErrorResponse "Dados inválidos"
+// @Failure 401 {object} ErrorResponse "Senha ou ID token inválido"
+// @Failure 403 {object} ErrorResponse "Indisponível durante acesso assistido"
+// @Failure 422 {object} ErrorResponse "Validação falhou"
+// @Failure 500 {object} ErrorResponse "Erro interno do servidor"
+// @Router /api/v1/client/me/data-erasure [post]
+// @Router /api/v1/professional/me/data-erasure [post]
+func (c *DataRightsController) RequestErasure(ctx *gin.Context) {
+	var req services.RequestErasureRequest
+
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		utils.SendErrorResponse(ctx, http.StatusBadRequest, "INVALID_REQUEST", "Formato da requisição inválido", nil)
+		return
+	}
+
+	// Validamos os dados
+	if req.Password == "" && (req.IDToken == "" || req.Provider == "" || req.Nonce == "") {
+		utils.SendErrorResponse(ctx, http.StatusUnprocessableEntity, "VALIDATION_ERROR", "Campos obrigatórios não preenchidos", map[string]interface{}{
+			"password": "Senha ou ID token do provedor, com provedor e nonce, é obrigatório",
+		})
+		return
+	}
+
+	// Adicionamos informações do cliente para auditoria
+	req.ClientIP = ctx.ClientIP()
+	req.UserAgent = ctx.GetHeader("User-Agent")
+
+	erasureAt, err := c.AuthService.RequestErasure(currentUser(ctx), req)
+	if err != nil {
+		switch err {
+		case services.ErrInvalidLogin:
+			utils.SendErrorResponse(ctx, http.StatusUnauthorized, "INVALID_CREDENTIALS", "Senha ou ID token inválido", nil)
+		default:
+			utils.SendErrorResponse(ctx, http.StatusInternalServerError, "SERVER_ERROR", "Erro ao solicitar eliminação dos dados", nil)
+		}
+		return
+	}
+
+	utils.SendSuccessResponse(ctx, http.StatusOK, map[string]interface{}{
+		"erasure_at": erasureAt,
+	}, map[string]interface{}{
+		"message": "Conta desativada. Os dados pessoais serão eliminados na data informada",
+	})
+}
+
+// RegisterRoutes registra as rotas do controlador em um grupo já autenticado
+func (c *DataRightsController) RegisterRoutes(router *gin.RouterGroup, authMiddleware *middlewares.AuthMiddleware) {
+	me := router.Group("/me")
+	me.Use(authMiddleware.ForbidImpersonation())
+	{
+		me.GET("/data-export", c.ExportData)
+		me.POST("/data-erasure", c.RequestErasure)
+	}
+}
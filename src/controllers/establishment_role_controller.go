@@ -0,0 +1,260 @@
+package controllers
+
+import (
+	"net/http"
+
+	"github.com/Barba2k2/aurora_backend/src/middlewares"
+	"github.com/Barba2k2/aurora_backend/src/models"
+	"github.com/Barba2k2/aurora_backend/src/services"
+	"github.com/Barba2k2/aurora_backend/src/utils"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// EstablishmentRoleController manipula os papéis e permissões da equipe do estabelecimento
+type EstablishmentRoleController struct {
+	AuthService *services.AuthService
+}
+
+// NewEstablishmentRoleController cria uma nova instância de EstablishmentRoleController
+func NewEstablishmentRoleController(authService *services.AuthService) *EstablishmentRoleController {
+	return &EstablishmentRoleController{
+		AuthService: authService,
+	}
+}
+
+// ListPermissions lista as permissões que podem compor um papel
+// @Summary Lista as permissões disponíveis
+// @Description Lista todas as permissões do sistema e as permissões padrão da equipe
+// @Tags establishment-roles
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {object} SuccessResponse "Permissões disponíveis"
+// @Router /api/v1/professional/establishment/permissions [get]
+func (c *EstablishmentRoleController) ListPermissions(ctx *gin.Context) {
+	utils.SendSuccessResponse(ctx, http.StatusOK, map[string]interface{}{
+		"permissions":       models.AllPermissions,
+		"staff_permissions": models.DefaultStaffPermissions,
+	}, nil)
+}
+
+// GetMyPermissions retorna as permissões efetivas do usuário autenticado
+// @Summary Permissões do usuário
+// @Description Retorna o estabelecimento e as permissões efetivas do usuário autenticado
+// @Tags establishment-roles
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {object} SuccessResponse "Permissões do usuário"
+// @Failure 500 {object} ErrorResponse "Erro interno do servidor"
+// @Router /api/v1/professional/me/permissions [get]
+func (c *EstablishmentRoleController) GetMyPermissions(ctx *gin.Context) {
+	permissions, err := c.AuthService.ResolvePermissions(currentUser(ctx), currentEstablishmentID(ctx))
+	if err != nil {
+		utils.SendErrorResponse(ctx, http.StatusInternalServerError, "SERVER_ERROR", "Erro ao obter permissões", nil)
+		return
+	}
+
+	utils.SendSuccessResponse(ctx, http.StatusOK, map[string]interface{}{
+		"establishment_id": permissions.EstablishmentID,
+		"permissions":      permissions.List(),
+	}, nil)
+}
+
+// ListRoles lista os papéis do estabelecimento
+// @Summary Lista os papéis do estabelecimento
+// @Tags establishment-roles
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {array} models.EstablishmentRole "Papéis do estabelecimento"
+// @Failure 403 {object} ErrorResponse "Acesso negado"
+// @Failure 404 {object} ErrorResponse "Estabelecimento não encontrado"
+// @Failure 500 {object} ErrorResponse "Erro interno do servidor"
+// @Router /api/v1/professional/establishment/roles [get]
+func (c *EstablishmentRoleController) ListRoles(ctx *gin.Context) {
+	roles, err := c.AuthService.ListEstablishmentRoles(currentPermissions(ctx))
+	if err != nil {
+		c.sendRoleError(ctx, err, "Erro ao listar papéis")
+		return
+	}
+
+	utils.SendSuccessResponse(ctx, http.StatusOK, roles, nil)
+}
+
+// CreateRole cria um papel no estabelecimento
+// @Summary Cria um papel
+// @Description Cria um conjunto nomeado de permissões para atribuir à equipe
+// @Tags establishment-roles
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param request body services.EstablishmentRoleRequest true "Nome, descrição e permissões"
+// @Success 201 {object} models.EstablishmentRole "Papel criado com sucesso"
+// @Failure 400 {object} ErrorResponse "Dados inválidos"
+// @Failure 403 {object} ErrorResponse "Acesso negado"
+// @Failure 409 {object} ErrorResponse "Papel já existe"
+// @Failure 422 {object} ErrorResponse "Validação falhou"
+// @Failure 500 {object} ErrorResponse "Erro interno do servidor"
+// @Router /api/v1/professional/establishment/roles [post]
+func (c *EstablishmentRoleController) CreateRole(ctx *gin.Context) {
+	var req services.EstablishmentRoleRequest
+
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		utils.SendErrorResponse(ctx, http.StatusBadRequest, "INVALID_REQUEST", "Formato da requisição inválido", nil)
+		return
+	}
+
+	role, err := c.AuthService.CreateEstablishmentRole(currentPermissions(ctx), req)
+	if err != nil {
+		c.sendRoleError(ctx, err, "Erro ao criar papel")
+		return
+	}
+
+	utils.SendSuccessResponse(ctx, http.StatusCreated, role, nil)
+}
+
+// UpdateRole altera um papel do estabelecimento
+// @Summary Altera um papel
+// @Description Altera o nome, a descrição e as permissões do papel; a mudança vale imediatamente para os membros
+// @Tags establishment-roles
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "ID do papel"
+// @Param request body services.EstablishmentRoleRequest true "Nome, descrição e permissões"
+// @Success 200 {object} models.EstablishmentRole "Papel alterado com sucesso"
+// @Failure 400 {object} ErrorResponse "Dados inválidos"
+// @Failure 403 {object} ErrorResponse "Acesso negado"
+// @Failure 404 {object} ErrorResponse "Papel não encontrado"
+// @Failure 409 {object} ErrorResponse "Papel já existe"
+// @Failure 422 {object} ErrorResponse "Validação falhou"
+// @Failure 500 {object} ErrorResponse "Erro interno do servidor"
+// @Router /api/v1/professional/establishment/roles/{id} [put]
+func (c *EstablishmentRoleController) UpdateRole(ctx *gin.Context) {
+	roleID, err := uuid.Parse(ctx.Param("id"))
+	if err != nil {
+		utils.SendErrorResponse(ctx, http.StatusBadRequest, "INVALID_REQUEST", "ID de papel inválido", nil)
+		return
+	}
+
+	var req services.EstablishmentRoleRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		utils.SendErrorResponse(ctx, http.StatusBadRequest, "INVALID_REQUEST", "Formato da requisição inválido", nil)
+		return
+	}
+
+	role, err := c.AuthService.UpdateEstablishmentRole(currentPermissions(ctx), roleID, req)
+	if err != nil {
+		c.sendRoleError(ctx, err, "Erro ao alterar papel")
+		return
+	}
+
+	utils.SendSuccessResponse(ctx, http.StatusOK, role, nil)
+}
+
+// DeleteRole remove um papel do estabelecimento
+// @Summary Remove um papel
+// @Description Remove o papel; os membros que o tinham voltam às permissões padrão da equipe
+// @Tags establishment-roles
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "ID do papel"
+// @Success 200 {object} SuccessResponse "Papel removido com sucesso"
+// @Failure 400 {object} ErrorResponse "ID inválido"
+// @Failure 403 {object} ErrorResponse "Acesso negado"
+// @Failure 404 {object} ErrorResponse "Papel não encontrado"
+// @Failure 500 {object} ErrorResponse "Erro interno do servidor"
+// @Router /api/v1/professional/establishment/roles/{id} [delete]
+func (c *EstablishmentRoleController) DeleteRole(ctx *gin.Context) {
+	roleID, err := uuid.Parse(ctx.Param("id"))
+	if err != nil {
+		utils.SendErrorResponse(ctx, http.StatusBadRequest, "INVALID_REQUEST", "ID de papel inválido", nil)
+		return
+	}
+
+	if err := c.AuthService.DeleteEstablishmentRole(currentPermissions(ctx), roleID); err != nil {
+		c.sendRoleError(ctx, err, "Erro ao remover papel")
+		return
+	}
+
+	utils.SendSuccessResponse(ctx, http.StatusOK, nil, map[string]interface{}{
+		"message": "Papel removido com sucesso",
+	})
+}
+
+// AssignMemberRole atribui um papel a um membro da equipe
+// @Summary Atribui um papel a um membro
+// @Description Atribui um papel ao membro da equipe; sem role_id o membro volta às permissões padrão
+// @Tags establishment-roles
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param user_id path string true "ID do usuário membro"
+// @Param request body services.AssignMemberRoleRequest true "Papel"
+// @Success 200 {object} models.EstablishmentMember "Papel atribuído com sucesso"
+// @Failure 400 {object} ErrorResponse "Dados inválidos"
+// @Failure 403 {object} ErrorResponse "Acesso negado"
+// @Failure 404 {object} ErrorResponse "Membro ou papel não encontrado"
+// @Failure 500 {object} ErrorResponse "Erro interno do servidor"
+// @Router /api/v1/professional/establishment/members/{user_id}/role [put]
+func (c *EstablishmentRoleController) AssignMemberRole(ctx *gin.Context) {
+	userID, err := uuid.Parse(ctx.Param("user_id"))
+	if err != nil {
+		utils.SendErrorResponse(ctx, http.StatusBadRequest, "INVALID_REQUEST", "ID de usuário inválido", nil)
+		return
+	}
+
+	var req services.AssignMemberRoleRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		utils.SendErrorResponse(ctx, http.StatusBadRequest, "INVALID_REQUEST", "Formato da requisição inválido", nil)
+		return
+	}
+
+	member, err := c.AuthService.AssignMemberRole(currentUser(ctx).ID, currentPermissions(ctx), userID, req)
+	if err != nil {
+		c.sendRoleError(ctx, err, "Erro ao atribuir papel")
+		return
+	}
+
+	utils.SendSuccessResponse(ctx, http.StatusOK, member, nil)
+}
+
+// sendRoleError traduz os erros de papéis e permissões em respostas HTTP
+func (c *EstablishmentRoleController) sendRoleError(ctx *gin.Context, err error, fallback string) {
+	switch err {
+	case services.ErrEstablishmentNotFound:
+		utils.SendErrorResponse(ctx, http.StatusNotFound, "ESTABLISHMENT_NOT_FOUND", "Usuário não pertence a um estabelecimento", nil)
+	case services.ErrRoleNotFound:
+		utils.SendErrorResponse(ctx, http.StatusNotFound, "ROLE_NOT_FOUND", "Papel não encontrado", nil)
+	case services.ErrMemberNotFound:
+		utils.SendErrorResponse(ctx, http.StatusNotFound, "MEMBER_NOT_FOUND", "Membro não encontrado neste estabelecimento", nil)
+	case services.ErrRoleAlreadyExists:
+		utils.SendErrorResponse(ctx, http.StatusConflict, "ROLE_ALREADY_EXISTS", "Já existe um papel com este nome", nil)
+	case services.ErrInvalidRole:
+		utils.SendErrorResponse(ctx, http.StatusUnprocessableEntity, "VALIDATION_ERROR", "Nome do papel é obrigatório", map[string]interface{}{
+			"name": "Nome é obrigatório",
+		})
+	case services.ErrInvalidPermission:
+		utils.SendErrorResponse(ctx, http.StatusUnprocessableEntity, "VALIDATION_ERROR", "Permissão inválida", map[string]interface{}{
+			"permissions": "Use apenas permissões listadas em /establishment/permissions",
+		})
+	case services.ErrPermissionEscalation:
+		utils.SendErrorResponse(ctx, http.StatusForbidden, "FORBIDDEN", "Não é possível conceder permissões que você não tem", nil)
+	default:
+		utils.SendErrorResponse(ctx, http.StatusInternalServerError, "SERVER_ERROR", fallback, nil)
+	}
+}
+
+// RegisterRoutes registra as rotas do controlador em um grupo já autenticado
+func (c *EstablishmentRoleController) RegisterRoutes(router *gin.RouterGroup, authMiddleware *middlewares.AuthMiddleware) {
+	router.GET("/me/permissions", c.GetMyPermissions)
+
+	establishment := router.Group("/establishment")
+	{
+		establishment.GET("/permissions", c.ListPermissions)
+		establishment.GET("/roles", authMiddleware.ForbidImpersonation(), authMiddleware.RequirePermission(models.PermissionRolesManage), c.ListRoles)
+		establishment.POST("/roles", authMiddleware.ForbidImpersonation(), authMiddleware.RequirePermission(models.PermissionRolesManage), c.CreateRole)
+		establishment.PUT("/roles/:id", authMiddleware.ForbidImpersonation(), authMiddleware.RequirePermission(models.PermissionRolesManage), c.UpdateRole)
+		establishment.DELETE("/roles/:id", authMiddleware.ForbidImpersonation(), authMiddleware.RequirePermission(models.PermissionRolesManage), c.DeleteRole)
+		establishment.PUT("/members/:user_id/role", authMiddleware.ForbidImpersonation(), authMiddleware.RequirePermission(models.PermissionStaffManage), c.AssignMemberRole)
+	}
+}
@@ -0,0 +1,38 @@
+package controllers
+
+import (
+	"net/http"
+
+	"github.com/Barba2k2/aurora_backend/src/services"
+	"github.com/gin-gonic/gin"
+)
+
+// JWKSController publica as chaves públicas de verificação dos tokens
+type JWKSController struct {
+	AuthService *services.AuthService
+}
+
+// NewJWKSController cria uma nova instância de JWKSController
+func NewJWKSController(authService *services.AuthService) *JWKSController {
+	return &JWKSController{
+		AuthService: authService,
+	}
+}
+
+// GetJWKS retorna o conjunto de chaves públicas
+// @Summary Chaves públicas dos tokens
+// @Description Retorna o JWKS com as chaves aceitas para verificar os tokens de acesso, incluindo as que estão em rotação. As mesmas chaves assinam os demais tokens, então os verificadores devem exigir o cabeçalho typ at+jwt e a audiência da API
+// @Tags jwks
+// @Produce json
+// @Success 200 {object} utils.JWKS "Conjunto de chaves"
+// @Router /.well-known/jwks.json [get]
+func (c *JWKSController) GetJWKS(ctx *gin.Context) {
+	// O documento segue o formato padrão do JWKS, sem o envelope das demais respostas
+	ctx.Header("Cache-Control", "public, max-age=300")
+	ctx.JSON(http.StatusOK, c.AuthService.JWKS())
+}
+
+// RegisterRoutes registra as rotas do controlador
+func (c *JWKSController) RegisterRoutes(router *gin.RouterGroup) {
+	router.GET("/.well-known/jwks.json", c.GetJWKS)
+}
@@ -0,0 +1,131 @@
+package controllers
+
+import (
+	"net/http"
+
+	"github.com/Barba2k2/aurora_backend/src/middlewares"
+	"github.com/Barba2k2/aurora_backend/src/services"
+	"github.com/Barba2k2/aurora_backend/src/utils"
+	"github.com/gin-gonic/gin"
+)
+
+// LinkedAccountController manipula os provedores de identidade vinculados ao cliente autenticado
+type LinkedAccountController struct {
+	AuthService *services.AuthService
+}
+
+// NewLinkedAccountController cria uma nova instância de LinkedAccountController
+func NewLinkedAccountController(authService *services.AuthService) *LinkedAccountController {
+	return &LinkedAccountController{
+		AuthService: authService,
+	}
+}
+
+// ListLinkedAccounts lista os provedores vinculados
+// @Summary Lista provedores vinculados
+// @Description Lista as contas do Google e da Apple vinculadas ao cliente
+// @Tags client-linked-accounts
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {array} models.LinkedAccount "Provedores vinculados"
+// @Failure 401 {object} ErrorResponse "Não autenticado"
+// @Failure 403 {object} ErrorResponse "Indisponível durante acesso assistido"
+// @Failure 500 {object} ErrorResponse "Erro interno do servidor"
+// @Router /api/v1/client/auth/linked-accounts [get]
+func (c *LinkedAccountController) ListLinkedAccounts(ctx *gin.Context) {
+	user := currentUser(ctx)
+
+	accounts, err := c.AuthService.ListLinkedAccounts(user)
+	if err != nil {
+		utils.SendErrorResponse(ctx, http.StatusInternalServerError, "SERVER_ERROR", "Erro ao listar provedores vinculados", nil)
+		return
+	}
+
+	utils.SendSuccessResponse(ctx, http.StatusOK, accounts, nil)
+}
+
+// LinkProvider vincula um provedor de identidade
+// @Summary Vincula um provedor
+// @Description Valida o ID token do provedor e vincula a identidade ao cliente
+// @Tags client-linked-accounts
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param provider path string true "Provedor (google ou apple)"
+// @Param request body services.LinkProviderRequest true "ID token do provedor"
+// @Success 201 {object} models.LinkedAccount "Provedor vinculado com sucesso"
+// @Failure 400 {object} ErrorResponse "Dados inválidos ou provedor não suportado"
+// @Failure 401 {object} ErrorResponse "ID token inválido"
+// @Failure 403 {object} ErrorResponse "Indisponível durante acesso assistido"
+// @Failure 409 {object} ErrorResponse "Provedor já vinculado"
+// @Failure 500 {object} ErrorResponse "Erro interno do servidor"
+// @Router /api/v1/client/auth/linked-accounts/{provider} [post]
+func (c *LinkedAccountController) LinkProvider(ctx *gin.Context) {
+	var req services.LinkProviderRequest
+
+	if err := ctx.ShouldBindJSON(&req); err != nil || req.IDToken == "" || req.Nonce == "" {
+		utils.SendErrorResponse(ctx, http.StatusBadRequest, "INVALID_REQUEST", "ID token e nonce são obrigatórios", nil)
+		return
+	}
+
+	user := currentUser(ctx)
+
+	account, err := c.AuthService.LinkProvider(user, ctx.Param("provider"), req)
+	if err != nil {
+		switch err {
+		case services.ErrProviderNotSupported:
+			utils.SendErrorResponse(ctx, http.StatusBadRequest, "PROVIDER_NOT_SUPPORTED", "Provedor de identidade não suportado", nil)
+		case services.ErrInvalidToken:
+			utils.SendErrorResponse(ctx, http.StatusUnauthorized, "INVALID_TOKEN", "ID token inválido ou expirado", nil)
+		case services.ErrProviderAlreadyLinked:
+			utils.SendErrorResponse(ctx, http.StatusConflict, "PROVIDER_ALREADY_LINKED", "Provedor já vinculado a esta ou a outra conta", nil)
+		default:
+			utils.SendErrorResponse(ctx, http.StatusInternalServerError, "SERVER_ERROR", "Erro ao vincular provedor", nil)
+		}
+		return
+	}
+
+	utils.SendSuccessResponse(ctx, http.StatusCreated, account, nil)
+}
+
+// UnlinkProvider desvincula um provedor de identidade
+// @Summary Desvincula um provedor
+// @Description Remove o vínculo do provedor com o cliente
+// @Tags client-linked-accounts
+// @Produce json
+// @Security BearerAuth
+// @Param provider path string true "Provedor (google ou apple)"
+// @Success 200 {object} SuccessResponse "Provedor desvinculado com sucesso"
+// @Failure 403 {object} ErrorResponse "Indisponível durante acesso assistido"
+// @Failure 404 {object} ErrorResponse "Provedor não vinculado"
+// @Failure 500 {object} ErrorResponse "Erro interno do servidor"
+// @Router /api/v1/client/auth/linked-accounts/{provider} [delete]
+func (c *LinkedAccountController) UnlinkProvider(ctx *gin.Context) {
+	user := currentUser(ctx)
+
+	if err := c.AuthService.UnlinkProvider(user, ctx.Param("provider")); err != nil {
+		switch err {
+		case services.ErrProviderNotLinked:
+			utils.SendErrorResponse(ctx, http.StatusNotFound, "PROVIDER_NOT_LINKED", "Provedor não vinculado", nil)
+		default:
+			utils.SendErrorResponse(ctx, http.StatusInternalServerError, "SERVER_ERROR", "Erro ao desvincular provedor", nil)
+		}
+		return
+	}
+
+	utils.SendSuccessResponse(ctx, http.StatusOK, nil, map[string]interface{}{
+		"message": "Provedor desvinculado com sucesso",
+	})
+}
+
+// RegisterRoutes registra as rotas do controlador em um grupo já autenticado
+func (c *LinkedAccountController) RegisterRoutes(router *gin.RouterGroup, authMiddleware *middlewares.AuthMiddleware) {
+	// Um administrador personificando o usuario poderia vincular a propria identidade e entrar depois como ele
+	accounts := router.Group("/auth/linked-accounts")
+	accounts.Use(authMiddleware.ForbidImpersonation())
+	{
+		accounts.GET("", c.ListLinkedAccounts)
+		accounts.POST("/:provider", c.LinkProvider)
+		accounts.DELETE("/:provider", c.UnlinkProvider)
+	}
+}
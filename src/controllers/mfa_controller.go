@@ -0,0 +1,193 @@
+package controllers
+
+import (
+	"net/http"
+
+	"github.com/Barba2k2/aurora_backend/src/middlewares"
+	"github.com/Barba2k2/aurora_backend/src/services"
+	"github.com/Barba2k2/aurora_backend/src/utils"
+	"github.com/gin-gonic/gin"
+)
+
+// MFAController manipula o cadastro do segundo fator de autenticação de profissionais
+type MFAController struct {
+	AuthService *services.AuthService
+}
+
+// NewMFAController cria uma nova instância de MFAController
+func NewMFAController(authService *services.AuthService) *MFAController {
+	return &MFAController{
+		AuthService: authService,
+	}
+}
+
+// SetupTOTP inicia o cadastro do TOTP
+// @Summary Inicia o cadastro do TOTP
+// @Description Gera um novo segredo TOTP e a URI de provisionamento para o QR code
+// @Tags professional-mfa
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {object} services.TOTPSetupResponse "Segredo gerado com sucesso"
+// @Failure 409 {object} ErrorResponse "TOTP já habilitado"
+// @Failure 500 {object} ErrorResponse "Erro interno do servidor"
+// @Router /api/v1/professional/auth/mfa/totp/setup [post]
+func (c *MFAController) SetupTOTP(ctx *gin.Context) {
+	user := currentUser(ctx)
+
+	setup, err := c.AuthService.SetupTOTP(user)
+	if err != nil {
+		switch err {
+		case services.ErrMFAAlreadyEnabled:
+			utils.SendErrorResponse(ctx, http.StatusConflict, "MFA_ALREADY_ENABLED", "Autenticação em dois fatores já está habilitada", nil)
+		default:
+			utils.SendErrorResponse(ctx, http.StatusInternalServerError, "SERVER_ERROR", "Erro ao iniciar cadastro do autenticador", nil)
+		}
+		return
+	}
+
+	utils.SendSuccessResponse(ctx, http.StatusOK, setup, nil)
+}
+
+// ConfirmTOTP confirma o cadastro do TOTP
+// @Summary Confirma o cadastro do TOTP
+// @Description Valida o primeiro código do autenticador, habilita o TOTP e retorna os códigos de recuperação
+// @Tags professional-mfa
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param request body services.TOTPConfirmRequest true "Código do autenticador"
+// @Success 200 {object} services.RecoveryCodesResponse "TOTP habilitado com sucesso"
+// @Failure 400 {object} ErrorResponse "Dados inválidos ou cadastro não iniciado"
+// @Failure 401 {object} ErrorResponse "Código inválido"
+// @Failure 409 {object} ErrorResponse "TOTP já habilitado"
+// @Failure 500 {object} ErrorResponse "Erro interno do servidor"
+// @Router /api/v1/professional/auth/mfa/totp/confirm [post]
+func (c *MFAController) ConfirmTOTP(ctx *gin.Context) {
+	var req services.TOTPConfirmRequest
+
+	if err := ctx.ShouldBindJSON(&req); err != nil || req.Code == "" {
+		utils.SendErrorResponse(ctx, http.StatusBadRequest, "INVALID_REQUEST", "Código do autenticador não fornecido", nil)
+		return
+	}
+
+	user := currentUser(ctx)
+
+	codes, err := c.AuthService.ConfirmTOTP(user, req)
+	if err != nil {
+		switch err {
+		case services.ErrMFAAlreadyEnabled:
+			utils.SendErrorResponse(ctx, http.StatusConflict, "MFA_ALREADY_ENABLED", "Autenticação em dois fatores já está habilitada", nil)
+		case services.ErrMFASetupNotStarted:
+			utils.SendErrorResponse(ctx, http.StatusBadRequest, "MFA_SETUP_NOT_STARTED", "Cadastro do autenticador não iniciado", nil)
+		case services.ErrInvalidMFACode:
+			utils.SendErrorResponse(ctx, http.StatusUnauthorized, "INVALID_MFA_CODE", "Código inválido", nil)
+		default:
+			utils.SendErrorResponse(ctx, http.StatusInternalServerError, "SERVER_ERROR", "Erro ao confirmar autenticador", nil)
+		}
+		return
+	}
+
+	utils.SendSuccessResponse(ctx, http.StatusOK, codes, nil)
+}
+
+// DisableTOTP desativa o TOTP
+// @Summary Desativa o TOTP
+// @Description Desativa o segundo fator após confirmar a senha e um código válido
+// @Tags professional-mfa
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param request body services.TOTPDisableRequest true "Senha e código"
+// @Success 200 {object} SuccessResponse "TOTP desativado com sucesso"
+// @Failure 400 {object} ErrorResponse "Dados inválidos ou TOTP não habilitado"
+// @Failure 401 {object} ErrorResponse "Senha ou código inválido"
+// @Failure 500 {object} ErrorResponse "Erro interno do servidor"
+// @Router /api/v1/professional/auth/mfa/totp/disable [post]
+func (c *MFAController) DisableTOTP(ctx *gin.Context) {
+	var req services.TOTPDisableRequest
+
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		utils.SendErrorResponse(ctx, http.StatusBadRequest, "INVALID_REQUEST", "Formato da requisição inválido", nil)
+		return
+	}
+
+	// Validamos os dados
+	if req.Password == "" || (req.Code == "" && req.RecoveryCode == "") {
+		utils.SendErrorResponse(ctx, http.StatusUnprocessableEntity, "VALIDATION_ERROR", "Campos obrigatórios não preenchidos", map[string]interface{}{
+			"password": "Senha é obrigatória",
+			"code":     "Código do autenticador ou código de recuperação é obrigatório",
+		})
+		return
+	}
+
+	user := currentUser(ctx)
+
+	if err := c.AuthService.DisableTOTP(user, req); err != nil {
+		switch err {
+		case services.ErrMFANotEnabled:
+			utils.SendErrorResponse(ctx, http.StatusBadRequest, "MFA_NOT_ENABLED", "Autenticação em dois fatores não habilitada", nil)
+		case services.ErrInvalidLogin:
+			utils.SendErrorResponse(ctx, http.StatusUnauthorized, "INVALID_CREDENTIALS", "Senha inválida", nil)
+		case services.ErrInvalidMFACode:
+			utils.SendErrorResponse(ctx, http.StatusUnauthorized, "INVALID_MFA_CODE", "Código inválido", nil)
+		default:
+			utils.SendErrorResponse(ctx, http.StatusInternalServerError, "SERVER_ERROR", "Erro ao desativar autenticador", nil)
+		}
+		return
+	}
+
+	utils.SendSuccessResponse(ctx, http.StatusOK, nil, map[string]interface{}{
+		"message": "Autenticação em dois fatores desativada",
+	})
+}
+
+// RegenerateRecoveryCodes gera novos códigos de recuperação
+// @Summary Gera novos códigos de recuperação
+// @Description Invalida os códigos de recuperação atuais e emite novos
+// @Tags professional-mfa
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param request body services.RecoveryCodesRequest true "Senha atual"
+// @Success 200 {object} services.RecoveryCodesResponse "Códigos gerados com sucesso"
+// @Failure 400 {object} ErrorResponse "Dados inválidos ou TOTP não habilitado"
+// @Failure 401 {object} ErrorResponse "Senha inválida"
+// @Failure 500 {object} ErrorResponse "Erro interno do servidor"
+// @Router /api/v1/professional/auth/mfa/recovery-codes [post]
+func (c *MFAController) RegenerateRecoveryCodes(ctx *gin.Context) {
+	var req services.RecoveryCodesRequest
+
+	if err := ctx.ShouldBindJSON(&req); err != nil || req.Password == "" {
+		utils.SendErrorResponse(ctx, http.StatusBadRequest, "INVALID_REQUEST", "Senha não fornecida", nil)
+		return
+	}
+
+	user := currentUser(ctx)
+
+	codes, err := c.AuthService.RegenerateRecoveryCodes(user, req)
+	if err != nil {
+		switch err {
+		case services.ErrMFANotEnabled:
+			utils.SendErrorResponse(ctx, http.StatusBadRequest, "MFA_NOT_ENABLED", "Autenticação em dois fatores não habilitada", nil)
+		case services.ErrInvalidLogin:
+			utils.SendErrorResponse(ctx, http.StatusUnauthorized, "INVALID_CREDENTIALS", "Senha inválida", nil)
+		default:
+			utils.SendErrorResponse(ctx, http.StatusInternalServerError, "SERVER_ERROR", "Erro ao gerar códigos de recuperação", nil)
+		}
+		return
+	}
+
+	utils.SendSuccessResponse(ctx, http.StatusOK, codes, nil)
+}
+
+// RegisterRoutes registra as rotas do controlador em um grupo já autenticado
+func (c *MFAController) RegisterRoutes(router *gin.RouterGroup, authMiddleware *middlewares.AuthMiddleware) {
+	mfa := router.Group("/auth/mfa")
+	mfa.Use(authMiddleware.ForbidImpersonation())
+	{
+		mfa.POST("/totp/setup", c.SetupTOTP)
+		mfa.POST("/totp/confirm", c.ConfirmTOTP)
+		mfa.POST("/totp/disable", c.DisableTOTP)
+		mfa.POST("/recovery-codes", c.RegenerateRecoveryCodes)
+	}
+}
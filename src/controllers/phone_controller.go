@@ -0,0 +1,128 @@
+package controllers
+
+import (
+	"net/http"
+
+	"github.com/Barba2k2/aurora_backend/src/models"
+	"github.com/Barba2k2/aurora_backend/src/services"
+	"github.com/Barba2k2/aurora_backend/src/utils"
+	"github.com/gin-gonic/gin"
+)
+
+// PhoneController manipula a verificação do telefone do usuário autenticado
+type PhoneController struct {
+	AuthService *services.AuthService
+}
+
+// NewPhoneController cria uma nova instância de PhoneController
+func NewPhoneController(authService *services.AuthService) *PhoneController {
+	return &PhoneController{
+		AuthService: authService,
+	}
+}
+
+// SendVerification envia o código de verificação do telefone
+// @Summary Envia o código de verificação do telefone
+// @Description Envia por SMS ou WhatsApp um código para confirmar o telefone cadastrado
+// @Tags phone
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param request body services.PhoneVerificationRequest true "Canal de envio (SMS ou WHATSAPP)"
+// @Success 200 {object} SuccessResponse "Código enviado com sucesso"
+// @Failure 400 {object} ErrorResponse "Dados inválidos ou telefone não cadastrado"
+// @Failure 409 {object} ErrorResponse "Telefone já verificado"
+// @Failure 429 {object} ErrorResponse "Muitas requisições"
+// @Failure 500 {object} ErrorResponse "Erro interno do servidor"
+// @Router /api/v1/client/auth/phone/verify [post]
+// @Router /api/v1/professional/auth/phone/verify [post]
+func (c *PhoneController) SendVerification(ctx *gin.Context) {
+	var req services.PhoneVerificationRequest
+
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		utils.SendErrorResponse(ctx, http.StatusBadRequest, "INVALID_REQUEST", "Formato da requisição inválido", nil)
+		return
+	}
+
+	// Validamos os dados
+	if req.Channel != models.TokenChannelSMS && req.Channel != models.TokenChannelWhatsApp {
+		utils.SendErrorResponse(ctx, http.StatusUnprocessableEntity, "VALIDATION_ERROR", "Canal inválido", map[string]interface{}{
+			"channel": "Canal deve ser SMS ou WHATSAPP",
+		})
+		return
+	}
+
+	// Adicionamos informações do cliente para auditoria
+	req.ClientIP = ctx.ClientIP()
+	req.UserAgent = ctx.GetHeader("User-Agent")
+
+	user := currentUser(ctx)
+
+	if err := c.AuthService.SendPhoneVerification(user, req); err != nil {
+		switch err {
+		case services.ErrPhoneMissing:
+			utils.SendErrorResponse(ctx, http.StatusBadRequest, "PHONE_MISSING", "Usuário não possui telefone cadastrado", nil)
+		case services.ErrPhoneAlreadyVerified:
+			utils.SendErrorResponse(ctx, http.StatusConflict, "PHONE_ALREADY_VERIFIED", "Telefone já verificado", nil)
+		case services.ErrTooManyRequests:
+			utils.SendErrorResponse(ctx, http.StatusTooManyRequests, "TOO_MANY_REQUESTS", "Muitas solicitações em um curto período", nil)
+		default:
+			utils.SendErrorResponse(ctx, http.StatusInternalServerError, "SERVER_ERROR", "Erro ao enviar código de verificação", nil)
+		}
+		return
+	}
+
+	utils.SendSuccessResponse(ctx, http.StatusOK, nil, map[string]interface{}{
+		"message": "Código de verificação enviado com sucesso",
+	})
+}
+
+// Confirm confirma o telefone com o código recebido
+// @Summary Confirma o telefone
+// @Description Valida o código recebido e marca o telefone como verificado
+// @Tags phone
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param request body services.ConfirmPhoneRequest true "Código recebido"
+// @Success 200 {object} SuccessResponse "Telefone verificado com sucesso"
+// @Failure 400 {object} ErrorResponse "Código inválido ou expirado"
+// @Failure 409 {object} ErrorResponse "Telefone já verificado"
+// @Failure 500 {object} ErrorResponse "Erro interno do servidor"
+// @Router /api/v1/client/auth/phone/confirm [post]
+// @Router /api/v1/professional/auth/phone/confirm [post]
+func (c *PhoneController) Confirm(ctx *gin.Context) {
+	var req services.ConfirmPhoneRequest
+
+	if err := ctx.ShouldBindJSON(&req); err != nil || req.Code == "" {
+		utils.SendErrorResponse(ctx, http.StatusBadRequest, "INVALID_REQUEST", "Código não fornecido", nil)
+		return
+	}
+
+	user := currentUser(ctx)
+
+	if err := c.AuthService.ConfirmPhone(user, req); err != nil {
+		switch err {
+		case services.ErrInvalidToken:
+			utils.SendErrorResponse(ctx, http.StatusBadRequest, "INVALID_CODE", "Código inválido ou expirado", nil)
+		case services.ErrPhoneAlreadyVerified:
+			utils.SendErrorResponse(ctx, http.StatusConflict, "PHONE_ALREADY_VERIFIED", "Telefone já verificado", nil)
+		default:
+			utils.SendErrorResponse(ctx, http.StatusInternalServerError, "SERVER_ERROR", "Erro ao verificar telefone", nil)
+		}
+		return
+	}
+
+	utils.SendSuccessResponse(ctx, http.StatusOK, nil, map[string]interface{}{
+		"message": "Telefone verificado com sucesso",
+	})
+}
+
+// RegisterRoutes registra as rotas do controlador em um grupo já autenticado
+func (c *PhoneController) RegisterRoutes(router *gin.RouterGroup) {
+	phone := router.Group("/auth/phone")
+	{
+		phone.POST("/verify", c.SendVerification)
+		phone.POST("/confirm", c.Confirm)
+	}
+}
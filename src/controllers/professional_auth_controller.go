@@ -1,6 +1,7 @@
 package controllers
 
 import (
+	"errors"
 	"net/http"
 	"strings"
 
@@ -30,6 +31,7 @@ func NewProfessionalAuthController(authService *services.AuthService) *Professio
 // @Produce json
 // @Param request body services.RegisterRequest true "Dados do registro"
 // @Success 201 {object} models.User "Usuário criado com sucesso"
+// @Success 202 {object} SuccessResponse "Cadastro em andamento (modo de privacidade)"
 // @Failure 400 {object} ErrorResponse "Dados inválidos"
 // @Failure 409 {object} ErrorResponse "Usuário já existe"
 // @Failure 422 {object} ErrorResponse "Validação falhou"
@@ -68,10 +70,9 @@ func (c *ProfessionalAuthController) Register(ctx *gin.Context) {
 	user, err := c.AuthService.Register(req)
 	if err != nil {
 		switch err {
-		case services.ErrPasswordTooWeak:
-			utils.SendErrorResponse(ctx, http.StatusUnprocessableEntity, "VALIDATION_ERROR", "Senha muito fraca", map[string]interface{}{
-				"password": "A senha deve conter letras maiúsculas, minúsculas, números e caracteres especiais",
-			})
+		case services.ErrPasswordTooWeak, services.ErrPasswordRepeatedChars, services.ErrPasswordCommon,
+			services.ErrPasswordPersonalInfo, services.ErrPasswordBreached:
+			sendPasswordPolicyError(ctx, err)
 		case services.ErrPasswordConfirmation:
 			utils.SendErrorResponse(ctx, http.StatusUnprocessableEntity, "VALIDATION_ERROR", "Senhas não conferem", map[string]interface{}{
 				"confirm_password": "Senhas não conferem",
@@ -86,6 +87,14 @@ func (c *ProfessionalAuthController) Register(ctx *gin.Context) {
 		return
 	}
 	
+	// No modo de privacidade a resposta é a mesma para contas novas e existentes
+	if c.AuthService.Config.PrivacyMode {
+		utils.SendSuccessResponse(ctx, http.StatusAccepted, nil, map[string]interface{}{
+			"message": "Enviamos um email com as instruções para concluir o cadastro",
+		})
+		return
+	}
+
 	// Retornamos o usuário criado
 	utils.SendSuccessResponse(ctx, http.StatusCreated, user, nil)
 }
@@ -121,25 +130,100 @@ func (c *ProfessionalAuthController) Login(ctx *gin.Context) {
 		return
 	}
 	
+	// Adicionamos informações do cliente para auditoria
+	req.ClientIP = ctx.ClientIP()
+	req.UserAgent = ctx.GetHeader("User-Agent")
+	
+	// So os papeis do portal podem entrar; a conferencia acontece antes de abrir a sessao
+	req.AllowedRoles = []models.UserRole{models.UserRoleProfessional, models.UserRoleStaff, models.UserRoleAdmin}
+	
 	// Realizamos o login
-	user, tokens, err := c.AuthService.Login(req)
-	if err != nil {
+	_, tokens, err := c.AuthService.Login(req)
+	var mfaRequired *services.MFARequiredError
+	if err != nil && !errors.As(err, &mfaRequired) {
 		switch err {
 		case services.ErrInvalidLogin:
 			utils.SendErrorResponse(ctx, http.StatusUnauthorized, "INVALID_CREDENTIALS", "Email ou senha inválidos", nil)
 		case services.ErrUserBlocked:
 			utils.SendErrorResponse(ctx, http.StatusForbidden, "USER_BLOCKED", "Usuário bloqueado por excesso de tentativas de login", nil)
+		case services.ErrPasswordResetRequired:
+			utils.SendErrorResponse(ctx, http.StatusForbidden, "PASSWORD_RESET_REQUIRED", "Redefina a senha para voltar a acessar a conta", nil)
 		case services.ErrUserInactive:
 			utils.SendErrorResponse(ctx, http.StatusForbidden, "USER_INACTIVE", "Usuário inativo", nil)
+		case services.ErrRoleNotAllowed:
+			utils.SendErrorResponse(ctx, http.StatusForbidden, "INVALID_ROLE", "Este usuário não tem acesso ao painel de profissional", nil)
+		case services.ErrEmailNotVerified:
+			utils.SendErrorResponse(ctx, http.StatusForbidden, "EMAIL_NOT_VERIFIED", "Email ainda não verificado", nil)
 		default:
 			utils.SendErrorResponse(ctx, http.StatusInternalServerError, "SERVER_ERROR", "Erro ao realizar login", nil)
 		}
 		return
 	}
 	
-	// Verificamos se o usuário é profissional
-	if user.Role != models.UserRoleProfessional && user.Role != models.UserRoleStaff && user.Role != models.UserRoleAdmin {
-		utils.SendErrorResponse(ctx, http.StatusForbidden, "INVALID_ROLE", "Este usuário não tem acesso ao painel de profissional", nil)
+	// Com o segundo fator habilitado, retornamos o desafio em vez dos tokens
+	if mfaRequired != nil {
+		utils.SendSuccessResponse(ctx, http.StatusOK, mfaRequired.Challenge, nil)
+		return
+	}
+	
+	// Retornamos os tokens
+	utils.SendSuccessResponse(ctx, http.StatusOK, tokens, nil)
+}
+
+// LoginMFA conclui o login de profissionais com segundo fator habilitado
+// @Summary Segunda etapa do login
+// @Description Valida o código TOTP ou um código de recuperação e retorna tokens de acesso
+// @Tags professional-auth
+// @Accept json
+// @Produce json
+// @Param request body services.MFALoginRequest true "Token de desafio e código"
+// @Success 200 {object} services.TokenResponse "Tokens gerados com sucesso"
+// @Failure 400 {object} ErrorResponse "Dados inválidos"
+// @Failure 401 {object} ErrorResponse "Código ou token de desafio inválido"
+// @Failure 403 {object} ErrorResponse "Usuário bloqueado"
+// @Failure 422 {object} ErrorResponse "Validação falhou"
+// @Failure 500 {object} ErrorResponse "Erro interno do servidor"
+// @Router /api/v1/professional/auth/login/mfa [post]
+func (c *ProfessionalAuthController) LoginMFA(ctx *gin.Context) {
+	var req services.MFALoginRequest
+	
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		utils.SendErrorResponse(ctx, http.StatusBadRequest, "INVALID_REQUEST", "Formato da requisição inválido", nil)
+		return
+	}
+	
+	// Validamos os dados
+	if req.MFAToken == "" || (req.Code == "" && req.RecoveryCode == "") {
+		utils.SendErrorResponse(ctx, http.StatusUnprocessableEntity, "VALIDATION_ERROR", "Campos obrigatórios não preenchidos", map[string]interface{}{
+			"mfa_token": "Token de desafio é obrigatório",
+			"code":      "Código do autenticador ou código de recuperação é obrigatório",
+		})
+		return
+	}
+	
+	// Adicionamos informações do cliente para auditoria
+	req.ClientIP = ctx.ClientIP()
+	req.UserAgent = ctx.GetHeader("User-Agent")
+	
+	// Validamos o segundo fator
+	_, tokens, err := c.AuthService.LoginMFA(req)
+	if err != nil {
+		switch err {
+		case services.ErrInvalidToken:
+			utils.SendErrorResponse(ctx, http.StatusUnauthorized, "INVALID_TOKEN", "Token de desafio inválido ou expirado", nil)
+		case services.ErrInvalidMFACode:
+			utils.SendErrorResponse(ctx, http.StatusUnauthorized, "INVALID_MFA_CODE", "Código inválido", nil)
+		case services.ErrMFANotEnabled:
+			utils.SendErrorResponse(ctx, http.StatusBadRequest, "MFA_NOT_ENABLED", "Autenticação em dois fatores não habilitada", nil)
+		case services.ErrUserBlocked:
+			utils.SendErrorResponse(ctx, http.StatusForbidden, "USER_BLOCKED", "Usuário bloqueado por excesso de tentativas de login", nil)
+		case services.ErrPasswordResetRequired:
+			utils.SendErrorResponse(ctx, http.StatusForbidden, "PASSWORD_RESET_REQUIRED", "Redefina a senha para voltar a acessar a conta", nil)
+		case services.ErrUserInactive:
+			utils.SendErrorResponse(ctx, http.StatusForbidden, "USER_INACTIVE", "Usuário inativo", nil)
+		default:
+			utils.SendErrorResponse(ctx, http.StatusInternalServerError, "SERVER_ERROR", "Erro ao realizar login", nil)
+		}
 		return
 	}
 	
@@ -175,12 +259,18 @@ func (c *ProfessionalAuthController) RefreshToken(ctx *gin.Context) {
 		return
 	}
 	
+	// Adicionamos informações do cliente para auditoria
+	req.ClientIP = ctx.ClientIP()
+	req.UserAgent = ctx.GetHeader("User-Agent")
+	
 	// Renovamos o token
 	tokens, err := c.AuthService.RefreshToken(req)
 	if err != nil {
 		switch err {
 		case services.ErrInvalidToken:
 			utils.SendErrorResponse(ctx, http.StatusUnauthorized, "INVALID_TOKEN", "Token inválido ou expirado", nil)
+		case services.ErrRefreshTokenReused:
+			utils.SendErrorResponse(ctx, http.StatusUnauthorized, "TOKEN_REUSED", "Refresh token reutilizado, sessão encerrada por segurança", nil)
 		case services.ErrUserInactive:
 			utils.SendErrorResponse(ctx, http.StatusForbidden, "USER_INACTIVE", "Usuário inativo", nil)
 		default:
@@ -193,6 +283,51 @@ func (c *ProfessionalAuthController) RefreshToken(ctx *gin.Context) {
 	utils.SendSuccessResponse(ctx, http.StatusOK, tokens, nil)
 }
 
+// Logout manipula o encerramento da sessão
+// @Summary Logout
+// @Description Encerra a sessão associada ao refresh token informado
+// @Tags professional-auth
+// @Accept json
+// @Produce json
+// @Param request body services.LogoutRequest true "Refresh token"
+// @Success 200 {object} SuccessResponse "Sessão encerrada com sucesso"
+// @Failure 400 {object} ErrorResponse "Dados inválidos"
+// @Failure 401 {object} ErrorResponse "Token inválido"
+// @Failure 500 {object} ErrorResponse "Erro interno do servidor"
+// @Router /api/v1/professional/auth/logout [post]
+func (c *ProfessionalAuthController) Logout(ctx *gin.Context) {
+	var req services.LogoutRequest
+	
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		utils.SendErrorResponse(ctx, http.StatusBadRequest, "INVALID_REQUEST", "Formato da requisição inválido", nil)
+		return
+	}
+	
+	// Validamos os dados
+	if req.RefreshToken == "" {
+		utils.SendErrorResponse(ctx, http.StatusUnprocessableEntity, "VALIDATION_ERROR", "Refresh token não fornecido", map[string]interface{}{
+			"refresh_token": "Refresh token é obrigatório",
+		})
+		return
+	}
+	
+	// Encerramos a sessão
+	if err := c.AuthService.Logout(req); err != nil {
+		switch err {
+		case services.ErrInvalidToken:
+			utils.SendErrorResponse(ctx, http.StatusUnauthorized, "INVALID_TOKEN", "Token inválido ou expirado", nil)
+		default:
+			utils.SendErrorResponse(ctx, http.StatusInternalServerError, "SERVER_ERROR", "Erro ao encerrar sessão", nil)
+		}
+		return
+	}
+	
+	// Retornamos sucesso
+	utils.SendSuccessResponse(ctx, http.StatusOK, nil, map[string]interface{}{
+		"message": "Sessão encerrada com sucesso",
+	})
+}
+
 // ForgotPasswordEmail manipula a solicitação de recuperação de senha via email
 // @Summary Recuperação de senha via email
 // @Description Envia um email com token para recuperação de senha
@@ -242,9 +377,13 @@ func (c *ProfessionalAuthController) ForgotPasswordEmail(ctx *gin.Context) {
 		return
 	}
 	
-	// Retornamos sucesso
+	// Retornamos sucesso; no modo de privacidade a mensagem não confirma a existência da conta
+	message := "Email de recuperação enviado com sucesso"
+	if c.AuthService.Config.PrivacyMode {
+		message = "Se o email estiver cadastrado, enviaremos as instruções de recuperação"
+	}
 	utils.SendSuccessResponse(ctx, http.StatusOK, nil, map[string]interface{}{
-		"message": "Email de recuperação enviado com sucesso",
+		"message": message,
 	})
 }
 
@@ -257,6 +396,7 @@ func (c *ProfessionalAuthController) ForgotPasswordEmail(ctx *gin.Context) {
 // @Param request body services.ForgotPasswordRequest true "Telefone do usuário"
 // @Success 200 {object} SuccessResponse "SMS enviado com sucesso"
 // @Failure 400 {object} ErrorResponse "Dados inválidos"
+// @Failure 403 {object} ErrorResponse "Telefone não verificado"
 // @Failure 404 {object} ErrorResponse "Telefone não encontrado"
 // @Failure 429 {object} ErrorResponse "Muitas requisições"
 // @Failure 500 {object} ErrorResponse "Erro interno do servidor"
@@ -287,6 +427,8 @@ func (c *ProfessionalAuthController) ForgotPasswordSMS(ctx *gin.Context) {
 		switch err {
 		case services.ErrPhoneNotFound:
 			utils.SendErrorResponse(ctx, http.StatusNotFound, "PHONE_NOT_FOUND", "Não existe usuário com este telefone", nil)
+		case services.ErrPhoneNotVerified:
+			utils.SendErrorResponse(ctx, http.StatusForbidden, "PHONE_NOT_VERIFIED", "Telefone não verificado, use a recuperação por email", nil)
 		case services.ErrUserInactive:
 			utils.SendErrorResponse(ctx, http.StatusForbidden, "USER_INACTIVE", "Usuário inativo", nil)
 		case services.ErrTooManyRequests:
@@ -297,9 +439,13 @@ func (c *ProfessionalAuthController) ForgotPasswordSMS(ctx *gin.Context) {
 		return
 	}
 	
-	// Retornamos sucesso
+	// Retornamos sucesso; no modo de privacidade a mensagem não confirma a existência da conta
+	message := "SMS de recuperação enviado com sucesso"
+	if c.AuthService.Config.PrivacyMode {
+		message = "Se o telefone estiver cadastrado e verificado, enviaremos o código de recuperação"
+	}
 	utils.SendSuccessResponse(ctx, http.StatusOK, nil, map[string]interface{}{
-		"message": "SMS de recuperação enviado com sucesso",
+		"message": message,
 	})
 }
 
@@ -312,6 +458,7 @@ func (c *ProfessionalAuthController) ForgotPasswordSMS(ctx *gin.Context) {
 // @Param request body services.ForgotPasswordRequest true "Telefone do usuário"
 // @Success 200 {object} SuccessResponse "Mensagem enviada com sucesso"
 // @Failure 400 {object} ErrorResponse "Dados inválidos"
+// @Failure 403 {object} ErrorResponse "Telefone não verificado"
 // @Failure 404 {object} ErrorResponse "Telefone não encontrado"
 // @Failure 429 {object} ErrorResponse "Muitas requisições"
 // @Failure 500 {object} ErrorResponse "Erro interno do servidor"
@@ -342,6 +489,8 @@ func (c *ProfessionalAuthController) ForgotPasswordWhatsApp(ctx *gin.Context) {
 		switch err {
 		case services.ErrPhoneNotFound:
 			utils.SendErrorResponse(ctx, http.StatusNotFound, "PHONE_NOT_FOUND", "Não existe usuário com este telefone", nil)
+		case services.ErrPhoneNotVerified:
+			utils.SendErrorResponse(ctx, http.StatusForbidden, "PHONE_NOT_VERIFIED", "Telefone não verificado, use a recuperação por email", nil)
 		case services.ErrUserInactive:
 			utils.SendErrorResponse(ctx, http.StatusForbidden, "USER_INACTIVE", "Usuário inativo", nil)
 		case services.ErrTooManyRequests:
@@ -352,9 +501,13 @@ func (c *ProfessionalAuthController) ForgotPasswordWhatsApp(ctx *gin.Context) {
 		return
 	}
 	
-	// Retornamos sucesso
+	// Retornamos sucesso; no modo de privacidade a mensagem não confirma a existência da conta
+	message := "Mensagem de WhatsApp enviada com sucesso"
+	if c.AuthService.Config.PrivacyMode {
+		message = "Se o telefone estiver cadastrado e verificado, enviaremos o código de recuperação"
+	}
 	utils.SendSuccessResponse(ctx, http.StatusOK, nil, map[string]interface{}{
-		"message": "Mensagem de WhatsApp enviada com sucesso",
+		"message": message,
 	})
 }
 
@@ -389,9 +542,43 @@ func (c *ProfessionalAuthController) ValidateResetToken(ctx *gin.Context) {
 	})
 }
 
+// VerifyResetCode confere um código de recuperação recebido por SMS ou WhatsApp
+// @Summary Conferência de código de recuperação
+// @Description Confere o código enviado ao telefone sem consumi-lo. Após 5 códigos errados o código é revogado
+// @Tags professional-auth
+// @Accept json
+// @Produce json
+// @Param request body services.VerifyResetCodeRequest true "Telefone e código recebido"
+// @Success 200 {object} SuccessResponse "Código válido"
+// @Failure 400 {object} ErrorResponse "Código inválido, expirado ou revogado"
+// @Failure 500 {object} ErrorResponse "Erro interno do servidor"
+// @Router /api/v1/professional/auth/reset-password/verify-code [post]
+func (c *ProfessionalAuthController) VerifyResetCode(ctx *gin.Context) {
+	var req services.VerifyResetCodeRequest
+	
+	if err := ctx.ShouldBindJSON(&req); err != nil || req.Phone == "" || req.Code == "" {
+		utils.SendErrorResponse(ctx, http.StatusBadRequest, "INVALID_REQUEST", "Telefone e código são obrigatórios", nil)
+		return
+	}
+	
+	if err := c.AuthService.VerifyResetCode(req); err != nil {
+		switch err {
+		case services.ErrInvalidToken:
+			utils.SendErrorResponse(ctx, http.StatusBadRequest, "INVALID_CODE", "Código inválido ou expirado", nil)
+		default:
+			utils.SendErrorResponse(ctx, http.StatusInternalServerError, "SERVER_ERROR", "Erro ao verificar código", nil)
+		}
+		return
+	}
+	
+	utils.SendSuccessResponse(ctx, http.StatusOK, nil, map[string]interface{}{
+		"valid": true,
+	})
+}
+
 // ResetPassword redefine a senha de um usuário
 // @Summary Redefinição de senha
-// @Description Redefine a senha de um usuário usando um token de recuperação
+// @Description Redefine a senha de um usuário usando um token de recuperação. Códigos recebidos por SMS ou WhatsApp devem ser enviados junto com o telefone
 // @Tags professional-auth
 // @Accept json
 // @Produce json
@@ -428,15 +615,22 @@ func (c *ProfessionalAuthController) ResetPassword(ctx *gin.Context) {
 		return
 	}
 	
+	// Adicionamos informações do cliente para auditoria
+	req.ClientIP = ctx.ClientIP()
+	req.UserAgent = ctx.GetHeader("User-Agent")
+	
 	// Redefinimos a senha
 	err := c.AuthService.ResetPassword(req)
 	if err != nil {
 		switch err {
 		case services.ErrInvalidToken:
 			utils.SendErrorResponse(ctx, http.StatusUnauthorized, "INVALID_TOKEN", "Token inválido ou expirado", nil)
-		case services.ErrPasswordTooWeak:
-			utils.SendErrorResponse(ctx, http.StatusUnprocessableEntity, "VALIDATION_ERROR", "Senha muito fraca", map[string]interface{}{
-				"password": "A senha deve conter letras maiúsculas, minúsculas, números e caracteres especiais",
+		case services.ErrPasswordTooWeak, services.ErrPasswordRepeatedChars, services.ErrPasswordCommon,
+			services.ErrPasswordPersonalInfo, services.ErrPasswordBreached:
+			sendPasswordPolicyError(ctx, err)
+		case services.ErrPasswordReused:
+			utils.SendErrorResponse(ctx, http.StatusUnprocessableEntity, "VALIDATION_ERROR", "Senha usada recentemente", map[string]interface{}{
+				"password": "A nova senha não pode repetir as últimas senhas da conta",
 			})
 		case services.ErrPasswordConfirmation:
 			utils.SendErrorResponse(ctx, http.StatusUnprocessableEntity, "VALIDATION_ERROR", "Senhas não conferem", map[string]interface{}{
@@ -456,17 +650,289 @@ func (c *ProfessionalAuthController) ResetPassword(ctx *gin.Context) {
 	})
 }
 
+// BeginWebAuthnLogin inicia o login de profissionais com passkey
+// @Summary Inicia o login com passkey
+// @Description Gera o desafio e as opções para navigator.credentials.get()
+// @Tags professional-auth
+// @Accept json
+// @Produce json
+// @Param request body services.WebAuthnLoginBeginRequest false "Email opcional para limitar as passkeys oferecidas"
+// @Success 200 {object} services.WebAuthnLoginOptions "Opções geradas com sucesso"
+// @Failure 400 {object} ErrorResponse "Dados inválidos"
+// @Failure 500 {object} ErrorResponse "Erro interno do servidor"
+// @Router /api/v1/professional/auth/webauthn/login/begin [post]
+func (c *ProfessionalAuthController) BeginWebAuthnLogin(ctx *gin.Context) {
+	var req services.WebAuthnLoginBeginRequest
+	
+	// O corpo é opcional para passkeys descobríveis
+	if ctx.Request.ContentLength > 0 {
+		if err := ctx.ShouldBindJSON(&req); err != nil {
+			utils.SendErrorResponse(ctx, http.StatusBadRequest, "INVALID_REQUEST", "Formato da requisição inválido", nil)
+			return
+		}
+	}
+	
+	options, err := c.AuthService.BeginWebAuthnLogin(req)
+	if err != nil {
+		utils.SendErrorResponse(ctx, http.StatusInternalServerError, "SERVER_ERROR", "Erro ao iniciar login com passkey", nil)
+		return
+	}
+	
+	utils.SendSuccessResponse(ctx, http.StatusOK, options, nil)
+}
+
+// FinishWebAuthnLogin conclui o login de profissionais com passkey
+// @Summary Conclui o login com passkey
+// @Description Valida a asserção do autenticador e retorna tokens de acesso, ou o desafio do segundo fator quando a passkey não verificou o usuário e o TOTP está habilitado
+// @Tags professional-auth
+// @Accept json
+// @Produce json
+// @Param request body services.WebAuthnLoginFinishRequest true "Resposta do autenticador"
+// @Success 200 {object} services.TokenResponse "Tokens gerados com sucesso"
+// @Failure 400 {object} ErrorResponse "Dados inválidos"
+// @Failure 401 {object} ErrorResponse "Passkey inválida"
+// @Failure 403 {object} ErrorResponse "Usuário bloqueado"
+// @Failure 500 {object} ErrorResponse "Erro interno do servidor"
+// @Router /api/v1/professional/auth/webauthn/login/finish [post]
+func (c *ProfessionalAuthController) FinishWebAuthnLogin(ctx *gin.Context) {
+	var req services.WebAuthnLoginFinishRequest
+	
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		utils.SendErrorResponse(ctx, http.StatusBadRequest, "INVALID_REQUEST", "Formato da requisição inválido", nil)
+		return
+	}
+	
+	// Validamos os dados
+	if req.Credential.RawID == "" || req.Credential.Response.ClientDataJSON == "" {
+		utils.SendErrorResponse(ctx, http.StatusUnprocessableEntity, "VALIDATION_ERROR", "Resposta do autenticador não fornecida", map[string]interface{}{
+			"credential": "Resposta do autenticador é obrigatória",
+		})
+		return
+	}
+	
+	// Adicionamos informações do cliente para auditoria
+	req.ClientIP = ctx.ClientIP()
+	req.UserAgent = ctx.GetHeader("User-Agent")
+	
+	req.AllowedRoles = []models.UserRole{models.UserRoleProfessional, models.UserRoleStaff, models.UserRoleAdmin}
+	
+	_, tokens, err := c.AuthService.FinishWebAuthnLogin(req)
+	var mfaRequired *services.MFARequiredError
+	if err != nil && !errors.As(err, &mfaRequired) {
+		switch err {
+		case services.ErrWebAuthnFailed:
+			utils.SendErrorResponse(ctx, http.StatusUnauthorized, "INVALID_PASSKEY", "Passkey inválida", nil)
+		case services.ErrUserBlocked:
+			utils.SendErrorResponse(ctx, http.StatusForbidden, "USER_BLOCKED", "Usuário bloqueado por excesso de tentativas de login", nil)
+		case services.ErrPasswordResetRequired:
+			utils.SendErrorResponse(ctx, http.StatusForbidden, "PASSWORD_RESET_REQUIRED", "Redefina a senha para voltar a acessar a conta", nil)
+		case services.ErrUserInactive:
+			utils.SendErrorResponse(ctx, http.StatusForbidden, "USER_INACTIVE", "Usuário inativo", nil)
+		case services.ErrRoleNotAllowed:
+			utils.SendErrorResponse(ctx, http.StatusForbidden, "INVALID_ROLE", "Este usuário não tem acesso ao painel de profissional", nil)
+		default:
+			utils.SendErrorResponse(ctx, http.StatusInternalServerError, "SERVER_ERROR", "Erro ao realizar login", nil)
+		}
+		return
+	}
+	
+	// Sem a verificacao do usuario na passkey, quem tem TOTP ainda informa o codigo
+	if mfaRequired != nil {
+		utils.SendSuccessResponse(ctx, http.StatusOK, mfaRequired.Challenge, nil)
+		return
+	}
+	
+	// Retornamos os tokens
+	utils.SendSuccessResponse(ctx, http.StatusOK, tokens, nil)
+}
+	
+// VerifyEmail confirma o email de um novo profissional
+// @Summary Verificação de email
+// @Description Confirma o email usando o token do link enviado no cadastro e ativa a conta
+// @Tags professional-auth
+// @Accept json
+// @Produce json
+// @Param request body services.VerifyEmailRequest true "Token de verificação"
+// @Success 200 {object} models.User "Email verificado com sucesso"
+// @Failure 400 {object} ErrorResponse "Token inválido"
+// @Failure 403 {object} ErrorResponse "Usuário inativo"
+// @Failure 500 {object} ErrorResponse "Erro interno do servidor"
+// @Router /api/v1/professional/auth/verify-email [post]
+func (c *ProfessionalAuthController) VerifyEmail(ctx *gin.Context) {
+	var req services.VerifyEmailRequest
+	
+	if err := ctx.ShouldBindJSON(&req); err != nil || req.Token == "" {
+		utils.SendErrorResponse(ctx, http.StatusBadRequest, "INVALID_TOKEN", "Token não fornecido", nil)
+		return
+	}
+	
+	user, err := c.AuthService.VerifyEmail(req)
+	if err != nil {
+		switch err {
+		case services.ErrInvalidToken:
+			utils.SendErrorResponse(ctx, http.StatusBadRequest, "INVALID_TOKEN", "Token inválido ou expirado", nil)
+		case services.ErrUserInactive:
+			utils.SendErrorResponse(ctx, http.StatusForbidden, "USER_INACTIVE", "Usuário inativo", nil)
+		default:
+			utils.SendErrorResponse(ctx, http.StatusInternalServerError, "SERVER_ERROR", "Erro ao verificar email", nil)
+		}
+		return
+	}
+	
+	utils.SendSuccessResponse(ctx, http.StatusOK, user, nil)
+}
+
+// ResendVerificationEmail reenvia o link de verificação de email
+// @Summary Reenvio da verificação de email
+// @Description Envia um novo link de verificação, invalidando os anteriores
+// @Tags professional-auth
+// @Accept json
+// @Produce json
+// @Param request body services.ResendVerificationRequest true "Email do usuário"
+// @Success 200 {object} SuccessResponse "Email enviado com sucesso"
+// @Failure 400 {object} ErrorResponse "Dados inválidos"
+// @Failure 404 {object} ErrorResponse "Email não encontrado"
+// @Failure 409 {object} ErrorResponse "Email já verificado"
+// @Failure 429 {object} ErrorResponse "Muitas requisições"
+// @Failure 500 {object} ErrorResponse "Erro interno do servidor"
+// @Router /api/v1/professional/auth/verify-email/resend [post]
+func (c *ProfessionalAuthController) ResendVerificationEmail(ctx *gin.Context) {
+	var req services.ResendVerificationRequest
+	
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		utils.SendErrorResponse(ctx, http.StatusBadRequest, "INVALID_REQUEST", "Formato da requisição inválido", nil)
+		return
+	}
+	
+	// Validamos os dados
+	if req.Email == "" {
+		utils.SendErrorResponse(ctx, http.StatusUnprocessableEntity, "VALIDATION_ERROR", "Email não fornecido", map[string]interface{}{
+			"email": "Email é obrigatório",
+		})
+		return
+	}
+	
+	// Adicionamos informações do cliente para auditoria
+	req.ClientIP = ctx.ClientIP()
+	req.UserAgent = ctx.GetHeader("User-Agent")
+	
+	if err := c.AuthService.ResendVerificationEmail(req); err != nil {
+		switch err {
+		case services.ErrEmailNotFound:
+			utils.SendErrorResponse(ctx, http.StatusNotFound, "EMAIL_NOT_FOUND", "Não existe usuário com este email", nil)
+		case services.ErrEmailAlreadyVerified:
+			utils.SendErrorResponse(ctx, http.StatusConflict, "EMAIL_ALREADY_VERIFIED", "Email já verificado", nil)
+		case services.ErrUserInactive:
+			utils.SendErrorResponse(ctx, http.StatusForbidden, "USER_INACTIVE", "Usuário inativo", nil)
+		case services.ErrTooManyRequests:
+			utils.SendErrorResponse(ctx, http.StatusTooManyRequests, "TOO_MANY_REQUESTS", "Muitas solicitações em um curto período", nil)
+		default:
+			utils.SendErrorResponse(ctx, http.StatusInternalServerError, "SERVER_ERROR", "Erro ao enviar email de verificação", nil)
+		}
+		return
+	}
+	
+	utils.SendSuccessResponse(ctx, http.StatusOK, nil, map[string]interface{}{
+		"message": "Email de verificação enviado com sucesso",
+	})
+}
+	
+// UnlockAccount desbloqueia a conta pelo link enviado no aviso de bloqueio
+// @Summary Desbloqueio de conta
+// @Description Remove o bloqueio por tentativas de login usando o token do link enviado por email
+// @Tags professional-auth
+// @Accept json
+// @Produce json
+// @Param request body services.UnlockAccountRequest true "Token de desbloqueio"
+// @Success 200 {object} SuccessResponse "Conta desbloqueada com sucesso"
+// @Failure 400 {object} ErrorResponse "Token inválido"
+// @Failure 500 {object} ErrorResponse "Erro interno do servidor"
+// @Router /api/v1/professional/auth/unlock [post]
+func (c *ProfessionalAuthController) UnlockAccount(ctx *gin.Context) {
+	var req services.UnlockAccountRequest
+	
+	if err := ctx.ShouldBindJSON(&req); err != nil || req.Token == "" {
+		utils.SendErrorResponse(ctx, http.StatusBadRequest, "INVALID_TOKEN", "Token não fornecido", nil)
+		return
+	}
+	
+	// Adicionamos informações do cliente para auditoria
+	req.ClientIP = ctx.ClientIP()
+	req.UserAgent = ctx.GetHeader("User-Agent")
+	
+	if err := c.AuthService.UnlockAccount(req); err != nil {
+		switch err {
+		case services.ErrInvalidToken:
+			utils.SendErrorResponse(ctx, http.StatusBadRequest, "INVALID_TOKEN", "Token inválido ou expirado", nil)
+		default:
+			utils.SendErrorResponse(ctx, http.StatusInternalServerError, "SERVER_ERROR", "Erro ao desbloquear conta", nil)
+		}
+		return
+	}
+	
+	utils.SendSuccessResponse(ctx, http.StatusOK, nil, map[string]interface{}{
+		"message": "Conta desbloqueada com sucesso",
+	})
+}
+
+// SecureAccount atende o link "não fui eu" enviado no aviso de login em novo dispositivo
+// @Summary Protege a conta após um login não reconhecido
+// @Description Encerra todas as sessões, remove passkeys, provedores vinculados e chaves de API criados desde o login avisado, exige a redefinição da senha e envia um link de recuperação para o email da conta
+// @Tags professional-auth
+// @Accept json
+// @Produce json
+// @Param request body services.SecureAccountRequest true "Token do link"
+// @Success 200 {object} SuccessResponse "Conta protegida com sucesso"
+// @Failure 400 {object} ErrorResponse "Token inválido"
+// @Failure 500 {object} ErrorResponse "Erro interno do servidor"
+// @Router /api/v1/professional/auth/secure-account [post]
+func (c *ProfessionalAuthController) SecureAccount(ctx *gin.Context) {
+	var req services.SecureAccountRequest
+	
+	if err := ctx.ShouldBindJSON(&req); err != nil || req.Token == "" {
+		utils.SendErrorResponse(ctx, http.StatusBadRequest, "INVALID_TOKEN", "Token não fornecido", nil)
+		return
+	}
+	
+	// Adicionamos informações do cliente para auditoria
+	req.ClientIP = ctx.ClientIP()
+	req.UserAgent = ctx.GetHeader("User-Agent")
+	
+	if err := c.AuthService.SecureAccount(req); err != nil {
+		switch err {
+		case services.ErrInvalidToken:
+			utils.SendErrorResponse(ctx, http.StatusBadRequest, "INVALID_TOKEN", "Token inválido ou expirado", nil)
+		default:
+			utils.SendErrorResponse(ctx, http.StatusInternalServerError, "SERVER_ERROR", "Erro ao proteger a conta", nil)
+		}
+		return
+	}
+	
+	utils.SendSuccessResponse(ctx, http.StatusOK, nil, map[string]interface{}{
+		"message": "Sessões encerradas. Enviamos um link para redefinir a senha",
+	})
+}
+
 // RegisterRoutes registra as rotas do controlador
 func (c *ProfessionalAuthController) RegisterRoutes(router *gin.RouterGroup) {
 	auth := router.Group("/auth")
 	{
 		auth.POST("/register", c.Register)
 		auth.POST("/login", c.Login)
+		auth.POST("/verify-email", c.VerifyEmail)
+		auth.POST("/verify-email/resend", c.ResendVerificationEmail)
+		auth.POST("/unlock", c.UnlockAccount)
+		auth.POST("/secure-account", c.SecureAccount)
+		auth.POST("/login/mfa", c.LoginMFA)
 		auth.POST("/refresh", c.RefreshToken)
+		auth.POST("/logout", c.Logout)
+		auth.POST("/webauthn/login/begin", c.BeginWebAuthnLogin)
+		auth.POST("/webauthn/login/finish", c.FinishWebAuthnLogin)
 		auth.POST("/forgot-password/email", c.ForgotPasswordEmail)
 		auth.POST("/forgot-password/sms", c.ForgotPasswordSMS)
 		auth.POST("/forgot-password/whatsapp", c.ForgotPasswordWhatsApp)
 		auth.GET("/reset-password/validate/:token", c.ValidateResetToken)
+		auth.POST("/reset-password/verify-code", c.VerifyResetCode)
 		auth.POST("/reset-password", c.ResetPassword)
 	}
 }
\ No newline at end of file
@@ -0,0 +1,174 @@
+package controllers
+
+import (
+	"net/http"
+
+	"github.com/Barba2k2/aurora_backend/src/middlewares"
+	"github.com/Barba2k2/aurora_backend/src/services"
+	"github.com/Barba2k2/aurora_backend/src/utils"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// SessionController manipula as requisições de gerenciamento de sessões do usuário autenticado
+type SessionController struct {
+	AuthService *services.AuthService
+}
+
+// NewSessionController cria uma nova instância de SessionController
+func NewSessionController(authService *services.AuthService) *SessionController {
+	return &SessionController{
+		AuthService: authService,
+	}
+}
+
+// ListSessions lista as sessões ativas do usuário
+// @Summary Lista sessões ativas
+// @Description Lista os dispositivos com sessão ativa, indicando a sessão atual
+// @Tags sessions
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {array} services.SessionResponse "Sessões ativas"
+// @Failure 401 {object} ErrorResponse "Não autenticado"
+// @Failure 500 {object} ErrorResponse "Erro interno do servidor"
+// @Router /api/v1/client/auth/sessions [get]
+// @Router /api/v1/professional/auth/sessions [get]
+func (c *SessionController) ListSessions(ctx *gin.Context) {
+	user := currentUser(ctx)
+
+	sessions, err := c.AuthService.ListSessions(user.ID, currentSessionID(ctx))
+	if err != nil {
+		utils.SendErrorResponse(ctx, http.StatusInternalServerError, "SERVER_ERROR", "Erro ao listar sessões", nil)
+		return
+	}
+
+	utils.SendSuccessResponse(ctx, http.StatusOK, sessions, nil)
+}
+
+// RevokeSession encerra uma sessão específica
+// @Summary Encerra uma sessão
+// @Description Encerra uma sessão do usuário e revoga seus tokens
+// @Tags sessions
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "ID da sessão"
+// @Success 200 {object} SuccessResponse "Sessão encerrada com sucesso"
+// @Failure 400 {object} ErrorResponse "ID inválido"
+// @Failure 403 {object} ErrorResponse "Indisponível durante acesso assistido"
+// @Failure 404 {object} ErrorResponse "Sessão não encontrada"
+// @Failure 500 {object} ErrorResponse "Erro interno do servidor"
+// @Router /api/v1/client/auth/sessions/{id} [delete]
+// @Router /api/v1/professional/auth/sessions/{id} [delete]
+func (c *SessionController) RevokeSession(ctx *gin.Context) {
+	sessionID, err := uuid.Parse(ctx.Param("id"))
+	if err != nil {
+		utils.SendErrorResponse(ctx, http.StatusBadRequest, "INVALID_REQUEST", "ID de sessão inválido", nil)
+		return
+	}
+
+	user := currentUser(ctx)
+
+	if err := c.AuthService.RevokeSession(user.ID, sessionID); err != nil {
+		switch err {
+		case services.ErrSessionNotFound:
+			utils.SendErrorResponse(ctx, http.StatusNotFound, "SESSION_NOT_FOUND", "Sessão não encontrada", nil)
+		default:
+			utils.SendErrorResponse(ctx, http.StatusInternalServerError, "SERVER_ERROR", "Erro ao encerrar sessão", nil)
+		}
+		return
+	}
+
+	utils.SendSuccessResponse(ctx, http.StatusOK, nil, map[string]interface{}{
+		"message": "Sessão encerrada com sucesso",
+	})
+}
+
+// RevokeOtherSessions encerra todas as sessões exceto a atual
+// @Summary Encerra as demais sessões
+// @Description Encerra todas as sessões do usuário exceto a usada nesta requisição
+// @Tags sessions
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {object} SuccessResponse "Sessões encerradas com sucesso"
+// @Failure 401 {object} ErrorResponse "Não autenticado"
+// @Failure 403 {object} ErrorResponse "Indisponível durante acesso assistido"
+// @Failure 500 {object} ErrorResponse "Erro interno do servidor"
+// @Router /api/v1/client/auth/sessions/revoke-others [post]
+// @Router /api/v1/professional/auth/sessions/revoke-others [post]
+func (c *SessionController) RevokeOtherSessions(ctx *gin.Context) {
+	user := currentUser(ctx)
+
+	if err := c.AuthService.RevokeOtherSessions(user.ID, currentSessionID(ctx)); err != nil {
+		utils.SendErrorResponse(ctx, http.StatusInternalServerError, "SERVER_ERROR", "Erro ao encerrar sessões", nil)
+		return
+	}
+
+	utils.SendSuccessResponse(ctx, http.StatusOK, nil, map[string]interface{}{
+		"message": "Demais sessões encerradas com sucesso",
+	})
+}
+
+// LogoutAll encerra todas as sessões do usuário
+// @Summary Logout de todos os dispositivos
+// @Description Encerra todas as sessões do usuário, incluindo a atual
+// @Tags sessions
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {object} SuccessResponse "Sessões encerradas com sucesso"
+// @Failure 401 {object} ErrorResponse "Não autenticado"
+// @Failure 403 {object} ErrorResponse "Indisponível durante acesso assistido"
+// @Failure 500 {object} ErrorResponse "Erro interno do servidor"
+// @Router /api/v1/client/auth/logout-all [post]
+// @Router /api/v1/professional/auth/logout-all [post]
+func (c *SessionController) LogoutAll(ctx *gin.Context) {
+	user := currentUser(ctx)
+
+	if err := c.AuthService.LogoutAll(user.ID); err != nil {
+		utils.SendErrorResponse(ctx, http.StatusInternalServerError, "SERVER_ERROR", "Erro ao encerrar sessões", nil)
+		return
+	}
+
+	utils.SendSuccessResponse(ctx, http.StatusOK, nil, map[string]interface{}{
+		"message": "Todas as sessões foram encerradas",
+	})
+}
+
+// StopImpersonation encerra a personificação em andamento usando o próprio token de personificação
+// @Summary Sai da personificação
+// @Description Encerra a sessão de personificação do token usado na requisição
+// @Tags sessions
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {object} SuccessResponse "Personificação encerrada com sucesso"
+// @Failure 400 {object} ErrorResponse "O token não é de personificação"
+// @Failure 500 {object} ErrorResponse "Erro interno do servidor"
+// @Router /api/v1/client/auth/impersonation/stop [post]
+// @Router /api/v1/professional/auth/impersonation/stop [post]
+func (c *SessionController) StopImpersonation(ctx *gin.Context) {
+	impersonatorID := currentImpersonatorID(ctx)
+	if impersonatorID == nil {
+		utils.SendErrorResponse(ctx, http.StatusBadRequest, "NOT_IMPERSONATING", "O token não é de personificação", nil)
+		return
+	}
+
+	if err := c.AuthService.StopImpersonation(*impersonatorID, currentSessionID(ctx), ctx.ClientIP(), ctx.GetHeader("User-Agent")); err != nil {
+		utils.SendErrorResponse(ctx, http.StatusInternalServerError, "SERVER_ERROR", "Erro ao encerrar personificação", nil)
+		return
+	}
+
+	utils.SendSuccessResponse(ctx, http.StatusOK, nil, map[string]interface{}{
+		"message": "Personificação encerrada com sucesso",
+	})
+}
+
+// RegisterRoutes registra as rotas do controlador em um grupo já autenticado
+func (c *SessionController) RegisterRoutes(router *gin.RouterGroup, authMiddleware *middlewares.AuthMiddleware) {
+	auth := router.Group("/auth")
+	{
+		auth.GET("/sessions", c.ListSessions)
+		auth.DELETE("/sessions/:id", authMiddleware.ForbidImpersonation(), c.RevokeSession)
+		auth.POST("/sessions/revoke-others", authMiddleware.ForbidImpersonation(), c.RevokeOtherSessions)
+		auth.POST("/logout-all", authMiddleware.ForbidImpersonation(), c.LogoutAll)
+		auth.POST("/impersonation/stop", c.StopImpersonation)
+	}
+}
@@ -0,0 +1,357 @@
+package controllers
+
+import (
+	"net/http"
+
+	"github.com/Barba2k2/aurora_backend/src/middlewares"
+	"github.com/Barba2k2/aurora_backend/src/models"
+	"github.com/Barba2k2/aurora_backend/src/services"
+	"github.com/Barba2k2/aurora_backend/src/utils"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// StaffController manipula os convites e os vínculos da equipe com os estabelecimentos
+type StaffController struct {
+	AuthService *services.AuthService
+}
+
+// NewStaffController cria uma nova instância de StaffController
+func NewStaffController(authService *services.AuthService) *StaffController {
+	return &StaffController{
+		AuthService: authService,
+	}
+}
+
+// InviteStaff convida alguém para a equipe do estabelecimento
+// @Summary Convida um membro para a equipe
+// @Description Envia um convite por email ou, sem email, por WhatsApp. Um novo convite para o mesmo contato substitui o anterior.
+// @Tags staff
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param request body services.InviteStaffRequest true "Dados do convite"
+// @Success 201 {object} models.StaffInvitation "Convite enviado com sucesso"
+// @Failure 400 {object} ErrorResponse "Dados inválidos"
+// @Failure 403 {object} ErrorResponse "Acesso negado"
+// @Failure 404 {object} ErrorResponse "Estabelecimento ou papel não encontrado"
+// @Failure 409 {object} ErrorResponse "Usuário já faz parte da equipe"
+// @Failure 500 {object} ErrorResponse "Erro interno do servidor"
+// @Router /api/v1/professional/establishment/invitations [post]
+func (c *StaffController) InviteStaff(ctx *gin.Context) {
+	var req services.InviteStaffRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		utils.SendErrorResponse(ctx, http.StatusBadRequest, "INVALID_REQUEST", "Formato da requisição inválido", nil)
+		return
+	}
+
+	invitation, err := c.AuthService.InviteStaff(currentUser(ctx), currentPermissions(ctx), req)
+	if err != nil {
+		c.sendStaffError(ctx, err, "Erro ao enviar convite")
+		return
+	}
+
+	utils.SendSuccessResponse(ctx, http.StatusCreated, invitation, nil)
+}
+
+// ListInvitations lista os convites pendentes do estabelecimento
+// @Summary Lista os convites pendentes
+// @Tags staff
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {array} models.StaffInvitation "Convites pendentes"
+// @Failure 403 {object} ErrorResponse "Acesso negado"
+// @Failure 500 {object} ErrorResponse "Erro interno do servidor"
+// @Router /api/v1/professional/establishment/invitations [get]
+func (c *StaffController) ListInvitations(ctx *gin.Context) {
+	invitations, err := c.AuthService.ListStaffInvitations(currentPermissions(ctx))
+	if err != nil {
+		c.sendStaffError(ctx, err, "Erro ao listar convites")
+		return
+	}
+
+	utils.SendSuccessResponse(ctx, http.StatusOK, invitations, nil)
+}
+
+// RevokeInvitation cancela um convite pendente
+// @Summary Cancela um convite
+// @Tags staff
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "ID do convite"
+// @Success 200 {object} SuccessResponse "Convite cancelado com sucesso"
+// @Failure 400 {object} ErrorResponse "ID inválido"
+// @Failure 403 {object} ErrorResponse "Acesso negado"
+// @Failure 404 {object} ErrorResponse "Convite não encontrado"
+// @Failure 500 {object} ErrorResponse "Erro interno do servidor"
+// @Router /api/v1/professional/establishment/invitations/{id} [delete]
+func (c *StaffController) RevokeInvitation(ctx *gin.Context) {
+	invitationID, err := uuid.Parse(ctx.Param("id"))
+	if err != nil {
+		utils.SendErrorResponse(ctx, http.StatusBadRequest, "INVALID_REQUEST", "ID de convite inválido", nil)
+		return
+	}
+
+	if err := c.AuthService.RevokeStaffInvitation(currentPermissions(ctx), invitationID); err != nil {
+		c.sendStaffError(ctx, err, "Erro ao cancelar convite")
+		return
+	}
+
+	utils.SendSuccessResponse(ctx, http.StatusOK, nil, map[string]interface{}{
+		"message": "Convite cancelado com sucesso",
+	})
+}
+
+// ListMembers lista a equipe do estabelecimento
+// @Summary Lista a equipe do estabelecimento
+// @Tags staff
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {array} services.StaffMemberResponse "Membros da equipe"
+// @Failure 403 {object} ErrorResponse "Acesso negado"
+// @Failure 500 {object} ErrorResponse "Erro interno do servidor"
+// @Router /api/v1/professional/establishment/members [get]
+func (c *StaffController) ListMembers(ctx *gin.Context) {
+	members, err := c.AuthService.ListEstablishmentMembers(currentPermissions(ctx))
+	if err != nil {
+		c.sendStaffError(ctx, err, "Erro ao listar equipe")
+		return
+	}
+
+	utils.SendSuccessResponse(ctx, http.StatusOK, members, nil)
+}
+
+// RemoveMember remove um membro da equipe
+// @Summary Remove um membro da equipe
+// @Description Remove o vínculo do membro com o estabelecimento; a conta do membro continua existindo
+// @Tags staff
+// @Produce json
+// @Security BearerAuth
+// @Param user_id path string true "ID do usuário membro"
+// @Success 200 {object} SuccessResponse "Membro removido com sucesso"
+// @Failure 400 {object} ErrorResponse "ID inválido"
+// @Failure 403 {object} ErrorResponse "Acesso negado"
+// @Failure 404 {object} ErrorResponse "Membro não encontrado"
+// @Failure 500 {object} ErrorResponse "Erro interno do servidor"
+// @Router /api/v1/professional/establishment/members/{user_id} [delete]
+func (c *StaffController) RemoveMember(ctx *gin.Context) {
+	userID, err := uuid.Parse(ctx.Param("user_id"))
+	if err != nil {
+		utils.SendErrorResponse(ctx, http.StatusBadRequest, "INVALID_REQUEST", "ID de usuário inválido", nil)
+		return
+	}
+
+	err = c.AuthService.RemoveMember(currentUser(ctx).ID, currentPermissions(ctx), userID, ctx.ClientIP(), ctx.Request.UserAgent())
+	if err != nil {
+		c.sendStaffError(ctx, err, "Erro ao remover membro")
+		return
+	}
+
+	utils.SendSuccessResponse(ctx, http.StatusOK, nil, map[string]interface{}{
+		"message": "Membro removido com sucesso",
+	})
+}
+
+// AcceptInvitation aceita um convite criando a conta do novo membro
+// @Summary Aceita um convite para a equipe
+// @Description Cria a conta do membro com a senha escolhida. Convites enviados por telefone exigem um email, que precisa ser confirmado.
+// @Tags staff
+// @Accept json
+// @Produce json
+// @Param request body services.AcceptStaffInvitationRequest true "Dados da conta"
+// @Success 201 {object} models.User "Conta criada com sucesso"
+// @Failure 400 {object} ErrorResponse "Dados inválidos"
+// @Failure 404 {object} ErrorResponse "Convite inválido ou expirado"
+// @Failure 409 {object} ErrorResponse "Já existe uma conta para o convite"
+// @Failure 422 {object} ErrorResponse "Erro de validação"
+// @Failure 500 {object} ErrorResponse "Erro interno do servidor"
+// @Router /api/v1/professional/auth/invitations/accept [post]
+func (c *StaffController) AcceptInvitation(ctx *gin.Context) {
+	var req services.AcceptStaffInvitationRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		utils.SendErrorResponse(ctx, http.StatusBadRequest, "INVALID_REQUEST", "Formato da requisição inválido", nil)
+		return
+	}
+
+	if req.Token == "" || req.Name == "" || req.Password == "" {
+		utils.SendErrorResponse(ctx, http.StatusUnprocessableEntity, "VALIDATION_ERROR", "Campos obrigatórios não preenchidos", map[string]interface{}{
+			"token":    "Token é obrigatório",
+			"name":     "Nome é obrigatório",
+			"password": "Senha é obrigatória",
+		})
+		return
+	}
+
+	// Adicionamos informações do cliente para auditoria
+	req.ClientIP = ctx.ClientIP()
+	req.UserAgent = ctx.Request.UserAgent()
+
+	user, err := c.AuthService.AcceptStaffInvitation(req)
+	if err != nil {
+		c.sendStaffError(ctx, err, "Erro ao aceitar convite")
+		return
+	}
+
+	utils.SendSuccessResponse(ctx, http.StatusCreated, user, nil)
+}
+
+// JoinEstablishment aceita um convite com a conta autenticada
+// @Summary Aceita um convite com a conta atual
+// @Description Vincula a conta de equipe autenticada a mais um estabelecimento
+// @Tags staff
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param request body services.JoinEstablishmentRequest true "Token do convite"
+// @Success 200 {object} models.EstablishmentMember "Convite aceito com sucesso"
+// @Failure 400 {object} ErrorResponse "Dados inválidos"
+// @Failure 403 {object} ErrorResponse "Convite enviado para outro contato"
+// @Failure 404 {object} ErrorResponse "Convite inválido ou expirado"
+// @Failure 409 {object} ErrorResponse "Usuário já faz parte da equipe"
+// @Failure 500 {object} ErrorResponse "Erro interno do servidor"
+// @Router /api/v1/professional/me/invitations/accept [post]
+func (c *StaffController) JoinEstablishment(ctx *gin.Context) {
+	var req services.JoinEstablishmentRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil || req.Token == "" {
+		utils.SendErrorResponse(ctx, http.StatusBadRequest, "INVALID_REQUEST", "Token do convite é obrigatório", nil)
+		return
+	}
+
+	// Adicionamos informações do cliente para auditoria
+	req.ClientIP = ctx.ClientIP()
+	req.UserAgent = ctx.Request.UserAgent()
+
+	member, err := c.AuthService.AcceptStaffInvitationAsUser(currentUser(ctx), req)
+	if err != nil {
+		c.sendStaffError(ctx, err, "Erro ao aceitar convite")
+		return
+	}
+
+	utils.SendSuccessResponse(ctx, http.StatusOK, member, nil)
+}
+
+// ListMyEstablishments lista os estabelecimentos de que o usuário faz parte
+// @Summary Lista os estabelecimentos do membro
+// @Description Lista os vínculos do usuário, indicando o estabelecimento em que a sessão atua
+// @Tags staff
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {array} services.MembershipResponse "Estabelecimentos do membro"
+// @Failure 500 {object} ErrorResponse "Erro interno do servidor"
+// @Router /api/v1/professional/me/establishments [get]
+func (c *StaffController) ListMyEstablishments(ctx *gin.Context) {
+	memberships, err := c.AuthService.ListMemberships(currentUser(ctx), currentEstablishmentID(ctx))
+	if err != nil {
+		utils.SendErrorResponse(ctx, http.StatusInternalServerError, "SERVER_ERROR", "Erro ao listar estabelecimentos", nil)
+		return
+	}
+
+	utils.SendSuccessResponse(ctx, http.StatusOK, memberships, nil)
+}
+
+// SwitchEstablishment troca o estabelecimento em que a sessão atua
+// @Summary Troca o estabelecimento da sessão
+// @Description Emite um novo par de tokens para o estabelecimento escolhido, rotacionando o refresh token informado
+// @Tags staff
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param request body services.SwitchEstablishmentRequest true "Estabelecimento e refresh token atual"
+// @Success 200 {object} services.TokenResponse "Tokens gerados com sucesso"
+// @Failure 400 {object} ErrorResponse "Dados inválidos"
+// @Failure 401 {object} ErrorResponse "Refresh token inválido"
+// @Failure 404 {object} ErrorResponse "Usuário não faz parte do estabelecimento"
+// @Failure 500 {object} ErrorResponse "Erro interno do servidor"
+// @Router /api/v1/professional/me/establishments/switch [post]
+func (c *StaffController) SwitchEstablishment(ctx *gin.Context) {
+	var req services.SwitchEstablishmentRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil || req.EstablishmentID == uuid.Nil || req.RefreshToken == "" {
+		utils.SendErrorResponse(ctx, http.StatusBadRequest, "INVALID_REQUEST", "Estabelecimento e refresh token são obrigatórios", nil)
+		return
+	}
+
+	// Adicionamos informações do cliente para auditoria
+	req.ClientIP = ctx.ClientIP()
+	req.UserAgent = ctx.Request.UserAgent()
+
+	tokens, err := c.AuthService.SwitchEstablishment(currentUser(ctx), currentSessionID(ctx), req)
+	if err != nil {
+		switch err {
+		case services.ErrInvalidToken, services.ErrSessionNotFound:
+			utils.SendErrorResponse(ctx, http.StatusUnauthorized, "INVALID_TOKEN", "Refresh token inválido ou de outra sessão", nil)
+		case services.ErrRefreshTokenReused:
+			utils.SendErrorResponse(ctx, http.StatusUnauthorized, "TOKEN_REUSED", "Refresh token já utilizado, sessão encerrada", nil)
+		default:
+			c.sendStaffError(ctx, err, "Erro ao trocar de estabelecimento")
+		}
+		return
+	}
+
+	utils.SendSuccessResponse(ctx, http.StatusOK, tokens, nil)
+}
+
+// sendStaffError traduz os erros de convites e vínculos da equipe em respostas HTTP
+func (c *StaffController) sendStaffError(ctx *gin.Context, err error, fallback string) {
+	switch err {
+	case services.ErrEstablishmentNotFound:
+		utils.SendErrorResponse(ctx, http.StatusNotFound, "ESTABLISHMENT_NOT_FOUND", "Estabelecimento não encontrado", nil)
+	case services.ErrRoleNotFound:
+		utils.SendErrorResponse(ctx, http.StatusNotFound, "ROLE_NOT_FOUND", "Papel não encontrado", nil)
+	case services.ErrMemberNotFound:
+		utils.SendErrorResponse(ctx, http.StatusNotFound, "MEMBER_NOT_FOUND", "Membro não encontrado neste estabelecimento", nil)
+	case services.ErrInvitationNotFound:
+		utils.SendErrorResponse(ctx, http.StatusNotFound, "INVITATION_NOT_FOUND", "Convite inválido ou expirado", nil)
+	case services.ErrInvitationMismatch:
+		utils.SendErrorResponse(ctx, http.StatusForbidden, "INVITATION_MISMATCH", "O convite foi enviado para outro email ou telefone", nil)
+	case services.ErrInvitationNeedsLogin:
+		utils.SendErrorResponse(ctx, http.StatusConflict, "LOGIN_REQUIRED", "Já existe uma conta para este convite, entre para aceitá-lo", nil)
+	case services.ErrStaffRoleRequired:
+		utils.SendErrorResponse(ctx, http.StatusConflict, "STAFF_ROLE_REQUIRED", "Apenas contas de equipe podem fazer parte de um estabelecimento", nil)
+	case services.ErrAlreadyMember:
+		utils.SendErrorResponse(ctx, http.StatusConflict, "ALREADY_MEMBER", "Usuário já faz parte da equipe", nil)
+	case services.ErrEmailAlreadyInUse:
+		utils.SendErrorResponse(ctx, http.StatusConflict, "EMAIL_IN_USE", "Email já cadastrado em outra conta", nil)
+	case services.ErrPermissionEscalation:
+		utils.SendErrorResponse(ctx, http.StatusForbidden, "FORBIDDEN", "Não é possível conceder ou remover permissões que você não tem", nil)
+	case services.ErrContactRequired:
+		utils.SendErrorResponse(ctx, http.StatusUnprocessableEntity, "VALIDATION_ERROR", "Email ou telefone é obrigatório", map[string]interface{}{
+			"email": "Informe o email ou o telefone",
+			"phone": "Informe o email ou o telefone",
+		})
+	case services.ErrEmailRequired:
+		utils.SendErrorResponse(ctx, http.StatusUnprocessableEntity, "VALIDATION_ERROR", "Email é obrigatório", map[string]interface{}{
+			"email": "Email é obrigatório para convites enviados por telefone",
+		})
+	case services.ErrPasswordTooWeak, services.ErrPasswordRepeatedChars, services.ErrPasswordCommon,
+		services.ErrPasswordPersonalInfo, services.ErrPasswordBreached:
+		sendPasswordPolicyError(ctx, err)
+	case services.ErrPasswordConfirmation:
+		utils.SendErrorResponse(ctx, http.StatusUnprocessableEntity, "VALIDATION_ERROR", "Senhas não conferem", map[string]interface{}{
+			"confirm_password": "Senhas não conferem",
+		})
+	default:
+		utils.SendErrorResponse(ctx, http.StatusInternalServerError, "SERVER_ERROR", fallback, nil)
+	}
+}
+
+// RegisterPublicRoutes registra a rota de aceite de convite por quem ainda não tem conta
+func (c *StaffController) RegisterPublicRoutes(router *gin.RouterGroup) {
+	router.POST("/auth/invitations/accept", c.AcceptInvitation)
+}
+
+// RegisterRoutes registra as rotas do controlador em um grupo já autenticado
+func (c *StaffController) RegisterRoutes(router *gin.RouterGroup, authMiddleware *middlewares.AuthMiddleware) {
+	router.GET("/me/establishments", c.ListMyEstablishments)
+	router.POST("/me/establishments/switch", authMiddleware.ForbidImpersonation(), c.SwitchEstablishment)
+	router.POST("/me/invitations/accept", authMiddleware.ForbidImpersonation(), c.JoinEstablishment)
+
+	establishment := router.Group("/establishment")
+	establishment.Use(authMiddleware.ForbidImpersonation(), authMiddleware.RequirePermission(models.PermissionStaffManage))
+	{
+		establishment.GET("/invitations", c.ListInvitations)
+		establishment.POST("/invitations", c.InviteStaff)
+		establishment.DELETE("/invitations/:id", c.RevokeInvitation)
+		establishment.GET("/members", c.ListMembers)
+		establishment.DELETE("/members/:user_id", c.RemoveMember)
+	}
+}
@@ -0,0 +1,166 @@
+package controllers
+
+import (
+	"net/http"
+
+	"github.com/Barba2k2/aurora_backend/src/middlewares"
+	"github.com/Barba2k2/aurora_backend/src/services"
+	"github.com/Barba2k2/aurora_backend/src/utils"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// WebAuthnController manipula o cadastro de passkeys do usuário autenticado
+type WebAuthnController struct {
+	AuthService *services.AuthService
+}
+
+// NewWebAuthnController cria uma nova instância de WebAuthnController
+func NewWebAuthnController(authService *services.AuthService) *WebAuthnController {
+	return &WebAuthnController{
+		AuthService: authService,
+	}
+}
+
+// BeginRegistration inicia o cadastro de uma passkey
+// @Summary Inicia o cadastro de uma passkey
+// @Description Gera o desafio e as opções para navigator.credentials.create()
+// @Tags passkeys
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {object} services.WebAuthnRegistrationOptions "Opções geradas com sucesso"
+// @Failure 401 {object} ErrorResponse "Não autenticado"
+// @Failure 500 {object} ErrorResponse "Erro interno do servidor"
+// @Router /api/v1/client/auth/webauthn/register/begin [post]
+// @Router /api/v1/professional/auth/webauthn/register/begin [post]
+func (c *WebAuthnController) BeginRegistration(ctx *gin.Context) {
+	user := currentUser(ctx)
+
+	options, err := c.AuthService.BeginWebAuthnRegistration(user)
+	if err != nil {
+		utils.SendErrorResponse(ctx, http.StatusInternalServerError, "SERVER_ERROR", "Erro ao iniciar cadastro da passkey", nil)
+		return
+	}
+
+	utils.SendSuccessResponse(ctx, http.StatusOK, options, nil)
+}
+
+// FinishRegistration conclui o cadastro de uma passkey
+// @Summary Conclui o cadastro de uma passkey
+// @Description Valida a resposta do autenticador e salva a passkey
+// @Tags passkeys
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param request body services.WebAuthnRegistrationRequest true "Resposta do autenticador"
+// @Success 201 {object} models.WebAuthnCredential "Passkey cadastrada com sucesso"
+// @Failure 400 {object} ErrorResponse "Dados inválidos"
+// @Failure 401 {object} ErrorResponse "Passkey inválida"
+// @Failure 409 {object} ErrorResponse "Passkey já cadastrada"
+// @Failure 500 {object} ErrorResponse "Erro interno do servidor"
+// @Router /api/v1/client/auth/webauthn/register/finish [post]
+// @Router /api/v1/professional/auth/webauthn/register/finish [post]
+func (c *WebAuthnController) FinishRegistration(ctx *gin.Context) {
+	var req services.WebAuthnRegistrationRequest
+
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		utils.SendErrorResponse(ctx, http.StatusBadRequest, "INVALID_REQUEST", "Formato da requisição inválido", nil)
+		return
+	}
+
+	// Validamos os dados
+	if req.Credential.Response.ClientDataJSON == "" || req.Credential.Response.AttestationObject == "" {
+		utils.SendErrorResponse(ctx, http.StatusUnprocessableEntity, "VALIDATION_ERROR", "Resposta do autenticador não fornecida", map[string]interface{}{
+			"credential": "Resposta do autenticador é obrigatória",
+		})
+		return
+	}
+
+	user := currentUser(ctx)
+
+	credential, err := c.AuthService.FinishWebAuthnRegistration(user, req)
+	if err != nil {
+		switch err {
+		case services.ErrWebAuthnFailed:
+			utils.SendErrorResponse(ctx, http.StatusUnauthorized, "INVALID_PASSKEY", "Não foi possível validar a passkey", nil)
+		case services.ErrPasskeyAlreadyExists:
+			utils.SendErrorResponse(ctx, http.StatusConflict, "PASSKEY_ALREADY_REGISTERED", "Passkey já cadastrada", nil)
+		default:
+			utils.SendErrorResponse(ctx, http.StatusInternalServerError, "SERVER_ERROR", "Erro ao cadastrar passkey", nil)
+		}
+		return
+	}
+
+	utils.SendSuccessResponse(ctx, http.StatusCreated, credential, nil)
+}
+
+// ListCredentials lista as passkeys do usuário
+// @Summary Lista passkeys
+// @Description Lista as passkeys cadastradas pelo usuário
+// @Tags passkeys
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {array} models.WebAuthnCredential "Passkeys cadastradas"
+// @Failure 401 {object} ErrorResponse "Não autenticado"
+// @Failure 500 {object} ErrorResponse "Erro interno do servidor"
+// @Router /api/v1/client/auth/webauthn/credentials [get]
+// @Router /api/v1/professional/auth/webauthn/credentials [get]
+func (c *WebAuthnController) ListCredentials(ctx *gin.Context) {
+	user := currentUser(ctx)
+
+	credentials, err := c.AuthService.ListWebAuthnCredentials(user.ID)
+	if err != nil {
+		utils.SendErrorResponse(ctx, http.StatusInternalServerError, "SERVER_ERROR", "Erro ao listar passkeys", nil)
+		return
+	}
+
+	utils.SendSuccessResponse(ctx, http.StatusOK, credentials, nil)
+}
+
+// DeleteCredential remove uma passkey
+// @Summary Remove uma passkey
+// @Description Remove uma passkey do usuário, que deixa de poder ser usada no login
+// @Tags passkeys
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "ID da passkey"
+// @Success 200 {object} SuccessResponse "Passkey removida com sucesso"
+// @Failure 400 {object} ErrorResponse "ID inválido"
+// @Failure 404 {object} ErrorResponse "Passkey não encontrada"
+// @Failure 500 {object} ErrorResponse "Erro interno do servidor"
+// @Router /api/v1/client/auth/webauthn/credentials/{id} [delete]
+// @Router /api/v1/professional/auth/webauthn/credentials/{id} [delete]
+func (c *WebAuthnController) DeleteCredential(ctx *gin.Context) {
+	credentialID, err := uuid.Parse(ctx.Param("id"))
+	if err != nil {
+		utils.SendErrorResponse(ctx, http.StatusBadRequest, "INVALID_REQUEST", "ID de passkey inválido", nil)
+		return
+	}
+
+	user := currentUser(ctx)
+
+	if err := c.AuthService.DeleteWebAuthnCredential(user.ID, credentialID); err != nil {
+		switch err {
+		case services.ErrPasskeyNotFound:
+			utils.SendErrorResponse(ctx, http.StatusNotFound, "PASSKEY_NOT_FOUND", "Passkey não encontrada", nil)
+		default:
+			utils.SendErrorResponse(ctx, http.StatusInternalServerError, "SERVER_ERROR", "Erro ao remover passkey", nil)
+		}
+		return
+	}
+
+	utils.SendSuccessResponse(ctx, http.StatusOK, nil, map[string]interface{}{
+		"message": "Passkey removida com sucesso",
+	})
+}
+
+// RegisterRoutes registra as rotas do controlador em um grupo já autenticado
+func (c *WebAuthnController) RegisterRoutes(router *gin.RouterGroup, authMiddleware *middlewares.AuthMiddleware) {
+	webauthn := router.Group("/auth/webauthn")
+	{
+		webauthn.POST("/register/begin", authMiddleware.ForbidImpersonation(), c.BeginRegistration)
+		webauthn.POST("/register/finish", authMiddleware.ForbidImpersonation(), c.FinishRegistration)
+		webauthn.GET("/credentials", c.ListCredentials)
+		webauthn.DELETE("/credentials/:id", authMiddleware.ForbidImpersonation(), c.DeleteCredential)
+	}
+}
@@ -1,14 +1,18 @@
 package main
 
 import (
+	"crypto/rand"
+	"errors"
 	"fmt"
 	"log"
 	"os"
 	"strconv"
+	"strings"
 	"time"
 
 	"github.com/Barba2k2/aurora_backend/src/controllers"
 	"github.com/Barba2k2/aurora_backend/src/middlewares"
+	"github.com/Barba2k2/aurora_backend/src/models"
 	"github.com/Barba2k2/aurora_backend/src/repositories"
 	"github.com/Barba2k2/aurora_backend/src/services"
 	"github.com/Barba2k2/aurora_backend/src/utils"
@@ -40,6 +44,43 @@ func getEnvAsInt(key string, defaultValue int) int {
 	return intValue
 }
 
+// getEnvAsBool obtem uma variavel de ambiente como bool ou retorna um valor padrão
+func getEnvAsBool(key string, defaultValue bool) bool {
+	value := os.Getenv(key)
+	if value == "" {
+		return defaultValue
+	}
+	boolValue, err := strconv.ParseBool(value)
+	if err != nil {
+		return defaultValue
+	}
+	return boolValue
+}
+
+// getEnvAsFloat obtem uma variavel de ambiente como float ou retorna um valor padrão
+func getEnvAsFloat(key string, defaultValue float64) float64 {
+	value := os.Getenv(key)
+	if value == "" {
+		return defaultValue
+	}
+	floatValue, err := strconv.ParseFloat(value, 64)
+	if err != nil {
+		return defaultValue
+	}
+	return floatValue
+}
+
+// getEnvAsList obtem uma variavel de ambiente como lista separada por virgulas
+func getEnvAsList(key, defaultValue string) []string {
+	var list []string
+	for _, item := range strings.Split(getEnv(key, defaultValue), ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			list = append(list, item)
+		}
+	}
+	return list
+}
+
 // setupDatabase configura a conexão com o banco de dados
 func setupDatabase() (*gorm.DB, error) {
 	dbHost := getEnv("DB_HOST", "localhost")
@@ -69,8 +110,162 @@ func setupDatabase() (*gorm.DB, error) {
 	return db, nil
 }
 
+// setupJWTKeyRing carrega as chaves de assinatura dos tokens.
+// Fora do ambiente de desenvolvimento o arquivo gerado pelo cmd/jwtkeys é obrigatório.
+func setupJWTKeyRing() (*utils.JWTKeyRing, error) {
+	if keysFile := getEnv("JWT_KEYS_FILE", ""); keysFile != "" {
+		return utils.LoadJWTKeyRing(keysFile)
+	}
+
+	if getEnv("APP_ENV", "development") != "development" {
+		return nil, errors.New("JWT_KEYS_FILE não configurado")
+	}
+
+	// Em desenvolvimento usamos uma chave temporaria; os tokens nao sobrevivem a um reinicio
+	log.Println("JWT_KEYS_FILE não configurado, usando chave temporária de desenvolvimento")
+	return utils.NewJWTKeyRing(utils.JWTAlgorithmEdDSA)
+}
+
+// setupTokenHashKey carrega a chave usada no hash dos tokens de uso único.
+// Trocar a chave invalida os links e códigos pendentes.
+func setupTokenHashKey() ([]byte, error) {
+	if key := getEnv("TOKEN_HASH_KEY", ""); key != "" {
+		if len(key) < 32 {
+			return nil, errors.New("TOKEN_HASH_KEY deve ter pelo menos 32 caracteres")
+		}
+		return []byte(key), nil
+	}
+
+	if getEnv("APP_ENV", "development") != "development" {
+		return nil, errors.New("TOKEN_HASH_KEY não configurado")
+	}
+
+	// Em desenvolvimento usamos uma chave temporaria; os tokens pendentes nao sobrevivem a um reinicio
+	log.Println("TOKEN_HASH_KEY não configurado, usando chave temporária de desenvolvimento")
+	key := make([]byte, 32)
+	if _, err := rand.Read(key); err != nil {
+		return nil, err
+	}
+	return key, nil
+}
+
+// setupPasswordPolicy monta a política de senhas a partir das variaveis de ambiente.
+// Os arquivos de dicionário e de senhas vazadas são opcionais.
+func setupPasswordPolicy() (utils.PasswordPolicy, error) {
+	policy := utils.DefaultPasswordPolicy()
+	policy.MinLength = getEnvAsInt("PASSWORD_MIN_LENGTH", policy.MinLength)
+	policy.MinCharacterClasses = getEnvAsInt("PASSWORD_MIN_CLASSES", policy.MinCharacterClasses)
+	policy.MaxRepeatedChars = getEnvAsInt("PASSWORD_MAX_REPEATED_CHARS", policy.MaxRepeatedChars)
+	policy.CheckUserInfo = getEnvAsBool("PASSWORD_CHECK_USER_INFO", policy.CheckUserInfo)
+
+	if dictionaryFile := getEnv("PASSWORD_DICTIONARY_FILE", ""); dictionaryFile != "" {
+		dictionary, err := utils.LoadPasswordDictionary(dictionaryFile)
+		if err != nil {
+			return policy, err
+		}
+		policy.Dictionary = dictionary
+	}
+
+	// Arquivo ordenado ou diretorio de faixas do Have I Been Pwned, consultado em disco
+	if breachedFile := getEnv("PASSWORD_BREACHED_FILE", ""); breachedFile != "" {
+		breached, err := utils.LoadBreachedPasswordList(breachedFile)
+		if err != nil {
+			return policy, err
+		}
+		log.Printf("Lista de senhas vazadas aberta em %s", breachedFile)
+		policy.Breached = breached
+	}
+
+	return policy, nil
+}
+
+// setupPasswordHasher escolhe o algoritmo usado nas novas senhas.
+// Hashes bcrypt existentes continuam válidos e são atualizados no próximo login.
+func setupPasswordHasher() (utils.PasswordHasher, []utils.PasswordHasher, error) {
+	bcryptHasher := utils.NewBcryptHasher(getEnvAsInt("BCRYPT_COST", utils.DefaultBcryptCost))
+
+	switch getEnv("PASSWORD_HASHER", utils.PasswordHasherArgon2id) {
+	case utils.PasswordHasherArgon2id:
+		argon2idHasher := utils.NewArgon2idHasher()
+		argon2idHasher.Memory = uint32(getEnvAsInt("ARGON2_MEMORY_KB", int(argon2idHasher.Memory)))
+		argon2idHasher.Iterations = uint32(getEnvAsInt("ARGON2_ITERATIONS", int(argon2idHasher.Iterations)))
+		argon2idHasher.Parallelism = uint8(getEnvAsInt("ARGON2_PARALLELISM", int(argon2idHasher.Parallelism)))
+		return argon2idHasher, []utils.PasswordHasher{bcryptHasher}, nil
+	case utils.PasswordHasherBcrypt:
+		return bcryptHasher, []utils.PasswordHasher{utils.NewArgon2idHasher()}, nil
+	default:
+		return nil, nil, errors.New("PASSWORD_HASHER deve ser argon2id ou bcrypt")
+	}
+}
+
+// setupCaptcha configura o desafio anti-robô das rotas públicas de autenticação.
+// Sem CAPTCHA_PROVIDER o desafio fica desabilitado.
+func setupCaptcha() (*middlewares.CaptchaMiddleware, error) {
+	provider := getEnv("CAPTCHA_PROVIDER", "")
+	if provider == "" {
+		return nil, nil
+	}
+
+	// O verificador de teste aceita um token fixo, entao nao pode sair do ambiente de desenvolvimento
+	if provider == utils.CaptchaProviderTest && getEnv("APP_ENV", "development") != "development" {
+		return nil, errors.New("CAPTCHA_PROVIDER=test só pode ser usado em desenvolvimento")
+	}
+
+	verifier, err := utils.NewCaptchaVerifier(provider, getEnv("CAPTCHA_SECRET", ""), getEnvAsFloat("CAPTCHA_MIN_SCORE", 0.5))
+	if err != nil {
+		return nil, err
+	}
+
+	config := middlewares.DefaultCaptchaConfig()
+	config.Routes = getEnvAsList("CAPTCHA_ROUTES", strings.Join(config.Routes, ","))
+	config.FailureThreshold = getEnvAsInt("CAPTCHA_FAILURE_THRESHOLD", config.FailureThreshold)
+	config.FailureWindow = time.Duration(getEnvAsInt("CAPTCHA_FAILURE_WINDOW_MINUTES", int(config.FailureWindow/time.Minute))) * time.Minute
+
+	return middlewares.NewCaptchaMiddleware(verifier, config), nil
+}
+
+// setupRateLimit configura o rate limit das rotas da API.
+// O backend em memória atende uma única instância; com várias instâncias os limites ficam no Postgres.
+func setupRateLimit(db *gorm.DB) (*middlewares.RateLimitMiddleware, error) {
+	var store middlewares.RateLimitStore
+
+	switch getEnv("RATE_LIMIT_BACKEND", "memory") {
+	case "memory":
+		store = middlewares.NewMemoryRateLimitStore()
+	case "postgres":
+		postgresStore := middlewares.NewPostgresRateLimitStore(repositories.NewRateLimitRepository(db))
+		postgresStore.StartCleanup(10 * time.Minute)
+		store = postgresStore
+	case "off":
+		return nil, nil
+	default:
+		return nil, errors.New("RATE_LIMIT_BACKEND deve ser memory, postgres ou off")
+	}
+
+	return middlewares.NewRateLimitMiddleware(store, middlewares.DefaultRateLimitPolicies()), nil
+}
+
+// setupTrustedProxies define os proxies cujos cabeçalhos X-Forwarded-For são aceitos no IP do cliente.
+// O gin confia em qualquer proxy por padrão, o que permitiria forjar o IP usado no rate limit e no desafio anti-robô;
+// TRUSTED_PROXIES=none ignora os cabeçalhos e usa o endereço da conexão.
+func setupTrustedProxies(router *gin.Engine) error {
+	proxies := getEnvAsList("TRUSTED_PROXIES", "")
+	if len(proxies) == 0 {
+		if getEnv("APP_ENV", "development") != "development" {
+			return errors.New("TRUSTED_PROXIES não configurado")
+		}
+		return router.SetTrustedProxies(nil)
+	}
+
+	if len(proxies) == 1 && proxies[0] == "none" {
+		return router.SetTrustedProxies(nil)
+	}
+
+	return router.SetTrustedProxies(proxies)
+}
+
 // setupRouter configura o router gin
-func setupRouter() *gin.Engine {
+func setupRouter() (*gin.Engine, error) {
 	// Definimos o modo do Gin
 	if getEnv("APP_ENV", "development") == "development" {
 		gin.SetMode(gin.ReleaseMode)
@@ -78,19 +273,23 @@ func setupRouter() *gin.Engine {
 
 	router := gin.Default()
 
+	if err := setupTrustedProxies(router); err != nil {
+		return nil, err
+	}
+
 	// Configuramos o CORS
 	router.Use(cors.New(cors.Config{
 		AllowOrigins:     []string{getEnv("CORS_ALLOW_ORIGINS", "*")},
 		AllowMethods:     []string{"GET", "POST", "PUT", "DELETE", "OPTIONS"},
-		AllowHeaders:     []string{"Origin", "Content-Type", "Accept", "Authorization"},
-		ExposeHeaders:    []string{"Content-Length"},
+		AllowHeaders:     []string{"Origin", "Content-Type", "Accept", "Authorization", middlewares.CaptchaTokenHeader},
+		ExposeHeaders:    []string{"Content-Length", middlewares.RateLimitLimitHeader, middlewares.RateLimitRemainingHeader, middlewares.RateLimitResetHeader, middlewares.RateLimitPolicyHeader, middlewares.RetryAfterHeader},
 		AllowCredentials: true,
 		MaxAge:           12 * time.Hour,
 	}))
 
 	router.Use(gin.Recovery())
 
-	return router
+	return router, nil
 }
 
 func main() {
@@ -106,18 +305,71 @@ func main() {
 	defer db.Close()
 
 	// Inicializamos o router
-	router := setupRouter()
+	router, err := setupRouter()
+	if err != nil {
+		log.Fatalf("Erro ao configurar o router: %v", err)
+	}
 
 	// Incializamos os componentes
 	userRepo := repositories.NewUserRepository(db)
 	tokenRepo := repositories.NewTokenRepository(db)
+	refreshTokenRepo := repositories.NewRefreshTokenRepository(db)
+	sessionRepo := repositories.NewSessionRepository(db)
+	recoveryCodeRepo := repositories.NewMFARecoveryCodeRepository(db)
+	webAuthnRepo := repositories.NewWebAuthnRepository(db)
+	linkedAccountRepo := repositories.NewLinkedAccountRepository(db)
+	passwordHistoryRepo := repositories.NewPasswordHistoryRepository(db)
+	authEventRepo := repositories.NewAuthEventRepository(db)
+	knownDeviceRepo := repositories.NewKnownDeviceRepository(db)
+	establishmentRoleRepo := repositories.NewEstablishmentRoleRepository(db)
+	establishmentMemberRepo := repositories.NewEstablishmentMemberRepository(db)
+	staffInvitationRepo := repositories.NewStaffInvitationRepository(db)
+	apiKeyRepo := repositories.NewAPIKeyRepository(db)
+	personalDataRepo := repositories.NewPersonalDataRepository(db)
 
 	// Utilitarios
-	passwordUtil := utils.NewPasswordUtil(12)
+	tokenHashKey, err := setupTokenHashKey()
+	if err != nil {
+		log.Fatalf("Erro ao carregar a chave de hash dos tokens: %v", err)
+	}
+	passwordPolicy, err := setupPasswordPolicy()
+	if err != nil {
+		log.Fatalf("Erro ao carregar a política de senhas: %v", err)
+	}
+	passwordHasher, legacyPasswordHashers, err := setupPasswordHasher()
+	if err != nil {
+		log.Fatalf("Erro ao configurar o hash de senhas: %v", err)
+	}
+	passwordUtil := utils.NewPasswordUtil(passwordHasher, legacyPasswordHashers, tokenHashKey, passwordPolicy)
+	jwtKeyRing, err := setupJWTKeyRing()
+	if err != nil {
+		log.Fatalf("Erro ao carregar as chaves JWT: %v", err)
+	}
 	jwtUtil := utils.NewJWTUtil(utils.JWTConfig{
-		AccessSecret:  getEnv("JWT_ACCESS_SECRET", "access_secret_key"),
-		RefreshSecret: getEnv("JWT_REFRESH_SECRET", "refresh_secret_key"),
-		Issuer:        getEnv("JWT_ISSUER", "aurora_backend"),
+		KeyRing:  jwtKeyRing,
+		Issuer:   getEnv("JWT_ISSUER", "aurora_backend"),
+		Audience: getEnv("JWT_AUDIENCE", "aurora_api"),
+	})
+	totpUtil := utils.NewTOTPUtil(getEnv("TOTP_ISSUER", "Aurora"))
+	webAuthnUtil := utils.NewWebAuthnUtil(utils.WebAuthnConfig{
+		RPID:      getEnv("WEBAUTHN_RP_ID", "localhost"),
+		RPName:    getEnv("WEBAUTHN_RP_NAME", "Aurora"),
+		RPOrigins: getEnvAsList("WEBAUTHN_RP_ORIGINS", "http://localhost:3000"),
+		// Sem a verificacao do usuario, o login com passkey de quem tem TOTP pede tambem o codigo
+		RequireUserVerification: getEnvAsBool("WEBAUTHN_REQUIRE_USER_VERIFICATION", true),
+	})
+	// Provedores sem client ID configurado ficam desabilitados
+	oidcUtil := utils.NewOIDCUtil(map[string]utils.OIDCProviderConfig{
+		models.IdentityProviderGoogle: {
+			Issuers:   []string{"https://accounts.google.com", "accounts.google.com"},
+			ClientIDs: getEnvAsList("GOOGLE_CLIENT_IDS", ""),
+			JWKSURL:   getEnv("GOOGLE_JWKS_URL", "https://www.googleapis.com/oauth2/v3/certs"),
+		},
+		models.IdentityProviderApple: {
+			Issuers:   []string{"https://appleid.apple.com"},
+			ClientIDs: getEnvAsList("APPLE_CLIENT_IDS", ""),
+			JWKSURL:   getEnv("APPLE_JWKS_URL", "https://appleid.apple.com/auth/keys"),
+		},
 	})
 
 	// Servicos de notificacao
@@ -131,6 +383,7 @@ func main() {
 		TemplatesDir: getEnv("SMTP_TEMPLATES_DIR", "./templates/email"),
 		IsSMTP:       true,
 		ServiceType:  getEnv("EMAIL_SERVICE", "smtp"),
+		AppURL:       getEnv("APP_URL", "http://localhost:3000"),
 	})
 
 	smsService := services.NewSMSService(services.SMSConfig{
@@ -147,51 +400,154 @@ func main() {
 		AccountSID:    getEnv("TWILIO_ACCOUNT_SID", ""),
 		AuthToken:     getEnv("TWILIO_AUTH_TOKEN", ""),
 		FromNumber:    getEnv("TWILIO_WHATSAPP_FROM", ""),
+		AppURL:        getEnv("APP_URL", "http://localhost:3000"),
 	})
 
+	authConfig := services.DefaultAuthConfig()
+	authConfig.PrivacyMode = getEnvAsBool("AUTH_PRIVACY_MODE", false)
+	authConfig.PasswordHistorySize = getEnvAsInt("PASSWORD_HISTORY_SIZE", authConfig.PasswordHistorySize)
+
 	authService := services.NewAuthService(
 		userRepo,
 		tokenRepo,
+		refreshTokenRepo,
+		sessionRepo,
+		recoveryCodeRepo,
+		webAuthnRepo,
+		linkedAccountRepo,
+		passwordHistoryRepo,
+		authEventRepo,
+		knownDeviceRepo,
+		establishmentRoleRepo,
+		establishmentMemberRepo,
+		staffInvitationRepo,
+		apiKeyRepo,
+		personalDataRepo,
 		passwordUtil,
 		jwtUtil,
+		totpUtil,
+		webAuthnUtil,
+		oidcUtil,
 		emailService,
 		smsService,
 		whatsAppService,
-		services.DefaultAuthConfig(),
+		authConfig,
 	)
 
+	// Anonimiza periodicamente as contas cujo prazo para eliminacao terminou (LGPD)
+	authService.StartErasureWorker(time.Hour)
+
 	// Middlewares
 	authMiddleware := middlewares.NewAuthMiddleware(authService)
+	captchaMiddleware, err := setupCaptcha()
+	if err != nil {
+		log.Fatalf("Erro ao configurar o desafio anti-robô: %v", err)
+	}
+	rateLimitMiddleware, err := setupRateLimit(db)
+	if err != nil {
+		log.Fatalf("Erro ao configurar o rate limit: %v", err)
+	}
 
 	// Controladores
 	clientAuthController := controllers.NewClientAuthController(authService)
 	professionalAuthController := controllers.NewProfessionalAuthController(authService)
+	sessionController := controllers.NewSessionController(authService)
+	accountController := controllers.NewAccountController(authService)
+	mfaController := controllers.NewMFAController(authService)
+	webAuthnController := controllers.NewWebAuthnController(authService)
+	phoneController := controllers.NewPhoneController(authService)
+	linkedAccountController := controllers.NewLinkedAccountController(authService)
+	adminController := controllers.NewAdminController(authService)
+	authEventController := controllers.NewAuthEventController(authService)
+	establishmentRoleController := controllers.NewEstablishmentRoleController(authService)
+	staffController := controllers.NewStaffController(authService)
+	apiKeyController := controllers.NewAPIKeyController(authService)
+	dataRightsController := controllers.NewDataRightsController(authService)
+	jwksController := controllers.NewJWKSController(authService)
 
 	// Configuracao das rotas
 	api := router.Group("/api/v1")
 
+	// Rate limit por IP, email e telefone; as politicas por usuario sao aplicadas apos a autenticacao
+	if rateLimitMiddleware != nil {
+		api.Use(rateLimitMiddleware.Limit())
+	}
+
+	// Desafio anti-robô nas rotas publicas configuradas, antes de registrar as rotas
+	if captchaMiddleware != nil {
+		api.Use(captchaMiddleware.Require())
+	}
+
+	// Chaves publicas para verificacao dos tokens por outros servicos
+	jwksController.RegisterRoutes(router.Group(""))
+
 	// Rotas de cliente
 	clientRoutes := api.Group("/client")
 	clientAuthController.RegisterRoutes(clientRoutes)
+	accountController.RegisterPublicRoutes(clientRoutes)
 
 	// Rotas protegidas do cliente
 	clientProtected := clientRoutes.Group("")
 	clientProtected.Use(authMiddleware.RequireAuth())
 	clientProtected.Use(authMiddleware.RequireClient())
+	if rateLimitMiddleware != nil {
+		clientProtected.Use(rateLimitMiddleware.Limit())
+	}
 	{
-		// Protect client routes
+		sessionController.RegisterRoutes(clientProtected, authMiddleware)
+		accountController.RegisterRoutes(clientProtected, authMiddleware)
+		webAuthnController.RegisterRoutes(clientProtected, authMiddleware)
+		phoneController.RegisterRoutes(clientProtected)
+		linkedAccountController.RegisterRoutes(clientProtected, authMiddleware)
+		dataRightsController.RegisterRoutes(clientProtected, authMiddleware)
 	}
 
 	// Rotas do profissional
 	professionalRoutes := api.Group("/professional")
 	professionalAuthController.RegisterRoutes(professionalRoutes)
+	staffController.RegisterPublicRoutes(professionalRoutes)
+	accountController.RegisterPublicRoutes(professionalRoutes)
 
 	// Rotas protegidas do profissional
 	professionalProtected := professionalRoutes.Group("")
 	professionalProtected.Use(authMiddleware.RequireAuth())
 	professionalProtected.Use(authMiddleware.RequireProfessional())
+	if rateLimitMiddleware != nil {
+		professionalProtected.Use(rateLimitMiddleware.Limit())
+	}
+	{
+		sessionController.RegisterRoutes(professionalProtected, authMiddleware)
+		accountController.RegisterRoutes(professionalProtected, authMiddleware)
+		mfaController.RegisterRoutes(professionalProtected, authMiddleware)
+		webAuthnController.RegisterRoutes(professionalProtected, authMiddleware)
+		phoneController.RegisterRoutes(professionalProtected)
+		authEventController.RegisterRoutes(professionalProtected)
+		establishmentRoleController.RegisterRoutes(professionalProtected, authMiddleware)
+		staffController.RegisterRoutes(professionalProtected, authMiddleware)
+		apiKeyController.RegisterRoutes(professionalProtected, authMiddleware)
+		dataRightsController.RegisterRoutes(professionalProtected, authMiddleware)
+	}
+
+	// Rotas das integracoes dos estabelecimentos, autenticadas por chave de API
+	integrationRoutes := api.Group("/integrations")
+	integrationRoutes.Use(authMiddleware.RequireAPIKey())
+	if rateLimitMiddleware != nil {
+		integrationRoutes.Use(rateLimitMiddleware.Limit())
+	}
+	{
+		apiKeyController.RegisterIntegrationRoutes(integrationRoutes)
+	}
+
+	// Rotas administrativas
+	adminRoutes := api.Group("/admin")
+	adminRoutes.Use(authMiddleware.RequireAuth())
+	adminRoutes.Use(authMiddleware.RequireAdmin())
+	if rateLimitMiddleware != nil {
+		adminRoutes.Use(rateLimitMiddleware.Limit())
+	}
 	{
-		// Protect professional routes
+		adminController.RegisterRoutes(adminRoutes)
+		authEventController.RegisterRoutes(adminRoutes)
 	}
 
 	// Inicia o servidor
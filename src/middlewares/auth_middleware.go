@@ -8,6 +8,7 @@ import (
 	"github.com/Barba2k2/aurora_backend/src/services"
 	"github.com/Barba2k2/aurora_backend/src/utils"
 	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
 )
 
 // AuthMiddleware é o middleware de autenticação
@@ -42,8 +43,8 @@ func (m *AuthMiddleware) RequireAuth() gin.HandlerFunc {
 			return
 		}
 
-		// Validamos o token
-		user, err := m.AuthService.GetUserFromToken(tokenString)
+		// Validamos o token e a sessão a que ele pertence
+		user, claims, err := m.AuthService.AuthenticateAccessToken(tokenString)
 		if err != nil {
 			utils.SendErrorResponse(ctx, http.StatusUnauthorized, "INVALID_TOKEN", "Token inválido ou expirado", nil)
 			ctx.Abort()
@@ -61,6 +62,48 @@ func (m *AuthMiddleware) RequireAuth() gin.HandlerFunc {
 		ctx.Set("user", user)
 		ctx.Set("user_id", user.ID.String())
 		ctx.Set("user_role", string(user.Role))
+		ctx.Set("session_id", claims.SessionID.String())
+		if claims.EstablishmentID != nil {
+			ctx.Set("establishment_id", claims.EstablishmentID.String())
+		}
+		if claims.ImpersonatorID != nil {
+			ctx.Set("impersonator_id", claims.ImpersonatorID.String())
+		}
+
+		ctx.Next()
+	}
+}
+
+// RequireAPIKey exige uma chave de API do estabelecimento no cabeçalho X-API-Key.
+// O contexto é preenchido como no RequireAuth, com o dono do estabelecimento como usuário
+// e os escopos da chave como permissões.
+func (m *AuthMiddleware) RequireAPIKey() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		rawKey := ctx.GetHeader("X-API-Key")
+		if rawKey == "" {
+			utils.SendErrorResponse(ctx, http.StatusUnauthorized, "UNAUTHORIZED", "Chave de API não fornecida", nil)
+			ctx.Abort()
+			return
+		}
+
+		auth, err := m.AuthService.AuthenticateAPIKey(rawKey, ctx.ClientIP())
+		if err != nil {
+			if err == services.ErrInvalidAPIKey {
+				utils.SendErrorResponse(ctx, http.StatusUnauthorized, "INVALID_API_KEY", "Chave de API inválida, expirada ou revogada", nil)
+			} else {
+				utils.SendErrorResponse(ctx, http.StatusInternalServerError, "SERVER_ERROR", "Erro ao validar chave de API", nil)
+			}
+			ctx.Abort()
+			return
+		}
+
+		// Armazenamos o dono, o estabelecimento e os escopos da chave no contexto
+		ctx.Set("user", auth.Owner)
+		ctx.Set("user_id", auth.Owner.ID.String())
+		ctx.Set("user_role", string(auth.Owner.Role))
+		ctx.Set("establishment_id", auth.APIKey.EstablishmentID.String())
+		ctx.Set("api_key_id", auth.APIKey.ID.String())
+		ctx.Set("permissions", auth.Permissions)
 
 		ctx.Next()
 	}
@@ -97,12 +140,85 @@ func (m *AuthMiddleware) RequireRole(roles ...models.UserRole) gin.HandlerFunc {
 	}
 }
 
+// Permissions obtém as permissões efetivas do usuário autenticado.
+// O cálculo é feito uma única vez por requisição e guardado no contexto.
+func (m *AuthMiddleware) Permissions(ctx *gin.Context) (*services.PermissionSet, error) {
+	if cached, exists := ctx.Get("permissions"); exists {
+		return cached.(*services.PermissionSet), nil
+	}
+
+	user, exists := ctx.Get("user")
+	if !exists {
+		return nil, services.ErrUserNotFound
+	}
+
+	// O estabelecimento escolhido pela equipe vem do token
+	var establishmentID *uuid.UUID
+	if value, err := uuid.Parse(ctx.GetString("establishment_id")); err == nil {
+		establishmentID = &value
+	}
+
+	permissions, err := m.AuthService.ResolvePermissions(user.(*models.User), establishmentID)
+	if err != nil {
+		return nil, err
+	}
+
+	ctx.Set("permissions", permissions)
+	return permissions, nil
+}
+
+// RequirePermission exige que o usuário tenha todas as permissões informadas
+func (m *AuthMiddleware) RequirePermission(permissions ...models.Permission) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		// Verificamos se o usuario esta autenticado
+		if _, exists := ctx.Get("user"); !exists {
+			utils.SendErrorResponse(ctx, http.StatusUnauthorized, "UNAUTHORIZED", "Usuário não autenticado", nil)
+			ctx.Abort()
+			return
+		}
+
+		granted, err := m.Permissions(ctx)
+		if err != nil {
+			utils.SendErrorResponse(ctx, http.StatusInternalServerError, "SERVER_ERROR", "Erro ao verificar permissões", nil)
+			ctx.Abort()
+			return
+		}
+
+		for _, permission := range permissions {
+			if !granted.Has(permission) {
+				utils.SendErrorResponse(ctx, http.StatusForbidden, "FORBIDDEN", "Acesso negado", map[string]interface{}{
+					"permission": string(permission),
+				})
+				ctx.Abort()
+				return
+			}
+		}
+
+		ctx.Next()
+	}
+}
+
+// ForbidImpersonation bloqueia ações sensíveis quando um administrador está agindo como o usuário,
+// como a troca de senha ou de email
+func (m *AuthMiddleware) ForbidImpersonation() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		if ctx.GetString("impersonator_id") != "" {
+			utils.SendErrorResponse(ctx, http.StatusForbidden, "IMPERSONATION_FORBIDDEN", "Ação não permitida durante a personificação", nil)
+			ctx.Abort()
+			return
+		}
+
+		ctx.Next()
+	}
+}
+
 // RequireClient exige que o usuário seja um cliente
 func (m *AuthMiddleware) RequireClient() gin.HandlerFunc {
 	return m.RequireRole(models.UserRoleClient)
 }
 
-// RequireProfessional exige que o usuário seja um profissional
+// RequireProfessional exige que o usuário seja um profissional.
+// A equipe também passa; rotas restritas ao dono devem usar RequirePermission.
 func (m *AuthMiddleware) RequireProfessional() gin.HandlerFunc {
 	return m.RequireRole(models.UserRoleProfessional, models.UserRoleStaff, models.UserRoleAdmin)
 }
@@ -0,0 +1,156 @@
+package middlewares
+
+import (
+	"log"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Barba2k2/aurora_backend/src/utils"
+	"github.com/gin-gonic/gin"
+)
+
+// CaptchaTokenHeader é o cabeçalho com a resposta do desafio resolvido pelo cliente
+const CaptchaTokenHeader = "X-Captcha-Token"
+
+// captchaMaxTrackedIPs limita a memória usada na contagem de falhas antes de descartar as janelas vencidas
+const captchaMaxTrackedIPs = 10000
+
+// CaptchaConfig contém as configurações do desafio anti-robô
+type CaptchaConfig struct {
+	// Routes lista os finais dos caminhos protegidos, comparados com a rota registrada no gin,
+	// de modo que "/auth/login" protege o login de clientes e de profissionais
+	Routes []string
+	// FailureThreshold é o número de respostas de erro a um IP a partir do qual o desafio é exigido; zero exige sempre.
+	// O IP vem de ctx.ClientIP, então os proxies confiáveis do router precisam estar configurados.
+	FailureThreshold int
+	// FailureWindow é o período em que as falhas de um IP são contadas
+	FailureWindow time.Duration
+}
+
+// DefaultCaptchaConfig retorna a configuração padrão: cadastro, login e recuperação de senha, exigindo sempre o desafio
+func DefaultCaptchaConfig() CaptchaConfig {
+	return CaptchaConfig{
+		Routes: []string{
+			"/auth/register",
+			"/auth/login",
+			"/auth/forgot-password/email",
+			"/auth/forgot-password/sms",
+			"/auth/forgot-password/whatsapp",
+		},
+		FailureThreshold: 0,
+		FailureWindow:    15 * time.Minute,
+	}
+}
+
+// captchaFailures conta as respostas de erro a um IP dentro da janela atual
+type captchaFailures struct {
+	count       int
+	windowStart time.Time
+}
+
+// CaptchaMiddleware exige a resolução de um desafio anti-robô nas rotas configuradas
+type CaptchaMiddleware struct {
+	Verifier utils.CaptchaVerifier
+	Config   CaptchaConfig
+
+	mu       sync.Mutex
+	failures map[string]*captchaFailures
+}
+
+// NewCaptchaMiddleware cria uma nova instância do CaptchaMiddleware
+func NewCaptchaMiddleware(verifier utils.CaptchaVerifier, config CaptchaConfig) *CaptchaMiddleware {
+	return &CaptchaMiddleware{
+		Verifier: verifier,
+		Config:   config,
+		failures: make(map[string]*captchaFailures),
+	}
+}
+
+// Require verifica o desafio nas rotas configuradas. Com um limiar de risco, o desafio só é exigido
+// dos IPs que acumularam respostas de erro nessas rotas dentro da janela.
+func (m *CaptchaMiddleware) Require() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		if !m.protects(ctx.FullPath()) {
+			ctx.Next()
+			return
+		}
+
+		clientIP := ctx.ClientIP()
+
+		if m.challengeRequired(clientIP) {
+			if err := m.Verifier.Verify(ctx.GetHeader(CaptchaTokenHeader), clientIP); err != nil {
+				if err == utils.ErrCaptchaFailed {
+					utils.SendErrorResponse(ctx, http.StatusForbidden, "CAPTCHA_REQUIRED", "Resolva o desafio de verificação para continuar", nil)
+				} else {
+					log.Printf("Erro ao verificar desafio anti-robô: %v", err)
+					utils.SendErrorResponse(ctx, http.StatusServiceUnavailable, "CAPTCHA_UNAVAILABLE", "Verificação temporariamente indisponível, tente novamente", nil)
+				}
+				ctx.Abort()
+				return
+			}
+		}
+
+		ctx.Next()
+
+		// Credenciais erradas e dados invalidos contam para o limiar de risco do IP
+		status := ctx.Writer.Status()
+		if m.Config.FailureThreshold > 0 && status >= http.StatusBadRequest && status < http.StatusInternalServerError {
+			m.recordFailure(clientIP)
+		}
+	}
+}
+
+// protects informa se a rota está entre as configuradas
+func (m *CaptchaMiddleware) protects(route string) bool {
+	if route == "" {
+		return false
+	}
+
+	for _, suffix := range m.Config.Routes {
+		if strings.HasSuffix(route, suffix) {
+			return true
+		}
+	}
+	return false
+}
+
+// challengeRequired informa se o IP precisa resolver o desafio
+func (m *CaptchaMiddleware) challengeRequired(clientIP string) bool {
+	if m.Config.FailureThreshold <= 0 {
+		return true
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	entry, ok := m.failures[clientIP]
+	if !ok || time.Since(entry.windowStart) > m.Config.FailureWindow {
+		return false
+	}
+	return entry.count >= m.Config.FailureThreshold
+}
+
+// recordFailure contabiliza uma resposta de erro ao IP, abrindo uma nova janela quando a anterior venceu
+func (m *CaptchaMiddleware) recordFailure(clientIP string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	now := time.Now()
+
+	if len(m.failures) >= captchaMaxTrackedIPs {
+		for ip, entry := range m.failures {
+			if now.Sub(entry.windowStart) > m.Config.FailureWindow {
+				delete(m.failures, ip)
+			}
+		}
+	}
+
+	entry, ok := m.failures[clientIP]
+	if !ok || now.Sub(entry.windowStart) > m.Config.FailureWindow {
+		m.failures[clientIP] = &captchaFailures{count: 1, windowStart: now}
+		return
+	}
+	entry.count++
+}
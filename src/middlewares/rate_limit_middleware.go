@@ -0,0 +1,291 @@
+package middlewares
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"log"
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Barba2k2/aurora_backend/src/utils"
+	"github.com/gin-gonic/gin"
+)
+
+// Cabeçalhos de rate limit, no formato proposto pela IETF e no Retry-After do HTTP
+const (
+	RateLimitLimitHeader     = "RateLimit-Limit"
+	RateLimitRemainingHeader = "RateLimit-Remaining"
+	RateLimitResetHeader     = "RateLimit-Reset"
+	RateLimitPolicyHeader    = "RateLimit-Policy"
+	RetryAfterHeader         = "Retry-After"
+)
+
+// rateLimitAppliedKey guarda no contexto as políticas já aplicadas à requisição,
+// já que o middleware roda de novo depois da autenticação
+const rateLimitAppliedKey = "rate_limit_applied"
+
+// rateLimitBodyKey guarda no contexto o email e o telefone lidos do corpo da requisição
+const rateLimitBodyKey = "rate_limit_body"
+
+// rateLimitMaxBodySize limita a parte do corpo lida em busca do email e do telefone
+const rateLimitMaxBodySize = 64 * 1024
+
+// RateLimitKey identifica o que uma política limita
+type RateLimitKey string
+
+const (
+	// RateLimitByIP limita o endereço IP do cliente, obtido de ctx.ClientIP; depende dos proxies confiáveis
+	// configurados no router, já que sem eles o X-Forwarded-For permitiria trocar de balde a cada requisição
+	RateLimitByIP RateLimitKey = "ip"
+	// RateLimitByUser limita o usuário autenticado ou a chave de API
+	RateLimitByUser RateLimitKey = "user"
+	// RateLimitByPhone limita o telefone informado no corpo da requisição
+	RateLimitByPhone RateLimitKey = "phone"
+	// RateLimitByEmail limita o email informado no corpo da requisição
+	RateLimitByEmail RateLimitKey = "email"
+)
+
+// RateLimitPolicy limita as requisições às rotas indicadas a Limit por Window, por chave
+type RateLimitPolicy struct {
+	// Name identifica a política nas chaves dos baldes e no cabeçalho RateLimit-Policy
+	Name string
+	// Routes lista os finais dos caminhos limitados, comparados com a rota registrada no gin;
+	// sem rotas a política vale para todas
+	Routes []string
+	Key    RateLimitKey
+	Limit  int
+	Window time.Duration
+}
+
+// matches informa se a política vale para a rota
+func (p RateLimitPolicy) matches(route string) bool {
+	if len(p.Routes) == 0 {
+		return true
+	}
+
+	for _, suffix := range p.Routes {
+		if strings.HasSuffix(route, suffix) {
+			return true
+		}
+	}
+	return false
+}
+
+// DefaultRateLimitPolicies retorna as políticas padrão. Rotas que enviam SMS ou WhatsApp
+// têm limites por telefone, além dos limites por IP e por email.
+func DefaultRateLimitPolicies() []RateLimitPolicy {
+	return []RateLimitPolicy{
+		{
+			Name:   "login-ip",
+			Routes: []string{"/auth/login", "/auth/login/mfa", "/auth/passwordless/login", "/auth/webauthn/login/finish", "/auth/social/:provider"},
+			Key:    RateLimitByIP,
+			Limit:  30,
+			Window: 5 * time.Minute,
+		},
+		{
+			Name:   "login-email",
+			Routes: []string{"/auth/login"},
+			Key:    RateLimitByEmail,
+			Limit:  10,
+			Window: 15 * time.Minute,
+		},
+		{
+			Name:   "register-ip",
+			Routes: []string{"/auth/register"},
+			Key:    RateLimitByIP,
+			Limit:  10,
+			Window: time.Hour,
+		},
+		{
+			Name: "recovery-ip",
+			Routes: []string{
+				"/auth/forgot-password/email", "/auth/forgot-password/sms", "/auth/forgot-password/whatsapp",
+				"/auth/passwordless/email", "/auth/passwordless/sms", "/auth/passwordless/whatsapp",
+				"/auth/verify-email/resend", "/auth/restore",
+			},
+			Key:    RateLimitByIP,
+			Limit:  20,
+			Window: time.Hour,
+		},
+		{
+			Name:   "recovery-email",
+			Routes: []string{"/auth/forgot-password/email", "/auth/passwordless/email", "/auth/verify-email/resend"},
+			Key:    RateLimitByEmail,
+			Limit:  5,
+			Window: time.Hour,
+		},
+		{
+			Name:   "recovery-phone",
+			Routes: []string{"/auth/forgot-password/sms", "/auth/forgot-password/whatsapp", "/auth/passwordless/sms", "/auth/passwordless/whatsapp"},
+			Key:    RateLimitByPhone,
+			Limit:  3,
+			Window: time.Hour,
+		},
+		{
+			Name:   "reset-code-ip",
+			Routes: []string{"/auth/reset-password/verify-code", "/auth/reset-password"},
+			Key:    RateLimitByIP,
+			Limit:  10,
+			Window: 15 * time.Minute,
+		},
+		{
+			Name:   "api-user",
+			Key:    RateLimitByUser,
+			Limit:  300,
+			Window: time.Minute,
+		},
+	}
+}
+
+// RateLimitMiddleware limita as requisições conforme as políticas configuradas
+type RateLimitMiddleware struct {
+	Store    RateLimitStore
+	Policies []RateLimitPolicy
+}
+
+// NewRateLimitMiddleware cria uma nova instância do RateLimitMiddleware
+func NewRateLimitMiddleware(store RateLimitStore, policies []RateLimitPolicy) *RateLimitMiddleware {
+	return &RateLimitMiddleware{
+		Store:    store,
+		Policies: policies,
+	}
+}
+
+// Limit aplica as políticas que valem para a rota. Políticas por usuário só são aplicadas
+// depois da autenticação, então o middleware deve ser registrado também após RequireAuth e RequireAPIKey;
+// cada política é aplicada uma única vez por requisição.
+func (m *RateLimitMiddleware) Limit() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		route := ctx.FullPath()
+		if route == "" {
+			ctx.Next()
+			return
+		}
+
+		var applied map[string]bool
+		if value, ok := ctx.Get(rateLimitAppliedKey); ok {
+			applied = value.(map[string]bool)
+		} else {
+			applied = make(map[string]bool)
+			ctx.Set(rateLimitAppliedKey, applied)
+		}
+
+		now := time.Now()
+
+		var tightest *RateLimitResult
+		var tightestPolicy RateLimitPolicy
+		for _, policy := range m.Policies {
+			if applied[policy.Name] || !policy.matches(route) {
+				continue
+			}
+
+			value := m.keyValue(ctx, policy.Key)
+			if value == "" {
+				continue
+			}
+			applied[policy.Name] = true
+
+			result, err := m.Store.Take(rateLimitBucketKey(policy, value), policy, now)
+			if err != nil {
+				// Uma falha no armazenamento nao deve derrubar a autenticacao
+				log.Printf("Erro ao aplicar rate limit %s: %v", policy.Name, err)
+				continue
+			}
+
+			if !result.Allowed {
+				setRateLimitHeaders(ctx, policy, result)
+				retryAfter := int(math.Ceil(result.RetryAfter.Seconds()))
+				if retryAfter < 1 {
+					retryAfter = 1
+				}
+				ctx.Header(RetryAfterHeader, strconv.Itoa(retryAfter))
+				utils.SendErrorResponse(ctx, http.StatusTooManyRequests, "TOO_MANY_REQUESTS", "Muitas solicitações em um curto período", map[string]interface{}{
+					"retry_after": retryAfter,
+				})
+				ctx.Abort()
+				return
+			}
+
+			if tightest == nil || result.Remaining < tightest.Remaining {
+				tightest = &result
+				tightestPolicy = policy
+			}
+		}
+
+		// Informamos a politica mais proxima do limite
+		if tightest != nil {
+			setRateLimitHeaders(ctx, tightestPolicy, *tightest)
+		}
+
+		ctx.Next()
+	}
+}
+
+// keyValue obtém o valor limitado pela política; vazio quando a requisição não o informa
+func (m *RateLimitMiddleware) keyValue(ctx *gin.Context, key RateLimitKey) string {
+	switch key {
+	case RateLimitByIP:
+		return ctx.ClientIP()
+	case RateLimitByUser:
+		if apiKeyID := ctx.GetString("api_key_id"); apiKeyID != "" {
+			return "api_key:" + apiKeyID
+		}
+		return ctx.GetString("user_id")
+	case RateLimitByPhone:
+		return strings.TrimSpace(readRateLimitBody(ctx).Phone)
+	case RateLimitByEmail:
+		return strings.ToLower(strings.TrimSpace(readRateLimitBody(ctx).Email))
+	}
+	return ""
+}
+
+// rateLimitBody contém os campos do corpo usados como chave
+type rateLimitBody struct {
+	Email string `json:"email"`
+	Phone string `json:"phone"`
+}
+
+// readRateLimitBody lê o email e o telefone do corpo JSON, devolvendo o corpo intacto para o controlador
+func readRateLimitBody(ctx *gin.Context) rateLimitBody {
+	if cached, ok := ctx.Get(rateLimitBodyKey); ok {
+		return cached.(rateLimitBody)
+	}
+
+	var body rateLimitBody
+	if ctx.Request.Body != nil {
+		data, err := io.ReadAll(io.LimitReader(ctx.Request.Body, rateLimitMaxBodySize))
+		if err == nil {
+			json.Unmarshal(data, &body)
+		}
+		ctx.Request.Body = readCloser{io.MultiReader(bytes.NewReader(data), ctx.Request.Body), ctx.Request.Body}
+	}
+
+	ctx.Set(rateLimitBodyKey, body)
+	return body
+}
+
+// readCloser combina o corpo já lido com o restante, fechando o corpo original
+type readCloser struct {
+	io.Reader
+	io.Closer
+}
+
+// rateLimitBucketKey monta a chave do balde; o valor é guardado como hash, já que pode ser um email ou telefone
+func rateLimitBucketKey(policy RateLimitPolicy, value string) string {
+	sum := sha256.Sum256([]byte(value))
+	return policy.Name + ":" + hex.EncodeToString(sum[:16])
+}
+
+// setRateLimitHeaders informa ao cliente o limite, o saldo e o tempo até a renovação
+func setRateLimitHeaders(ctx *gin.Context, policy RateLimitPolicy, result RateLimitResult) {
+	ctx.Header(RateLimitLimitHeader, strconv.Itoa(result.Limit))
+	ctx.Header(RateLimitRemainingHeader, strconv.Itoa(result.Remaining))
+	ctx.Header(RateLimitResetHeader, strconv.Itoa(int(math.Ceil(result.Reset.Seconds()))))
+	ctx.Header(RateLimitPolicyHeader, strconv.Itoa(policy.Limit)+";w="+strconv.Itoa(int(policy.Window.Seconds())))
+}
@@ -0,0 +1,167 @@
+package middlewares
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// failingRateLimitStore simulates an unavailable store
+type failingRateLimitStore struct{}
+
+func (failingRateLimitStore) Take(key string, policy RateLimitPolicy, now time.Time) (RateLimitResult, error) {
+	return RateLimitResult{}, errors.New("store unavailable")
+}
+
+// newRateLimitRouter registers a login route limited by the middleware; the handler echoes the body it receives
+func newRateLimitRouter(m *RateLimitMiddleware, twice bool) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.Use(m.Limit())
+
+	handlers := []gin.HandlerFunc{}
+	if twice {
+		// As when the middleware runs again after RequireAuth
+		handlers = append(handlers, m.Limit())
+	}
+	handlers = append(handlers, func(ctx *gin.Context) {
+		body, _ := io.ReadAll(ctx.Request.Body)
+		ctx.String(http.StatusOK, string(body))
+	})
+	router.POST("/api/v1/client/auth/login", handlers...)
+
+	return router
+}
+
+// sendLogin sends a login request with the given email
+func sendLogin(router *gin.Engine, email string) *httptest.ResponseRecorder {
+	body := `{"email":"` + email + `","password":"secret"}`
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/client/auth/login", strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	return w
+}
+
+func TestRateLimitHeaders(t *testing.T) {
+	policy := RateLimitPolicy{Name: "login-ip", Routes: []string{"/auth/login"}, Key: RateLimitByIP, Limit: 2, Window: time.Minute}
+	router := newRateLimitRouter(NewRateLimitMiddleware(NewMemoryRateLimitStore(), []RateLimitPolicy{policy}), false)
+
+	tests := []struct {
+		name       string
+		status     int
+		remaining  string
+		reset      string
+		retryAfter string
+	}{
+		{"first request", http.StatusOK, "1", "30", ""},
+		{"last token", http.StatusOK, "0", "60", ""},
+		{"over the limit", http.StatusTooManyRequests, "0", "60", "30"},
+	}
+
+	for _, tt := range tests {
+		w := sendLogin(router, "client@example.com")
+
+		if w.Code != tt.status {
+			t.Fatalf("%s: status = %d, want %d", tt.name, w.Code, tt.status)
+		}
+		headers := map[string]string{
+			RateLimitLimitHeader:     "2",
+			RateLimitRemainingHeader: tt.remaining,
+			RateLimitResetHeader:     tt.reset,
+			RateLimitPolicyHeader:    "2;w=60",
+			RetryAfterHeader:         tt.retryAfter,
+		}
+		for header, want := range headers {
+			if got := w.Header().Get(header); got != want {
+				t.Errorf("%s: %s = %q, want %q", tt.name, header, got, want)
+			}
+		}
+	}
+}
+
+func TestRateLimitReportsTightestPolicy(t *testing.T) {
+	policies := []RateLimitPolicy{
+		{Name: "login-ip", Routes: []string{"/auth/login"}, Key: RateLimitByIP, Limit: 30, Window: 5 * time.Minute},
+		{Name: "login-email", Routes: []string{"/auth/login"}, Key: RateLimitByEmail, Limit: 10, Window: 15 * time.Minute},
+		{Name: "register-ip", Routes: []string{"/auth/register"}, Key: RateLimitByIP, Limit: 1, Window: time.Hour},
+	}
+	router := newRateLimitRouter(NewRateLimitMiddleware(NewMemoryRateLimitStore(), policies), false)
+
+	w := sendLogin(router, "client@example.com")
+	if w.Code != http.StatusOK {
+		t.Fatalf("status = %d", w.Code)
+	}
+	if got := w.Header().Get(RateLimitPolicyHeader); got != "10;w=900" {
+		t.Fatalf("%s = %q, want the email policy", RateLimitPolicyHeader, got)
+	}
+	if got := w.Header().Get(RateLimitRemainingHeader); got != "9" {
+		t.Fatalf("%s = %q, want 9", RateLimitRemainingHeader, got)
+	}
+
+	// The body read for the email key still reaches the handler
+	if !strings.Contains(w.Body.String(), `"email":"client@example.com"`) {
+		t.Fatalf("handler received %q", w.Body.String())
+	}
+
+	// The email is normalized, so changing its case does not give a new bucket
+	for i := 0; i < 9; i++ {
+		sendLogin(router, "Client@Example.com ")
+	}
+	if w := sendLogin(router, "CLIENT@example.com"); w.Code != http.StatusTooManyRequests {
+		t.Fatalf("status = %d, want 429", w.Code)
+	}
+	if w := sendLogin(router, "other@example.com"); w.Code != http.StatusOK {
+		t.Fatalf("another email: status = %d, want 200", w.Code)
+	}
+}
+
+func TestRateLimitAppliesEachPolicyOncePerRequest(t *testing.T) {
+	policy := RateLimitPolicy{Name: "login-ip", Routes: []string{"/auth/login"}, Key: RateLimitByIP, Limit: 2, Window: time.Minute}
+
+	tests := []struct {
+		name    string
+		twice   bool
+		allowed int
+	}{
+		{"registered once", false, 2},
+		{"registered twice", true, 2},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			router := newRateLimitRouter(NewRateLimitMiddleware(NewMemoryRateLimitStore(), []RateLimitPolicy{policy}), tt.twice)
+
+			allowed := 0
+			for i := 0; i < 5; i++ {
+				if sendLogin(router, "client@example.com").Code == http.StatusOK {
+					allowed++
+				}
+			}
+			if allowed != tt.allowed {
+				t.Fatalf("allowed %d requests, want %d", allowed, tt.allowed)
+			}
+		})
+	}
+}
+
+func TestRateLimitStoreFailureLetsRequestsThrough(t *testing.T) {
+	policy := RateLimitPolicy{Name: "login-ip", Routes: []string{"/auth/login"}, Key: RateLimitByIP, Limit: 1, Window: time.Minute}
+	router := newRateLimitRouter(NewRateLimitMiddleware(failingRateLimitStore{}, []RateLimitPolicy{policy}), false)
+
+	for i := 0; i < 3; i++ {
+		w := sendLogin(router, "client@example.com")
+		if w.Code != http.StatusOK {
+			t.Fatalf("status = %d, want 200", w.Code)
+		}
+		if got := w.Header().Get(RateLimitLimitHeader); got != "" {
+			t.Fatalf("%s = %q, want no header", RateLimitLimitHeader, got)
+		}
+	}
+}
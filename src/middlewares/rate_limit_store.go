@@ -0,0 +1,140 @@
+package middlewares
+
+import (
+	"log"
+	"math"
+	"sync"
+	"time"
+
+	"github.com/Barba2k2/aurora_backend/src/models"
+	"github.com/Barba2k2/aurora_backend/src/repositories"
+)
+
+// rateLimitSweepInterval é o intervalo entre as limpezas dos baldes já cheios no armazenamento em memória
+const rateLimitSweepInterval = time.Minute
+
+// RateLimitResult é o resultado da consulta a um balde
+type RateLimitResult struct {
+	Allowed   bool
+	Limit     int
+	Remaining int
+	// Reset é o tempo até o balde encher novamente
+	Reset time.Duration
+	// RetryAfter é o tempo até a próxima requisição ser aceita, quando a atual foi recusada
+	RetryAfter time.Duration
+}
+
+// RateLimitStore guarda os baldes de tokens das chaves limitadas
+type RateLimitStore interface {
+	Take(key string, policy RateLimitPolicy, now time.Time) (RateLimitResult, error)
+}
+
+// takeToken repõe os tokens do balde pelo tempo decorrido e consome um, se houver.
+// O balde comporta Limit tokens e se enche por completo em Window, o que permite rajadas curtas
+// sem ultrapassar a média da política.
+func takeToken(bucket *models.RateLimitBucket, policy RateLimitPolicy, now time.Time) RateLimitResult {
+	capacity := float64(policy.Limit)
+	rate := capacity / policy.Window.Seconds()
+
+	// Um balde novo comeca cheio
+	tokens := capacity
+	if !bucket.UpdatedAt.IsZero() {
+		elapsed := math.Max(0, now.Sub(bucket.UpdatedAt).Seconds())
+		tokens = math.Min(capacity, bucket.Tokens+elapsed*rate)
+	}
+
+	result := RateLimitResult{Limit: policy.Limit}
+	if tokens >= 1 {
+		tokens--
+		result.Allowed = true
+	} else {
+		result.RetryAfter = secondsToDuration((1 - tokens) / rate)
+	}
+
+	result.Remaining = int(math.Floor(tokens))
+	result.Reset = secondsToDuration((capacity - tokens) / rate)
+
+	bucket.Tokens = tokens
+	bucket.UpdatedAt = now
+	bucket.ExpiresAt = now.Add(result.Reset)
+
+	return result
+}
+
+// secondsToDuration converte segundos fracionários em time.Duration
+func secondsToDuration(seconds float64) time.Duration {
+	return time.Duration(seconds * float64(time.Second))
+}
+
+// MemoryRateLimitStore guarda os baldes na memória do processo; serve a uma única instância da API
+type MemoryRateLimitStore struct {
+	mu        sync.Mutex
+	buckets   map[string]*models.RateLimitBucket
+	lastSweep time.Time
+}
+
+// NewMemoryRateLimitStore cria uma nova instância de MemoryRateLimitStore
+func NewMemoryRateLimitStore() *MemoryRateLimitStore {
+	return &MemoryRateLimitStore{
+		buckets: make(map[string]*models.RateLimitBucket),
+	}
+}
+
+// Take consome um token do balde da chave
+func (s *MemoryRateLimitStore) Take(key string, policy RateLimitPolicy, now time.Time) (RateLimitResult, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	// Baldes cheios equivalem a baldes inexistentes, entao podem ser descartados
+	if now.Sub(s.lastSweep) > rateLimitSweepInterval {
+		for k, bucket := range s.buckets {
+			if now.After(bucket.ExpiresAt) {
+				delete(s.buckets, k)
+			}
+		}
+		s.lastSweep = now
+	}
+
+	bucket, ok := s.buckets[key]
+	if !ok {
+		bucket = &models.RateLimitBucket{Key: key}
+		s.buckets[key] = bucket
+	}
+
+	return takeToken(bucket, policy, now), nil
+}
+
+// PostgresRateLimitStore guarda os baldes no banco, compartilhando os limites entre as instâncias da API
+type PostgresRateLimitStore struct {
+	Repo repositories.RateLimitRepositoryInterface
+}
+
+// NewPostgresRateLimitStore cria uma nova instância de PostgresRateLimitStore
+func NewPostgresRateLimitStore(repo repositories.RateLimitRepositoryInterface) *PostgresRateLimitStore {
+	return &PostgresRateLimitStore{Repo: repo}
+}
+
+// Take consome um token do balde da chave, com o registro bloqueado durante a atualização
+func (s *PostgresRateLimitStore) Take(key string, policy RateLimitPolicy, now time.Time) (RateLimitResult, error) {
+	var result RateLimitResult
+
+	err := s.Repo.Update(key, func(bucket *models.RateLimitBucket) {
+		result = takeToken(bucket, policy, now)
+	})
+
+	return result, err
+}
+
+// StartCleanup remove periodicamente os baldes que já encheram novamente
+func (s *PostgresRateLimitStore) StartCleanup(interval time.Duration) {
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for range ticker.C {
+			if err := s.Repo.DeleteExpired(time.Now()); err != nil {
+				log.Printf("Erro ao remover baldes de rate limit expirados: %v", err)
+			}
+		}
+	}()
+}
@@ -0,0 +1,81 @@
+package middlewares
+
+import (
+	"testing"
+	"time"
+)
+
+func TestMemoryRateLimitStoreTake(t *testing.T) {
+	// One token per second, up to ten
+	policy := RateLimitPolicy{Name: "test", Limit: 10, Window: 10 * time.Second}
+	start := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	type step struct {
+		name       string
+		at         time.Duration
+		allowed    bool
+		remaining  int
+		reset      time.Duration
+		retryAfter time.Duration
+	}
+	steps := []step{
+		{name: "new bucket starts full", at: 0, allowed: true, remaining: 9, reset: time.Second},
+	}
+	for remaining := 8; remaining >= 0; remaining-- {
+		steps = append(steps, step{name: "burst", at: 0, allowed: true, remaining: remaining, reset: time.Duration(10-remaining) * time.Second})
+	}
+	steps = append(steps,
+		step{name: "empty bucket", at: 0, allowed: false, remaining: 0, reset: 10 * time.Second, retryAfter: time.Second},
+		step{name: "half a token", at: 500 * time.Millisecond, allowed: false, remaining: 0, reset: 9500 * time.Millisecond, retryAfter: 500 * time.Millisecond},
+		step{name: "refilled one token", at: time.Second, allowed: true, remaining: 0, reset: 10 * time.Second},
+		step{name: "clock going backwards adds nothing", at: 0, allowed: false, remaining: 0, reset: 10 * time.Second, retryAfter: time.Second},
+		step{name: "refill is capped at the limit", at: time.Hour, allowed: true, remaining: 9, reset: time.Second},
+	)
+
+	store := NewMemoryRateLimitStore()
+	for _, s := range steps {
+		result, err := store.Take("key", policy, start.Add(s.at))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		want := RateLimitResult{Allowed: s.allowed, Limit: 10, Remaining: s.remaining, Reset: s.reset, RetryAfter: s.retryAfter}
+		if result != want {
+			t.Fatalf("%s: got %+v, want %+v", s.name, result, want)
+		}
+	}
+}
+
+func TestMemoryRateLimitStoreKeysAreIndependent(t *testing.T) {
+	policy := RateLimitPolicy{Name: "test", Limit: 1, Window: time.Minute}
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	store := NewMemoryRateLimitStore()
+
+	if result, _ := store.Take("a", policy, now); !result.Allowed {
+		t.Fatal("first request of a denied")
+	}
+	if result, _ := store.Take("a", policy, now); result.Allowed {
+		t.Fatal("second request of a allowed")
+	}
+	if result, _ := store.Take("b", policy, now); !result.Allowed {
+		t.Fatal("first request of b denied")
+	}
+}
+
+func TestMemoryRateLimitStoreSweepsFullBuckets(t *testing.T) {
+	policy := RateLimitPolicy{Name: "test", Limit: 2, Window: time.Minute}
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	store := NewMemoryRateLimitStore()
+
+	store.Take("a", policy, now)
+	store.Take("a", policy, now)
+	store.Take("b", policy, now.Add(2*rateLimitSweepInterval))
+
+	// a refilled long before the sweep; b is still being refilled
+	if _, ok := store.buckets["a"]; ok {
+		t.Fatal("full bucket was not swept")
+	}
+	if _, ok := store.buckets["b"]; !ok {
+		t.Fatal("bucket in use was swept")
+	}
+}
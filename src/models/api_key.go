@@ -0,0 +1,41 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+)
+
+// APIKey lets an establishment's own backend call the API without a user login.
+// The key shown to the owner is "<prefix>.<secret>"; only the prefix and a hash of the secret are stored.
+type APIKey struct {
+	ID              uuid.UUID `json:"id" gorm:"type:uuid;primary_key;default:gen_random_uuid()"`
+	EstablishmentID uuid.UUID `json:"-" gorm:"type:uuid;not null;index"`
+	CreatedBy       uuid.UUID `json:"created_by" gorm:"type:uuid;not null"`
+	Name            string    `json:"name" gorm:"type:varchar(100);not null"`
+	// Prefix identifies the key in lookups and listings
+	Prefix     string `json:"prefix" gorm:"type:varchar(32);not null;unique_index"`
+	SecretHash string `json:"-" gorm:"type:varchar(64);not null"`
+	// Scopes are the permissions granted to requests made with the key
+	Scopes     pq.StringArray `json:"scopes" gorm:"type:text[]"`
+	ExpiresAt  *time.Time     `json:"expires_at,omitempty"`
+	LastUsedAt *time.Time     `json:"last_used_at,omitempty"`
+	LastUsedIP string         `json:"last_used_ip,omitempty" gorm:"type:varchar(45)"`
+	RevokedAt  *time.Time     `json:"revoked_at,omitempty"`
+	// Audit fields
+	CreatedAt time.Time `json:"created_at" gorm:"not null"`
+	UpdatedAt time.Time `json:"updated_at" gorm:"not null"`
+}
+
+func (APIKey) TableName() string {
+	return "api_keys"
+}
+
+// IsActive reports whether the key was not revoked and has not expired
+func (k *APIKey) IsActive() bool {
+	if k.RevokedAt != nil {
+		return false
+	}
+	return k.ExpiresAt == nil || time.Now().Before(*k.ExpiresAt)
+}
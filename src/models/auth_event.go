@@ -0,0 +1,76 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// AuthEventType identifies what happened in an authentication event
+type AuthEventType string
+
+const (
+	AuthEventLoginSucceeded         AuthEventType = "LOGIN_SUCCEEDED"
+	AuthEventLoginFailed            AuthEventType = "LOGIN_FAILED"
+	AuthEventAccountLocked          AuthEventType = "ACCOUNT_LOCKED"
+	AuthEventAccountUnlocked        AuthEventType = "ACCOUNT_UNLOCKED"
+	AuthEventPasswordResetRequested AuthEventType = "PASSWORD_RESET_REQUESTED"
+	AuthEventPasswordResetCompleted AuthEventType = "PASSWORD_RESET_COMPLETED"
+	AuthEventPasswordChanged        AuthEventType = "PASSWORD_CHANGED"
+	AuthEventEmailChanged           AuthEventType = "EMAIL_CHANGED"
+	AuthEventTokenRefreshed         AuthEventType = "TOKEN_REFRESHED"
+	AuthEventRefreshTokenReused     AuthEventType = "REFRESH_TOKEN_REUSED"
+	AuthEventRoleChanged            AuthEventType = "ROLE_CHANGED"
+	AuthEventNewDeviceLogin         AuthEventType = "NEW_DEVICE_LOGIN"
+	AuthEventAccountSecured         AuthEventType = "ACCOUNT_SECURED"
+	AuthEventMemberAdded            AuthEventType = "MEMBER_ADDED"
+	AuthEventMemberRemoved          AuthEventType = "MEMBER_REMOVED"
+	AuthEventImpersonationStarted   AuthEventType = "IMPERSONATION_STARTED"
+	AuthEventImpersonationStopped   AuthEventType = "IMPERSONATION_STOPPED"
+	AuthEventAPIKeyCreated          AuthEventType = "API_KEY_CREATED"
+	AuthEventAPIKeyRotated          AuthEventType = "API_KEY_ROTATED"
+	AuthEventAPIKeyRevoked          AuthEventType = "API_KEY_REVOKED"
+	AuthEventDataExported           AuthEventType = "DATA_EXPORTED"
+	AuthEventErasureRequested       AuthEventType = "ERASURE_REQUESTED"
+	AuthEventAccountErased          AuthEventType = "ACCOUNT_ERASED"
+	AuthEventAccountDeleted         AuthEventType = "ACCOUNT_DELETED"
+	AuthEventAccountRestored        AuthEventType = "ACCOUNT_RESTORED"
+)
+
+// IsValid reports whether the event type is known
+func (t AuthEventType) IsValid() bool {
+	switch t {
+	case AuthEventLoginSucceeded, AuthEventLoginFailed, AuthEventAccountLocked, AuthEventAccountUnlocked,
+		AuthEventPasswordResetRequested, AuthEventPasswordResetCompleted, AuthEventPasswordChanged,
+		AuthEventEmailChanged, AuthEventTokenRefreshed, AuthEventRefreshTokenReused, AuthEventRoleChanged,
+		AuthEventNewDeviceLogin, AuthEventAccountSecured, AuthEventMemberAdded, AuthEventMemberRemoved,
+		AuthEventImpersonationStarted, AuthEventImpersonationStopped, AuthEventAPIKeyCreated, AuthEventAPIKeyRotated,
+		AuthEventAPIKeyRevoked, AuthEventDataExported, AuthEventErasureRequested, AuthEventAccountErased,
+		AuthEventAccountDeleted, AuthEventAccountRestored:
+		return true
+	}
+	return false
+}
+
+// AuthEvent is an append-only record of a security relevant authentication event.
+// Events are never updated or deleted by the application.
+type AuthEvent struct {
+	ID   uuid.UUID     `json:"id" gorm:"type:uuid;primary_key;default:gen_random_uuid()"`
+	Type AuthEventType `json:"type" gorm:"type:varchar(40);not null;index"`
+	// UserID is the account the event refers to; it is empty when a login names an unknown email
+	UserID *uuid.UUID `json:"user_id,omitempty" gorm:"type:uuid;index"`
+	// ActorID is who performed the action when it was not the user, such as an administrator
+	ActorID *uuid.UUID `json:"actor_id,omitempty" gorm:"type:uuid"`
+	// Email is the identifier presented by the client, kept for failed logins of unknown accounts
+	Email string `json:"email,omitempty" gorm:"type:varchar(255)"`
+	// Details holds event specific information, such as the failure reason or the new role
+	Details   string `json:"details,omitempty" gorm:"type:text"`
+	IPAddress string `json:"ip_address,omitempty" gorm:"type:varchar(45)"`
+	UserAgent string `json:"user_agent,omitempty" gorm:"type:text"`
+	// Audit fields
+	CreatedAt time.Time `json:"created_at" gorm:"not null;index"`
+}
+
+func (AuthEvent) TableName() string {
+	return "auth_events"
+}
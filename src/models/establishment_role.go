@@ -0,0 +1,41 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+)
+
+// EstablishmentRole is a named bundle of permissions defined by an establishment for its members
+type EstablishmentRole struct {
+	ID              uuid.UUID      `json:"id" gorm:"type:uuid;primary_key;default:gen_random_uuid()"`
+	EstablishmentID uuid.UUID      `json:"-" gorm:"type:uuid;not null;unique_index:idx_establishment_roles_name"`
+	Name            string         `json:"name" gorm:"type:varchar(50);not null;unique_index:idx_establishment_roles_name"`
+	Description     string         `json:"description,omitempty" gorm:"type:varchar(255)"`
+	Permissions     pq.StringArray `json:"permissions" gorm:"type:text[]"`
+	// Audit fields
+	CreatedAt time.Time `json:"created_at" gorm:"not null"`
+	UpdatedAt time.Time `json:"updated_at" gorm:"not null"`
+}
+
+func (EstablishmentRole) TableName() string {
+	return "establishment_roles"
+}
+
+// EstablishmentMember links a staff user to an establishment.
+// The owner of the establishment is not a member: it is the establishment's UserID.
+type EstablishmentMember struct {
+	ID              uuid.UUID `json:"id" gorm:"type:uuid;primary_key;default:gen_random_uuid()"`
+	EstablishmentID uuid.UUID `json:"establishment_id" gorm:"type:uuid;not null;unique_index:idx_establishment_members_user"`
+	UserID          uuid.UUID `json:"user_id" gorm:"type:uuid;not null;unique_index:idx_establishment_members_user"`
+	// RoleID is the custom role of the member; without one the default staff permissions apply
+	RoleID *uuid.UUID `json:"role_id,omitempty" gorm:"type:uuid;index"`
+	// Audit fields
+	CreatedAt time.Time `json:"created_at" gorm:"not null"`
+	UpdatedAt time.Time `json:"updated_at" gorm:"not null"`
+}
+
+func (EstablishmentMember) TableName() string {
+	return "establishment_members"
+}
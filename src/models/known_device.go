@@ -0,0 +1,26 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// KnownDevice is a device/IP combination a user has already signed in from.
+// A login from a combination that is not known triggers a new-device alert.
+type KnownDevice struct {
+	ID     uuid.UUID `json:"id" gorm:"type:uuid;primary_key;default:gen_random_uuid()"`
+	UserID uuid.UUID `json:"-" gorm:"type:uuid;not null;unique_index:idx_known_devices_user_fingerprint"`
+	// Fingerprint is the hash of the user agent and IP address
+	Fingerprint string    `json:"-" gorm:"type:varchar(64);not null;unique_index:idx_known_devices_user_fingerprint"`
+	DeviceName  string    `json:"device_name,omitempty" gorm:"type:varchar(255)"`
+	IPAddress   string    `json:"ip_address" gorm:"type:varchar(45)"`
+	UserAgent   string    `json:"user_agent" gorm:"type:text"`
+	LastSeenAt  time.Time `json:"last_seen_at" gorm:"not null"`
+	// Audit fields
+	CreatedAt time.Time `json:"created_at" gorm:"not null"`
+}
+
+func (KnownDevice) TableName() string {
+	return "known_devices"
+}
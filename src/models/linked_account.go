@@ -0,0 +1,30 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Supported identity providers for social login
+const (
+	IdentityProviderGoogle = "google"
+	IdentityProviderApple  = "apple"
+)
+
+// LinkedAccount links an identity at an external OIDC provider to a user.
+// The subject is the stable user identifier issued by the provider.
+type LinkedAccount struct {
+	ID       uuid.UUID `json:"id" gorm:"type:uuid;primary_key;default:gen_random_uuid()"`
+	UserID   uuid.UUID `json:"-" gorm:"type:uuid;not null;index"`
+	Provider string    `json:"provider" gorm:"type:varchar(20);not null;unique_index:idx_linked_accounts_provider_subject"`
+	Subject  string    `json:"-" gorm:"type:varchar(255);not null;unique_index:idx_linked_accounts_provider_subject"`
+	Email    string    `json:"email,omitempty" gorm:"type:varchar(255)"`
+	// Audit fields
+	CreatedAt time.Time `json:"created_at" gorm:"not null"`
+	UpdatedAt time.Time `json:"updated_at" gorm:"not null"`
+}
+
+func (LinkedAccount) TableName() string {
+	return "linked_accounts"
+}
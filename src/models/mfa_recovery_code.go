@@ -0,0 +1,23 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// MFARecoveryCode is a one-time code that replaces the TOTP code when the
+// authenticator is unavailable. Only the keyed hash of the code is stored, so it can be looked up directly.
+type MFARecoveryCode struct {
+	ID       uuid.UUID  `json:"id" gorm:"type:uuid;primary_key;default:gen_random_uuid()"`
+	UserID   uuid.UUID  `json:"-" gorm:"type:uuid;not null;index"`
+	CodeHash string     `json:"-" gorm:"type:varchar(255);not null;index"`
+	UsedAt   *time.Time `json:"used_at,omitempty"`
+	// Audit fields
+	CreatedAt time.Time `json:"created_at" gorm:"not null"`
+	UpdatedAt time.Time `json:"updated_at" gorm:"not null"`
+}
+
+func (MFARecoveryCode) TableName() string {
+	return "mfa_recovery_codes"
+}
@@ -0,0 +1,21 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// PasswordHistory keeps the hash of a password previously used by a user,
+// so the password policy can prevent it from being reused.
+type PasswordHistory struct {
+	ID           uuid.UUID `json:"id" gorm:"type:uuid;primary_key;default:gen_random_uuid()"`
+	UserID       uuid.UUID `json:"-" gorm:"type:uuid;not null;index"`
+	PasswordHash string    `json:"-" gorm:"type:varchar(255);not null"`
+	// Audit fields
+	CreatedAt time.Time `json:"created_at" gorm:"not null"`
+}
+
+func (PasswordHistory) TableName() string {
+	return "password_histories"
+}
@@ -22,12 +22,29 @@ const (
 	TokenStatusRevoked TokenStatus = "REVOKED"
 )
 
+// TokenPurpose identifies what a one-time token can be used for
+type TokenPurpose string
+
+const (
+	TokenPurposePasswordReset     TokenPurpose = "PASSWORD_RESET"
+	TokenPurposeEmailVerification TokenPurpose = "EMAIL_VERIFICATION"
+	TokenPurposePhoneVerification TokenPurpose = "PHONE_VERIFICATION"
+	TokenPurposeLogin             TokenPurpose = "LOGIN"
+	TokenPurposeAccountUnlock     TokenPurpose = "ACCOUNT_UNLOCK"
+	TokenPurposeEmailChange       TokenPurpose = "EMAIL_CHANGE"
+	TokenPurposeSecureAccount     TokenPurpose = "SECURE_ACCOUNT"
+)
+
+// MaxTokenFailedAttempts is the number of wrong codes after which a token is revoked
+const MaxTokenFailedAttempts = 5
+
 type PasswordResetToken struct {
 	ID             uuid.UUID    `json:"id" gorm:"type:uuid;primary_key;default:gen_random_uuid()"`
 	UserID         uuid.UUID    `json:"-" gorm:"type:uuid;index"`
 	User           User         `json:"-" gorm:"foreignKey:UserID"`
-	Token          string       `json:"-" gorm:"type:varchar(255);not null;unique_index"`
+	TokenHash      string       `json:"-" gorm:"type:varchar(64);not null;index"`
 	Channel        TokenChannel `json:"channel" gorm:"type:varchar(20);not null"`
+	Purpose        TokenPurpose `json:"purpose" gorm:"type:varchar(30);not null;default:'PASSWORD_RESET';index"`
 	Status         TokenStatus  `json:"status" gorm:"type:varchar(20);not null;default:'ACTIVE'"`
 	ExpiresAt      time.Time    `json:"expires_at" gorm:"not null"`
 	UsedAt         *time.Time   `json:"used_at,omitempty"`
@@ -63,7 +80,7 @@ func (t *PasswordResetToken) IncrementFailedAttempts() {
 	t.FailedAttempts++
 	t.UpdatedAt = time.Now()
 
-	if t.FailedAttempts >= 5 {
+	if t.FailedAttempts >= MaxTokenFailedAttempts {
 		t.Status = TokenStatusRevoked
 	}
 }
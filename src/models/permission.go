@@ -0,0 +1,50 @@
+package models
+
+// Permission is a named action on a resource, in the "resource:action" format
+type Permission string
+
+const (
+	PermissionAppointmentsRead    Permission = "appointments:read"
+	PermissionAppointmentsWrite   Permission = "appointments:write"
+	PermissionClientsRead         Permission = "clients:read"
+	PermissionClientsWrite        Permission = "clients:write"
+	PermissionServicesManage      Permission = "services:manage"
+	PermissionScheduleManage      Permission = "schedule:manage"
+	PermissionReportsRead         Permission = "reports:read"
+	PermissionFinanceManage       Permission = "finance:manage"
+	PermissionStaffManage         Permission = "staff:manage"
+	PermissionRolesManage         Permission = "roles:manage"
+	PermissionEstablishmentManage Permission = "establishment:manage"
+)
+
+// AllPermissions lists every permission known to the system
+var AllPermissions = []Permission{
+	PermissionAppointmentsRead,
+	PermissionAppointmentsWrite,
+	PermissionClientsRead,
+	PermissionClientsWrite,
+	PermissionServicesManage,
+	PermissionScheduleManage,
+	PermissionReportsRead,
+	PermissionFinanceManage,
+	PermissionStaffManage,
+	PermissionRolesManage,
+	PermissionEstablishmentManage,
+}
+
+// DefaultStaffPermissions is the bundle of a staff member without a custom establishment role
+var DefaultStaffPermissions = []Permission{
+	PermissionAppointmentsRead,
+	PermissionAppointmentsWrite,
+	PermissionClientsRead,
+}
+
+// IsValid reports whether the permission is known
+func (p Permission) IsValid() bool {
+	for _, permission := range AllPermissions {
+		if p == permission {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,20 @@
+package models
+
+import (
+	"time"
+)
+
+// RateLimitBucket is the token bucket of a rate limit key, shared by every instance of the API
+type RateLimitBucket struct {
+	// Key identifies the policy and the limited IP, user, phone or email (hashed)
+	Key string `gorm:"type:varchar(128);primary_key"`
+	// Tokens left in the bucket at UpdatedAt
+	Tokens    float64   `gorm:"not null"`
+	UpdatedAt time.Time `gorm:"not null"`
+	// ExpiresAt is when the bucket will be full again, after which the row can be deleted
+	ExpiresAt time.Time `gorm:"not null;index"`
+}
+
+func (RateLimitBucket) TableName() string {
+	return "rate_limit_buckets"
+}
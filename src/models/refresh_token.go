@@ -0,0 +1,40 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// RefreshToken is the server-side record of an issued refresh token.
+// Only the hash of the token is stored; every rotation creates a new record
+// in the same family so that replaying an already rotated token can revoke
+// the whole chain.
+type RefreshToken struct {
+	ID           uuid.UUID   `json:"id" gorm:"type:uuid;primary_key;default:gen_random_uuid()"`
+	UserID       uuid.UUID   `json:"-" gorm:"type:uuid;not null;index"`
+	FamilyID     uuid.UUID   `json:"-" gorm:"type:uuid;not null;index"`
+	TokenHash    string      `json:"-" gorm:"type:varchar(64);not null;unique_index"`
+	Status       TokenStatus `json:"status" gorm:"type:varchar(20);not null;default:'ACTIVE'"`
+	ExpiresAt    time.Time   `json:"expires_at" gorm:"not null"`
+	RotatedAt    *time.Time  `json:"rotated_at,omitempty"`
+	ReplacedByID *uuid.UUID  `json:"-" gorm:"type:uuid"`
+	IPAddress    string      `json:"-" gorm:"type:varchar(45)"`
+	UserAgent    string      `json:"-" gorm:"type:text"`
+	// Audit fields
+	CreatedAt time.Time `json:"created_at" gorm:"not null"`
+	UpdatedAt time.Time `json:"updated_at" gorm:"not null"`
+}
+
+func (RefreshToken) TableName() string {
+	return "refresh_tokens"
+}
+
+func (t *RefreshToken) IsValid() bool {
+	return t.Status == TokenStatusActive && time.Now().Before(t.ExpiresAt)
+}
+
+// IsRotated reports whether the token has already been exchanged for a new one
+func (t *RefreshToken) IsRotated() bool {
+	return t.Status == TokenStatusUsed
+}
@@ -0,0 +1,35 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Session represents a signed-in device. Its ID is also the family ID of the
+// refresh tokens issued for it and is carried in the access token claims.
+type Session struct {
+	ID         uuid.UUID `json:"id" gorm:"type:uuid;primary_key;default:gen_random_uuid()"`
+	UserID     uuid.UUID `json:"-" gorm:"type:uuid;not null;index"`
+	DeviceName string    `json:"device_name,omitempty" gorm:"type:varchar(255)"`
+	IPAddress  string    `json:"ip_address" gorm:"type:varchar(45)"`
+	UserAgent  string    `json:"user_agent" gorm:"type:text"`
+	// EstablishmentID is the establishment a staff member is acting on in this session
+	EstablishmentID *uuid.UUID `json:"establishment_id,omitempty" gorm:"type:uuid"`
+	// ImpersonatorID is the administrator using the session to act as the user
+	ImpersonatorID *uuid.UUID `json:"impersonator_id,omitempty" gorm:"type:uuid;index"`
+	LastSeenAt     time.Time  `json:"last_seen_at" gorm:"not null"`
+	ExpiresAt      time.Time  `json:"expires_at" gorm:"not null"`
+	RevokedAt      *time.Time `json:"-"`
+	// Audit fields
+	CreatedAt time.Time `json:"created_at" gorm:"not null"`
+	UpdatedAt time.Time `json:"updated_at" gorm:"not null"`
+}
+
+func (Session) TableName() string {
+	return "sessions"
+}
+
+func (s *Session) IsActive() bool {
+	return s.RevokedAt == nil && time.Now().Before(s.ExpiresAt)
+}
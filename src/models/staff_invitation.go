@@ -0,0 +1,45 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type InvitationStatus string
+
+const (
+	InvitationStatusPending  InvitationStatus = "PENDING"
+	InvitationStatusAccepted InvitationStatus = "ACCEPTED"
+	InvitationStatusRevoked  InvitationStatus = "REVOKED"
+)
+
+// StaffInvitation is an invitation for someone to join an establishment's staff.
+// It is sent by email or phone and accepted through a one-time link.
+type StaffInvitation struct {
+	ID              uuid.UUID `json:"id" gorm:"type:uuid;primary_key;default:gen_random_uuid()"`
+	EstablishmentID uuid.UUID `json:"establishment_id" gorm:"type:uuid;not null;index"`
+	InvitedBy       uuid.UUID `json:"invited_by" gorm:"type:uuid;not null"`
+	Name            string    `json:"name,omitempty" gorm:"type:varchar(255)"`
+	Email           string    `json:"email,omitempty" gorm:"type:varchar(255);index"`
+	Phone           string    `json:"phone,omitempty" gorm:"type:varchar(20);index"`
+	// RoleID is the establishment role the member receives when accepting
+	RoleID     *uuid.UUID       `json:"role_id,omitempty" gorm:"type:uuid"`
+	TokenHash  string           `json:"-" gorm:"type:varchar(64);not null;unique_index"`
+	Status     InvitationStatus `json:"status" gorm:"type:varchar(20);not null;default:'PENDING'"`
+	ExpiresAt  time.Time        `json:"expires_at" gorm:"not null"`
+	AcceptedAt *time.Time       `json:"accepted_at,omitempty"`
+	AcceptedBy *uuid.UUID       `json:"accepted_by,omitempty" gorm:"type:uuid"`
+	// Audit fields
+	CreatedAt time.Time `json:"created_at" gorm:"not null"`
+	UpdatedAt time.Time `json:"updated_at" gorm:"not null"`
+}
+
+func (StaffInvitation) TableName() string {
+	return "staff_invitations"
+}
+
+// IsValid reports whether the invitation can still be accepted
+func (i *StaffInvitation) IsValid() bool {
+	return i.Status == InvitationStatusPending && time.Now().Before(i.ExpiresAt)
+}
@@ -37,7 +37,25 @@ type User struct {
 	ProfileImageURL   string         `json:"profile_image_url,omitempty" gorm:"type:varchar(255)"`
 	PushSubscriptions pq.StringArray `json:"-" gorm:"type:text[]"`
 	FailedLoginCount  int            `json:"-" gorm:"type:int;dafult:0"`
+	LockedUntil       *time.Time     `json:"locked_until,omitempty"`
+	LockoutCount      int            `json:"-" gorm:"type:int;default:0"`
 	LastLoginAt       *time.Time     `json:"last_login_at,omitempty"`
+	EmailVerifiedAt   *time.Time     `json:"email_verified_at,omitempty"`
+	PhoneVerifiedAt   *time.Time     `json:"phone_verified_at,omitempty"`
+	TOTPSecret        string         `json:"-" gorm:"type:varchar(64)"`
+	TOTPEnabled       bool           `json:"mfa_enabled" gorm:"not null;default:false"`
+	TOTPConfirmedAt   *time.Time     `json:"-"`
+	TOTPLastCounter   int64          `json:"-" gorm:"type:bigint;default:0"`
+	// PreferredChannel is where security alerts are sent: EMAIL or WHATSAPP
+	PreferredChannel TokenChannel `json:"preferred_channel" gorm:"type:varchar(20);not null;default:'EMAIL'"`
+	// PasswordResetRequired blocks sign-in until the password is reset, after the user reports a login that was not theirs
+	PasswordResetRequired bool `json:"-" gorm:"not null;default:false"`
+	// ErasureScheduledAt is when the personal data of a deleted account will be anonymized (LGPD)
+	ErasureScheduledAt *time.Time `json:"-" gorm:"index"`
+	// AnonymizedAt is when the personal data was erased; the row is kept for financial records
+	AnonymizedAt *time.Time `json:"-"`
+	// StatusBeforeDeletion is the status given back when a deleted account is restored
+	StatusBeforeDeletion UserStatus `json:"-" gorm:"type:varchar(20)"`
 
 	CreatedAt time.Time  `json:"created_at" gorm:"not null"`
 	UpdatedAt time.Time  `json:"updated_at" gorm:"not null"`
@@ -63,6 +81,8 @@ type Establishment struct {
 	WebsiteURL     string     `json:"website_url,omitempty" gorm:"type:varchar(255)"`
 	Timezone       string     `json:"timezone" gorm:"type:varchar(50);not null;default:'UTC'"`
 	Status         UserStatus `json:"status" gorm:"type:varchar(20);not null;default:'ACTIVE'"`
+	// StatusBeforeDeletion is the status given back when the establishment is restored with its owner
+	StatusBeforeDeletion UserStatus `json:"-" gorm:"type:varchar(20)"`
 
 	CreatedAt time.Time  `json:"created_at" gorm:"not null"`
 	UpdatedAt time.Time  `json:"updated_at" gorm:"not null"`
@@ -74,6 +94,11 @@ func (User) TableName() string {
 	return "users"
 }
 
+// IsLocked reports whether the account is temporarily locked after too many failed logins
+func (u *User) IsLocked() bool {
+	return u.LockedUntil != nil && time.Now().Before(*u.LockedUntil)
+}
+
 func (Establishment) TableName() string {
 	return "estabilishments"
 }
@@ -0,0 +1,53 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+)
+
+type WebAuthnCeremony string
+
+const (
+	WebAuthnCeremonyRegistration   WebAuthnCeremony = "REGISTRATION"
+	WebAuthnCeremonyAuthentication WebAuthnCeremony = "AUTHENTICATION"
+)
+
+// WebAuthnCredential is a passkey registered by a user
+type WebAuthnCredential struct {
+	ID           uuid.UUID      `json:"id" gorm:"type:uuid;primary_key;default:gen_random_uuid()"`
+	UserID       uuid.UUID      `json:"-" gorm:"type:uuid;not null;index"`
+	CredentialID string         `json:"credential_id" gorm:"type:varchar(1024);not null;unique_index"`
+	PublicKey    []byte         `json:"-" gorm:"type:bytea;not null"`
+	Algorithm    int64          `json:"algorithm" gorm:"not null"`
+	SignCount    int64          `json:"-" gorm:"type:bigint;not null;default:0"`
+	AAGUID       string         `json:"aaguid,omitempty" gorm:"type:varchar(36)"`
+	Transports   pq.StringArray `json:"transports,omitempty" gorm:"type:text[]"`
+	Name         string         `json:"name" gorm:"type:varchar(100)"`
+	LastUsedAt   *time.Time     `json:"last_used_at,omitempty"`
+	// Audit fields
+	CreatedAt time.Time `json:"created_at" gorm:"not null"`
+	UpdatedAt time.Time `json:"updated_at" gorm:"not null"`
+}
+
+func (WebAuthnCredential) TableName() string {
+	return "webauthn_credentials"
+}
+
+// WebAuthnChallenge is a pending registration or authentication ceremony
+type WebAuthnChallenge struct {
+	ID        uuid.UUID        `json:"id" gorm:"type:uuid;primary_key;default:gen_random_uuid()"`
+	UserID    *uuid.UUID       `json:"-" gorm:"type:uuid;index"`
+	Challenge string           `json:"-" gorm:"type:varchar(128);not null;unique_index"`
+	Ceremony  WebAuthnCeremony `json:"ceremony" gorm:"type:varchar(20);not null"`
+	ExpiresAt time.Time        `json:"expires_at" gorm:"not null"`
+	UsedAt    *time.Time       `json:"used_at,omitempty"`
+	// Audit fields
+	CreatedAt time.Time `json:"created_at" gorm:"not null"`
+	UpdatedAt time.Time `json:"updated_at" gorm:"not null"`
+}
+
+func (WebAuthnChallenge) TableName() string {
+	return "webauthn_challenges"
+}
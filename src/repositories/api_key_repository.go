@@ -0,0 +1,150 @@
+package repositories
+
+import (
+	"errors"
+	"time"
+
+	"github.com/Barba2k2/aurora_backend/src/models"
+	"github.com/google/uuid"
+	"github.com/jinzhu/gorm"
+)
+
+// Common errors related to API keys
+var (
+	ErrAPIKeyNotFound = errors.New("api key not found")
+)
+
+// APIKeyRepositoryInterface defines the interface for accessing API key data
+type APIKeyRepositoryInterface interface {
+	Create(key *models.APIKey) error
+	FindByID(establishmentID, id uuid.UUID) (*models.APIKey, error)
+	FindByPrefix(prefix string) (*models.APIKey, error)
+	FindAllByEstablishment(establishmentID uuid.UUID) ([]*models.APIKey, error)
+	UpdateSecret(id uuid.UUID, prefix, secretHash string) error
+	Touch(id uuid.UUID, ipAddress string) error
+	Revoke(id uuid.UUID) error
+	RevokeCreatedBySince(userID uuid.UUID, since time.Time) ([]*models.APIKey, error)
+}
+
+// APIKeyRepository implements the APIKeyRepositoryInterface
+type APIKeyRepository struct {
+	DB *gorm.DB
+}
+
+// NewAPIKeyRepository creates a new instance of APIKeyRepository
+func NewAPIKeyRepository(db *gorm.DB) APIKeyRepositoryInterface {
+	return &APIKeyRepository{DB: db}
+}
+
+// Create stores a new API key
+func (r *APIKeyRepository) Create(key *models.APIKey) error {
+	// We define creation/update timestamps
+	now := time.Now()
+	key.CreatedAt = now
+	key.UpdatedAt = now
+
+	return r.DB.Create(key).Error
+}
+
+// FindByID finds an API key of an establishment by ID
+func (r *APIKeyRepository) FindByID(establishmentID, id uuid.UUID) (*models.APIKey, error) {
+	var key models.APIKey
+
+	if err := r.DB.Where("id = ? AND establishment_id = ?", id, establishmentID).First(&key).Error; err != nil {
+		if gorm.IsRecordNotFoundError(err) {
+			return nil, ErrAPIKeyNotFound
+		}
+		return nil, err
+	}
+
+	return &key, nil
+}
+
+// FindByPrefix finds an API key by its public prefix
+func (r *APIKeyRepository) FindByPrefix(prefix string) (*models.APIKey, error) {
+	var key models.APIKey
+
+	if err := r.DB.Where("prefix = ?", prefix).First(&key).Error; err != nil {
+		if gorm.IsRecordNotFoundError(err) {
+			return nil, ErrAPIKeyNotFound
+		}
+		return nil, err
+	}
+
+	return &key, nil
+}
+
+// FindAllByEstablishment returns the keys of an establishment that were not revoked, newest first
+func (r *APIKeyRepository) FindAllByEstablishment(establishmentID uuid.UUID) ([]*models.APIKey, error) {
+	var keys []*models.APIKey
+
+	if err := r.DB.Where("establishment_id = ? AND revoked_at IS NULL", establishmentID).
+		Order("created_at DESC").
+		Find(&keys).Error; err != nil {
+		return nil, err
+	}
+
+	return keys, nil
+}
+
+// UpdateSecret replaces the prefix and secret of a key, invalidating the previous value
+func (r *APIKeyRepository) UpdateSecret(id uuid.UUID, prefix, secretHash string) error {
+	return r.DB.Model(&models.APIKey{}).Where("id = ?", id).Updates(map[string]interface{}{
+		"prefix":      prefix,
+		"secret_hash": secretHash,
+		"updated_at":  time.Now(),
+	}).Error
+}
+
+// Touch records the use of a key
+func (r *APIKeyRepository) Touch(id uuid.UUID, ipAddress string) error {
+	now := time.Now()
+
+	return r.DB.Model(&models.APIKey{}).Where("id = ?", id).Updates(map[string]interface{}{
+		"last_used_at": now,
+		"last_used_ip": ipAddress,
+	}).Error
+}
+
+// Revoke revokes a key
+func (r *APIKeyRepository) Revoke(id uuid.UUID) error {
+	now := time.Now()
+
+	return r.DB.Model(&models.APIKey{}).
+		Where("id = ? AND revoked_at IS NULL", id).
+		Updates(map[string]interface{}{
+			"revoked_at": now,
+			"updated_at": now,
+		}).Error
+}
+
+// RevokeCreatedBySince revokes the active keys a user created at or after the given time and returns them
+func (r *APIKeyRepository) RevokeCreatedBySince(userID uuid.UUID, since time.Time) ([]*models.APIKey, error) {
+	var keys []*models.APIKey
+
+	err := r.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("created_by = ? AND created_at >= ? AND revoked_at IS NULL", userID, since).Find(&keys).Error; err != nil {
+			return err
+		}
+		if len(keys) == 0 {
+			return nil
+		}
+
+		ids := make([]uuid.UUID, 0, len(keys))
+		for _, key := range keys {
+			ids = append(ids, key.ID)
+		}
+		now := time.Now()
+		return tx.Model(&models.APIKey{}).
+			Where("id IN (?) AND revoked_at IS NULL", ids).
+			Updates(map[string]interface{}{
+				"revoked_at": now,
+				"updated_at": now,
+			}).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return keys, nil
+}
@@ -0,0 +1,79 @@
+package repositories
+
+import (
+	"time"
+
+	"github.com/Barba2k2/aurora_backend/src/models"
+	"github.com/google/uuid"
+	"github.com/jinzhu/gorm"
+)
+
+// AuthEventFilter restricts the authentication events returned by a query.
+// Zero values are ignored.
+type AuthEventFilter struct {
+	UserID *uuid.UUID
+	Types  []models.AuthEventType
+	From   time.Time
+	To     time.Time
+}
+
+// AuthEventRepositoryInterface defines the interface for accessing the authentication event log.
+// The log is append-only, so there are no update or delete operations.
+type AuthEventRepositoryInterface interface {
+	Create(event *models.AuthEvent) error
+	Find(filter AuthEventFilter, page, limit int) ([]*models.AuthEvent, int64, error)
+}
+
+// AuthEventRepository implements the AuthEventRepositoryInterface
+type AuthEventRepository struct {
+	DB *gorm.DB
+}
+
+// NewAuthEventRepository creates a new instance of AuthEventRepository
+func NewAuthEventRepository(db *gorm.DB) AuthEventRepositoryInterface {
+	return &AuthEventRepository{DB: db}
+}
+
+// Create appends an event to the log
+func (r *AuthEventRepository) Create(event *models.AuthEvent) error {
+	// We define the creation timestamp
+	event.CreatedAt = time.Now()
+
+	return r.DB.Create(event).Error
+}
+
+// Find returns the events matching the filter, most recent first, with pagination
+func (r *AuthEventRepository) Find(filter AuthEventFilter, page, limit int) ([]*models.AuthEvent, int64, error) {
+	var events []*models.AuthEvent
+	var total int64
+
+	// Configure the base query
+	query := r.DB.Model(&models.AuthEvent{})
+
+	// Apply filters
+	if filter.UserID != nil {
+		query = query.Where("user_id = ?", *filter.UserID)
+	}
+	if len(filter.Types) > 0 {
+		query = query.Where("type IN (?)", filter.Types)
+	}
+	if !filter.From.IsZero() {
+		query = query.Where("created_at >= ?", filter.From)
+	}
+	if !filter.To.IsZero() {
+		query = query.Where("created_at < ?", filter.To)
+	}
+
+	// Count the total number of records
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	// Apply pagination
+	offset := (page - 1) * limit
+	if err := query.Order("created_at DESC").Offset(offset).Limit(limit).Find(&events).Error; err != nil {
+		return nil, 0, err
+	}
+
+	return events, total, nil
+}
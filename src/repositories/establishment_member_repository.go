@@ -0,0 +1,146 @@
+package repositories
+
+import (
+	"errors"
+	"time"
+
+	"github.com/Barba2k2/aurora_backend/src/models"
+	"github.com/google/uuid"
+	"github.com/jinzhu/gorm"
+)
+
+// Common errors related to establishment members
+var (
+	ErrEstablishmentMemberNotFound      = errors.New("establishment member not found")
+	ErrEstablishmentMemberAlreadyExists = errors.New("establishment member already exists")
+)
+
+// EstablishmentMemberRepositoryInterface defines the interface for accessing establishment membership data
+type EstablishmentMemberRepositoryInterface interface {
+	Create(member *models.EstablishmentMember) error
+	FindByEstablishmentAndUser(establishmentID, userID uuid.UUID) (*models.EstablishmentMember, error)
+	FindFirstByUser(userID uuid.UUID) (*models.EstablishmentMember, error)
+	FindAllByUser(userID uuid.UUID) ([]*models.EstablishmentMember, error)
+	FindAllByEstablishment(establishmentID uuid.UUID) ([]*models.EstablishmentMember, error)
+	UpdateRole(id uuid.UUID, roleID *uuid.UUID) error
+	ClearRole(roleID uuid.UUID) error
+	Delete(id uuid.UUID) error
+}
+
+// EstablishmentMemberRepository implements the EstablishmentMemberRepositoryInterface
+type EstablishmentMemberRepository struct {
+	DB *gorm.DB
+}
+
+// NewEstablishmentMemberRepository creates a new instance of EstablishmentMemberRepository
+func NewEstablishmentMemberRepository(db *gorm.DB) EstablishmentMemberRepositoryInterface {
+	return &EstablishmentMemberRepository{DB: db}
+}
+
+// Create adds a user to the staff of an establishment
+func (r *EstablishmentMemberRepository) Create(member *models.EstablishmentMember) error {
+	// Check if the user is already a member
+	var count int
+	if err := r.DB.Model(&models.EstablishmentMember{}).
+		Where("establishment_id = ? AND user_id = ?", member.EstablishmentID, member.UserID).
+		Count(&count).Error; err != nil {
+		return err
+	}
+	if count > 0 {
+		return ErrEstablishmentMemberAlreadyExists
+	}
+
+	// We define creation/update timestamps
+	now := time.Now()
+	member.CreatedAt = now
+	member.UpdatedAt = now
+
+	return r.DB.Create(member).Error
+}
+
+// FindByEstablishmentAndUser finds the membership of a user in an active establishment
+func (r *EstablishmentMemberRepository) FindByEstablishmentAndUser(establishmentID, userID uuid.UUID) (*models.EstablishmentMember, error) {
+	var member models.EstablishmentMember
+
+	if err := r.activeEstablishments().
+		Where("establishment_members.establishment_id = ? AND establishment_members.user_id = ?", establishmentID, userID).
+		First(&member).Error; err != nil {
+		if gorm.IsRecordNotFoundError(err) {
+			return nil, ErrEstablishmentMemberNotFound
+		}
+		return nil, err
+	}
+
+	return &member, nil
+}
+
+// FindFirstByUser finds the oldest membership of a user in an active establishment
+func (r *EstablishmentMemberRepository) FindFirstByUser(userID uuid.UUID) (*models.EstablishmentMember, error) {
+	var member models.EstablishmentMember
+
+	if err := r.activeEstablishments().
+		Where("establishment_members.user_id = ?", userID).
+		Order("establishment_members.created_at").
+		First(&member).Error; err != nil {
+		if gorm.IsRecordNotFoundError(err) {
+			return nil, ErrEstablishmentMemberNotFound
+		}
+		return nil, err
+	}
+
+	return &member, nil
+}
+
+// FindAllByUser returns the memberships of a user in active establishments, oldest first
+func (r *EstablishmentMemberRepository) FindAllByUser(userID uuid.UUID) ([]*models.EstablishmentMember, error) {
+	var members []*models.EstablishmentMember
+
+	if err := r.activeEstablishments().
+		Where("establishment_members.user_id = ?", userID).
+		Order("establishment_members.created_at").
+		Find(&members).Error; err != nil {
+		return nil, err
+	}
+
+	return members, nil
+}
+
+// FindAllByEstablishment returns the staff of an establishment, oldest first
+func (r *EstablishmentMemberRepository) FindAllByEstablishment(establishmentID uuid.UUID) ([]*models.EstablishmentMember, error) {
+	var members []*models.EstablishmentMember
+
+	if err := r.DB.Where("establishment_id = ?", establishmentID).Order("created_at").Find(&members).Error; err != nil {
+		return nil, err
+	}
+
+	return members, nil
+}
+
+// UpdateRole assigns a role to a member; a nil role restores the default staff permissions
+func (r *EstablishmentMemberRepository) UpdateRole(id uuid.UUID, roleID *uuid.UUID) error {
+	return r.DB.Model(&models.EstablishmentMember{}).Where("id = ?", id).Updates(map[string]interface{}{
+		"role_id":    roleID,
+		"updated_at": time.Now(),
+	}).Error
+}
+
+// ClearRole removes a role from every member that has it
+func (r *EstablishmentMemberRepository) ClearRole(roleID uuid.UUID) error {
+	return r.DB.Model(&models.EstablishmentMember{}).Where("role_id = ?", roleID).Updates(map[string]interface{}{
+		"role_id":    nil,
+		"updated_at": time.Now(),
+	}).Error
+}
+
+// Delete removes a user from the staff of an establishment
+func (r *EstablishmentMemberRepository) Delete(id uuid.UUID) error {
+	return r.DB.Where("id = ?", id).Delete(&models.EstablishmentMember{}).Error
+}
+
+// activeEstablishments restricts a query to memberships of establishments that were not deleted,
+// so the staff of a deleted establishment loses access to it until the establishment is restored
+func (r *EstablishmentMemberRepository) activeEstablishments() *gorm.DB {
+	return r.DB.Select("establishment_members.*").
+		Joins("JOIN estabilishments ON estabilishments.id = establishment_members.establishment_id").
+		Where("estabilishments.status = ? AND estabilishments.deleted_at IS NULL", models.UserStatusActive)
+}
@@ -0,0 +1,114 @@
+package repositories
+
+import (
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/Barba2k2/aurora_backend/src/models"
+	"github.com/google/uuid"
+	"github.com/jinzhu/gorm"
+)
+
+// Common errors related to establishment roles
+var (
+	ErrEstablishmentRoleNotFound      = errors.New("establishment role not found")
+	ErrEstablishmentRoleAlreadyExists = errors.New("establishment role already exists")
+)
+
+// EstablishmentRoleRepositoryInterface defines the interface for accessing establishment role data
+type EstablishmentRoleRepositoryInterface interface {
+	Create(role *models.EstablishmentRole) error
+	FindByID(establishmentID, id uuid.UUID) (*models.EstablishmentRole, error)
+	FindAllByEstablishment(establishmentID uuid.UUID) ([]*models.EstablishmentRole, error)
+	Update(role *models.EstablishmentRole) error
+	Delete(establishmentID, id uuid.UUID) error
+}
+
+// EstablishmentRoleRepository implements the EstablishmentRoleRepositoryInterface
+type EstablishmentRoleRepository struct {
+	DB *gorm.DB
+}
+
+// NewEstablishmentRoleRepository creates a new instance of EstablishmentRoleRepository
+func NewEstablishmentRoleRepository(db *gorm.DB) EstablishmentRoleRepositoryInterface {
+	return &EstablishmentRoleRepository{DB: db}
+}
+
+// Create creates a new role for an establishment
+func (r *EstablishmentRoleRepository) Create(role *models.EstablishmentRole) error {
+	// Check if the establishment already has a role with this name
+	var count int
+	if err := r.DB.Model(&models.EstablishmentRole{}).
+		Where("establishment_id = ? AND LOWER(name) = ?", role.EstablishmentID, strings.ToLower(role.Name)).
+		Count(&count).Error; err != nil {
+		return err
+	}
+	if count > 0 {
+		return ErrEstablishmentRoleAlreadyExists
+	}
+
+	// We define creation/update timestamps
+	now := time.Now()
+	role.CreatedAt = now
+	role.UpdatedAt = now
+
+	return r.DB.Create(role).Error
+}
+
+// FindByID finds a role of an establishment by ID
+func (r *EstablishmentRoleRepository) FindByID(establishmentID, id uuid.UUID) (*models.EstablishmentRole, error) {
+	var role models.EstablishmentRole
+
+	if err := r.DB.Where("id = ? AND establishment_id = ?", id, establishmentID).First(&role).Error; err != nil {
+		if gorm.IsRecordNotFoundError(err) {
+			return nil, ErrEstablishmentRoleNotFound
+		}
+		return nil, err
+	}
+
+	return &role, nil
+}
+
+// FindAllByEstablishment returns the roles of an establishment ordered by name
+func (r *EstablishmentRoleRepository) FindAllByEstablishment(establishmentID uuid.UUID) ([]*models.EstablishmentRole, error) {
+	var roles []*models.EstablishmentRole
+
+	if err := r.DB.Where("establishment_id = ?", establishmentID).Order("name").Find(&roles).Error; err != nil {
+		return nil, err
+	}
+
+	return roles, nil
+}
+
+// Update updates a role's name, description and permissions
+func (r *EstablishmentRoleRepository) Update(role *models.EstablishmentRole) error {
+	// Check if another role of the establishment already uses the name
+	var count int
+	if err := r.DB.Model(&models.EstablishmentRole{}).
+		Where("establishment_id = ? AND LOWER(name) = ? AND id <> ?", role.EstablishmentID, strings.ToLower(role.Name), role.ID).
+		Count(&count).Error; err != nil {
+		return err
+	}
+	if count > 0 {
+		return ErrEstablishmentRoleAlreadyExists
+	}
+
+	// Update the timestamp
+	role.UpdatedAt = time.Now()
+
+	return r.DB.Save(role).Error
+}
+
+// Delete deletes a role of an establishment
+func (r *EstablishmentRoleRepository) Delete(establishmentID, id uuid.UUID) error {
+	result := r.DB.Where("id = ? AND establishment_id = ?", id, establishmentID).Delete(&models.EstablishmentRole{})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrEstablishmentRoleNotFound
+	}
+
+	return nil
+}
@@ -0,0 +1,79 @@
+package repositories
+
+import (
+	"errors"
+	"time"
+
+	"github.com/Barba2k2/aurora_backend/src/models"
+	"github.com/google/uuid"
+	"github.com/jinzhu/gorm"
+)
+
+// Common errors related to known devices
+var (
+	ErrKnownDeviceNotFound = errors.New("known device not found")
+)
+
+// KnownDeviceRepositoryInterface defines the interface for accessing known device data
+type KnownDeviceRepositoryInterface interface {
+	Create(device *models.KnownDevice) error
+	FindByFingerprint(userID uuid.UUID, fingerprint string) (*models.KnownDevice, error)
+	CountByUser(userID uuid.UUID) (int, error)
+	Touch(id uuid.UUID) error
+	DeleteAllByUser(userID uuid.UUID) error
+}
+
+// KnownDeviceRepository implements the KnownDeviceRepositoryInterface
+type KnownDeviceRepository struct {
+	DB *gorm.DB
+}
+
+// NewKnownDeviceRepository creates a new instance of KnownDeviceRepository
+func NewKnownDeviceRepository(db *gorm.DB) KnownDeviceRepositoryInterface {
+	return &KnownDeviceRepository{DB: db}
+}
+
+// Create stores a new known device
+func (r *KnownDeviceRepository) Create(device *models.KnownDevice) error {
+	// We define the creation timestamp
+	now := time.Now()
+	device.CreatedAt = now
+	device.LastSeenAt = now
+
+	return r.DB.Create(device).Error
+}
+
+// FindByFingerprint finds a known device of a user by its fingerprint
+func (r *KnownDeviceRepository) FindByFingerprint(userID uuid.UUID, fingerprint string) (*models.KnownDevice, error) {
+	var device models.KnownDevice
+
+	if err := r.DB.Where("user_id = ? AND fingerprint = ?", userID, fingerprint).First(&device).Error; err != nil {
+		if gorm.IsRecordNotFoundError(err) {
+			return nil, ErrKnownDeviceNotFound
+		}
+		return nil, err
+	}
+
+	return &device, nil
+}
+
+// CountByUser counts the known devices of a user
+func (r *KnownDeviceRepository) CountByUser(userID uuid.UUID) (int, error) {
+	var count int
+
+	if err := r.DB.Model(&models.KnownDevice{}).Where("user_id = ?", userID).Count(&count).Error; err != nil {
+		return 0, err
+	}
+
+	return count, nil
+}
+
+// Touch records a new sign-in from a known device
+func (r *KnownDeviceRepository) Touch(id uuid.UUID) error {
+	return r.DB.Model(&models.KnownDevice{}).Where("id = ?", id).Update("last_seen_at", time.Now()).Error
+}
+
+// DeleteAllByUser forgets all the known devices of a user
+func (r *KnownDeviceRepository) DeleteAllByUser(userID uuid.UUID) error {
+	return r.DB.Where("user_id = ?", userID).Delete(&models.KnownDevice{}).Error
+}
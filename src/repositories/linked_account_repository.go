@@ -0,0 +1,119 @@
+package repositories
+
+import (
+	"errors"
+	"time"
+
+	"github.com/Barba2k2/aurora_backend/src/models"
+	"github.com/google/uuid"
+	"github.com/jinzhu/gorm"
+)
+
+// Common errors related to linked accounts
+var (
+	ErrLinkedAccountNotFound      = errors.New("linked account not found")
+	ErrLinkedAccountAlreadyExists = errors.New("linked account already exists")
+)
+
+// LinkedAccountRepositoryInterface defines the interface for accessing linked account data
+type LinkedAccountRepositoryInterface interface {
+	Create(account *models.LinkedAccount) error
+	FindByProviderSubject(provider, subject string) (*models.LinkedAccount, error)
+	FindByUser(userID uuid.UUID) ([]*models.LinkedAccount, error)
+	DeleteByUserAndProvider(userID uuid.UUID, provider string) error
+	DeleteByUserCreatedSince(userID uuid.UUID, since time.Time) ([]*models.LinkedAccount, error)
+}
+
+// LinkedAccountRepository implements the LinkedAccountRepositoryInterface
+type LinkedAccountRepository struct {
+	DB *gorm.DB
+}
+
+// NewLinkedAccountRepository creates a new instance of LinkedAccountRepository
+func NewLinkedAccountRepository(db *gorm.DB) LinkedAccountRepositoryInterface {
+	return &LinkedAccountRepository{DB: db}
+}
+
+// Create links a provider identity to a user. A user can link each provider only once.
+func (r *LinkedAccountRepository) Create(account *models.LinkedAccount) error {
+	// We check if the identity or the provider for this user is already linked
+	var count int
+	if err := r.DB.Model(&models.LinkedAccount{}).
+		Where("(provider = ? AND subject = ?) OR (provider = ? AND user_id = ?)", account.Provider, account.Subject, account.Provider, account.UserID).
+		Count(&count).Error; err != nil {
+		return err
+	}
+	if count > 0 {
+		return ErrLinkedAccountAlreadyExists
+	}
+
+	// We define creation/update timestamps
+	now := time.Now()
+	account.CreatedAt = now
+	account.UpdatedAt = now
+
+	return r.DB.Create(account).Error
+}
+
+// FindByProviderSubject finds the account linked to a provider identity
+func (r *LinkedAccountRepository) FindByProviderSubject(provider, subject string) (*models.LinkedAccount, error) {
+	var account models.LinkedAccount
+
+	if err := r.DB.Where("provider = ? AND subject = ?", provider, subject).First(&account).Error; err != nil {
+		if gorm.IsRecordNotFoundError(err) {
+			return nil, ErrLinkedAccountNotFound
+		}
+		return nil, err
+	}
+
+	return &account, nil
+}
+
+// FindByUser finds the accounts linked to a user
+func (r *LinkedAccountRepository) FindByUser(userID uuid.UUID) ([]*models.LinkedAccount, error) {
+	var accounts []*models.LinkedAccount
+
+	if err := r.DB.Where("user_id = ?", userID).Order("created_at ASC").Find(&accounts).Error; err != nil {
+		return nil, err
+	}
+
+	return accounts, nil
+}
+
+// DeleteByUserAndProvider unlinks a provider from a user
+func (r *LinkedAccountRepository) DeleteByUserAndProvider(userID uuid.UUID, provider string) error {
+	result := r.DB.Where("user_id = ? AND provider = ?", userID, provider).Delete(&models.LinkedAccount{})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrLinkedAccountNotFound
+	}
+
+	return nil
+}
+
+// DeleteByUserCreatedSince unlinks the providers a user linked at or after the given time and returns them
+func (r *LinkedAccountRepository) DeleteByUserCreatedSince(userID uuid.UUID, since time.Time) ([]*models.LinkedAccount, error) {
+	var accounts []*models.LinkedAccount
+
+	err := r.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("user_id = ? AND created_at >= ?", userID, since).Find(&accounts).Error; err != nil {
+			return err
+		}
+		if len(accounts) == 0 {
+			return nil
+		}
+
+		ids := make([]uuid.UUID, 0, len(accounts))
+		for _, account := range accounts {
+			ids = append(ids, account.ID)
+		}
+		return tx.Where("id IN (?)", ids).Delete(&models.LinkedAccount{}).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return accounts, nil
+}
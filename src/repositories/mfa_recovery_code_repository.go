@@ -0,0 +1,97 @@
+package repositories
+
+import (
+	"errors"
+	"time"
+
+	"github.com/Barba2k2/aurora_backend/src/models"
+	"github.com/google/uuid"
+	"github.com/jinzhu/gorm"
+)
+
+// Common errors related to recovery codes
+var (
+	ErrRecoveryCodeNotFound    = errors.New("recovery code not found")
+	ErrRecoveryCodeAlreadyUsed = errors.New("recovery code already used")
+)
+
+// MFARecoveryCodeRepositoryInterface defines the interface for accessing MFA recovery code data
+type MFARecoveryCodeRepositoryInterface interface {
+	ReplaceForUser(userID uuid.UUID, codeHashes []string) error
+	FindUnusedByHash(userID uuid.UUID, codeHash string) (*models.MFARecoveryCode, error)
+	MarkAsUsed(id uuid.UUID) error
+	DeleteByUser(userID uuid.UUID) error
+}
+
+// MFARecoveryCodeRepository implements the MFARecoveryCodeRepositoryInterface
+type MFARecoveryCodeRepository struct {
+	DB *gorm.DB
+}
+
+// NewMFARecoveryCodeRepository creates a new instance of MFARecoveryCodeRepository
+func NewMFARecoveryCodeRepository(db *gorm.DB) MFARecoveryCodeRepositoryInterface {
+	return &MFARecoveryCodeRepository{DB: db}
+}
+
+// ReplaceForUser deletes the current codes of a user and stores the new ones
+func (r *MFARecoveryCodeRepository) ReplaceForUser(userID uuid.UUID, codeHashes []string) error {
+	return r.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("user_id = ?", userID).Delete(&models.MFARecoveryCode{}).Error; err != nil {
+			return err
+		}
+
+		now := time.Now()
+		for _, hash := range codeHashes {
+			code := &models.MFARecoveryCode{
+				UserID:    userID,
+				CodeHash:  hash,
+				CreatedAt: now,
+				UpdatedAt: now,
+			}
+			if err := tx.Create(code).Error; err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+}
+
+// FindUnusedByHash finds a recovery code of a user that was not used yet by its hash
+func (r *MFARecoveryCodeRepository) FindUnusedByHash(userID uuid.UUID, codeHash string) (*models.MFARecoveryCode, error) {
+	var code models.MFARecoveryCode
+
+	if err := r.DB.Where("user_id = ? AND code_hash = ? AND used_at IS NULL", userID, codeHash).First(&code).Error; err != nil {
+		if gorm.IsRecordNotFoundError(err) {
+			return nil, ErrRecoveryCodeNotFound
+		}
+		return nil, err
+	}
+
+	return &code, nil
+}
+
+// MarkAsUsed marks a recovery code as used, failing if it was already consumed
+func (r *MFARecoveryCodeRepository) MarkAsUsed(id uuid.UUID) error {
+	now := time.Now()
+
+	result := r.DB.Model(&models.MFARecoveryCode{}).
+		Where("id = ? AND used_at IS NULL", id).
+		Updates(map[string]interface{}{
+			"used_at":    now,
+			"updated_at": now,
+		})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrRecoveryCodeAlreadyUsed
+	}
+
+	return nil
+}
+
+// DeleteByUser deletes all recovery codes of a user
+func (r *MFARecoveryCodeRepository) DeleteByUser(userID uuid.UUID) error {
+	return r.DB.Where("user_id = ?", userID).Delete(&models.MFARecoveryCode{}).Error
+}
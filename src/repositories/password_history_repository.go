@@ -0,0 +1,57 @@
+package repositories
+
+import (
+	"time"
+
+	"github.com/Barba2k2/aurora_backend/src/models"
+	"github.com/google/uuid"
+	"github.com/jinzhu/gorm"
+)
+
+// PasswordHistoryRepositoryInterface defines the interface for accessing password history data
+type PasswordHistoryRepositoryInterface interface {
+	Create(entry *models.PasswordHistory) error
+	FindRecentByUser(userID uuid.UUID, limit int) ([]*models.PasswordHistory, error)
+	PruneByUser(userID uuid.UUID, keep int) error
+}
+
+// PasswordHistoryRepository implements the PasswordHistoryRepositoryInterface
+type PasswordHistoryRepository struct {
+	DB *gorm.DB
+}
+
+// NewPasswordHistoryRepository creates a new instance of PasswordHistoryRepository
+func NewPasswordHistoryRepository(db *gorm.DB) PasswordHistoryRepositoryInterface {
+	return &PasswordHistoryRepository{DB: db}
+}
+
+// Create stores a previous password hash of a user
+func (r *PasswordHistoryRepository) Create(entry *models.PasswordHistory) error {
+	// We define the creation timestamp
+	entry.CreatedAt = time.Now()
+
+	return r.DB.Create(entry).Error
+}
+
+// FindRecentByUser returns the most recent previous passwords of a user
+func (r *PasswordHistoryRepository) FindRecentByUser(userID uuid.UUID, limit int) ([]*models.PasswordHistory, error) {
+	var entries []*models.PasswordHistory
+
+	if err := r.DB.Where("user_id = ?", userID).Order("created_at DESC").Limit(limit).Find(&entries).Error; err != nil {
+		return nil, err
+	}
+
+	return entries, nil
+}
+
+// PruneByUser deletes all but the most recent entries of a user
+func (r *PasswordHistoryRepository) PruneByUser(userID uuid.UUID, keep int) error {
+	recent := r.DB.Model(&models.PasswordHistory{}).
+		Select("id").
+		Where("user_id = ?", userID).
+		Order("created_at DESC").
+		Limit(keep).
+		QueryExpr()
+
+	return r.DB.Where("user_id = ? AND id NOT IN (?)", userID, recent).Delete(&models.PasswordHistory{}).Error
+}
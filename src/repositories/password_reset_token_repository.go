@@ -21,13 +21,16 @@ var (
 // TokenRepositoryInterface defines the interface for accessing token data
 type TokenRepositoryInterface interface {
 	Create(token *models.PasswordResetToken) error
-	FindByToken(token string) (*models.PasswordResetToken, error)
+	FindByTokenHash(tokenHash string) (*models.PasswordResetToken, error)
 	FindByUserAndChannel(userID uuid.UUID, channel models.TokenChannel) ([]*models.PasswordResetToken, error)
 	InvalidateAllUserTokens(userID uuid.UUID) error
 	InvalidateToken(tokenID uuid.UUID) error
 	MarkTokenAsUsed(tokenID uuid.UUID) error
 	IncrementFailedAttempts(tokenID uuid.UUID) error
 	CountActiveTokensByUser(userID uuid.UUID, timeWindow time.Duration) (int, error)
+	InvalidateUserTokensByPurpose(userID uuid.UUID, purpose models.TokenPurpose) error
+	CountTokensByUserAndPurpose(userID uuid.UUID, purpose models.TokenPurpose, timeWindow time.Duration) (int, error)
+	FindActiveByUserAndPurpose(userID uuid.UUID, purpose models.TokenPurpose) (*models.PasswordResetToken, error)
 }
 
 // TokenRepository implements the TokenRepositoryInterface
@@ -51,11 +54,11 @@ func (r *TokenRepository) Create(token *models.PasswordResetToken) error {
 	return r.DB.Create(token).Error
 }
 
-// FindByToken finds a token by its token value
-func (r *TokenRepository) FindByToken(token string) (*models.PasswordResetToken, error) {
+// FindByTokenHash finds a token by the hash of its value
+func (r *TokenRepository) FindByTokenHash(tokenHash string) (*models.PasswordResetToken, error) {
 	var passwordResetToken models.PasswordResetToken
 
-	if err := r.DB.Where("token = ?", token).First(&passwordResetToken).Error; err != nil {
+	if err := r.DB.Where("token_hash = ?", tokenHash).First(&passwordResetToken).Error; err != nil {
 		if gorm.IsRecordNotFoundError(err) {
 			return nil, ErrTokenNotFound
 		}
@@ -123,36 +126,52 @@ func (r *TokenRepository) InvalidateToken(tokenID uuid.UUID) error {
 	return r.DB.Save(&token).Error
 }
 
-// MarkTokenAsUsed marks a token as used
+// MarkTokenAsUsed marks an active, unexpired token as used. The condition on the status makes the
+// token single use under concurrency: only one caller gets the row, the others get ErrTokenAlreadyUsed.
 func (r *TokenRepository) MarkTokenAsUsed(tokenID uuid.UUID) error {
-	var token models.PasswordResetToken
+	now := time.Now()
 
-	if err := r.DB.Where("id = ?", tokenID).First(&token).Error; err != nil {
-		if gorm.IsRecordNotFoundError(err) {
-			return ErrTokenNotFound
-		}
-		return err
+	result := r.DB.Model(&models.PasswordResetToken{}).
+		Where("id = ? AND status = ? AND expires_at > ?", tokenID, models.TokenStatusActive, now).
+		UpdateColumns(map[string]interface{}{
+			"status":     models.TokenStatusUsed,
+			"used_at":    now,
+			"updated_at": now,
+		})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrTokenAlreadyUsed
 	}
 
-	token.MarkAsUsed()
-
-	return r.DB.Save(&token).Error
+	return nil
 }
 
-// IncrementFailedAttempts increments the failed attempts counter for a token
+// IncrementFailedAttempts atomically increments the failed attempts counter for a token,
+// revoking the token once the limit is reached
 func (r *TokenRepository) IncrementFailedAttempts(tokenID uuid.UUID) error {
-	var token models.PasswordResetToken
+	now := time.Now()
 
-	if err := r.DB.Where("id = ?", tokenID).First(&token).Error; err != nil {
-		if gorm.IsRecordNotFoundError(err) {
-			return ErrTokenNotFound
-		}
-		return err
+	result := r.DB.Model(&models.PasswordResetToken{}).Where("id = ?", tokenID).
+		UpdateColumns(map[string]interface{}{
+			"failed_attempts": gorm.Expr("failed_attempts + 1"),
+			"updated_at":      now,
+		})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrTokenNotFound
 	}
 
-	token.IncrementFailedAttempts()
-
-	return r.DB.Save(&token).Error
+	// The status is decided by the stored counter, so concurrent wrong codes cannot go past the limit
+	return r.DB.Model(&models.PasswordResetToken{}).
+		Where("id = ? AND status = ? AND failed_attempts >= ?", tokenID, models.TokenStatusActive, models.MaxTokenFailedAttempts).
+		UpdateColumns(map[string]interface{}{
+			"status":     models.TokenStatusRevoked,
+			"updated_at": now,
+		}).Error
 }
 
 // CountActiveTokensByUser counts active tokens created by a user within a time period
@@ -170,3 +189,46 @@ func (r *TokenRepository) CountActiveTokensByUser(userID uuid.UUID, timeWindow t
 
 	return count, nil
 }
+
+// InvalidateUserTokensByPurpose invalidates the active tokens of a user issued for a given purpose
+func (r *TokenRepository) InvalidateUserTokensByPurpose(userID uuid.UUID, purpose models.TokenPurpose) error {
+	now := time.Now()
+
+	return r.DB.Model(&models.PasswordResetToken{}).
+		Where("user_id = ? AND purpose = ? AND status = ?", userID, purpose, models.TokenStatusActive).
+		Updates(map[string]interface{}{
+			"status":     models.TokenStatusExpired,
+			"updated_at": now,
+		}).Error
+}
+
+// CountTokensByUserAndPurpose counts tokens issued to a user for a given purpose within a time period
+func (r *TokenRepository) CountTokensByUserAndPurpose(userID uuid.UUID, purpose models.TokenPurpose, timeWindow time.Duration) (int, error) {
+	var count int
+
+	fromTime := time.Now().Add(-timeWindow)
+
+	if err := r.DB.Model(&models.PasswordResetToken{}).
+		Where("user_id = ? AND purpose = ? AND created_at > ?", userID, purpose, fromTime).
+		Count(&count).Error; err != nil {
+		return 0, err
+	}
+
+	return count, nil
+}
+
+// FindActiveByUserAndPurpose finds the most recent active token of a user for a given purpose
+func (r *TokenRepository) FindActiveByUserAndPurpose(userID uuid.UUID, purpose models.TokenPurpose) (*models.PasswordResetToken, error) {
+	var token models.PasswordResetToken
+
+	if err := r.DB.Where("user_id = ? AND purpose = ? AND status = ? AND expires_at > ?", userID, purpose, models.TokenStatusActive, time.Now()).
+		Order("created_at DESC").
+		First(&token).Error; err != nil {
+		if gorm.IsRecordNotFoundError(err) {
+			return nil, ErrTokenNotFound
+		}
+		return nil, err
+	}
+
+	return &token, nil
+}
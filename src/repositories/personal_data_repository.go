@@ -0,0 +1,317 @@
+package repositories
+
+import (
+	"time"
+
+	"github.com/Barba2k2/aurora_backend/src/models"
+	"github.com/google/uuid"
+	"github.com/jinzhu/gorm"
+)
+
+// Names given to erased accounts and to the establishments they owned
+const (
+	anonymizedName              = "Deleted user"
+	anonymizedEstablishmentName = "Deleted establishment"
+)
+
+// PersonalData gathers everything stored about a user, for data subject access requests (LGPD)
+type PersonalData struct {
+	User           *models.User
+	Establishment  *models.Establishment
+	Memberships    []*models.EstablishmentMember
+	Sessions       []*models.Session
+	KnownDevices   []*models.KnownDevice
+	LinkedAccounts []*models.LinkedAccount
+	Passkeys       []*models.WebAuthnCredential
+	Tokens         []*models.PasswordResetToken
+	AuthEvents     []*models.AuthEvent
+}
+
+// PersonalDataRepositoryInterface defines the interface for exporting and erasing the personal data of a user
+type PersonalDataRepositoryInterface interface {
+	FindByUser(userID uuid.UUID) (*PersonalData, error)
+	SoftDelete(user *models.User, deletedBy uuid.UUID, erasureAt time.Time) error
+	Restore(user *models.User) error
+	FindDueForErasure(now time.Time, limit int) ([]*models.User, error)
+	Anonymize(user *models.User) error
+}
+
+// PersonalDataRepository implements the PersonalDataRepositoryInterface
+type PersonalDataRepository struct {
+	DB *gorm.DB
+}
+
+// NewPersonalDataRepository creates a new instance of PersonalDataRepository
+func NewPersonalDataRepository(db *gorm.DB) PersonalDataRepositoryInterface {
+	return &PersonalDataRepository{DB: db}
+}
+
+// FindByUser loads the personal data of a user across all tables, including soft-deleted accounts
+func (r *PersonalDataRepository) FindByUser(userID uuid.UUID) (*PersonalData, error) {
+	var user models.User
+	if err := r.DB.Unscoped().Where("id = ? AND anonymized_at IS NULL", userID).First(&user).Error; err != nil {
+		if gorm.IsRecordNotFoundError(err) {
+			return nil, ErrUserNotFound
+		}
+		return nil, err
+	}
+
+	data := &PersonalData{User: &user}
+
+	var establishment models.Establishment
+	if err := r.DB.Unscoped().Where("user_id = ?", userID).First(&establishment).Error; err == nil {
+		data.Establishment = &establishment
+	} else if !gorm.IsRecordNotFoundError(err) {
+		return nil, err
+	}
+
+	queries := []struct {
+		dest  interface{}
+		order string
+	}{
+		{&data.Memberships, "created_at"},
+		{&data.Sessions, "created_at DESC"},
+		{&data.KnownDevices, "created_at DESC"},
+		{&data.LinkedAccounts, "created_at"},
+		{&data.Passkeys, "created_at"},
+		{&data.Tokens, "created_at DESC"},
+		{&data.AuthEvents, "created_at DESC"},
+	}
+	for _, query := range queries {
+		if err := r.DB.Where("user_id = ?", userID).Order(query.order).Find(query.dest).Error; err != nil {
+			return nil, err
+		}
+	}
+
+	return data, nil
+}
+
+// SoftDelete deletes an account and schedules the erasure of its personal data in a single transaction.
+// Sessions and pending tokens are revoked; the establishment of an owner is deleted along with the account,
+// revoking its pending invitations and the staff sessions acting on it.
+func (r *PersonalDataRepository) SoftDelete(user *models.User, deletedBy uuid.UUID, erasureAt time.Time) error {
+	return r.DB.Transaction(func(tx *gorm.DB) error {
+		now := time.Now()
+
+		// The current status is kept, so a restore neither skips email verification nor lifts a block
+		result := tx.Model(&models.User{}).Where("id = ?", user.ID).Updates(map[string]interface{}{
+			"status_before_deletion": gorm.Expr("status"),
+			"status":                 models.UserStatusInactive,
+			"erasure_scheduled_at":   erasureAt,
+			"deleted_at":             now,
+			"deleted_by":             deletedBy,
+			"updated_at":             now,
+		})
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return ErrUserNotFound
+		}
+
+		if err := tx.Model(&models.Session{}).
+			Where("user_id = ? AND revoked_at IS NULL", user.ID).
+			Updates(map[string]interface{}{
+				"revoked_at": now,
+				"updated_at": now,
+			}).Error; err != nil {
+			return err
+		}
+
+		if err := tx.Model(&models.RefreshToken{}).
+			Where("user_id = ? AND status = ?", user.ID, models.TokenStatusActive).
+			Updates(map[string]interface{}{
+				"status":     models.TokenStatusRevoked,
+				"updated_at": now,
+			}).Error; err != nil {
+			return err
+		}
+
+		if err := tx.Model(&models.PasswordResetToken{}).
+			Where("user_id = ? AND status = ?", user.ID, models.TokenStatusActive).
+			Updates(map[string]interface{}{
+				"status":     models.TokenStatusExpired,
+				"updated_at": now,
+			}).Error; err != nil {
+			return err
+		}
+
+		if user.Role != models.UserRoleProfessional {
+			return nil
+		}
+
+		var establishment models.Establishment
+		if err := tx.Where("user_id = ?", user.ID).First(&establishment).Error; err != nil {
+			if gorm.IsRecordNotFoundError(err) {
+				return nil
+			}
+			return err
+		}
+
+		if err := tx.Model(&models.StaffInvitation{}).
+			Where("establishment_id = ? AND status = ?", establishment.ID, models.InvitationStatusPending).
+			Updates(map[string]interface{}{
+				"status":     models.InvitationStatusRevoked,
+				"updated_at": now,
+			}).Error; err != nil {
+			return err
+		}
+
+		if err := tx.Model(&models.Session{}).
+			Where("establishment_id = ? AND revoked_at IS NULL", establishment.ID).
+			Updates(map[string]interface{}{
+				"revoked_at": now,
+				"updated_at": now,
+			}).Error; err != nil {
+			return err
+		}
+
+		return tx.Model(&models.Establishment{}).Where("id = ?", establishment.ID).Updates(map[string]interface{}{
+			"status_before_deletion": gorm.Expr("status"),
+			"status":                 models.UserStatusInactive,
+			"deleted_at":             now,
+			"deleted_by":             deletedBy,
+			"updated_at":             now,
+		}).Error
+	})
+}
+
+// Restore undoes the deletion of an account and of the establishment it owns, cancelling the scheduled erasure.
+// Both get back the status they had before the deletion.
+func (r *PersonalDataRepository) Restore(user *models.User) error {
+	return r.DB.Transaction(func(tx *gorm.DB) error {
+		now := time.Now()
+
+		result := tx.Unscoped().Model(&models.User{}).
+			Where("id = ? AND deleted_at IS NOT NULL AND anonymized_at IS NULL", user.ID).
+			Updates(map[string]interface{}{
+				"status":                 statusBeforeDeletion(),
+				"status_before_deletion": "",
+				"deleted_at":             nil,
+				"deleted_by":             nil,
+				"erasure_scheduled_at":   nil,
+				"updated_at":             now,
+			})
+		if result.Error != nil {
+			return result.Error
+		}
+
+		// Nothing was restored if the account was erased in the meantime
+		if result.RowsAffected == 0 {
+			return ErrUserNotFound
+		}
+
+		if user.Role != models.UserRoleProfessional {
+			return nil
+		}
+
+		return tx.Unscoped().Model(&models.Establishment{}).
+			Where("user_id = ? AND deleted_at IS NOT NULL", user.ID).
+			Updates(map[string]interface{}{
+				"status":                 statusBeforeDeletion(),
+				"status_before_deletion": "",
+				"deleted_at":             nil,
+				"deleted_by":             nil,
+				"updated_at":             now,
+			}).Error
+	})
+}
+
+// statusBeforeDeletion restores the status saved by SoftDelete; rows deleted before it was saved become active
+func statusBeforeDeletion() interface{} {
+	return gorm.Expr("COALESCE(NULLIF(status_before_deletion, ''), ?)", models.UserStatusActive)
+}
+
+// FindDueForErasure returns deleted accounts whose grace period ended and were not anonymized yet
+func (r *PersonalDataRepository) FindDueForErasure(now time.Time, limit int) ([]*models.User, error) {
+	var users []*models.User
+
+	// Users and establishments carry deleted_at, which gorm hides unless the query is unscoped
+	if err := r.DB.Unscoped().Where("erasure_scheduled_at <= ? AND anonymized_at IS NULL", now).
+		Order("erasure_scheduled_at").
+		Limit(limit).
+		Find(&users).Error; err != nil {
+		return nil, err
+	}
+
+	return users, nil
+}
+
+// Anonymize erases the personal data of a user in a single transaction.
+// The user row is kept with its ID, so financial records and the establishment that reference it stay valid,
+// while credentials, devices and other data that only identify the person are deleted.
+func (r *PersonalDataRepository) Anonymize(user *models.User) error {
+	return r.DB.Transaction(func(tx *gorm.DB) error {
+		now := time.Now()
+
+		// The unique email is replaced by an address that identifies nobody
+		if err := tx.Unscoped().Model(&models.User{}).Where("id = ?", user.ID).Updates(map[string]interface{}{
+			"email":              "erased-" + user.ID.String() + "@anonymized.invalid",
+			"phone":              "",
+			"name":               anonymizedName,
+			"password_hash":      "",
+			"status":             models.UserStatusInactive,
+			"profile_image_url":  "",
+			"push_subscriptions": nil,
+			"totp_secret":        "",
+			"totp_enabled":       false,
+			"last_login_at":      nil,
+			"email_verified_at":  nil,
+			"phone_verified_at":  nil,
+			"anonymized_at":      now,
+			"updated_at":         now,
+		}).Error; err != nil {
+			return err
+		}
+
+		deletions := []interface{}{
+			&models.Session{},
+			&models.RefreshToken{},
+			&models.PasswordResetToken{},
+			&models.KnownDevice{},
+			&models.LinkedAccount{},
+			&models.WebAuthnCredential{},
+			&models.WebAuthnChallenge{},
+			&models.MFARecoveryCode{},
+			&models.PasswordHistory{},
+			&models.EstablishmentMember{},
+		}
+		for _, model := range deletions {
+			if err := tx.Where("user_id = ?", user.ID).Delete(model).Error; err != nil {
+				return err
+			}
+		}
+
+		// The establishment is kept for the records that reference it, but Register names it after the owner
+		// and its contact fields usually belong to the person
+		if err := tx.Unscoped().Model(&models.Establishment{}).Where("user_id = ?", user.ID).Updates(map[string]interface{}{
+			"bussiness_name":  anonymizedEstablishmentName,
+			"description":     "",
+			"address":         "",
+			"zip_code":        "",
+			"bussiness_phone": "",
+			"bussiness_email": "",
+			"logo_url":        "",
+			"website_url":     "",
+			"updated_at":      now,
+		}).Error; err != nil {
+			return err
+		}
+
+		// Invitations carry the contact of the invitee
+		if err := tx.Where("accepted_by = ? OR LOWER(email) = LOWER(?)", user.ID, user.Email).
+			Delete(&models.StaffInvitation{}).Error; err != nil {
+			return err
+		}
+
+		// The audit trail keeps what happened, but no longer who or where; details may hold a previous email
+		return tx.Model(&models.AuthEvent{}).
+			Where("user_id = ? OR LOWER(email) = LOWER(?)", user.ID, user.Email).
+			Updates(map[string]interface{}{
+				"email":      "",
+				"details":    "",
+				"ip_address": "",
+				"user_agent": "",
+			}).Error
+	})
+}
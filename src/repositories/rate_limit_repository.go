@@ -0,0 +1,56 @@
+package repositories
+
+import (
+	"time"
+
+	"github.com/Barba2k2/aurora_backend/src/models"
+	"github.com/jinzhu/gorm"
+)
+
+// RateLimitRepositoryInterface defines the interface for accessing the rate limit buckets
+type RateLimitRepositoryInterface interface {
+	Update(key string, update func(bucket *models.RateLimitBucket)) error
+	DeleteExpired(now time.Time) error
+}
+
+// RateLimitRepository implements the RateLimitRepositoryInterface
+type RateLimitRepository struct {
+	DB *gorm.DB
+}
+
+// NewRateLimitRepository creates a new instance of RateLimitRepository
+func NewRateLimitRepository(db *gorm.DB) RateLimitRepositoryInterface {
+	return &RateLimitRepository{DB: db}
+}
+
+// Update loads the bucket of a key under a row lock, lets update change it and saves the result.
+// A bucket that did not exist is passed with a zero UpdatedAt.
+func (r *RateLimitRepository) Update(key string, update func(bucket *models.RateLimitBucket)) error {
+	return r.DB.Transaction(func(tx *gorm.DB) error {
+		// Concurrent requests for a new key must not fail on the primary key, so the row is created first
+		if err := tx.Exec(
+			"INSERT INTO rate_limit_buckets (key, tokens, updated_at, expires_at) VALUES (?, 0, ?, ?) ON CONFLICT (key) DO NOTHING",
+			key, time.Time{}, time.Time{},
+		).Error; err != nil {
+			return err
+		}
+
+		var bucket models.RateLimitBucket
+		if err := tx.Set("gorm:query_option", "FOR UPDATE").Where("key = ?", key).First(&bucket).Error; err != nil {
+			return err
+		}
+
+		update(&bucket)
+
+		return tx.Model(&models.RateLimitBucket{}).Where("key = ?", key).UpdateColumns(map[string]interface{}{
+			"tokens":     bucket.Tokens,
+			"updated_at": bucket.UpdatedAt,
+			"expires_at": bucket.ExpiresAt,
+		}).Error
+	})
+}
+
+// DeleteExpired deletes the buckets that are full again, which behave like missing ones
+func (r *RateLimitRepository) DeleteExpired(now time.Time) error {
+	return r.DB.Where("expires_at < ?", now).Delete(&models.RateLimitBucket{}).Error
+}
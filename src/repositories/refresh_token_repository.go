@@ -0,0 +1,115 @@
+package repositories
+
+import (
+	"errors"
+	"time"
+
+	"github.com/Barba2k2/aurora_backend/src/models"
+	"github.com/google/uuid"
+	"github.com/jinzhu/gorm"
+)
+
+// Common errors related to refresh tokens
+var (
+	ErrRefreshTokenNotFound       = errors.New("refresh token not found")
+	ErrRefreshTokenAlreadyRotated = errors.New("refresh token already rotated")
+)
+
+// RefreshTokenRepositoryInterface defines the interface for accessing refresh token data
+type RefreshTokenRepositoryInterface interface {
+	Create(token *models.RefreshToken) error
+	FindByTokenHash(tokenHash string) (*models.RefreshToken, error)
+	MarkAsRotated(tokenID uuid.UUID, replacedByID uuid.UUID) error
+	RevokeFamily(familyID uuid.UUID) error
+	RevokeAllUserTokens(userID uuid.UUID) error
+	RevokeAllUserTokensExceptFamily(userID uuid.UUID, familyID uuid.UUID) error
+}
+
+// RefreshTokenRepository implements the RefreshTokenRepositoryInterface
+type RefreshTokenRepository struct {
+	DB *gorm.DB
+}
+
+// NewRefreshTokenRepository creates a new instance of RefreshTokenRepository
+func NewRefreshTokenRepository(db *gorm.DB) RefreshTokenRepositoryInterface {
+	return &RefreshTokenRepository{DB: db}
+}
+
+// Create creates a new refresh token in the database
+func (r *RefreshTokenRepository) Create(token *models.RefreshToken) error {
+	// We define creation/update timestamps
+	now := time.Now()
+	token.CreatedAt = now
+	token.UpdatedAt = now
+
+	// We create the token
+	return r.DB.Create(token).Error
+}
+
+// FindByTokenHash finds a refresh token by the hash of its value
+func (r *RefreshTokenRepository) FindByTokenHash(tokenHash string) (*models.RefreshToken, error) {
+	var refreshToken models.RefreshToken
+
+	if err := r.DB.Where("token_hash = ?", tokenHash).First(&refreshToken).Error; err != nil {
+		if gorm.IsRecordNotFoundError(err) {
+			return nil, ErrRefreshTokenNotFound
+		}
+		return nil, err
+	}
+
+	return &refreshToken, nil
+}
+
+// MarkAsRotated marks an active token as used and links it to its replacement.
+// The update only applies to active tokens, so two concurrent rotations of the
+// same token cannot both succeed.
+func (r *RefreshTokenRepository) MarkAsRotated(tokenID uuid.UUID, replacedByID uuid.UUID) error {
+	now := time.Now()
+
+	result := r.DB.Model(&models.RefreshToken{}).
+		Where("id = ? AND status = ?", tokenID, models.TokenStatusActive).
+		Updates(map[string]interface{}{
+			"status":         models.TokenStatusUsed,
+			"rotated_at":     now,
+			"replaced_by_id": replacedByID,
+			"updated_at":     now,
+		})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrRefreshTokenAlreadyRotated
+	}
+
+	return nil
+}
+
+// RevokeFamily revokes every token that is still active in a token family
+func (r *RefreshTokenRepository) RevokeFamily(familyID uuid.UUID) error {
+	return r.DB.Model(&models.RefreshToken{}).
+		Where("family_id = ? AND status = ?", familyID, models.TokenStatusActive).
+		Updates(map[string]interface{}{
+			"status":     models.TokenStatusRevoked,
+			"updated_at": time.Now(),
+		}).Error
+}
+
+// RevokeAllUserTokens revokes every active refresh token of a user
+func (r *RefreshTokenRepository) RevokeAllUserTokens(userID uuid.UUID) error {
+	return r.DB.Model(&models.RefreshToken{}).
+		Where("user_id = ? AND status = ?", userID, models.TokenStatusActive).
+		Updates(map[string]interface{}{
+			"status":     models.TokenStatusRevoked,
+			"updated_at": time.Now(),
+		}).Error
+}
+
+// RevokeAllUserTokensExceptFamily revokes every active refresh token of a user outside the given family
+func (r *RefreshTokenRepository) RevokeAllUserTokensExceptFamily(userID uuid.UUID, familyID uuid.UUID) error {
+	return r.DB.Model(&models.RefreshToken{}).
+		Where("user_id = ? AND family_id <> ? AND status = ?", userID, familyID, models.TokenStatusActive).
+		Updates(map[string]interface{}{
+			"status":     models.TokenStatusRevoked,
+			"updated_at": time.Now(),
+		}).Error
+}
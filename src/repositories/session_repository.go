@@ -0,0 +1,130 @@
+package repositories
+
+import (
+	"errors"
+	"time"
+
+	"github.com/Barba2k2/aurora_backend/src/models"
+	"github.com/google/uuid"
+	"github.com/jinzhu/gorm"
+)
+
+// Common errors related to sessions
+var (
+	ErrSessionNotFound = errors.New("session not found")
+)
+
+// SessionRepositoryInterface defines the interface for accessing session data
+type SessionRepositoryInterface interface {
+	Create(session *models.Session) error
+	FindByID(id uuid.UUID) (*models.Session, error)
+	FindActiveByUser(userID uuid.UUID) ([]*models.Session, error)
+	Touch(id uuid.UUID, ipAddress, userAgent string, expiresAt time.Time) error
+	UpdateEstablishment(id uuid.UUID, establishmentID *uuid.UUID) error
+	Revoke(id uuid.UUID) error
+	RevokeAllByUser(userID uuid.UUID, exceptID *uuid.UUID) error
+}
+
+// SessionRepository implements the SessionRepositoryInterface
+type SessionRepository struct {
+	DB *gorm.DB
+}
+
+// NewSessionRepository creates a new instance of SessionRepository
+func NewSessionRepository(db *gorm.DB) SessionRepositoryInterface {
+	return &SessionRepository{DB: db}
+}
+
+// Create creates a new session in the database
+func (r *SessionRepository) Create(session *models.Session) error {
+	// We define creation/update timestamps
+	now := time.Now()
+	session.CreatedAt = now
+	session.UpdatedAt = now
+	session.LastSeenAt = now
+
+	// We create the session
+	return r.DB.Create(session).Error
+}
+
+// FindByID finds a session by ID
+func (r *SessionRepository) FindByID(id uuid.UUID) (*models.Session, error) {
+	var session models.Session
+
+	if err := r.DB.Where("id = ?", id).First(&session).Error; err != nil {
+		if gorm.IsRecordNotFoundError(err) {
+			return nil, ErrSessionNotFound
+		}
+		return nil, err
+	}
+
+	return &session, nil
+}
+
+// FindActiveByUser returns the sessions of a user that were not revoked nor expired
+func (r *SessionRepository) FindActiveByUser(userID uuid.UUID) ([]*models.Session, error) {
+	var sessions []*models.Session
+
+	if err := r.DB.Where("user_id = ? AND revoked_at IS NULL AND expires_at > ?", userID, time.Now()).
+		Order("last_seen_at DESC").
+		Find(&sessions).Error; err != nil {
+		return nil, err
+	}
+
+	return sessions, nil
+}
+
+// Touch records activity on a session and extends its expiration
+func (r *SessionRepository) Touch(id uuid.UUID, ipAddress, userAgent string, expiresAt time.Time) error {
+	now := time.Now()
+	updates := map[string]interface{}{
+		"last_seen_at": now,
+		"updated_at":   now,
+	}
+	if ipAddress != "" {
+		updates["ip_address"] = ipAddress
+	}
+	if userAgent != "" {
+		updates["user_agent"] = userAgent
+	}
+	if !expiresAt.IsZero() {
+		updates["expires_at"] = expiresAt
+	}
+
+	return r.DB.Model(&models.Session{}).Where("id = ?", id).Updates(updates).Error
+}
+
+// UpdateEstablishment changes the establishment a session acts on
+func (r *SessionRepository) UpdateEstablishment(id uuid.UUID, establishmentID *uuid.UUID) error {
+	return r.DB.Model(&models.Session{}).Where("id = ?", id).Updates(map[string]interface{}{
+		"establishment_id": establishmentID,
+		"updated_at":       time.Now(),
+	}).Error
+}
+
+// Revoke revokes a specific session
+func (r *SessionRepository) Revoke(id uuid.UUID) error {
+	now := time.Now()
+
+	return r.DB.Model(&models.Session{}).
+		Where("id = ? AND revoked_at IS NULL", id).
+		Updates(map[string]interface{}{
+			"revoked_at": now,
+			"updated_at": now,
+		}).Error
+}
+
+// RevokeAllByUser revokes all sessions of a user, optionally keeping one of them
+func (r *SessionRepository) RevokeAllByUser(userID uuid.UUID, exceptID *uuid.UUID) error {
+	now := time.Now()
+
+	query := r.DB.Model(&models.Session{}).Where("user_id = ? AND revoked_at IS NULL", userID)
+	if exceptID != nil {
+		query = query.Where("id <> ?", *exceptID)
+	}
+
+	return query.Updates(map[string]interface{}{
+		"revoked_at": now,
+		"updated_at": now,
+	}).Error
+}
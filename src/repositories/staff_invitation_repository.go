@@ -0,0 +1,156 @@
+package repositories
+
+import (
+	"errors"
+	"time"
+
+	"github.com/Barba2k2/aurora_backend/src/models"
+	"github.com/google/uuid"
+	"github.com/jinzhu/gorm"
+)
+
+// Common errors related to staff invitations
+var (
+	ErrStaffInvitationNotFound = errors.New("staff invitation not found")
+)
+
+// StaffInvitationRepositoryInterface defines the interface for accessing staff invitation data
+type StaffInvitationRepositoryInterface interface {
+	Create(invitation *models.StaffInvitation) error
+	FindByID(establishmentID, id uuid.UUID) (*models.StaffInvitation, error)
+	FindByTokenHash(tokenHash string) (*models.StaffInvitation, error)
+	FindPendingByEstablishment(establishmentID uuid.UUID) ([]*models.StaffInvitation, error)
+	Accept(id uuid.UUID, newUser *models.User, member *models.EstablishmentMember) error
+	Revoke(id uuid.UUID) error
+	RevokePendingByContact(establishmentID uuid.UUID, email, phone string) error
+}
+
+// StaffInvitationRepository implements the StaffInvitationRepositoryInterface
+type StaffInvitationRepository struct {
+	DB *gorm.DB
+}
+
+// NewStaffInvitationRepository creates a new instance of StaffInvitationRepository
+func NewStaffInvitationRepository(db *gorm.DB) StaffInvitationRepositoryInterface {
+	return &StaffInvitationRepository{DB: db}
+}
+
+// Create creates a new invitation
+func (r *StaffInvitationRepository) Create(invitation *models.StaffInvitation) error {
+	// We define creation/update timestamps
+	now := time.Now()
+	invitation.CreatedAt = now
+	invitation.UpdatedAt = now
+
+	return r.DB.Create(invitation).Error
+}
+
+// FindByID finds an invitation of an establishment by ID
+func (r *StaffInvitationRepository) FindByID(establishmentID, id uuid.UUID) (*models.StaffInvitation, error) {
+	var invitation models.StaffInvitation
+
+	if err := r.DB.Where("id = ? AND establishment_id = ?", id, establishmentID).First(&invitation).Error; err != nil {
+		if gorm.IsRecordNotFoundError(err) {
+			return nil, ErrStaffInvitationNotFound
+		}
+		return nil, err
+	}
+
+	return &invitation, nil
+}
+
+// FindByTokenHash finds an invitation by the hash of its token
+func (r *StaffInvitationRepository) FindByTokenHash(tokenHash string) (*models.StaffInvitation, error) {
+	var invitation models.StaffInvitation
+
+	if err := r.DB.Where("token_hash = ?", tokenHash).First(&invitation).Error; err != nil {
+		if gorm.IsRecordNotFoundError(err) {
+			return nil, ErrStaffInvitationNotFound
+		}
+		return nil, err
+	}
+
+	return &invitation, nil
+}
+
+// FindPendingByEstablishment returns the invitations of an establishment that were not accepted, revoked nor expired
+func (r *StaffInvitationRepository) FindPendingByEstablishment(establishmentID uuid.UUID) ([]*models.StaffInvitation, error) {
+	var invitations []*models.StaffInvitation
+
+	if err := r.DB.Where("establishment_id = ? AND status = ? AND expires_at > ?", establishmentID, models.InvitationStatusPending, time.Now()).
+		Order("created_at DESC").
+		Find(&invitations).Error; err != nil {
+		return nil, err
+	}
+
+	return invitations, nil
+}
+
+// MarkAsAccepted marks an invitation as accepted by a user
+func (r *StaffInvitationRepository) MarkAsAccepted(id, userID uuid.UUID) error {
+	now := time.Now()
+
+	result := r.DB.Model(&models.StaffInvitation{}).
+		Where("id = ? AND status = ?", id, models.InvitationStatusPending).
+		Updates(map[string]interface{}{
+			"status":      models.InvitationStatusAccepted,
+			"accepted_at": now,
+			"accepted_by": userID,
+			"updated_at":  now,
+		})
+	if result.Error != nil {
+		return result.Error
+	}
+	// Another request accepted the invitation first
+	if result.RowsAffected == 0 {
+		return ErrStaffInvitationNotFound
+	}
+
+	return nil
+}
+
+// Accept marks an invitation as accepted and adds the membership in a single transaction,
+// creating the account of the invitee first when newUser is given.
+// A failure in any step leaves the invitation pending, so it can be accepted again.
+func (r *StaffInvitationRepository) Accept(id uuid.UUID, newUser *models.User, member *models.EstablishmentMember) error {
+	return r.DB.Transaction(func(tx *gorm.DB) error {
+		// Marking the invitation first locks its row, so concurrent accepts wait and then find it accepted
+		if err := (&StaffInvitationRepository{DB: tx}).MarkAsAccepted(id, member.UserID); err != nil {
+			return err
+		}
+
+		if newUser != nil {
+			if err := (&UserRepositoryImpl{DB: tx}).Create(newUser); err != nil {
+				return err
+			}
+		}
+
+		return (&EstablishmentMemberRepository{DB: tx}).Create(member)
+	})
+}
+
+// Revoke revokes a pending invitation
+func (r *StaffInvitationRepository) Revoke(id uuid.UUID) error {
+	return r.DB.Model(&models.StaffInvitation{}).
+		Where("id = ? AND status = ?", id, models.InvitationStatusPending).
+		Updates(map[string]interface{}{
+			"status":     models.InvitationStatusRevoked,
+			"updated_at": time.Now(),
+		}).Error
+}
+
+// RevokePendingByContact revokes the pending invitations of an establishment sent to the same email or phone
+func (r *StaffInvitationRepository) RevokePendingByContact(establishmentID uuid.UUID, email, phone string) error {
+	query := r.DB.Model(&models.StaffInvitation{}).
+		Where("establishment_id = ? AND status = ?", establishmentID, models.InvitationStatusPending)
+	if email != "" {
+		query = query.Where("LOWER(email) = LOWER(?)", email)
+	} else {
+		query = query.Where("phone = ?", phone)
+	}
+
+	return query.Updates(map[string]interface{}{
+		"status":     models.InvitationStatusRevoked,
+		"updated_at": time.Now(),
+	}).Error
+}
@@ -1,12 +1,14 @@
 package repositories
 
 import (
+	"database/sql"
 	"errors"
 	"time"
 
 	"github.com/Barba2k2/aurora_backend/src/models"
 	"github.com/google/uuid"
 	"github.com/jinzhu/gorm"
+	"github.com/lib/pq"
 )
 
 // Common errors related to users
@@ -23,13 +25,26 @@ type UserRepository interface {
 	FindByID(id uuid.UUID) (*models.User, error)
 	FindByEmail(email string) (*models.User, error)
 	FindByPhone(phone string) (*models.User, error)
+	FindByIDAnyStatus(id uuid.UUID) (*models.User, error)
+	FindByEmailAnyStatus(email string) (*models.User, error)
+	FindByPhoneAnyStatus(phone string) (*models.User, error)
 	Update(user *models.User) error
+	UpdateColumns(id uuid.UUID, columns map[string]interface{}) error
 	Delete(id uuid.UUID, deletedBy uuid.UUID) error
 	
+	// Deleted accounts, until their personal data is erased
+	FindDeleted(page, limit int) ([]*models.User, int64, error)
+	FindDeletedByID(id uuid.UUID) (*models.User, error)
+	FindDeletedByEmail(email string) (*models.User, error)
+	
 	// Authentication operations
 	UpdateLastLogin(id uuid.UUID) error
-	IncrementFailedLoginCount(id uuid.UUID) error
+	UpdatePasswordHash(id uuid.UUID, passwordHash string) error
+	IncrementFailedLoginCount(id uuid.UUID) (int, error)
 	ResetFailedLoginCount(id uuid.UUID) error
+	Lock(id uuid.UUID, until time.Time, minFailedLogins int) (bool, error)
+	Unlock(id uuid.UUID) error
+	AdvanceTOTPCounter(id uuid.UUID, counter int64) (bool, error)
 	
 	// For clients
 	FindAllClients(page, limit int, filters map[string]interface{}) ([]*models.User, int64, error)
@@ -40,6 +55,7 @@ type UserRepository interface {
 	// For establishments
 	CreateEstablishment(establishment *models.Establishment) error
 	FindEstablishmentByUserID(userID uuid.UUID) (*models.Establishment, error)
+	FindEstablishmentByID(id uuid.UUID) (*models.Establishment, error)
 	UpdateEstablishment(establishment *models.Establishment) error
 }
 
@@ -55,9 +71,10 @@ func NewUserRepository(db *gorm.DB) UserRepository {
 
 // Create creates a new user in the database
 func (r *UserRepositoryImpl) Create(user *models.User) error {
-	// We check if a user with this email already exists
+	// We check if a user with this email already exists, including soft-deleted users,
+	// whose rows still hold the email in the unique index
 	var count int
-	if err := r.DB.Model(&models.User{}).Where("email = ?", user.Email).Count(&count).Error; err != nil {
+	if err := r.DB.Unscoped().Model(&models.User{}).Where("email = ?", user.Email).Count(&count).Error; err != nil {
 		return err
 	}
 	if count > 0 {
@@ -80,8 +97,15 @@ func (r *UserRepositoryImpl) Create(user *models.User) error {
 	user.CreatedAt = now
 	user.UpdatedAt = now
 	
-	// We create the user
-	return r.DB.Create(user).Error
+	// We create the user; a concurrent registration with the same email hits the unique index
+	if err := r.DB.Create(user).Error; err != nil {
+		if pqErr, ok := err.(*pq.Error); ok && pqErr.Code == "23505" {
+			return ErrUserAlreadyExists
+		}
+		return err
+	}
+	
+	return nil
 }
 
 // FindByID finds a user by ID
@@ -129,6 +153,48 @@ func (r *UserRepositoryImpl) FindByPhone(phone string) (*models.User, error) {
 	return &user, nil
 }
 
+// FindByIDAnyStatus finds a non-deleted user by ID regardless of status
+func (r *UserRepositoryImpl) FindByIDAnyStatus(id uuid.UUID) (*models.User, error) {
+	var user models.User
+	
+	if err := r.DB.Where("id = ? AND deleted_at IS NULL", id).First(&user).Error; err != nil {
+		if gorm.IsRecordNotFoundError(err) {
+			return nil, ErrUserNotFound
+		}
+		return nil, err
+	}
+	
+	return &user, nil
+}
+
+// FindByEmailAnyStatus finds a non-deleted user by email regardless of status
+func (r *UserRepositoryImpl) FindByEmailAnyStatus(email string) (*models.User, error) {
+	var user models.User
+	
+	if err := r.DB.Where("email = ? AND deleted_at IS NULL", email).First(&user).Error; err != nil {
+		if gorm.IsRecordNotFoundError(err) {
+			return nil, ErrUserNotFound
+		}
+		return nil, err
+	}
+	
+	return &user, nil
+}
+
+// FindByPhoneAnyStatus finds a non-deleted user by phone number regardless of status
+func (r *UserRepositoryImpl) FindByPhoneAnyStatus(phone string) (*models.User, error) {
+	var user models.User
+	
+	if err := r.DB.Where("phone = ? AND deleted_at IS NULL", phone).First(&user).Error; err != nil {
+		if gorm.IsRecordNotFoundError(err) {
+			return nil, ErrUserNotFound
+		}
+		return nil, err
+	}
+	
+	return &user, nil
+}
+
 // Update updates a user's data
 func (r *UserRepositoryImpl) Update(user *models.User) error {
 	// We update the timestamp
@@ -146,6 +212,21 @@ func (r *UserRepositoryImpl) Update(user *models.User) error {
 	return r.DB.Save(user).Error
 }
 
+// UpdateColumns writes only the given columns, leaving the rest of the row as stored.
+// Flows that own a few fields use it so they never overwrite counters changed concurrently, like the lockout ones.
+func (r *UserRepositoryImpl) UpdateColumns(id uuid.UUID, columns map[string]interface{}) error {
+	columns["updated_at"] = time.Now()
+	result := r.DB.Model(&models.User{}).Where("id = ?", id).Updates(columns)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrUserNotFound
+	}
+	
+	return nil
+}
+
 // Delete performs a soft delete of the user
 func (r *UserRepositoryImpl) Delete(id uuid.UUID, deletedBy uuid.UUID) error {
 	// We check if the user exists
@@ -167,6 +248,57 @@ func (r *UserRepositoryImpl) Delete(id uuid.UUID, deletedBy uuid.UUID) error {
 	}).Error
 }
 
+// FindDeleted returns soft-deleted users whose personal data was not erased yet, most recently deleted first
+func (r *UserRepositoryImpl) FindDeleted(page, limit int) ([]*models.User, int64, error) {
+	var users []*models.User
+	var total int64
+	
+	// Soft-deleted rows are hidden by default, so the query must be unscoped
+	query := r.DB.Unscoped().Model(&models.User{}).
+		Where("deleted_at IS NOT NULL AND anonymized_at IS NULL")
+	
+	// Count the total number of records
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+	
+	// Apply pagination
+	offset := (page - 1) * limit
+	if err := query.Order("deleted_at DESC").Offset(offset).Limit(limit).Find(&users).Error; err != nil {
+		return nil, 0, err
+	}
+	
+	return users, total, nil
+}
+
+// FindDeletedByID finds a soft-deleted user whose personal data was not erased yet
+func (r *UserRepositoryImpl) FindDeletedByID(id uuid.UUID) (*models.User, error) {
+	var user models.User
+	
+	if err := r.DB.Unscoped().Where("id = ? AND deleted_at IS NOT NULL AND anonymized_at IS NULL", id).First(&user).Error; err != nil {
+		if gorm.IsRecordNotFoundError(err) {
+			return nil, ErrUserNotFound
+		}
+		return nil, err
+	}
+	
+	return &user, nil
+}
+
+// FindDeletedByEmail finds a soft-deleted user by email whose personal data was not erased yet
+func (r *UserRepositoryImpl) FindDeletedByEmail(email string) (*models.User, error) {
+	var user models.User
+	
+	if err := r.DB.Unscoped().Where("email = ? AND deleted_at IS NOT NULL AND anonymized_at IS NULL", email).First(&user).Error; err != nil {
+		if gorm.IsRecordNotFoundError(err) {
+			return nil, ErrUserNotFound
+		}
+		return nil, err
+	}
+	
+	return &user, nil
+}
+
 // UpdateLastLogin updates the last login timestamp
 func (r *UserRepositoryImpl) UpdateLastLogin(id uuid.UUID) error {
 	now := time.Now()
@@ -176,15 +308,31 @@ func (r *UserRepositoryImpl) UpdateLastLogin(id uuid.UUID) error {
 	}).Error
 }
 
-// IncrementFailedLoginCount increments the failed login counter
-func (r *UserRepositoryImpl) IncrementFailedLoginCount(id uuid.UUID) error {
-	return r.DB.Model(&models.User{}).Where("id = ?", id).
-		UpdateColumn("failed_login_count", gorm.Expr("failed_login_count + 1")).
-		UpdateColumn("updated_at", time.Now()).
-		Error
+// UpdatePasswordHash replaces the stored password hash, used to upgrade hashes of an outdated algorithm
+func (r *UserRepositoryImpl) UpdatePasswordHash(id uuid.UUID, passwordHash string) error {
+	return r.DB.Model(&models.User{}).Where("id = ?", id).Updates(map[string]interface{}{
+		"password_hash": passwordHash,
+		"updated_at":    time.Now(),
+	}).Error
+}
+
+// IncrementFailedLoginCount atomically increments the failed login counter and returns its new value,
+// so concurrent failures never read a stale count
+func (r *UserRepositoryImpl) IncrementFailedLoginCount(id uuid.UUID) (int, error) {
+	var count int
+	
+	row := r.DB.Raw("UPDATE users SET failed_login_count = failed_login_count + 1, updated_at = ? WHERE id = ? RETURNING failed_login_count", time.Now(), id).Row()
+	if err := row.Scan(&count); err != nil {
+		if err == sql.ErrNoRows {
+			return 0, ErrUserNotFound
+		}
+		return 0, err
+	}
+	
+	return count, nil
 }
 
-// ResetFailedLoginCount resets the failed login counter
+// ResetFailedLoginCount resets the failed login counter; the lockout history is kept to escalate repeat lockouts
 func (r *UserRepositoryImpl) ResetFailedLoginCount(id uuid.UUID) error {
 	return r.DB.Model(&models.User{}).Where("id = ?", id).Updates(map[string]interface{}{
 		"failed_login_count": 0,
@@ -192,6 +340,50 @@ func (r *UserRepositoryImpl) ResetFailedLoginCount(id uuid.UUID) error {
 	}).Error
 }
 
+// Lock locks the account until the given time, counting one more lockout, if it has at least minFailedLogins failures.
+// The condition makes concurrent failures lock the account only once; it reports whether this call locked it.
+func (r *UserRepositoryImpl) Lock(id uuid.UUID, until time.Time, minFailedLogins int) (bool, error) {
+	result := r.DB.Model(&models.User{}).
+		Where("id = ? AND failed_login_count >= ?", id, minFailedLogins).
+		Updates(map[string]interface{}{
+			"locked_until":       until,
+			"lockout_count":      gorm.Expr("lockout_count + 1"),
+			"failed_login_count": 0,
+			"updated_at":         time.Now(),
+		})
+	if result.Error != nil {
+		return false, result.Error
+	}
+	
+	return result.RowsAffected > 0, nil
+}
+
+// Unlock lifts a lock and clears the failed login counters
+func (r *UserRepositoryImpl) Unlock(id uuid.UUID) error {
+	return r.DB.Model(&models.User{}).Where("id = ?", id).Updates(map[string]interface{}{
+		"locked_until":       nil,
+		"lockout_count":      0,
+		"failed_login_count": 0,
+		"updated_at":         time.Now(),
+	}).Error
+}
+
+// AdvanceTOTPCounter records the time step of an accepted TOTP code if it is newer than the last one.
+// The condition makes a code usable only once even under concurrent requests; it reports whether the step was recorded.
+func (r *UserRepositoryImpl) AdvanceTOTPCounter(id uuid.UUID, counter int64) (bool, error) {
+	result := r.DB.Model(&models.User{}).
+		Where("id = ? AND totp_last_counter < ?", id, counter).
+		Updates(map[string]interface{}{
+			"totp_last_counter": counter,
+			"updated_at":        time.Now(),
+		})
+	if result.Error != nil {
+		return false, result.Error
+	}
+	
+	return result.RowsAffected > 0, nil
+}
+
 // FindAllClients returns all clients with pagination and filters
 func (r *UserRepositoryImpl) FindAllClients(page, limit int, filters map[string]interface{}) ([]*models.User, int64, error) {
 	var users []*models.User
@@ -280,6 +472,20 @@ func (r *UserRepositoryImpl) FindEstablishmentByUserID(userID uuid.UUID) (*model
 	return &establishment, nil
 }
 
+// FindEstablishmentByID finds an active establishment by ID
+func (r *UserRepositoryImpl) FindEstablishmentByID(id uuid.UUID) (*models.Establishment, error) {
+	var establishment models.Establishment
+	
+	if err := r.DB.Where("id = ? AND status = ?", id, models.UserStatusActive).First(&establishment).Error; err != nil {
+		if gorm.IsRecordNotFoundError(err) {
+			return nil, ErrUserNotFound
+		}
+		return nil, err
+	}
+	
+	return &establishment, nil
+}
+
 // UpdateEstablishment updates an establishment's data
 func (r *UserRepositoryImpl) UpdateEstablishment(establishment *models.Establishment) error {
 	// Update the timestamp
@@ -0,0 +1,188 @@
+package repositories
+
+import (
+	"errors"
+	"time"
+
+	"github.com/Barba2k2/aurora_backend/src/models"
+	"github.com/google/uuid"
+	"github.com/jinzhu/gorm"
+)
+
+// Common errors related to WebAuthn
+var (
+	ErrCredentialNotFound        = errors.New("webauthn credential not found")
+	ErrCredentialAlreadyExists   = errors.New("webauthn credential already exists")
+	ErrChallengeNotFound         = errors.New("webauthn challenge not found")
+	ErrCredentialSignCountRaised = errors.New("webauthn credential sign count changed concurrently")
+)
+
+// WebAuthnRepositoryInterface defines the interface for accessing WebAuthn credentials and challenges
+type WebAuthnRepositoryInterface interface {
+	// Credentials
+	CreateCredential(credential *models.WebAuthnCredential) error
+	FindCredentialByCredentialID(credentialID string) (*models.WebAuthnCredential, error)
+	FindCredentialsByUser(userID uuid.UUID) ([]*models.WebAuthnCredential, error)
+	UpdateSignCount(id uuid.UUID, previousCount, newCount int64) error
+	DeleteCredential(id uuid.UUID, userID uuid.UUID) error
+	DeleteCredentialsCreatedSince(userID uuid.UUID, since time.Time) ([]*models.WebAuthnCredential, error)
+
+	// Challenges
+	CreateChallenge(challenge *models.WebAuthnChallenge) error
+	ConsumeChallenge(challenge string, ceremony models.WebAuthnCeremony) (*models.WebAuthnChallenge, error)
+}
+
+// WebAuthnRepository implements the WebAuthnRepositoryInterface
+type WebAuthnRepository struct {
+	DB *gorm.DB
+}
+
+// NewWebAuthnRepository creates a new instance of WebAuthnRepository
+func NewWebAuthnRepository(db *gorm.DB) WebAuthnRepositoryInterface {
+	return &WebAuthnRepository{DB: db}
+}
+
+// CreateCredential stores a newly registered credential
+func (r *WebAuthnRepository) CreateCredential(credential *models.WebAuthnCredential) error {
+	// We check if the credential was already registered
+	var count int
+	if err := r.DB.Model(&models.WebAuthnCredential{}).Where("credential_id = ?", credential.CredentialID).Count(&count).Error; err != nil {
+		return err
+	}
+	if count > 0 {
+		return ErrCredentialAlreadyExists
+	}
+
+	// We define creation/update timestamps
+	now := time.Now()
+	credential.CreatedAt = now
+	credential.UpdatedAt = now
+
+	return r.DB.Create(credential).Error
+}
+
+// FindCredentialByCredentialID finds a credential by the ID assigned by the authenticator
+func (r *WebAuthnRepository) FindCredentialByCredentialID(credentialID string) (*models.WebAuthnCredential, error) {
+	var credential models.WebAuthnCredential
+
+	if err := r.DB.Where("credential_id = ?", credentialID).First(&credential).Error; err != nil {
+		if gorm.IsRecordNotFoundError(err) {
+			return nil, ErrCredentialNotFound
+		}
+		return nil, err
+	}
+
+	return &credential, nil
+}
+
+// FindCredentialsByUser returns all credentials of a user
+func (r *WebAuthnRepository) FindCredentialsByUser(userID uuid.UUID) ([]*models.WebAuthnCredential, error) {
+	var credentials []*models.WebAuthnCredential
+
+	if err := r.DB.Where("user_id = ?", userID).Order("created_at").Find(&credentials).Error; err != nil {
+		return nil, err
+	}
+
+	return credentials, nil
+}
+
+// UpdateSignCount stores the new signature counter and last use of a credential.
+// The previous value is part of the condition so concurrent assertions cannot both succeed.
+func (r *WebAuthnRepository) UpdateSignCount(id uuid.UUID, previousCount, newCount int64) error {
+	now := time.Now()
+
+	result := r.DB.Model(&models.WebAuthnCredential{}).
+		Where("id = ? AND sign_count = ?", id, previousCount).
+		Updates(map[string]interface{}{
+			"sign_count":   newCount,
+			"last_used_at": now,
+			"updated_at":   now,
+		})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrCredentialSignCountRaised
+	}
+
+	return nil
+}
+
+// DeleteCredential removes a credential owned by the user
+func (r *WebAuthnRepository) DeleteCredential(id uuid.UUID, userID uuid.UUID) error {
+	result := r.DB.Where("id = ? AND user_id = ?", id, userID).Delete(&models.WebAuthnCredential{})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrCredentialNotFound
+	}
+
+	return nil
+}
+
+// DeleteCredentialsCreatedSince removes the credentials a user registered at or after the given time and returns them
+func (r *WebAuthnRepository) DeleteCredentialsCreatedSince(userID uuid.UUID, since time.Time) ([]*models.WebAuthnCredential, error) {
+	var credentials []*models.WebAuthnCredential
+
+	err := r.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("user_id = ? AND created_at >= ?", userID, since).Find(&credentials).Error; err != nil {
+			return err
+		}
+		if len(credentials) == 0 {
+			return nil
+		}
+
+		ids := make([]uuid.UUID, 0, len(credentials))
+		for _, credential := range credentials {
+			ids = append(ids, credential.ID)
+		}
+		return tx.Where("id IN (?)", ids).Delete(&models.WebAuthnCredential{}).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return credentials, nil
+}
+
+// CreateChallenge stores a new ceremony challenge
+func (r *WebAuthnRepository) CreateChallenge(challenge *models.WebAuthnChallenge) error {
+	// We define creation/update timestamps
+	now := time.Now()
+	challenge.CreatedAt = now
+	challenge.UpdatedAt = now
+
+	return r.DB.Create(challenge).Error
+}
+
+// ConsumeChallenge finds a pending, unexpired challenge and marks it as used
+func (r *WebAuthnRepository) ConsumeChallenge(challenge string, ceremony models.WebAuthnCeremony) (*models.WebAuthnChallenge, error) {
+	var record models.WebAuthnChallenge
+
+	now := time.Now()
+	if err := r.DB.Where("challenge = ? AND ceremony = ? AND used_at IS NULL AND expires_at > ?", challenge, ceremony, now).
+		First(&record).Error; err != nil {
+		if gorm.IsRecordNotFoundError(err) {
+			return nil, ErrChallengeNotFound
+		}
+		return nil, err
+	}
+
+	// The condition on used_at makes the challenge single use under concurrency
+	result := r.DB.Model(&models.WebAuthnChallenge{}).
+		Where("id = ? AND used_at IS NULL", record.ID).
+		Updates(map[string]interface{}{
+			"used_at":    now,
+			"updated_at": now,
+		})
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	if result.RowsAffected == 0 {
+		return nil, ErrChallengeNotFound
+	}
+
+	record.UsedAt = &now
+	return &record, nil
+}
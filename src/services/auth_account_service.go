@@ -0,0 +1,184 @@
+package services
+
+import (
+	"strings"
+	"time"
+
+	"github.com/Barba2k2/aurora_backend/src/models"
+	"github.com/Barba2k2/aurora_backend/src/repositories"
+	"github.com/google/uuid"
+)
+
+// ChangePasswordRequest representa os dados de requisição para troca de senha pelo usuário autenticado
+type ChangePasswordRequest struct {
+	CurrentPassword string `json:"current_password" validate:"required"`
+	Password        string `json:"password" validate:"required"`
+	ConfirmPassword string `json:"confirm_password" validate:"required,eqfield=Password"`
+	ClientIP        string `json:"-"`
+	UserAgent       string `json:"-"`
+}
+
+// ChangeEmailRequest representa os dados de requisição para troca de email pelo usuário autenticado
+type ChangeEmailRequest struct {
+	NewEmail  string `json:"new_email" validate:"required,email"`
+	Password  string `json:"password" validate:"required"`
+	ClientIP  string `json:"-"`
+	UserAgent string `json:"-"`
+}
+
+// ConfirmEmailChangeRequest representa os dados de requisição para confirmação do novo email
+type ConfirmEmailChangeRequest struct {
+	Token     string `json:"token" validate:"required"`
+	ClientIP  string `json:"-"`
+	UserAgent string `json:"-"`
+}
+
+// ChangePassword troca a senha do usuário autenticado e encerra as demais sessões
+func (s *AuthService) ChangePassword(user *models.User, currentSessionID uuid.UUID, req ChangePasswordRequest) error {
+	// Exigimos a senha atual, ja que o token de acesso pode ter sido obtido por terceiros
+	if err := s.PasswordUtil.VerifyPassword(user.PasswordHash, req.CurrentPassword); err != nil {
+		return ErrInvalidLogin
+	}
+
+	// Verificamos se a senhas sao iguais
+	if req.Password != req.ConfirmPassword {
+		return ErrPasswordConfirmation
+	}
+
+	// Validamos a nova senha, que nao pode repetir as ultimas senhas do usuario
+	if err := s.setPassword(user, req.Password); err != nil {
+		return err
+	}
+
+	if err := s.UserRepo.UpdateColumns(user.ID, passwordColumns(user)); err != nil {
+		return err
+	}
+
+	// Links de recuperacao pendentes deixam de fazer sentido
+	if err := s.TokenRepo.InvalidateUserTokensByPurpose(user.ID, models.TokenPurposePasswordReset); err != nil {
+		return err
+	}
+
+	s.recordUserEvent(models.AuthEventPasswordChanged, user, req.ClientIP, req.UserAgent, "")
+
+	// Mantemos apenas a sessao que realizou a troca
+	return s.RevokeOtherSessions(user.ID, currentSessionID)
+}
+
+// RequestEmailChange envia um link de confirmação para o novo email e avisa o email atual.
+// O email do usuário só é alterado quando o link é confirmado.
+func (s *AuthService) RequestEmailChange(user *models.User, req ChangeEmailRequest) error {
+	if err := s.PasswordUtil.VerifyPassword(user.PasswordHash, req.Password); err != nil {
+		return ErrInvalidLogin
+	}
+
+	newEmail := strings.TrimSpace(req.NewEmail)
+	if strings.EqualFold(newEmail, user.Email) {
+		return ErrEmailUnchanged
+	}
+
+	// Verificamos se o novo email ja pertence a outra conta
+	if _, err := s.UserRepo.FindByEmailAnyStatus(newEmail); err != repositories.ErrUserNotFound {
+		if err != nil {
+			return err
+		}
+		// No modo de privacidade nao confirmamos a existencia da outra conta
+		if s.Config.PrivacyMode {
+			return nil
+		}
+		return ErrEmailAlreadyInUse
+	}
+
+	// Verificamos o rate limit
+	count, err := s.TokenRepo.CountTokensByUserAndPurpose(user.ID, models.TokenPurposeEmailChange, s.Config.ResetTokenRateWindow)
+	if err != nil {
+		return err
+	}
+	if count >= s.Config.ResetTokenRateLimit {
+		return ErrTooManyRequests
+	}
+
+	// Apenas a solicitacao mais recente continua valida
+	if err := s.TokenRepo.InvalidateUserTokensByPurpose(user.ID, models.TokenPurposeEmailChange); err != nil {
+		return err
+	}
+
+	// Criamos o registro do token, referenciado pelo ID do link assinado
+	tokenID := uuid.New()
+	expiresAt := time.Now().Add(s.Config.EmailChangeExpiration)
+	token := &models.PasswordResetToken{
+		UserID:    user.ID,
+		TokenHash: s.PasswordUtil.HashOneTimeToken(tokenID.String()),
+		Channel:   models.TokenChannelEmail,
+		Purpose:   models.TokenPurposeEmailChange,
+		Status:    models.TokenStatusActive,
+		ExpiresAt: expiresAt,
+		IPAddress: req.ClientIP,
+		UserAgent: req.UserAgent,
+	}
+
+	if err := s.TokenRepo.Create(token); err != nil {
+		return err
+	}
+
+	signedToken, err := s.JWTUtil.GenerateEmailChangeToken(user.ID, tokenID, newEmail, expiresAt)
+	if err != nil {
+		return err
+	}
+
+	// O link vai para o novo endereco, comprovando que o usuario o possui
+	if err := s.EmailService.SendEmailChangeEmail(newEmail, user.Name, signedToken); err != nil {
+		return err
+	}
+
+	// O email atual e avisado para que o dono perceba uma troca nao autorizada
+	return s.EmailService.SendEmailChangeNoticeEmail(user.Email, user.Name, newEmail)
+}
+
+// ConfirmEmailChange confirma o novo email com o link recebido e o torna o email da conta
+func (s *AuthService) ConfirmEmailChange(user *models.User, req ConfirmEmailChangeRequest) (*models.User, error) {
+	// Validamos a assinatura do link
+	claims, err := s.JWTUtil.ValidateEmailChangeToken(req.Token)
+	if err != nil {
+		return nil, ErrInvalidToken
+	}
+
+	// O ID do token assinado corresponde ao registro de uso único
+	token, err := s.findTokenByValue(claims.Id)
+	if err != nil {
+		return nil, err
+	}
+
+	if !token.IsValid() || token.Purpose != models.TokenPurposeEmailChange || token.UserID != user.ID || claims.UserID != user.ID {
+		return nil, ErrInvalidToken
+	}
+
+	// O email pode ter sido usado por outro cadastro depois da solicitacao
+	if _, err := s.UserRepo.FindByEmailAnyStatus(claims.Email); err != repositories.ErrUserNotFound {
+		if err != nil {
+			return nil, err
+		}
+		return nil, ErrEmailAlreadyInUse
+	}
+
+	// Consumimos o token antes de trocar o email
+	if err := s.consumeToken(token); err != nil {
+		return nil, err
+	}
+
+	// O novo email ja esta comprovado pelo proprio link
+	previousEmail := user.Email
+	now := time.Now()
+	user.Email = claims.Email
+	user.EmailVerifiedAt = &now
+	if err := s.UserRepo.UpdateColumns(user.ID, map[string]interface{}{
+		"email":             user.Email,
+		"email_verified_at": user.EmailVerifiedAt,
+	}); err != nil {
+		return nil, err
+	}
+
+	s.recordUserEvent(models.AuthEventEmailChanged, user, req.ClientIP, req.UserAgent, "previous_email="+previousEmail)
+
+	return user, nil
+}
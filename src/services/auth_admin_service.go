@@ -0,0 +1,81 @@
+package services
+
+import (
+	"github.com/Barba2k2/aurora_backend/src/models"
+	"github.com/Barba2k2/aurora_backend/src/repositories"
+	"github.com/google/uuid"
+)
+
+// ChangeUserRoleRequest representa os dados de requisição para troca do papel de um usuário
+type ChangeUserRoleRequest struct {
+	Role      models.UserRole `json:"role" validate:"required,oneof=CLIENT PROFESSIONAL STAFF ADMIN"`
+	ClientIP  string          `json:"-"`
+	UserAgent string          `json:"-"`
+}
+
+// AdminChangeUserRole troca o papel de um usuário e encerra as sessões dele,
+// já que os tokens emitidos carregam o papel anterior
+func (s *AuthService) AdminChangeUserRole(adminID, userID uuid.UUID, req ChangeUserRoleRequest) (*models.User, error) {
+	switch req.Role {
+	case models.UserRoleClient, models.UserRoleProfessional, models.UserRoleStaff, models.UserRoleAdmin:
+	default:
+		return nil, ErrInvalidRole
+	}
+
+	// Um administrador nao pode alterar o proprio papel, evitando que o sistema fique sem administradores
+	if adminID == userID {
+		return nil, ErrCannotChangeOwnRole
+	}
+
+	user, err := s.UserRepo.FindByIDAnyStatus(userID)
+	if err != nil {
+		if err == repositories.ErrUserNotFound {
+			return nil, ErrUserNotFound
+		}
+		return nil, err
+	}
+
+	if user.Role == req.Role {
+		return user, nil
+	}
+
+	// Profissionais precisam de um estabelecimento, como no cadastro
+	if req.Role == models.UserRoleProfessional {
+		if _, err := s.UserRepo.FindEstablishmentByUserID(user.ID); err != nil {
+			if err != repositories.ErrUserNotFound {
+				return nil, err
+			}
+			establishment := &models.Establishment{
+				UserID:        user.ID,
+				BussinessName: user.Name,
+				Timezone:      user.Timezone,
+				Status:        models.UserStatusActive,
+			}
+			if err := s.UserRepo.CreateEstablishment(establishment); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	previousRole := user.Role
+	user.Role = req.Role
+	if err := s.UserRepo.UpdateColumns(user.ID, map[string]interface{}{"role": user.Role}); err != nil {
+		return nil, err
+	}
+
+	if err := s.LogoutAll(user.ID); err != nil {
+		return nil, err
+	}
+
+	s.recordAuthEvent(&models.AuthEvent{
+		Type:      models.AuthEventRoleChanged,
+		UserID:    &user.ID,
+		ActorID:   &adminID,
+		Email:     user.Email,
+		Details:   "from=" + string(previousRole) + " to=" + string(req.Role),
+		IPAddress: req.ClientIP,
+		UserAgent: req.UserAgent,
+	})
+
+	return user, nil
+}
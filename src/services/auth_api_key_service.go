@@ -0,0 +1,236 @@
+package services
+
+import (
+	"crypto/hmac"
+	"strings"
+	"time"
+
+	"github.com/Barba2k2/aurora_backend/src/models"
+	"github.com/Barba2k2/aurora_backend/src/repositories"
+	"github.com/google/uuid"
+)
+
+// apiKeyPrefix identifica as chaves de API, evitando confusão com outros tokens
+const apiKeyPrefix = "ak_"
+
+// apiKeyTouchInterval evita uma escrita no banco a cada requisição feita com a chave
+const apiKeyTouchInterval = 5 * time.Minute
+
+// CreateAPIKeyRequest representa os dados de requisição para criação de uma chave de API do estabelecimento
+type CreateAPIKeyRequest struct {
+	Name   string              `json:"name" validate:"required,max=100"`
+	Scopes []models.Permission `json:"scopes" validate:"required"`
+	// ExpiresAt é opcional; sem ele a chave vale até ser revogada
+	ExpiresAt *time.Time `json:"expires_at"`
+	ClientIP  string     `json:"-"`
+	UserAgent string     `json:"-"`
+}
+
+// APIKeyResponse representa uma chave recém criada ou rotacionada.
+// Key é exibida uma única vez; depois apenas o prefixo identifica a chave.
+type APIKeyResponse struct {
+	*models.APIKey
+	Key string `json:"key"`
+}
+
+// APIKeyAuthentication contém o resultado da autenticação de uma requisição por chave de API
+type APIKeyAuthentication struct {
+	APIKey *models.APIKey
+	// Owner é o dono do estabelecimento, em nome de quem a integração age
+	Owner       *models.User
+	Permissions *PermissionSet
+}
+
+// ListAPIKeys lista as chaves ativas do estabelecimento
+func (s *AuthService) ListAPIKeys(actor *PermissionSet) ([]*models.APIKey, error) {
+	if actor.EstablishmentID == nil {
+		return nil, ErrEstablishmentNotFound
+	}
+
+	return s.APIKeyRepo.FindAllByEstablishment(*actor.EstablishmentID)
+}
+
+// CreateAPIKey cria uma chave de API para o estabelecimento.
+// Os escopos são permissões e, como nos papéis, não podem exceder as de quem cria a chave.
+func (s *AuthService) CreateAPIKey(actorID uuid.UUID, actor *PermissionSet, req CreateAPIKeyRequest) (*APIKeyResponse, error) {
+	if actor.EstablishmentID == nil {
+		return nil, ErrEstablishmentNotFound
+	}
+
+	scopes, err := validateRolePermissions(actor, req.Scopes)
+	if err != nil {
+		return nil, err
+	}
+	if len(scopes) == 0 {
+		return nil, ErrInvalidPermission
+	}
+
+	if req.ExpiresAt != nil && !req.ExpiresAt.After(time.Now()) {
+		return nil, ErrInvalidExpiration
+	}
+
+	prefix, secret, err := s.generateAPIKey()
+	if err != nil {
+		return nil, err
+	}
+
+	key := &models.APIKey{
+		EstablishmentID: *actor.EstablishmentID,
+		CreatedBy:       actorID,
+		Name:            strings.TrimSpace(req.Name),
+		Prefix:          prefix,
+		SecretHash:      s.PasswordUtil.HashOneTimeToken(secret),
+		Scopes:          scopes,
+		ExpiresAt:       req.ExpiresAt,
+	}
+	if err := s.APIKeyRepo.Create(key); err != nil {
+		return nil, err
+	}
+
+	s.recordAPIKeyEvent(models.AuthEventAPIKeyCreated, actorID, key, req.ClientIP, req.UserAgent)
+
+	return &APIKeyResponse{APIKey: key, Key: prefix + "." + secret}, nil
+}
+
+// RotateAPIKey troca o valor de uma chave mantendo nome, escopos e validade; o valor anterior deixa de valer
+func (s *AuthService) RotateAPIKey(actorID uuid.UUID, actor *PermissionSet, keyID uuid.UUID, clientIP, userAgent string) (*APIKeyResponse, error) {
+	key, err := s.findEstablishmentAPIKey(actor, keyID)
+	if err != nil {
+		return nil, err
+	}
+
+	prefix, secret, err := s.generateAPIKey()
+	if err != nil {
+		return nil, err
+	}
+
+	secretHash := s.PasswordUtil.HashOneTimeToken(secret)
+	if err := s.APIKeyRepo.UpdateSecret(key.ID, prefix, secretHash); err != nil {
+		return nil, err
+	}
+	key.Prefix = prefix
+	key.SecretHash = secretHash
+
+	s.recordAPIKeyEvent(models.AuthEventAPIKeyRotated, actorID, key, clientIP, userAgent)
+
+	return &APIKeyResponse{APIKey: key, Key: prefix + "." + secret}, nil
+}
+
+// RevokeAPIKey revoga uma chave do estabelecimento
+func (s *AuthService) RevokeAPIKey(actorID uuid.UUID, actor *PermissionSet, keyID uuid.UUID, clientIP, userAgent string) error {
+	key, err := s.findEstablishmentAPIKey(actor, keyID)
+	if err != nil {
+		return err
+	}
+
+	if err := s.APIKeyRepo.Revoke(key.ID); err != nil {
+		return err
+	}
+
+	s.recordAPIKeyEvent(models.AuthEventAPIKeyRevoked, actorID, key, clientIP, userAgent)
+
+	return nil
+}
+
+// AuthenticateAPIKey valida uma chave de API no formato "<prefixo>.<segredo>" e calcula as permissões da requisição
+func (s *AuthService) AuthenticateAPIKey(rawKey, clientIP string) (*APIKeyAuthentication, error) {
+	parts := strings.SplitN(rawKey, ".", 2)
+	if len(parts) != 2 || !strings.HasPrefix(parts[0], apiKeyPrefix) || parts[1] == "" {
+		return nil, ErrInvalidAPIKey
+	}
+
+	key, err := s.APIKeyRepo.FindByPrefix(parts[0])
+	if err != nil {
+		if err == repositories.ErrAPIKeyNotFound {
+			return nil, ErrInvalidAPIKey
+		}
+		return nil, err
+	}
+
+	// A comparacao em tempo constante nao revela quantos caracteres do segredo conferem
+	if !hmac.Equal([]byte(key.SecretHash), []byte(s.PasswordUtil.HashOneTimeToken(parts[1]))) {
+		return nil, ErrInvalidAPIKey
+	}
+
+	if !key.IsActive() {
+		return nil, ErrInvalidAPIKey
+	}
+
+	// Chaves de estabelecimentos ou donos desativados deixam de valer
+	establishment, err := s.UserRepo.FindEstablishmentByID(key.EstablishmentID)
+	if err != nil {
+		if err == repositories.ErrUserNotFound {
+			return nil, ErrInvalidAPIKey
+		}
+		return nil, err
+	}
+
+	owner, err := s.UserRepo.FindByID(establishment.UserID)
+	if err != nil {
+		if err == repositories.ErrUserNotFound {
+			return nil, ErrInvalidAPIKey
+		}
+		return nil, err
+	}
+	if owner.Status != models.UserStatusActive {
+		return nil, ErrInvalidAPIKey
+	}
+
+	// Registramos o uso com no maximo uma escrita por intervalo
+	if key.LastUsedAt == nil || time.Since(*key.LastUsedAt) > apiKeyTouchInterval {
+		s.APIKeyRepo.Touch(key.ID, clientIP)
+	}
+
+	return &APIKeyAuthentication{
+		APIKey:      key,
+		Owner:       owner,
+		Permissions: newPermissionSet(&key.EstablishmentID, toPermissions(key.Scopes)),
+	}, nil
+}
+
+// findEstablishmentAPIKey busca uma chave ativa do estabelecimento em que o responsável atua
+func (s *AuthService) findEstablishmentAPIKey(actor *PermissionSet, keyID uuid.UUID) (*models.APIKey, error) {
+	if actor.EstablishmentID == nil {
+		return nil, ErrEstablishmentNotFound
+	}
+
+	key, err := s.APIKeyRepo.FindByID(*actor.EstablishmentID, keyID)
+	if err != nil {
+		if err == repositories.ErrAPIKeyNotFound {
+			return nil, ErrAPIKeyNotFound
+		}
+		return nil, err
+	}
+
+	if key.RevokedAt != nil {
+		return nil, ErrAPIKeyNotFound
+	}
+
+	return key, nil
+}
+
+// generateAPIKey gera o prefixo público e o segredo de uma chave
+func (s *AuthService) generateAPIKey() (string, string, error) {
+	prefix, err := s.PasswordUtil.GenerateRandomToken(12)
+	if err != nil {
+		return "", "", err
+	}
+
+	secret, err := s.PasswordUtil.GenerateRandomToken(40)
+	if err != nil {
+		return "", "", err
+	}
+
+	return apiKeyPrefix + prefix, secret, nil
+}
+
+// recordAPIKeyEvent grava no log de autenticação uma alteração em chave de API
+func (s *AuthService) recordAPIKeyEvent(eventType models.AuthEventType, actorID uuid.UUID, key *models.APIKey, clientIP, userAgent string) {
+	s.recordAuthEvent(&models.AuthEvent{
+		Type:      eventType,
+		UserID:    &actorID,
+		Details:   "key=" + key.ID.String() + " prefix=" + key.Prefix,
+		IPAddress: clientIP,
+		UserAgent: userAgent,
+	})
+}
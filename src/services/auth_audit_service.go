@@ -0,0 +1,70 @@
+package services
+
+import (
+	"log"
+	"time"
+
+	"github.com/Barba2k2/aurora_backend/src/models"
+	"github.com/Barba2k2/aurora_backend/src/repositories"
+	"github.com/google/uuid"
+)
+
+// Motivos registrados nas falhas de login
+const (
+	loginFailureUnknownEmail    = "unknown_email"
+	loginFailureInvalidPassword = "invalid_password"
+	loginFailureInvalidMFACode  = "invalid_mfa_code"
+	loginFailureLocked          = "locked"
+	loginFailureInactive        = "inactive"
+	loginFailureRoleNotAllowed  = "role_not_allowed"
+)
+
+// MaxAuthEventsPageSize é o limite de eventos por página nas consultas do log de autenticação
+const MaxAuthEventsPageSize = 100
+
+// AuthEventQuery representa os filtros de consulta ao log de autenticação
+type AuthEventQuery struct {
+	UserID *uuid.UUID
+	Types  []models.AuthEventType
+	From   time.Time
+	To     time.Time
+	Page   int
+	Limit  int
+}
+
+// recordAuthEvent grava um evento no log de autenticação.
+// Uma falha na gravação não interrompe a operação auditada, mas fica registrada no log da aplicação.
+func (s *AuthService) recordAuthEvent(event *models.AuthEvent) {
+	if err := s.AuthEventRepo.Create(event); err != nil {
+		log.Printf("Erro ao registrar evento de autenticação %s: %v", event.Type, err)
+	}
+}
+
+// recordUserEvent grava um evento referente a um usuário conhecido
+func (s *AuthService) recordUserEvent(eventType models.AuthEventType, user *models.User, clientIP, userAgent, details string) {
+	s.recordAuthEvent(&models.AuthEvent{
+		Type:      eventType,
+		UserID:    &user.ID,
+		Email:     user.Email,
+		Details:   details,
+		IPAddress: clientIP,
+		UserAgent: userAgent,
+	})
+}
+
+// ListAuthEvents consulta o log de autenticação, do evento mais recente para o mais antigo
+func (s *AuthService) ListAuthEvents(query AuthEventQuery) ([]*models.AuthEvent, int64, error) {
+	if query.Page < 1 {
+		query.Page = 1
+	}
+	if query.Limit < 1 || query.Limit > MaxAuthEventsPageSize {
+		query.Limit = MaxAuthEventsPageSize
+	}
+
+	return s.AuthEventRepo.Find(repositories.AuthEventFilter{
+		UserID: query.UserID,
+		Types:  query.Types,
+		From:   query.From,
+		To:     query.To,
+	}, query.Page, query.Limit)
+}
@@ -0,0 +1,145 @@
+package services
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/json"
+	"log"
+	"time"
+
+	"github.com/Barba2k2/aurora_backend/src/models"
+)
+
+// erasureBatchSize limita as contas anonimizadas em cada execução da rotina de eliminação
+const erasureBatchSize = 100
+
+// RequestErasureRequest representa os dados de requisição para eliminação dos dados pessoais (LGPD).
+// Contas sem senha, criadas pelo login social, confirmam a identidade com um ID token recente do provedor vinculado.
+type RequestErasureRequest struct {
+	Password  string `json:"password" validate:"required_without=IDToken"`
+	Provider  string `json:"provider,omitempty" validate:"required_with=IDToken"`
+	IDToken   string `json:"id_token,omitempty"`
+	Nonce     string `json:"nonce,omitempty" validate:"required_with=IDToken"`
+	ClientIP  string `json:"-"`
+	UserAgent string `json:"-"`
+}
+
+// sentNotification descreve uma mensagem enviada ao usuário, derivada dos tokens de uso único
+type sentNotification struct {
+	Channel models.TokenChannel `json:"channel"`
+	Purpose models.TokenPurpose `json:"purpose"`
+	SentAt  time.Time           `json:"sent_at"`
+}
+
+// ExportPersonalData monta um arquivo zip com todos os dados mantidos sobre o usuário, um JSON por assunto.
+// Segredos como hashes de senha e de tokens não são exportados.
+func (s *AuthService) ExportPersonalData(user *models.User, clientIP, userAgent string) ([]byte, error) {
+	data, err := s.PersonalDataRepo.FindByUser(user.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	notifications := make([]sentNotification, 0, len(data.Tokens))
+	for _, token := range data.Tokens {
+		notifications = append(notifications, sentNotification{
+			Channel: token.Channel,
+			Purpose: token.Purpose,
+			SentAt:  token.CreatedAt,
+		})
+	}
+
+	files := []struct {
+		name    string
+		content interface{}
+	}{
+		{"profile.json", map[string]interface{}{
+			"user":          data.User,
+			"establishment": data.Establishment,
+		}},
+		{"memberships.json", data.Memberships},
+		{"sessions.json", data.Sessions},
+		{"devices.json", data.KnownDevices},
+		{"linked_accounts.json", data.LinkedAccounts},
+		{"passkeys.json", data.Passkeys},
+		{"tokens.json", data.Tokens},
+		{"notifications.json", notifications},
+		{"auth_events.json", data.AuthEvents},
+	}
+
+	var archive bytes.Buffer
+	writer := zip.NewWriter(&archive)
+	for _, file := range files {
+		content, err := json.MarshalIndent(file.content, "", "  ")
+		if err != nil {
+			return nil, err
+		}
+
+		entry, err := writer.Create(file.name)
+		if err != nil {
+			return nil, err
+		}
+		if _, err := entry.Write(content); err != nil {
+			return nil, err
+		}
+	}
+	if err := writer.Close(); err != nil {
+		return nil, err
+	}
+
+	s.recordUserEvent(models.AuthEventDataExported, user, clientIP, userAgent, "")
+
+	return archive.Bytes(), nil
+}
+
+// RequestErasure atende o pedido de eliminação dos dados pessoais: a conta é excluída imediatamente
+// e os dados são anonimizados ao fim do prazo para restauração. Registros financeiros são mantidos.
+func (s *AuthService) RequestErasure(user *models.User, req RequestErasureRequest) (time.Time, error) {
+	if err := s.reauthenticate(user, req.Password, req.Provider, req.IDToken, req.Nonce); err != nil {
+		return time.Time{}, err
+	}
+
+	return s.deleteAccount(user, user.ID, models.AuthEventErasureRequested, req.ClientIP, req.UserAgent)
+}
+
+// ProcessDueErasures anonimiza as contas cujo prazo de carência terminou, retornando quantas foram anonimizadas
+func (s *AuthService) ProcessDueErasures() (int, error) {
+	users, err := s.PersonalDataRepo.FindDueForErasure(time.Now(), erasureBatchSize)
+	if err != nil {
+		return 0, err
+	}
+
+	count := 0
+	for _, user := range users {
+		if err := s.PersonalDataRepo.Anonymize(user); err != nil {
+			return count, err
+		}
+		count++
+
+		// O evento nao guarda dados pessoais, apenas o ID mantido nos registros financeiros
+		userID := user.ID
+		s.recordAuthEvent(&models.AuthEvent{
+			Type:   models.AuthEventAccountErased,
+			UserID: &userID,
+		})
+	}
+
+	return count, nil
+}
+
+// StartErasureWorker executa periodicamente a anonimização das contas com eliminação vencida
+func (s *AuthService) StartErasureWorker(interval time.Duration) {
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for range ticker.C {
+			count, err := s.ProcessDueErasures()
+			if err != nil {
+				log.Printf("Erro ao anonimizar contas excluídas: %v", err)
+			}
+			if count > 0 {
+				log.Printf("%d contas excluídas foram anonimizadas", count)
+			}
+		}
+	}()
+}
@@ -0,0 +1,197 @@
+package services
+
+import (
+	"time"
+
+	"github.com/Barba2k2/aurora_backend/src/models"
+	"github.com/Barba2k2/aurora_backend/src/repositories"
+	"github.com/google/uuid"
+)
+
+// MaxDeletedUsersPageSize é o limite de contas por página na listagem de contas excluídas
+const MaxDeletedUsersPageSize = 100
+
+// DeleteAccountRequest representa os dados de requisição para exclusão da conta pelo próprio usuário.
+// Contas sem senha, criadas pelo login social, confirmam a identidade com um ID token recente do provedor vinculado.
+type DeleteAccountRequest struct {
+	Password  string `json:"password" validate:"required_without=IDToken"`
+	Provider  string `json:"provider,omitempty" validate:"required_with=IDToken"`
+	IDToken   string `json:"id_token,omitempty"`
+	Nonce     string `json:"nonce,omitempty" validate:"required_with=IDToken"`
+	ClientIP  string `json:"-"`
+	UserAgent string `json:"-"`
+}
+
+// RestoreAccountRequest representa os dados de requisição para restauração de uma conta excluída pelo próprio usuário
+type RestoreAccountRequest struct {
+	Email     string `json:"email" validate:"required,email"`
+	Password  string `json:"password" validate:"required"`
+	ClientIP  string `json:"-"`
+	UserAgent string `json:"-"`
+}
+
+// DeletedAccountResponse representa uma conta excluída que ainda pode ser restaurada
+type DeletedAccountResponse struct {
+	*models.User
+	DeletedAt    *time.Time `json:"deleted_at"`
+	DeletedBy    *uuid.UUID `json:"deleted_by"`
+	RestoreUntil *time.Time `json:"restore_until"`
+}
+
+// DeleteAccount exclui a conta do usuário autenticado mediante a senha atual ou, sem senha, um ID token recente.
+// A conta pode ser restaurada até o fim do prazo; depois os dados pessoais são anonimizados.
+func (s *AuthService) DeleteAccount(user *models.User, req DeleteAccountRequest) (time.Time, error) {
+	if err := s.reauthenticate(user, req.Password, req.Provider, req.IDToken, req.Nonce); err != nil {
+		return time.Time{}, err
+	}
+
+	return s.deleteAccount(user, user.ID, models.AuthEventAccountDeleted, req.ClientIP, req.UserAgent)
+}
+
+// RestoreAccount restaura uma conta excluída pelo próprio usuário, dentro do prazo para restauração.
+// Contas excluídas por um administrador só podem ser restauradas por um administrador.
+func (s *AuthService) RestoreAccount(req RestoreAccountRequest) (*models.User, error) {
+	user, err := s.UserRepo.FindDeletedByEmail(req.Email)
+	if err != nil {
+		if err == repositories.ErrUserNotFound {
+			// Retornamos erro generico para evitar enumeracao de usuarios
+			return nil, ErrInvalidLogin
+		}
+		return nil, err
+	}
+
+	if err := s.PasswordUtil.VerifyPassword(user.PasswordHash, req.Password); err != nil {
+		return nil, ErrInvalidLogin
+	}
+
+	// So informamos o motivo para quem conhece a senha
+	if user.DeletedBy == nil || *user.DeletedBy != user.ID {
+		return nil, ErrUserInactive
+	}
+
+	return s.restoreAccount(user, user.ID, req.ClientIP, req.UserAgent)
+}
+
+// AdminListDeletedUsers lista as contas excluídas que ainda podem ser restauradas
+func (s *AuthService) AdminListDeletedUsers(page, limit int) ([]*DeletedAccountResponse, int64, error) {
+	if page < 1 {
+		page = 1
+	}
+	if limit < 1 || limit > MaxDeletedUsersPageSize {
+		limit = MaxDeletedUsersPageSize
+	}
+
+	users, total, err := s.UserRepo.FindDeleted(page, limit)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	accounts := make([]*DeletedAccountResponse, 0, len(users))
+	for _, user := range users {
+		accounts = append(accounts, &DeletedAccountResponse{
+			User:         user,
+			DeletedAt:    user.DeletedAt,
+			DeletedBy:    user.DeletedBy,
+			RestoreUntil: user.ErasureScheduledAt,
+		})
+	}
+
+	return accounts, total, nil
+}
+
+// AdminDeleteUser exclui a conta de um usuário, que só poderá ser restaurada por um administrador
+func (s *AuthService) AdminDeleteUser(adminID, userID uuid.UUID, clientIP, userAgent string) (time.Time, error) {
+	// Um administrador nao pode excluir a propria conta, evitando que o sistema fique sem administradores
+	if adminID == userID {
+		return time.Time{}, ErrCannotDeleteSelf
+	}
+
+	user, err := s.UserRepo.FindByIDAnyStatus(userID)
+	if err != nil {
+		if err == repositories.ErrUserNotFound {
+			return time.Time{}, ErrUserNotFound
+		}
+		return time.Time{}, err
+	}
+
+	return s.deleteAccount(user, adminID, models.AuthEventAccountDeleted, clientIP, userAgent)
+}
+
+// AdminRestoreUser restaura uma conta excluída, dentro do prazo para restauração
+func (s *AuthService) AdminRestoreUser(adminID, userID uuid.UUID, clientIP, userAgent string) (*models.User, error) {
+	user, err := s.UserRepo.FindDeletedByID(userID)
+	if err != nil {
+		if err == repositories.ErrUserNotFound {
+			return nil, ErrUserNotFound
+		}
+		return nil, err
+	}
+
+	return s.restoreAccount(user, adminID, clientIP, userAgent)
+}
+
+// deleteAccount exclui a conta, encerra as sessões e agenda a anonimização dos dados pessoais.
+// A conta de um profissional leva junto o estabelecimento: a equipe perde o acesso a ele,
+// mas os vínculos e as chaves de API são mantidos para o caso de restauração.
+// Tudo é gravado em uma única transação, para que uma falha não deixe a conta ativa com a eliminação agendada.
+func (s *AuthService) deleteAccount(user *models.User, actorID uuid.UUID, eventType models.AuthEventType, clientIP, userAgent string) (time.Time, error) {
+	erasureAt := time.Now().Add(s.Config.DataErasureGracePeriod)
+	if err := s.PersonalDataRepo.SoftDelete(user, actorID, erasureAt); err != nil {
+		if err == repositories.ErrUserNotFound {
+			return time.Time{}, ErrUserNotFound
+		}
+		return time.Time{}, err
+	}
+	user.ErasureScheduledAt = &erasureAt
+
+	event := &models.AuthEvent{
+		Type:      eventType,
+		UserID:    &user.ID,
+		Email:     user.Email,
+		Details:   "erasure_at=" + erasureAt.Format(time.RFC3339),
+		IPAddress: clientIP,
+		UserAgent: userAgent,
+	}
+	if actorID != user.ID {
+		event.ActorID = &actorID
+	}
+	s.recordAuthEvent(event)
+
+	// O email de confirmacao sai enquanto o endereco ainda existe
+	email, name := user.Email, user.Name
+	s.dispatchInBackground("email de confirmação da exclusão", func() error {
+		return s.EmailService.SendErasureScheduledEmail(email, name, erasureAt)
+	})
+
+	return erasureAt, nil
+}
+
+// restoreAccount desfaz a exclusão da conta e do estabelecimento do dono, cancelando a anonimização
+func (s *AuthService) restoreAccount(user *models.User, actorID uuid.UUID, clientIP, userAgent string) (*models.User, error) {
+	// A rotina de eliminacao pode ainda nao ter anonimizado uma conta vencida
+	if user.ErasureScheduledAt != nil && !user.ErasureScheduledAt.After(time.Now()) {
+		return nil, ErrAccountNotRestorable
+	}
+
+	// A conta volta ao status anterior a exclusao, junto com o estabelecimento do dono
+	if err := s.PersonalDataRepo.Restore(user); err != nil {
+		if err == repositories.ErrUserNotFound {
+			return nil, ErrAccountNotRestorable
+		}
+		return nil, err
+	}
+
+	event := &models.AuthEvent{
+		Type:      models.AuthEventAccountRestored,
+		UserID:    &user.ID,
+		Email:     user.Email,
+		IPAddress: clientIP,
+		UserAgent: userAgent,
+	}
+	if actorID != user.ID {
+		event.ActorID = &actorID
+	}
+	s.recordAuthEvent(event)
+
+	return s.UserRepo.FindByIDAnyStatus(user.ID)
+}
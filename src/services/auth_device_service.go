@@ -0,0 +1,243 @@
+package services
+
+import (
+	"strings"
+	"time"
+
+	"github.com/Barba2k2/aurora_backend/src/models"
+	"github.com/Barba2k2/aurora_backend/src/repositories"
+)
+
+// SecureAccountRequest representa os dados de requisição do link "não fui eu" enviado no aviso de novo dispositivo
+type SecureAccountRequest struct {
+	Token     string `json:"token" validate:"required"`
+	ClientIP  string `json:"-"`
+	UserAgent string `json:"-"`
+}
+
+// UpdatePreferredChannelRequest representa os dados de requisição para troca do canal dos avisos de segurança
+type UpdatePreferredChannelRequest struct {
+	Channel models.TokenChannel `json:"channel" validate:"required,oneof=EMAIL WHATSAPP"`
+}
+
+// trackLoginDevice registra o dispositivo do login e avisa o usuário quando a combinação
+// de dispositivo e IP ainda não é conhecida. O primeiro dispositivo da conta não gera aviso.
+func (s *AuthService) trackLoginDevice(user *models.User, deviceName, clientIP, userAgent string) {
+	fingerprint := s.PasswordUtil.HashToken(userAgent + "|" + clientIP)
+
+	device, err := s.KnownDeviceRepo.FindByFingerprint(user.ID, fingerprint)
+	if err == nil {
+		s.KnownDeviceRepo.Touch(device.ID)
+		return
+	}
+	if err != repositories.ErrKnownDeviceNotFound {
+		return
+	}
+
+	count, err := s.KnownDeviceRepo.CountByUser(user.ID)
+	if err != nil {
+		return
+	}
+
+	if err := s.KnownDeviceRepo.Create(&models.KnownDevice{
+		UserID:      user.ID,
+		Fingerprint: fingerprint,
+		DeviceName:  deviceName,
+		IPAddress:   clientIP,
+		UserAgent:   userAgent,
+	}); err != nil {
+		return
+	}
+
+	if count == 0 {
+		return
+	}
+
+	s.recordUserEvent(models.AuthEventNewDeviceLogin, user, clientIP, userAgent, deviceName)
+
+	// O aviso sai da requisicao para nao atrasar o login
+	loginAt := time.Now()
+	s.dispatchInBackground("aviso de novo dispositivo", func() error {
+		return s.sendNewDeviceAlert(user, deviceName, clientIP, userAgent, loginAt)
+	})
+}
+
+// sendNewDeviceAlert envia o aviso de novo dispositivo pelo canal preferido do usuário,
+// com o link que protege a conta caso o login não tenha sido feito por ele
+func (s *AuthService) sendNewDeviceAlert(user *models.User, deviceName, clientIP, userAgent string, loginAt time.Time) error {
+	secureToken, err := s.PasswordUtil.GenerateRandomToken(32)
+	if err != nil {
+		return err
+	}
+
+	// O WhatsApp so e usado com o telefone verificado
+	channel := models.TokenChannelEmail
+	if user.PreferredChannel == models.TokenChannelWhatsApp && user.PhoneVerifiedAt != nil {
+		channel = models.TokenChannelWhatsApp
+	}
+
+	// Cada aviso tem o proprio link, ja que o usuario pode receber varios antes de agir
+	token := &models.PasswordResetToken{
+		UserID:    user.ID,
+		TokenHash: s.PasswordUtil.HashOneTimeToken(secureToken),
+		Channel:   channel,
+		Purpose:   models.TokenPurposeSecureAccount,
+		Status:    models.TokenStatusActive,
+		ExpiresAt: loginAt.Add(s.Config.NewDeviceAlertExpiration),
+		IPAddress: clientIP,
+		UserAgent: userAgent,
+	}
+	if err := s.TokenRepo.Create(token); err != nil {
+		return err
+	}
+
+	device := deviceName
+	if device == "" {
+		device = userAgent
+	}
+	if device == "" {
+		device = "unknown device"
+	}
+
+	if channel == models.TokenChannelWhatsApp {
+		return s.WhatsAppService.SendNewDeviceLoginWhatsApp(user.Phone, user.Name, secureToken, device, clientIP, loginAt)
+	}
+	return s.EmailService.SendNewDeviceLoginEmail(user.Email, user.Name, secureToken, device, clientIP, loginAt)
+}
+
+// SecureAccount atende o link "não fui eu": encerra todas as sessões, remove as passkeys, os provedores
+// vinculados e as chaves de API criados desde o login avisado, exige a redefinição da senha antes do
+// próximo login e envia um link de recuperação para o email da conta
+func (s *AuthService) SecureAccount(req SecureAccountRequest) error {
+	token, err := s.findTokenByValue(req.Token)
+	if err != nil {
+		return err
+	}
+
+	if !token.IsValid() || token.Purpose != models.TokenPurposeSecureAccount {
+		return ErrInvalidToken
+	}
+
+	user, err := s.UserRepo.FindByID(token.UserID)
+	if err != nil {
+		if err == repositories.ErrUserNotFound {
+			return ErrInvalidToken
+		}
+		return err
+	}
+
+	// Consumimos o token antes de proteger a conta
+	if err := s.consumeToken(token); err != nil {
+		return err
+	}
+
+	user.PasswordResetRequired = true
+	if err := s.UserRepo.UpdateColumns(user.ID, map[string]interface{}{"password_reset_required": true}); err != nil {
+		return err
+	}
+
+	// Quem esta usando a conta perde o acesso imediatamente
+	if err := s.LogoutAll(user.ID); err != nil {
+		return err
+	}
+
+	// Links pendentes, incluindo este e outros avisos, deixam de valer
+	if err := s.TokenRepo.InvalidateAllUserTokens(user.ID); err != nil {
+		return err
+	}
+
+	// Os dispositivos conhecidos podem incluir o do invasor, entao todos voltam a gerar aviso
+	if err := s.KnownDeviceRepo.DeleteAllByUser(user.ID); err != nil {
+		return err
+	}
+
+	// O login avisado e anterior a criacao do token, que sai em segundo plano
+	loginAt := token.ExpiresAt.Add(-s.Config.NewDeviceAlertExpiration)
+	if token.CreatedAt.Before(loginAt) {
+		loginAt = token.CreatedAt
+	}
+
+	removed, err := s.removeCredentialsCreatedSince(user, loginAt)
+	if err != nil {
+		return err
+	}
+
+	s.recordUserEvent(models.AuthEventAccountSecured, user, req.ClientIP, req.UserAgent, removed)
+
+	// O limite de solicitacoes nao impede a protecao da conta; o usuario pode pedir o link depois
+	err = s.forgotPasswordEmail(ForgotPasswordRequest{
+		Email:     user.Email,
+		ClientIP:  req.ClientIP,
+		UserAgent: req.UserAgent,
+	})
+	if err != nil && err != ErrTooManyRequests {
+		return err
+	}
+
+	return nil
+}
+
+// removeCredentialsCreatedSince remove as passkeys e os provedores vinculados e revoga as chaves de API
+// criados desde o login avisado, ja que o invasor pode te-los usado para manter o acesso.
+// Retorna o que foi removido, no formato dos detalhes do evento.
+func (s *AuthService) removeCredentialsCreatedSince(user *models.User, since time.Time) (string, error) {
+	credentials, err := s.WebAuthnRepo.DeleteCredentialsCreatedSince(user.ID, since)
+	if err != nil {
+		return "", err
+	}
+
+	accounts, err := s.LinkedAccountRepo.DeleteByUserCreatedSince(user.ID, since)
+	if err != nil {
+		return "", err
+	}
+
+	keys, err := s.APIKeyRepo.RevokeCreatedBySince(user.ID, since)
+	if err != nil {
+		return "", err
+	}
+
+	var details []string
+	if len(credentials) > 0 {
+		ids := make([]string, 0, len(credentials))
+		for _, credential := range credentials {
+			ids = append(ids, credential.ID.String())
+		}
+		details = append(details, "removed_passkeys="+strings.Join(ids, ","))
+	}
+	if len(accounts) > 0 {
+		providers := make([]string, 0, len(accounts))
+		for _, account := range accounts {
+			providers = append(providers, account.Provider)
+		}
+		details = append(details, "removed_linked_accounts="+strings.Join(providers, ","))
+	}
+	if len(keys) > 0 {
+		prefixes := make([]string, 0, len(keys))
+		for _, key := range keys {
+			prefixes = append(prefixes, key.Prefix)
+		}
+		details = append(details, "revoked_api_keys="+strings.Join(prefixes, ","))
+	}
+
+	return strings.Join(details, " "), nil
+}
+
+// UpdatePreferredChannel troca o canal em que o usuário recebe os avisos de segurança
+func (s *AuthService) UpdatePreferredChannel(user *models.User, req UpdatePreferredChannelRequest) (*models.User, error) {
+	switch req.Channel {
+	case models.TokenChannelEmail:
+	case models.TokenChannelWhatsApp:
+		if user.PhoneVerifiedAt == nil {
+			return nil, ErrPhoneNotVerified
+		}
+	default:
+		return nil, ErrInvalidChannel
+	}
+
+	user.PreferredChannel = req.Channel
+	if err := s.UserRepo.UpdateColumns(user.ID, map[string]interface{}{"preferred_channel": user.PreferredChannel}); err != nil {
+		return nil, err
+	}
+
+	return user, nil
+}
@@ -0,0 +1,130 @@
+package services
+
+import (
+	"time"
+
+	"github.com/Barba2k2/aurora_backend/src/models"
+	"github.com/Barba2k2/aurora_backend/src/repositories"
+	"github.com/Barba2k2/aurora_backend/src/utils"
+	"github.com/google/uuid"
+)
+
+// ImpersonationRequest representa os dados de requisição para um administrador agir como outro usuário
+type ImpersonationRequest struct {
+	// Reason explica o atendimento e fica registrado no log de autenticação
+	Reason    string `json:"reason" validate:"max=255"`
+	ClientIP  string `json:"-"`
+	UserAgent string `json:"-"`
+}
+
+// ImpersonationResponse representa o token de acesso emitido para a personificação.
+// Não há refresh token: ao expirar, o administrador precisa iniciar uma nova personificação.
+type ImpersonationResponse struct {
+	AccessToken string       `json:"access_token"`
+	ExpiresIn   int64        `json:"expires_in"`
+	SessionID   uuid.UUID    `json:"session_id"`
+	User        *models.User `json:"user"`
+}
+
+// StartImpersonation abre uma sessão curta em que o administrador vê o sistema como o usuário.
+// A sessão pertence ao usuário, mas registra o administrador, que também segue no token.
+func (s *AuthService) StartImpersonation(adminID, userID uuid.UUID, req ImpersonationRequest) (*ImpersonationResponse, error) {
+	// Administradores, incluindo o proprio, nunca sao personificados
+	if adminID == userID {
+		return nil, ErrImpersonationDenied
+	}
+
+	user, err := s.UserRepo.FindByID(userID)
+	if err != nil {
+		if err == repositories.ErrUserNotFound {
+			return nil, ErrUserNotFound
+		}
+		return nil, err
+	}
+
+	if user.Role == models.UserRoleAdmin {
+		return nil, ErrImpersonationDenied
+	}
+	if user.Status != models.UserStatusActive {
+		return nil, ErrUserInactive
+	}
+
+	expiresAt := time.Now().Add(utils.TokenExpirationImpersonation)
+	session := &models.Session{
+		ID:             uuid.New(),
+		UserID:         user.ID,
+		DeviceName:     "impersonation",
+		IPAddress:      req.ClientIP,
+		UserAgent:      req.UserAgent,
+		ImpersonatorID: &adminID,
+		ExpiresAt:      expiresAt,
+	}
+	if err := s.SessionRepo.Create(session); err != nil {
+		return nil, err
+	}
+
+	accessToken, err := s.JWTUtil.GenerateImpersonationToken(adminID, user.ID, user.Role, session.ID, expiresAt)
+	if err != nil {
+		return nil, err
+	}
+
+	details := "session=" + session.ID.String()
+	if req.Reason != "" {
+		details += " reason=" + req.Reason
+	}
+	s.recordAuthEvent(&models.AuthEvent{
+		Type:      models.AuthEventImpersonationStarted,
+		UserID:    &user.ID,
+		ActorID:   &adminID,
+		Email:     user.Email,
+		Details:   details,
+		IPAddress: req.ClientIP,
+		UserAgent: req.UserAgent,
+	})
+
+	return &ImpersonationResponse{
+		AccessToken: accessToken,
+		ExpiresIn:   int64(utils.TokenExpirationImpersonation.Seconds()),
+		SessionID:   session.ID,
+		User:        user,
+	}, nil
+}
+
+// StopImpersonation encerra uma sessão de personificação aberta pelo administrador
+func (s *AuthService) StopImpersonation(adminID, sessionID uuid.UUID, clientIP, userAgent string) error {
+	session, err := s.SessionRepo.FindByID(sessionID)
+	if err != nil {
+		if err == repositories.ErrSessionNotFound {
+			return ErrSessionNotFound
+		}
+		return err
+	}
+
+	// Somente o administrador que abriu a sessao pode encerra-la por aqui
+	if session.ImpersonatorID == nil || *session.ImpersonatorID != adminID || !session.IsActive() {
+		return ErrSessionNotFound
+	}
+
+	if err := s.revokeSession(session.ID); err != nil {
+		return err
+	}
+
+	s.recordAuthEvent(&models.AuthEvent{
+		Type:      models.AuthEventImpersonationStopped,
+		UserID:    &session.UserID,
+		ActorID:   &adminID,
+		Details:   "session=" + session.ID.String(),
+		IPAddress: clientIP,
+		UserAgent: userAgent,
+	})
+
+	return nil
+}
+
+// sameImpersonator compara o administrador registrado na sessão com o do token
+func sameImpersonator(sessionImpersonator, tokenImpersonator *uuid.UUID) bool {
+	if sessionImpersonator == nil || tokenImpersonator == nil {
+		return sessionImpersonator == nil && tokenImpersonator == nil
+	}
+	return *sessionImpersonator == *tokenImpersonator
+}
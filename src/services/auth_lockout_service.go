@@ -0,0 +1,147 @@
+package services
+
+import (
+	"time"
+
+	"github.com/Barba2k2/aurora_backend/src/models"
+	"github.com/Barba2k2/aurora_backend/src/repositories"
+	"github.com/google/uuid"
+)
+
+// UnlockAccountRequest representa os dados de requisição para desbloqueio da conta pelo link enviado por email
+type UnlockAccountRequest struct {
+	Token     string `json:"token" validate:"required"`
+	ClientIP  string `json:"-"`
+	UserAgent string `json:"-"`
+}
+
+// registerFailedLogin contabiliza uma tentativa de login falha e bloqueia a conta ao atingir o limite.
+// reason identifica a falha no log de autenticação.
+func (s *AuthService) registerFailedLogin(user *models.User, clientIP, userAgent, reason string) {
+	s.recordUserEvent(models.AuthEventLoginFailed, user, clientIP, userAgent, reason)
+
+	// O contador vem do banco, ja que tentativas simultaneas leriam o mesmo valor em memoria
+	failedLogins, err := s.UserRepo.IncrementFailedLoginCount(user.ID)
+	if err != nil {
+		return
+	}
+
+	if failedLogins < s.Config.MaxLoginAttempts {
+		return
+	}
+
+	// So uma das tentativas simultaneas bloqueia a conta e envia o aviso
+	lockedUntil := time.Now().Add(s.lockDuration(user.LockoutCount))
+	locked, err := s.UserRepo.Lock(user.ID, lockedUntil, s.Config.MaxLoginAttempts)
+	if err != nil || !locked {
+		return
+	}
+
+	s.recordUserEvent(models.AuthEventAccountLocked, user, clientIP, userAgent, "locked_until="+lockedUntil.UTC().Format(time.RFC3339))
+
+	// O aviso nao impede o bloqueio; o usuario ainda pode esperar o fim do periodo
+	s.sendAccountLockedEmail(user, lockedUntil)
+}
+
+// lockDuration calcula o tempo de bloqueio, que dobra a cada reincidência até o limite configurado
+func (s *AuthService) lockDuration(lockoutCount int) time.Duration {
+	duration := s.Config.LoginLockDuration
+	for i := 0; i < lockoutCount; i++ {
+		duration *= 2
+		if duration >= s.Config.LoginLockMaxDuration {
+			return s.Config.LoginLockMaxDuration
+		}
+	}
+	return duration
+}
+
+// sendAccountLockedEmail avisa o usuário do bloqueio e envia um link para desbloquear a conta
+func (s *AuthService) sendAccountLockedEmail(user *models.User, lockedUntil time.Time) error {
+	unlockToken, err := s.PasswordUtil.GenerateRandomToken(32)
+	if err != nil {
+		return err
+	}
+
+	// Apenas o link mais recente continua valido
+	if err := s.TokenRepo.InvalidateUserTokensByPurpose(user.ID, models.TokenPurposeAccountUnlock); err != nil {
+		return err
+	}
+
+	// O link deixa de ser util quando o bloqueio termina
+	token := &models.PasswordResetToken{
+		UserID:    user.ID,
+		TokenHash: s.PasswordUtil.HashOneTimeToken(unlockToken),
+		Channel:   models.TokenChannelEmail,
+		Purpose:   models.TokenPurposeAccountUnlock,
+		Status:    models.TokenStatusActive,
+		ExpiresAt: lockedUntil,
+	}
+	if err := s.TokenRepo.Create(token); err != nil {
+		return err
+	}
+
+	return s.EmailService.SendAccountLockedEmail(user.Email, user.Name, unlockToken, lockedUntil)
+}
+
+// UnlockAccount desbloqueia a conta com o link enviado no aviso de bloqueio
+func (s *AuthService) UnlockAccount(req UnlockAccountRequest) error {
+	token, err := s.findTokenByValue(req.Token)
+	if err != nil {
+		return err
+	}
+
+	if !token.IsValid() || token.Purpose != models.TokenPurposeAccountUnlock {
+		return ErrInvalidToken
+	}
+
+	// Consumimos o token antes de desbloquear a conta
+	if err := s.consumeToken(token); err != nil {
+		return err
+	}
+
+	if err := s.UserRepo.Unlock(token.UserID); err != nil {
+		return err
+	}
+
+	s.recordAuthEvent(&models.AuthEvent{
+		Type:      models.AuthEventAccountUnlocked,
+		UserID:    &token.UserID,
+		Details:   "unlock_link",
+		IPAddress: req.ClientIP,
+		UserAgent: req.UserAgent,
+	})
+
+	return nil
+}
+
+// AdminUnlockUser remove o bloqueio por tentativas de login de um usuário.
+// adminID identifica o administrador no log de autenticação.
+func (s *AuthService) AdminUnlockUser(adminID, userID uuid.UUID, clientIP, userAgent string) (*models.User, error) {
+	user, err := s.UserRepo.FindByIDAnyStatus(userID)
+	if err != nil {
+		if err == repositories.ErrUserNotFound {
+			return nil, ErrUserNotFound
+		}
+		return nil, err
+	}
+
+	if err := s.UserRepo.Unlock(user.ID); err != nil {
+		return nil, err
+	}
+
+	user.LockedUntil = nil
+	user.LockoutCount = 0
+	user.FailedLoginCount = 0
+
+	s.recordAuthEvent(&models.AuthEvent{
+		Type:      models.AuthEventAccountUnlocked,
+		UserID:    &user.ID,
+		ActorID:   &adminID,
+		Email:     user.Email,
+		Details:   "admin",
+		IPAddress: clientIP,
+		UserAgent: userAgent,
+	})
+
+	return user, nil
+}
@@ -0,0 +1,329 @@
+package services
+
+import (
+	"crypto/rand"
+	"encoding/base32"
+	"strings"
+	"time"
+
+	"github.com/Barba2k2/aurora_backend/src/models"
+	"github.com/Barba2k2/aurora_backend/src/repositories"
+	"github.com/Barba2k2/aurora_backend/src/utils"
+)
+
+// Parâmetros dos códigos de recuperação
+const (
+	// RecoveryCodeCount é a quantidade de códigos gerados a cada emissão
+	RecoveryCodeCount = 10
+	// recoveryCodeBytes gera códigos de 10 caracteres base32
+	recoveryCodeBytes = 6
+)
+
+// MFAChallengeResponse representa a resposta do login quando o segundo fator é exigido
+type MFAChallengeResponse struct {
+	MFARequired bool     `json:"mfa_required"`
+	MFAToken    string   `json:"mfa_token"`
+	ExpiresIn   int64    `json:"expires_in"`
+	Methods     []string `json:"methods"`
+}
+
+// MFARequiredError é retornado pelo Login quando o usuário precisa completar o segundo fator
+type MFARequiredError struct {
+	Challenge *MFAChallengeResponse
+}
+
+func (e *MFARequiredError) Error() string {
+	return ErrMFARequired.Error()
+}
+
+func (e *MFARequiredError) Unwrap() error {
+	return ErrMFARequired
+}
+
+// MFALoginRequest representa os dados de requisição para a segunda etapa do login
+type MFALoginRequest struct {
+	MFAToken     string `json:"mfa_token" validate:"required"`
+	Code         string `json:"code"`
+	RecoveryCode string `json:"recovery_code"`
+	DeviceName   string `json:"device_name"`
+	ClientIP     string `json:"-"`
+	UserAgent    string `json:"-"`
+}
+
+// TOTPSetupResponse representa os dados para cadastrar o segredo no aplicativo autenticador
+type TOTPSetupResponse struct {
+	Secret          string `json:"secret"`
+	ProvisioningURI string `json:"provisioning_uri"`
+}
+
+// TOTPConfirmRequest representa os dados de requisição para confirmar o cadastro do TOTP
+type TOTPConfirmRequest struct {
+	Code string `json:"code" validate:"required"`
+}
+
+// TOTPDisableRequest representa os dados de requisição para desativar o TOTP
+type TOTPDisableRequest struct {
+	Password     string `json:"password" validate:"required"`
+	Code         string `json:"code"`
+	RecoveryCode string `json:"recovery_code"`
+}
+
+// RecoveryCodesRequest representa os dados de requisição para gerar novos códigos de recuperação
+type RecoveryCodesRequest struct {
+	Password string `json:"password" validate:"required"`
+}
+
+// RecoveryCodesResponse contém os códigos de recuperação, exibidos uma única vez
+type RecoveryCodesResponse struct {
+	RecoveryCodes []string `json:"recovery_codes"`
+}
+
+// createMFAChallenge gera o token de desafio entregue após a etapa de senha
+func (s *AuthService) createMFAChallenge(user *models.User) (*MFAChallengeResponse, error) {
+	mfaToken, err := s.JWTUtil.GenerateMFAToken(user.ID, user.Role)
+	if err != nil {
+		return nil, err
+	}
+
+	return &MFAChallengeResponse{
+		MFARequired: true,
+		MFAToken:    mfaToken,
+		ExpiresIn:   int64(utils.TokenExpirationMFA.Seconds()),
+		Methods:     []string{"totp", "recovery_code"},
+	}, nil
+}
+
+// LoginMFA conclui o login validando o código TOTP ou um código de recuperação
+func (s *AuthService) LoginMFA(req MFALoginRequest) (*models.User, *TokenResponse, error) {
+	// Validamos o token de desafio
+	claims, err := s.JWTUtil.ValidateMFAToken(req.MFAToken)
+	if err != nil {
+		return nil, nil, ErrInvalidToken
+	}
+
+	// Buscamos o usuario
+	user, err := s.UserRepo.FindByID(claims.UserID)
+	if err != nil {
+		if err == repositories.ErrUserNotFound {
+			return nil, nil, ErrInvalidToken
+		}
+		return nil, nil, err
+	}
+
+	// Verificamos se o usuario esta ativo
+	if user.Status != models.UserStatusActive {
+		return nil, nil, ErrUserInactive
+	}
+
+	// Os erros de segundo fator contam para o mesmo limite de tentativas do login
+	if user.IsLocked() {
+		return nil, nil, ErrUserBlocked
+	}
+
+	if !user.TOTPEnabled {
+		return nil, nil, ErrMFANotEnabled
+	}
+
+	if err := s.verifySecondFactor(user, req.Code, req.RecoveryCode); err != nil {
+		if err == ErrInvalidMFACode {
+			s.registerFailedLogin(user, req.ClientIP, req.UserAgent, loginFailureInvalidMFACode)
+		}
+		return nil, nil, err
+	}
+
+	tokenResponse, err := s.completeLogin(user, req.DeviceName, req.ClientIP, req.UserAgent)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return user, tokenResponse, nil
+}
+
+// SetupTOTP gera um novo segredo TOTP pendente de confirmação
+func (s *AuthService) SetupTOTP(user *models.User) (*TOTPSetupResponse, error) {
+	if user.TOTPEnabled {
+		return nil, ErrMFAAlreadyEnabled
+	}
+
+	secret, err := s.TOTPUtil.GenerateSecret()
+	if err != nil {
+		return nil, err
+	}
+
+	// O segredo só passa a valer depois da confirmação com um código válido
+	user.TOTPSecret = secret
+	user.TOTPConfirmedAt = nil
+	user.TOTPLastCounter = 0
+	if err := s.UserRepo.UpdateColumns(user.ID, totpColumns(user)); err != nil {
+		return nil, err
+	}
+
+	return &TOTPSetupResponse{
+		Secret:          secret,
+		ProvisioningURI: s.TOTPUtil.ProvisioningURI(user.Email, secret),
+	}, nil
+}
+
+// ConfirmTOTP habilita o TOTP após validar o primeiro código e retorna os códigos de recuperação
+func (s *AuthService) ConfirmTOTP(user *models.User, req TOTPConfirmRequest) (*RecoveryCodesResponse, error) {
+	if user.TOTPEnabled {
+		return nil, ErrMFAAlreadyEnabled
+	}
+	if user.TOTPSecret == "" {
+		return nil, ErrMFASetupNotStarted
+	}
+
+	counter, ok := s.TOTPUtil.ValidateCode(user.TOTPSecret, req.Code, time.Now())
+	if !ok {
+		return nil, ErrInvalidMFACode
+	}
+
+	now := time.Now()
+	user.TOTPEnabled = true
+	user.TOTPConfirmedAt = &now
+	user.TOTPLastCounter = counter
+	if err := s.UserRepo.UpdateColumns(user.ID, totpColumns(user)); err != nil {
+		return nil, err
+	}
+
+	return s.issueRecoveryCodes(user)
+}
+
+// DisableTOTP desativa o TOTP após confirmar a senha e o segundo fator
+func (s *AuthService) DisableTOTP(user *models.User, req TOTPDisableRequest) error {
+	if !user.TOTPEnabled {
+		return ErrMFANotEnabled
+	}
+
+	if err := s.PasswordUtil.VerifyPassword(user.PasswordHash, req.Password); err != nil {
+		return ErrInvalidLogin
+	}
+
+	if err := s.verifySecondFactor(user, req.Code, req.RecoveryCode); err != nil {
+		return err
+	}
+
+	user.TOTPEnabled = false
+	user.TOTPSecret = ""
+	user.TOTPConfirmedAt = nil
+	user.TOTPLastCounter = 0
+	if err := s.UserRepo.UpdateColumns(user.ID, totpColumns(user)); err != nil {
+		return err
+	}
+
+	return s.RecoveryCodeRepo.DeleteByUser(user.ID)
+}
+
+// RegenerateRecoveryCodes invalida os códigos atuais e emite novos
+func (s *AuthService) RegenerateRecoveryCodes(user *models.User, req RecoveryCodesRequest) (*RecoveryCodesResponse, error) {
+	if !user.TOTPEnabled {
+		return nil, ErrMFANotEnabled
+	}
+
+	if err := s.PasswordUtil.VerifyPassword(user.PasswordHash, req.Password); err != nil {
+		return nil, ErrInvalidLogin
+	}
+
+	return s.issueRecoveryCodes(user)
+}
+
+// totpColumns retorna as colunas do TOTP gravadas pela configuração e pela desativação
+func totpColumns(user *models.User) map[string]interface{} {
+	return map[string]interface{}{
+		"totp_secret":       user.TOTPSecret,
+		"totp_enabled":      user.TOTPEnabled,
+		"totp_confirmed_at": user.TOTPConfirmedAt,
+		"totp_last_counter": user.TOTPLastCounter,
+	}
+}
+
+// verifySecondFactor valida um código TOTP ou, na falta dele, um código de recuperação
+func (s *AuthService) verifySecondFactor(user *models.User, code, recoveryCode string) error {
+	if code != "" {
+		counter, ok := s.TOTPUtil.ValidateCode(user.TOTPSecret, code, time.Now())
+		if !ok {
+			return ErrInvalidMFACode
+		}
+
+		// Registramos o passo de forma atomica, rejeitando a reutilizacao de um codigo ja aceito
+		advanced, err := s.UserRepo.AdvanceTOTPCounter(user.ID, counter)
+		if err != nil {
+			return err
+		}
+		if !advanced {
+			return ErrInvalidMFACode
+		}
+
+		user.TOTPLastCounter = counter
+		return nil
+	}
+
+	if recoveryCode != "" {
+		return s.consumeRecoveryCode(user, recoveryCode)
+	}
+
+	return ErrInvalidMFACode
+}
+
+// consumeRecoveryCode procura um código de recuperação não usado pelo hash e o marca como usado.
+// Os códigos têm entropia suficiente para o hash com chave, evitando um hash de senha por código a cada tentativa.
+func (s *AuthService) consumeRecoveryCode(user *models.User, recoveryCode string) error {
+	hash := s.PasswordUtil.HashOneTimeToken(normalizeRecoveryCode(recoveryCode))
+
+	code, err := s.RecoveryCodeRepo.FindUnusedByHash(user.ID, hash)
+	if err != nil {
+		if err == repositories.ErrRecoveryCodeNotFound {
+			return ErrInvalidMFACode
+		}
+		return err
+	}
+
+	if err := s.RecoveryCodeRepo.MarkAsUsed(code.ID); err != nil {
+		if err == repositories.ErrRecoveryCodeAlreadyUsed {
+			return ErrInvalidMFACode
+		}
+		return err
+	}
+
+	return nil
+}
+
+// issueRecoveryCodes gera novos códigos de recuperação e armazena apenas seus hashes
+func (s *AuthService) issueRecoveryCodes(user *models.User) (*RecoveryCodesResponse, error) {
+	codes := make([]string, 0, RecoveryCodeCount)
+	hashes := make([]string, 0, RecoveryCodeCount)
+
+	for i := 0; i < RecoveryCodeCount; i++ {
+		code, err := generateRecoveryCode()
+		if err != nil {
+			return nil, err
+		}
+
+		codes = append(codes, code)
+		hashes = append(hashes, s.PasswordUtil.HashOneTimeToken(normalizeRecoveryCode(code)))
+	}
+
+	if err := s.RecoveryCodeRepo.ReplaceForUser(user.ID, hashes); err != nil {
+		return nil, err
+	}
+
+	return &RecoveryCodesResponse{RecoveryCodes: codes}, nil
+}
+
+// generateRecoveryCode gera um código no formato xxxxx-xxxxx
+func generateRecoveryCode() (string, error) {
+	b := make([]byte, recoveryCodeBytes)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+
+	code := strings.ToLower(base32.StdEncoding.WithPadding(base32.NoPadding).EncodeToString(b))[:10]
+	return code[:5] + "-" + code[5:], nil
+}
+
+// normalizeRecoveryCode remove separadores e diferenças de caixa digitados pelo usuário
+func normalizeRecoveryCode(code string) string {
+	code = strings.ToLower(strings.TrimSpace(code))
+	code = strings.ReplaceAll(code, "-", "")
+	return strings.ReplaceAll(code, " ", "")
+}
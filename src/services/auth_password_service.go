@@ -0,0 +1,129 @@
+package services
+
+import (
+	"errors"
+
+	"github.com/Barba2k2/aurora_backend/src/models"
+	"github.com/Barba2k2/aurora_backend/src/utils"
+)
+
+// validatePasswordPolicy confere a nova senha com a política configurada.
+// userInputs são o nome, email e telefone do usuário, que não podem fazer parte da senha.
+func (s *AuthService) validatePasswordPolicy(password string, userInputs ...string) error {
+	err := s.PasswordUtil.ValidatePasswordStrength(password, userInputs...)
+	switch err {
+	case nil:
+		return nil
+	case utils.ErrPasswordTooShort, utils.ErrPasswordTooLong, utils.ErrPasswordTooWeak:
+		return ErrPasswordTooWeak
+	case utils.ErrPasswordRepeatedChars:
+		return ErrPasswordRepeatedChars
+	case utils.ErrPasswordCommon:
+		return ErrPasswordCommon
+	case utils.ErrPasswordContainsUserInfo:
+		return ErrPasswordPersonalInfo
+	case utils.ErrPasswordBreached:
+		return ErrPasswordBreached
+	default:
+		// Falha ao consultar a lista de senhas vazadas
+		return err
+	}
+}
+
+// setPassword valida a nova senha de uma conta existente, incluindo o histórico, e a
+// aplica ao usuário. A senha substituída entra no histórico; cabe ao chamador salvar o usuário.
+func (s *AuthService) setPassword(user *models.User, password string) error {
+	if err := s.validatePasswordPolicy(password, user.Name, user.Email, user.Phone); err != nil {
+		return err
+	}
+
+	if err := s.checkPasswordHistory(user, password); err != nil {
+		return err
+	}
+
+	// Geramos o hash da nova senha
+	hashedPassword, err := s.PasswordUtil.HashPassword(password)
+	if err != nil {
+		// O algoritmo de hash pode recusar a senha com o limite dele anexado ao erro
+		if errors.Is(err, utils.ErrPasswordTooLong) {
+			return ErrPasswordTooWeak
+		}
+		return err
+	}
+
+	// Com historico de uma unica senha basta a comparacao com a senha atual
+	if s.Config.PasswordHistorySize > 1 && user.PasswordHash != "" {
+		if err := s.PasswordHistoryRepo.Create(&models.PasswordHistory{
+			UserID:       user.ID,
+			PasswordHash: user.PasswordHash,
+		}); err != nil {
+			return err
+		}
+
+		// A senha atual ocupa uma das posicoes do historico
+		if err := s.PasswordHistoryRepo.PruneByUser(user.ID, s.Config.PasswordHistorySize-1); err != nil {
+			return err
+		}
+	}
+
+	user.PasswordHash = hashedPassword
+	// A nova senha atende a exigencia feita pelo link "nao fui eu"
+	user.PasswordResetRequired = false
+	return nil
+}
+
+// passwordColumns retorna as colunas gravadas por setPassword
+func passwordColumns(user *models.User) map[string]interface{} {
+	return map[string]interface{}{
+		"password_hash":           user.PasswordHash,
+		"password_reset_required": user.PasswordResetRequired,
+	}
+}
+
+// checkPasswordHistory impede a reutilização da senha atual e das últimas senhas do usuário
+func (s *AuthService) checkPasswordHistory(user *models.User, password string) error {
+	if s.Config.PasswordHistorySize <= 0 {
+		return nil
+	}
+
+	if user.PasswordHash != "" && s.PasswordUtil.VerifyPassword(user.PasswordHash, password) == nil {
+		return ErrPasswordReused
+	}
+
+	// A senha atual conta como uma das ultimas senhas
+	if s.Config.PasswordHistorySize == 1 {
+		return nil
+	}
+
+	history, err := s.PasswordHistoryRepo.FindRecentByUser(user.ID, s.Config.PasswordHistorySize-1)
+	if err != nil {
+		return err
+	}
+
+	for _, entry := range history {
+		if s.PasswordUtil.VerifyPassword(entry.PasswordHash, password) == nil {
+			return ErrPasswordReused
+		}
+	}
+
+	return nil
+}
+
+// upgradePasswordHash substitui o hash da senha quando ele usa um algoritmo ou parâmetros antigos.
+// Só pode ser chamada logo após a senha ser conferida, único momento em que ela é conhecida.
+func (s *AuthService) upgradePasswordHash(user *models.User, password string) {
+	if !s.PasswordUtil.NeedsRehash(user.PasswordHash) {
+		return
+	}
+
+	hashedPassword, err := s.PasswordUtil.HashPassword(password)
+	if err != nil {
+		return
+	}
+
+	// Uma falha aqui nao impede o login; a troca e tentada novamente no proximo acesso
+	if err := s.UserRepo.UpdatePasswordHash(user.ID, hashedPassword); err != nil {
+		return
+	}
+	user.PasswordHash = hashedPassword
+}
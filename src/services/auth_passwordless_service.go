@@ -0,0 +1,249 @@
+package services
+
+import (
+	"time"
+
+	"github.com/Barba2k2/aurora_backend/src/models"
+	"github.com/Barba2k2/aurora_backend/src/repositories"
+)
+
+// PasswordlessRequest representa os dados de requisição para envio de link mágico ou código de login
+type PasswordlessRequest struct {
+	Email     string `json:"email" validate:"omitempty,email"`
+	Phone     string `json:"phone" validate:"omitempty"`
+	ClientIP  string `json:"-"`
+	UserAgent string `json:"-"`
+}
+
+// PasswordlessLoginRequest representa os dados de requisição para trocar o link mágico ou o código por tokens
+type PasswordlessLoginRequest struct {
+	Token      string `json:"token"`
+	Phone      string `json:"phone"`
+	Code       string `json:"code"`
+	DeviceName string `json:"device_name"`
+	ClientIP   string `json:"-"`
+	UserAgent  string `json:"-"`
+}
+
+// SendMagicLink envia um link mágico de login para o email do cliente. A solicitação é processada
+// em segundo plano, para que a resposta não revele se o email existe nem o papel da conta.
+func (s *AuthService) SendMagicLink(req PasswordlessRequest) {
+	s.dispatchInBackground("link mágico de login", func() error {
+		return s.sendMagicLink(req)
+	})
+}
+
+// sendMagicLink busca o cliente, gera o token do link e o envia por email
+func (s *AuthService) sendMagicLink(req PasswordlessRequest) error {
+	// Buscamos o usuario pelo email
+	user, err := s.UserRepo.FindByEmail(req.Email)
+	if err != nil {
+		if err == repositories.ErrUserNotFound {
+			return ErrEmailNotFound
+		}
+		return err
+	}
+
+	if err := s.checkPasswordlessUser(user); err != nil {
+		return err
+	}
+
+	// Geramos um token unico para o link
+	loginToken, err := s.PasswordUtil.GenerateRandomToken(32)
+	if err != nil {
+		return err
+	}
+
+	if err := s.createLoginToken(user, loginToken, models.TokenChannelEmail, s.Config.MagicLinkExpiration, req); err != nil {
+		return err
+	}
+
+	// Enviamos o email com o link
+	return s.EmailService.SendMagicLinkEmail(user.Email, user.Name, loginToken)
+}
+
+// SendLoginCode envia um código de login por SMS ou WhatsApp para o telefone verificado do cliente.
+// Assim como o link mágico, a solicitação é processada em segundo plano.
+func (s *AuthService) SendLoginCode(req PasswordlessRequest, channel models.TokenChannel) {
+	s.dispatchInBackground("código de login", func() error {
+		return s.sendLoginCode(req, channel)
+	})
+}
+
+// sendLoginCode busca o cliente pelo telefone, gera o código e o envia pelo canal escolhido
+func (s *AuthService) sendLoginCode(req PasswordlessRequest, channel models.TokenChannel) error {
+	// Buscamos o usuario pelo telefone
+	user, err := s.UserRepo.FindByPhone(req.Phone)
+	if err != nil {
+		if err == repositories.ErrUserNotFound {
+			return ErrPhoneNotFound
+		}
+		return err
+	}
+
+	if err := s.checkPasswordlessUser(user); err != nil {
+		return err
+	}
+
+	// O codigo so pode ser enviado para um telefone comprovadamente do usuario
+	if user.PhoneVerifiedAt == nil {
+		return ErrPhoneNotVerified
+	}
+
+	// Geramos um codigo numerico
+	code, err := s.PasswordUtil.GenerateNumericCode(6)
+	if err != nil {
+		return err
+	}
+
+	if err := s.createLoginToken(user, code, channel, s.Config.LoginCodeExpiration, req); err != nil {
+		return err
+	}
+
+	// Enviamos o codigo pelo canal escolhido
+	if channel == models.TokenChannelWhatsApp {
+		return s.WhatsAppService.SendLoginCodeWhatsApp(user.Phone, user.Name, code)
+	}
+	return s.SMSService.SendLoginCodeSMS(user.Phone, code)
+}
+
+// LoginPasswordless troca um link mágico ou um código de login por tokens de acesso
+func (s *AuthService) LoginPasswordless(req PasswordlessLoginRequest) (*models.User, *TokenResponse, error) {
+	var token *models.PasswordResetToken
+	var user *models.User
+	var err error
+
+	if req.Token != "" {
+		token, user, err = s.findMagicLinkToken(req.Token)
+	} else {
+		token, user, err = s.findLoginCodeToken(req.Phone, req.Code)
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+
+	// Consumimos o token antes de abrir a sessao
+	if err := s.consumeToken(token); err != nil {
+		return nil, nil, err
+	}
+
+	// Com o segundo fator habilitado, devolvemos um desafio em vez dos tokens
+	if user.TOTPEnabled {
+		challenge, err := s.createMFAChallenge(user)
+		if err != nil {
+			return nil, nil, err
+		}
+		return user, nil, &MFARequiredError{Challenge: challenge}
+	}
+
+	tokenResponse, err := s.completeLogin(user, req.DeviceName, req.ClientIP, req.UserAgent)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return user, tokenResponse, nil
+}
+
+// findMagicLinkToken valida o token de um link mágico e retorna o usuário dono dele
+func (s *AuthService) findMagicLinkToken(value string) (*models.PasswordResetToken, *models.User, error) {
+	token, err := s.findTokenByValue(value)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if !token.IsValid() || token.Purpose != models.TokenPurposeLogin || token.Channel != models.TokenChannelEmail {
+		return nil, nil, ErrInvalidToken
+	}
+
+	// Buscamos o usuario
+	user, err := s.UserRepo.FindByID(token.UserID)
+	if err != nil {
+		if err == repositories.ErrUserNotFound {
+			return nil, nil, ErrInvalidToken
+		}
+		return nil, nil, err
+	}
+
+	if err := s.checkPasswordlessUser(user); err != nil {
+		return nil, nil, err
+	}
+
+	return token, user, nil
+}
+
+// findLoginCodeToken valida o código enviado ao telefone e retorna o usuário dono dele
+func (s *AuthService) findLoginCodeToken(phone, code string) (*models.PasswordResetToken, *models.User, error) {
+	if phone == "" || code == "" {
+		return nil, nil, ErrInvalidToken
+	}
+
+	// Buscamos o usuario pelo telefone
+	user, err := s.UserRepo.FindByPhone(phone)
+	if err != nil {
+		if err == repositories.ErrUserNotFound {
+			return nil, nil, ErrInvalidToken
+		}
+		return nil, nil, err
+	}
+
+	// Conferimos o codigo antes da conta, para nao revelar o papel nem a situacao de quem nao o recebeu
+	token, err := s.verifyUserCode(user.ID, models.TokenPurposeLogin, code)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if err := s.checkPasswordlessUser(user); err != nil {
+		return nil, nil, err
+	}
+
+	return token, user, nil
+}
+
+// checkPasswordlessUser verifica se o usuário pode entrar sem senha
+func (s *AuthService) checkPasswordlessUser(user *models.User) error {
+	if user.Role != models.UserRoleClient {
+		return ErrPasswordlessDenied
+	}
+
+	// Verificamos se o usuario esta ativo
+	if user.Status != models.UserStatusActive {
+		return ErrUserInactive
+	}
+
+	// Verificamos se o usuario esta bloqueado por tentativas de login
+	if user.IsLocked() {
+		return ErrUserBlocked
+	}
+
+	return nil
+}
+
+// createLoginToken aplica o rate limit e registra um novo token de login, invalidando os anteriores
+func (s *AuthService) createLoginToken(user *models.User, value string, channel models.TokenChannel, expiration time.Duration, req PasswordlessRequest) error {
+	// Verificamos o rate limit
+	count, err := s.TokenRepo.CountTokensByUserAndPurpose(user.ID, models.TokenPurposeLogin, s.Config.ResetTokenRateWindow)
+	if err != nil {
+		return err
+	}
+	if count >= s.Config.ResetTokenRateLimit {
+		return ErrTooManyRequests
+	}
+
+	// Apenas o link ou codigo mais recente continua valido
+	if err := s.TokenRepo.InvalidateUserTokensByPurpose(user.ID, models.TokenPurposeLogin); err != nil {
+		return err
+	}
+
+	token := &models.PasswordResetToken{
+		UserID:    user.ID,
+		TokenHash: s.PasswordUtil.HashOneTimeToken(value),
+		Channel:   channel,
+		Purpose:   models.TokenPurposeLogin,
+		Status:    models.TokenStatusActive,
+		ExpiresAt: time.Now().Add(expiration),
+		IPAddress: req.ClientIP,
+		UserAgent: req.UserAgent,
+	}
+
+	return s.TokenRepo.Create(token)
+}
@@ -0,0 +1,301 @@
+package services
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/Barba2k2/aurora_backend/src/models"
+	"github.com/Barba2k2/aurora_backend/src/repositories"
+	"github.com/google/uuid"
+)
+
+// PermissionSet contém as permissões efetivas de um usuário e o estabelecimento a que elas se referem
+type PermissionSet struct {
+	// EstablishmentID é o estabelecimento em que as permissões valem; é nil para administradores e clientes
+	EstablishmentID *uuid.UUID
+	permissions     map[models.Permission]bool
+}
+
+// newPermissionSet cria um conjunto de permissões, ignorando nomes desconhecidos
+func newPermissionSet(establishmentID *uuid.UUID, permissions []models.Permission) *PermissionSet {
+	set := &PermissionSet{
+		EstablishmentID: establishmentID,
+		permissions:     make(map[models.Permission]bool, len(permissions)),
+	}
+	for _, permission := range permissions {
+		if permission.IsValid() {
+			set.permissions[permission] = true
+		}
+	}
+	return set
+}
+
+// Has informa se o conjunto contém a permissão
+func (p *PermissionSet) Has(permission models.Permission) bool {
+	return p.permissions[permission]
+}
+
+// List retorna as permissões do conjunto em ordem alfabética
+func (p *PermissionSet) List() []models.Permission {
+	list := make([]models.Permission, 0, len(p.permissions))
+	for permission := range p.permissions {
+		list = append(list, permission)
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i] < list[j] })
+	return list
+}
+
+// EstablishmentRoleRequest representa os dados de requisição para criação ou alteração de um papel do estabelecimento
+type EstablishmentRoleRequest struct {
+	Name        string              `json:"name" validate:"required,max=50"`
+	Description string              `json:"description" validate:"max=255"`
+	Permissions []models.Permission `json:"permissions" validate:"required"`
+}
+
+// AssignMemberRoleRequest representa os dados de requisição para troca do papel de um membro.
+// Sem RoleID o membro volta às permissões padrão da equipe.
+type AssignMemberRoleRequest struct {
+	RoleID *uuid.UUID `json:"role_id"`
+}
+
+// ResolvePermissions calcula as permissões efetivas do usuário.
+// Administradores e donos de estabelecimento têm todas as permissões; membros da equipe têm as
+// do papel atribuído pelo estabelecimento ou, sem papel, as permissões padrão da equipe.
+// establishmentID é o estabelecimento escolhido na sessão; sem ele vale o primeiro vínculo do membro.
+func (s *AuthService) ResolvePermissions(user *models.User, establishmentID *uuid.UUID) (*PermissionSet, error) {
+	switch user.Role {
+	case models.UserRoleAdmin:
+		return newPermissionSet(nil, models.AllPermissions), nil
+
+	case models.UserRoleProfessional:
+		establishment, err := s.UserRepo.FindEstablishmentByUserID(user.ID)
+		if err != nil {
+			if err == repositories.ErrUserNotFound {
+				return newPermissionSet(nil, models.AllPermissions), nil
+			}
+			return nil, err
+		}
+		return newPermissionSet(&establishment.ID, models.AllPermissions), nil
+
+	case models.UserRoleStaff:
+		member, err := s.findActiveMembership(user.ID, establishmentID)
+		if err != nil {
+			if err == repositories.ErrEstablishmentMemberNotFound {
+				return newPermissionSet(nil, nil), nil
+			}
+			return nil, err
+		}
+		return s.memberPermissions(member)
+	}
+
+	return newPermissionSet(nil, nil), nil
+}
+
+// findActiveMembership busca o vínculo do membro com o estabelecimento escolhido na sessão
+func (s *AuthService) findActiveMembership(userID uuid.UUID, establishmentID *uuid.UUID) (*models.EstablishmentMember, error) {
+	if establishmentID != nil {
+		return s.EstablishmentMemberRepo.FindByEstablishmentAndUser(*establishmentID, userID)
+	}
+	return s.EstablishmentMemberRepo.FindFirstByUser(userID)
+}
+
+// memberPermissions calcula as permissões de um membro da equipe no estabelecimento
+func (s *AuthService) memberPermissions(member *models.EstablishmentMember) (*PermissionSet, error) {
+	if member.RoleID == nil {
+		return newPermissionSet(&member.EstablishmentID, models.DefaultStaffPermissions), nil
+	}
+
+	role, err := s.EstablishmentRoleRepo.FindByID(member.EstablishmentID, *member.RoleID)
+	if err != nil {
+		// Um papel removido devolve o membro as permissoes padrao
+		if err == repositories.ErrEstablishmentRoleNotFound {
+			return newPermissionSet(&member.EstablishmentID, models.DefaultStaffPermissions), nil
+		}
+		return nil, err
+	}
+
+	return newPermissionSet(&member.EstablishmentID, toPermissions(role.Permissions)), nil
+}
+
+// ListEstablishmentRoles lista os papéis do estabelecimento
+func (s *AuthService) ListEstablishmentRoles(actor *PermissionSet) ([]*models.EstablishmentRole, error) {
+	if actor.EstablishmentID == nil {
+		return nil, ErrEstablishmentNotFound
+	}
+
+	return s.EstablishmentRoleRepo.FindAllByEstablishment(*actor.EstablishmentID)
+}
+
+// CreateEstablishmentRole cria um papel no estabelecimento
+func (s *AuthService) CreateEstablishmentRole(actor *PermissionSet, req EstablishmentRoleRequest) (*models.EstablishmentRole, error) {
+	if actor.EstablishmentID == nil {
+		return nil, ErrEstablishmentNotFound
+	}
+
+	permissions, err := validateRolePermissions(actor, req.Permissions)
+	if err != nil {
+		return nil, err
+	}
+
+	role := &models.EstablishmentRole{
+		EstablishmentID: *actor.EstablishmentID,
+		Name:            strings.TrimSpace(req.Name),
+		Description:     req.Description,
+		Permissions:     permissions,
+	}
+	if role.Name == "" {
+		return nil, ErrInvalidRole
+	}
+
+	if err := s.EstablishmentRoleRepo.Create(role); err != nil {
+		if err == repositories.ErrEstablishmentRoleAlreadyExists {
+			return nil, ErrRoleAlreadyExists
+		}
+		return nil, err
+	}
+
+	return role, nil
+}
+
+// UpdateEstablishmentRole altera um papel do estabelecimento; a mudança vale imediatamente para os membros
+func (s *AuthService) UpdateEstablishmentRole(actor *PermissionSet, roleID uuid.UUID, req EstablishmentRoleRequest) (*models.EstablishmentRole, error) {
+	role, err := s.findEstablishmentRole(actor, roleID)
+	if err != nil {
+		return nil, err
+	}
+
+	permissions, err := validateRolePermissions(actor, req.Permissions)
+	if err != nil {
+		return nil, err
+	}
+
+	role.Name = strings.TrimSpace(req.Name)
+	role.Description = req.Description
+	role.Permissions = permissions
+	if role.Name == "" {
+		return nil, ErrInvalidRole
+	}
+
+	if err := s.EstablishmentRoleRepo.Update(role); err != nil {
+		if err == repositories.ErrEstablishmentRoleAlreadyExists {
+			return nil, ErrRoleAlreadyExists
+		}
+		return nil, err
+	}
+
+	return role, nil
+}
+
+// DeleteEstablishmentRole remove um papel do estabelecimento; seus membros voltam às permissões padrão
+func (s *AuthService) DeleteEstablishmentRole(actor *PermissionSet, roleID uuid.UUID) error {
+	role, err := s.findEstablishmentRole(actor, roleID)
+	if err != nil {
+		return err
+	}
+
+	if err := s.EstablishmentMemberRepo.ClearRole(role.ID); err != nil {
+		return err
+	}
+
+	return s.EstablishmentRoleRepo.Delete(role.EstablishmentID, role.ID)
+}
+
+// AssignMemberRole atribui um papel a um membro da equipe do estabelecimento
+func (s *AuthService) AssignMemberRole(actorID uuid.UUID, actor *PermissionSet, userID uuid.UUID, req AssignMemberRoleRequest) (*models.EstablishmentMember, error) {
+	if actor.EstablishmentID == nil {
+		return nil, ErrEstablishmentNotFound
+	}
+
+	// Ninguem altera as proprias permissoes
+	if actorID == userID {
+		return nil, ErrPermissionEscalation
+	}
+
+	member, err := s.EstablishmentMemberRepo.FindByEstablishmentAndUser(*actor.EstablishmentID, userID)
+	if err != nil {
+		if err == repositories.ErrEstablishmentMemberNotFound {
+			return nil, ErrMemberNotFound
+		}
+		return nil, err
+	}
+
+	if err := s.checkRoleGrant(actor, req.RoleID); err != nil {
+		return nil, err
+	}
+
+	if err := s.EstablishmentMemberRepo.UpdateRole(member.ID, req.RoleID); err != nil {
+		return nil, err
+	}
+
+	member.RoleID = req.RoleID
+	return member, nil
+}
+
+// checkRoleGrant confere se o responsável pode atribuir o papel, que não pode conceder
+// permissões que ele não tem. Sem papel valem as permissões padrão da equipe.
+func (s *AuthService) checkRoleGrant(actor *PermissionSet, roleID *uuid.UUID) error {
+	permissions := models.DefaultStaffPermissions
+	if roleID != nil {
+		role, err := s.findEstablishmentRole(actor, *roleID)
+		if err != nil {
+			return err
+		}
+		permissions = toPermissions(role.Permissions)
+	}
+
+	for _, permission := range permissions {
+		if !actor.Has(permission) {
+			return ErrPermissionEscalation
+		}
+	}
+
+	return nil
+}
+
+// findEstablishmentRole busca um papel do estabelecimento em que o responsável atua
+func (s *AuthService) findEstablishmentRole(actor *PermissionSet, roleID uuid.UUID) (*models.EstablishmentRole, error) {
+	if actor.EstablishmentID == nil {
+		return nil, ErrEstablishmentNotFound
+	}
+
+	role, err := s.EstablishmentRoleRepo.FindByID(*actor.EstablishmentID, roleID)
+	if err != nil {
+		if err == repositories.ErrEstablishmentRoleNotFound {
+			return nil, ErrRoleNotFound
+		}
+		return nil, err
+	}
+
+	return role, nil
+}
+
+// validateRolePermissions confere as permissões de um papel, que precisam existir e não podem
+// exceder as do responsável, evitando que a equipe conceda a si mesma mais acesso
+func validateRolePermissions(actor *PermissionSet, permissions []models.Permission) ([]string, error) {
+	seen := make(map[models.Permission]bool, len(permissions))
+	list := make([]string, 0, len(permissions))
+	for _, permission := range permissions {
+		if !permission.IsValid() {
+			return nil, ErrInvalidPermission
+		}
+		if !actor.Has(permission) {
+			return nil, ErrPermissionEscalation
+		}
+		if seen[permission] {
+			continue
+		}
+		seen[permission] = true
+		list = append(list, string(permission))
+	}
+	sort.Strings(list)
+	return list, nil
+}
+
+// toPermissions converte as permissões armazenadas em um papel
+func toPermissions(values []string) []models.Permission {
+	permissions := make([]models.Permission, len(values))
+	for i, value := range values {
+		permissions[i] = models.Permission(value)
+	}
+	return permissions
+}
@@ -0,0 +1,93 @@
+package services
+
+import (
+	"time"
+
+	"github.com/Barba2k2/aurora_backend/src/models"
+)
+
+// PhoneVerificationRequest representa os dados de requisição para envio do código de verificação de telefone
+type PhoneVerificationRequest struct {
+	Channel   models.TokenChannel `json:"channel" validate:"required,oneof=SMS WHATSAPP"`
+	ClientIP  string              `json:"-"`
+	UserAgent string              `json:"-"`
+}
+
+// ConfirmPhoneRequest representa os dados de requisição para confirmação do telefone
+type ConfirmPhoneRequest struct {
+	Code string `json:"code" validate:"required"`
+}
+
+// SendPhoneVerification envia um código para confirmar que o usuário possui o telefone cadastrado
+func (s *AuthService) SendPhoneVerification(user *models.User, req PhoneVerificationRequest) error {
+	if user.Phone == "" {
+		return ErrPhoneMissing
+	}
+	if user.PhoneVerifiedAt != nil {
+		return ErrPhoneAlreadyVerified
+	}
+
+	// Verificamos o rate limit
+	count, err := s.TokenRepo.CountTokensByUserAndPurpose(user.ID, models.TokenPurposePhoneVerification, s.Config.ResetTokenRateWindow)
+	if err != nil {
+		return err
+	}
+	if count >= s.Config.ResetTokenRateLimit {
+		return ErrTooManyRequests
+	}
+
+	// Invalidamos os códigos anteriores
+	if err := s.TokenRepo.InvalidateUserTokensByPurpose(user.ID, models.TokenPurposePhoneVerification); err != nil {
+		return err
+	}
+
+	// Geramos um código numérico
+	code, err := s.PasswordUtil.GenerateNumericCode(6)
+	if err != nil {
+		return err
+	}
+
+	// Criamos o registro do token
+	token := &models.PasswordResetToken{
+		UserID:    user.ID,
+		TokenHash: s.PasswordUtil.HashOneTimeToken(code),
+		Channel:   req.Channel,
+		Purpose:   models.TokenPurposePhoneVerification,
+		Status:    models.TokenStatusActive,
+		ExpiresAt: time.Now().Add(s.Config.ResetTokenSMSExpiration),
+		IPAddress: req.ClientIP,
+		UserAgent: req.UserAgent,
+	}
+
+	if err := s.TokenRepo.Create(token); err != nil {
+		return err
+	}
+
+	// Enviamos o código pelo canal escolhido
+	if req.Channel == models.TokenChannelWhatsApp {
+		return s.WhatsAppService.SendPhoneVerificationWhatsApp(user.Phone, user.Name, code)
+	}
+	return s.SMSService.SendPhoneVerificationSMS(user.Phone, code)
+}
+
+// ConfirmPhone valida o código recebido e marca o telefone como verificado
+func (s *AuthService) ConfirmPhone(user *models.User, req ConfirmPhoneRequest) error {
+	if user.PhoneVerifiedAt != nil {
+		return ErrPhoneAlreadyVerified
+	}
+
+	// Conferimos o código ativo do usuário
+	token, err := s.verifyUserCode(user.ID, models.TokenPurposePhoneVerification, req.Code)
+	if err != nil {
+		return err
+	}
+
+	// Consumimos o token antes de marcar o telefone
+	if err := s.consumeToken(token); err != nil {
+		return err
+	}
+
+	now := time.Now()
+	user.PhoneVerifiedAt = &now
+	return s.UserRepo.UpdateColumns(user.ID, map[string]interface{}{"phone_verified_at": user.PhoneVerifiedAt})
+}
@@ -0,0 +1,35 @@
+package services
+
+import (
+	"log"
+
+	"github.com/Barba2k2/aurora_backend/src/repositories"
+)
+
+// dispatchInBackground executa uma ação fora da requisição, registrando apenas as falhas.
+// No modo de privacidade a resposta não pode depender do resultado nem do tempo da ação.
+func (s *AuthService) dispatchInBackground(action string, fn func() error) {
+	go func() {
+		if err := fn(); err != nil {
+			log.Printf("Erro ao processar %s em segundo plano: %v", action, err)
+		}
+	}()
+}
+
+// notifyRegistrationAttempt avisa o dono da conta existente sobre uma tentativa de cadastro com seus dados
+func (s *AuthService) notifyRegistrationAttempt(req RegisterRequest) error {
+	// O conflito pode ter ocorrido pelo email ou pelo telefone
+	user, err := s.UserRepo.FindByEmailAnyStatus(req.Email)
+	if err == repositories.ErrUserNotFound && req.Phone != "" {
+		user, err = s.UserRepo.FindByPhoneAnyStatus(req.Phone)
+	}
+	if err != nil {
+		if err == repositories.ErrUserNotFound {
+			// Contas removidas continuam reservando o email, mas nao recebem avisos
+			return nil
+		}
+		return err
+	}
+
+	return s.EmailService.SendRegistrationAttemptEmail(user.Email, user.Name)
+}
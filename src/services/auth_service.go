@@ -12,15 +12,68 @@ import (
 
 // Erros do serviço de autenticação
 var (
-	ErrInvalidLogin         = errors.New("invalid email or password")
-	ErrUserBlocked          = errors.New("user account is blocked due to too many failed login attempts")
-	ErrUserInactive         = errors.New("user account is inactive")
-	ErrInvalidToken         = errors.New("invalid or expired token")
-	ErrTooManyRequests      = errors.New("too many requests, please try again later")
-	ErrEmailNotFound        = errors.New("no user found with this email")
-	ErrPhoneNotFound        = errors.New("no user found with this phone number")
-	ErrPasswordTooWeak      = errors.New("password is too weak")
-	ErrPasswordConfirmation = errors.New("password and confirmation do not match")
+	ErrInvalidLogin          = errors.New("invalid email or password")
+	ErrUserBlocked           = errors.New("user account is blocked due to too many failed login attempts")
+	ErrUserInactive          = errors.New("user account is inactive")
+	ErrInvalidToken          = errors.New("invalid or expired token")
+	ErrTooManyRequests       = errors.New("too many requests, please try again later")
+	ErrEmailNotFound         = errors.New("no user found with this email")
+	ErrPhoneNotFound         = errors.New("no user found with this phone number")
+	ErrPasswordTooWeak       = errors.New("password is too weak")
+	ErrPasswordRepeatedChars = errors.New("password repeats the same character too many times")
+	ErrPasswordCommon        = errors.New("password is too common")
+	ErrPasswordPersonalInfo  = errors.New("password must not contain personal information")
+	ErrPasswordConfirmation  = errors.New("password and confirmation do not match")
+	ErrRefreshTokenReused    = errors.New("refresh token reuse detected, session revoked")
+	ErrSessionNotFound       = errors.New("session not found")
+	ErrMFARequired           = errors.New("multi-factor authentication required")
+	ErrMFAAlreadyEnabled     = errors.New("multi-factor authentication is already enabled")
+	ErrMFANotEnabled         = errors.New("multi-factor authentication is not enabled")
+	ErrMFASetupNotStarted    = errors.New("multi-factor authentication setup was not started")
+	ErrInvalidMFACode        = errors.New("invalid multi-factor authentication code")
+	ErrWebAuthnFailed        = errors.New("passkey verification failed")
+	ErrPasskeyNotFound       = errors.New("passkey not found")
+	ErrPasskeyAlreadyExists  = errors.New("passkey is already registered")
+	ErrEmailNotVerified      = errors.New("email address not verified")
+	ErrEmailAlreadyVerified  = errors.New("email address already verified")
+	ErrPhoneNotVerified      = errors.New("phone number not verified")
+	ErrPhoneAlreadyVerified  = errors.New("phone number already verified")
+	ErrPhoneMissing          = errors.New("user has no phone number")
+	ErrPasswordlessDenied    = errors.New("passwordless login is only available to clients")
+	ErrProviderNotSupported  = errors.New("identity provider not supported")
+	ErrProviderEmailMissing  = errors.New("identity provider did not return a verified email")
+	ErrProviderAlreadyLinked = errors.New("identity provider account is already linked")
+	ErrProviderNotLinked     = errors.New("identity provider is not linked")
+	ErrSocialLoginDenied     = errors.New("social login is only available to clients")
+	ErrUserNotFound          = errors.New("user not found")
+	ErrEmailAlreadyInUse     = errors.New("email address is already in use")
+	ErrEmailUnchanged        = errors.New("new email is the same as the current one")
+	ErrPasswordReused        = errors.New("password was used recently")
+	ErrPasswordBreached      = errors.New("password appears in a known data breach")
+	ErrInvalidRole           = errors.New("invalid user role")
+	ErrRoleNotAllowed        = errors.New("user role cannot sign in on this portal")
+	ErrCannotChangeOwnRole   = errors.New("administrators cannot change their own role")
+	ErrPasswordResetRequired = errors.New("password must be reset before signing in")
+	ErrInvalidChannel        = errors.New("invalid notification channel")
+	ErrEstablishmentNotFound = errors.New("establishment not found")
+	ErrRoleNotFound          = errors.New("establishment role not found")
+	ErrRoleAlreadyExists     = errors.New("establishment role already exists")
+	ErrInvalidPermission     = errors.New("invalid permission")
+	ErrPermissionEscalation  = errors.New("cannot grant permissions beyond your own")
+	ErrMemberNotFound        = errors.New("establishment member not found")
+	ErrInvitationNotFound    = errors.New("invitation not found or expired")
+	ErrInvitationMismatch    = errors.New("invitation was sent to a different email or phone")
+	ErrInvitationNeedsLogin  = errors.New("an account already exists for this invitation, sign in to accept it")
+	ErrStaffRoleRequired     = errors.New("only staff accounts can join an establishment")
+	ErrContactRequired       = errors.New("email or phone is required")
+	ErrAlreadyMember         = errors.New("user is already a member of this establishment")
+	ErrEmailRequired         = errors.New("email is required")
+	ErrImpersonationDenied   = errors.New("administrators cannot be impersonated")
+	ErrInvalidAPIKey         = errors.New("invalid, expired or revoked api key")
+	ErrAPIKeyNotFound        = errors.New("api key not found")
+	ErrInvalidExpiration     = errors.New("expiration must be in the future")
+	ErrCannotDeleteSelf      = errors.New("administrators cannot delete their own account")
+	ErrAccountNotRestorable  = errors.New("account cannot be restored after the restore window")
 )
 
 // AuthConfig contém as configurações para o serviço de autenticação
@@ -29,6 +82,8 @@ type AuthConfig struct {
 	MaxLoginAttempts int
 	// Tempo para bloqueio após exceder tentativas de login
 	LoginLockDuration time.Duration
+	// Tempo máximo de bloqueio; o bloqueio dobra a cada reincidência até este limite
+	LoginLockMaxDuration time.Duration
 	// Limite de tokens de recuperação de senha por período
 	ResetTokenRateLimit int
 	// Período para verificação de rate limit
@@ -37,52 +92,128 @@ type AuthConfig struct {
 	ResetTokenEmailExpiration time.Duration
 	// Tempo de expiração de token de recuperação via SMS/WhatsApp
 	ResetTokenSMSExpiration time.Duration
+	// Tempo de expiração do link de verificação de email
+	EmailVerificationExpiration time.Duration
+	// Tempo de expiração do link mágico de login
+	MagicLinkExpiration time.Duration
+	// Tempo de expiração do código de login via SMS/WhatsApp
+	LoginCodeExpiration time.Duration
+	// Tempo de expiração do link de confirmação de troca de email
+	EmailChangeExpiration time.Duration
+	// Quantidade de senhas recentes, incluindo a atual, que não podem ser reutilizadas
+	PasswordHistorySize int
+	// Tempo de expiração do link "não fui eu" enviado no aviso de novo dispositivo
+	NewDeviceAlertExpiration time.Duration
+	// Prazo para restaurar uma conta excluída; ao fim dele os dados pessoais são anonimizados (LGPD)
+	DataErasureGracePeriod time.Duration
+	// Tempo de expiração do convite para a equipe de um estabelecimento
+	StaffInvitationExpiration time.Duration
+	// Modo de privacidade: recuperação de senha e cadastro respondem da mesma forma
+	// exista ou não a conta, evitando a enumeração de usuários
+	PrivacyMode bool
 }
 
 // DefaultAuthConfig retorna uma configuração padrão para o serviço de autenticação
 func DefaultAuthConfig() AuthConfig {
 	return AuthConfig{
-		MaxLoginAttempts:          5,
-		LoginLockDuration:         1 * time.Hour,
-		ResetTokenRateLimit:       3,
-		ResetTokenRateWindow:      1 * time.Hour,
-		ResetTokenEmailExpiration: 15 * time.Minute,
-		ResetTokenSMSExpiration:   5 * time.Minute,
+		MaxLoginAttempts:            5,
+		LoginLockDuration:           1 * time.Hour,
+		LoginLockMaxDuration:        24 * time.Hour,
+		ResetTokenRateLimit:         3,
+		ResetTokenRateWindow:        1 * time.Hour,
+		ResetTokenEmailExpiration:   15 * time.Minute,
+		ResetTokenSMSExpiration:     5 * time.Minute,
+		EmailVerificationExpiration: 24 * time.Hour,
+		MagicLinkExpiration:         15 * time.Minute,
+		LoginCodeExpiration:         5 * time.Minute,
+		EmailChangeExpiration:       1 * time.Hour,
+		PasswordHistorySize:         5,
+		NewDeviceAlertExpiration:    72 * time.Hour,
+		StaffInvitationExpiration:   7 * 24 * time.Hour,
+		DataErasureGracePeriod:      30 * 24 * time.Hour,
 	}
 }
 
 // AuthService implementa os serviços de autenticação
 type AuthService struct {
-	UserRepo        repositories.UserRepository
-	TokenRepo       repositories.TokenRepositoryInterface
-	PasswordUtil    *utils.PasswordUtil
-	JWTUtil         *utils.JWTUtil
-	EmailService    EmailServiceInterface
-	SMSService      SMSServiceInterface
-	WhatsAppService WhatsAppServiceInterface
-	Config          AuthConfig
+	UserRepo                repositories.UserRepository
+	TokenRepo               repositories.TokenRepositoryInterface
+	RefreshTokenRepo        repositories.RefreshTokenRepositoryInterface
+	SessionRepo             repositories.SessionRepositoryInterface
+	RecoveryCodeRepo        repositories.MFARecoveryCodeRepositoryInterface
+	WebAuthnRepo            repositories.WebAuthnRepositoryInterface
+	LinkedAccountRepo       repositories.LinkedAccountRepositoryInterface
+	PasswordHistoryRepo     repositories.PasswordHistoryRepositoryInterface
+	AuthEventRepo           repositories.AuthEventRepositoryInterface
+	KnownDeviceRepo         repositories.KnownDeviceRepositoryInterface
+	EstablishmentRoleRepo   repositories.EstablishmentRoleRepositoryInterface
+	EstablishmentMemberRepo repositories.EstablishmentMemberRepositoryInterface
+	StaffInvitationRepo     repositories.StaffInvitationRepositoryInterface
+	APIKeyRepo              repositories.APIKeyRepositoryInterface
+	PersonalDataRepo        repositories.PersonalDataRepositoryInterface
+	PasswordUtil            *utils.PasswordUtil
+	JWTUtil                 *utils.JWTUtil
+	TOTPUtil                *utils.TOTPUtil
+	WebAuthnUtil            *utils.WebAuthnUtil
+	OIDCUtil                *utils.OIDCUtil
+	EmailService            EmailServiceInterface
+	SMSService              SMSServiceInterface
+	WhatsAppService         WhatsAppServiceInterface
+	Config                  AuthConfig
 }
 
 // NewAuthService cria uma nova instância do serviço de autenticação
 func NewAuthService(
 	userRepo repositories.UserRepository,
 	tokenRepo repositories.TokenRepositoryInterface,
+	refreshTokenRepo repositories.RefreshTokenRepositoryInterface,
+	sessionRepo repositories.SessionRepositoryInterface,
+	recoveryCodeRepo repositories.MFARecoveryCodeRepositoryInterface,
+	webAuthnRepo repositories.WebAuthnRepositoryInterface,
+	linkedAccountRepo repositories.LinkedAccountRepositoryInterface,
+	passwordHistoryRepo repositories.PasswordHistoryRepositoryInterface,
+	authEventRepo repositories.AuthEventRepositoryInterface,
+	knownDeviceRepo repositories.KnownDeviceRepositoryInterface,
+	establishmentRoleRepo repositories.EstablishmentRoleRepositoryInterface,
+	establishmentMemberRepo repositories.EstablishmentMemberRepositoryInterface,
+	staffInvitationRepo repositories.StaffInvitationRepositoryInterface,
+	apiKeyRepo repositories.APIKeyRepositoryInterface,
+	personalDataRepo repositories.PersonalDataRepositoryInterface,
 	passwordUtil *utils.PasswordUtil,
 	jwtUtil *utils.JWTUtil,
+	totpUtil *utils.TOTPUtil,
+	webAuthnUtil *utils.WebAuthnUtil,
+	oidcUtil *utils.OIDCUtil,
 	emailService EmailServiceInterface,
 	smsService SMSServiceInterface,
 	whatsAppService WhatsAppServiceInterface,
 	config AuthConfig,
 ) *AuthService {
 	return &AuthService{
-		UserRepo:        userRepo,
-		TokenRepo:       tokenRepo,
-		PasswordUtil:    passwordUtil,
-		JWTUtil:         jwtUtil,
-		EmailService:    emailService,
-		SMSService:      smsService,
-		WhatsAppService: whatsAppService,
-		Config:          config,
+		UserRepo:                userRepo,
+		TokenRepo:               tokenRepo,
+		RefreshTokenRepo:        refreshTokenRepo,
+		SessionRepo:             sessionRepo,
+		RecoveryCodeRepo:        recoveryCodeRepo,
+		WebAuthnRepo:            webAuthnRepo,
+		LinkedAccountRepo:       linkedAccountRepo,
+		PasswordHistoryRepo:     passwordHistoryRepo,
+		AuthEventRepo:           authEventRepo,
+		KnownDeviceRepo:         knownDeviceRepo,
+		EstablishmentRoleRepo:   establishmentRoleRepo,
+		EstablishmentMemberRepo: establishmentMemberRepo,
+		StaffInvitationRepo:     staffInvitationRepo,
+		APIKeyRepo:              apiKeyRepo,
+		PersonalDataRepo:        personalDataRepo,
+		PasswordUtil:            passwordUtil,
+		JWTUtil:                 jwtUtil,
+		TOTPUtil:                totpUtil,
+		WebAuthnUtil:            webAuthnUtil,
+		OIDCUtil:                oidcUtil,
+		EmailService:            emailService,
+		SMSService:              smsService,
+		WhatsAppService:         whatsAppService,
+		Config:                  config,
 	}
 }
 
@@ -99,20 +230,42 @@ type RegisterRequest struct {
 
 // LoginRequest representa os dados de requisição para login
 type LoginRequest struct {
-	Email    string `json:"email" validate:"required,email"`
-	Password string `json:"password" validate:"required"`
+	Email      string `json:"email" validate:"required,email"`
+	Password   string `json:"password" validate:"required"`
+	DeviceName string `json:"device_name"`
+	ClientIP   string `json:"-"`
+	UserAgent  string `json:"-"`
+	// AllowedRoles restringe o login aos papéis do portal; vazio aceita todos
+	AllowedRoles []models.UserRole `json:"-"`
+}
+
+// LogoutRequest representa os dados de requisição para logout
+type LogoutRequest struct {
+	RefreshToken string `json:"refresh_token" validate:"required"`
 }
 
 // RefreshTokenRequest representa os dados de requisição para refresh token
 type RefreshTokenRequest struct {
 	RefreshToken string `json:"refresh_token" validate:"required"`
+	ClientIP     string `json:"-"`
+	UserAgent    string `json:"-"`
 }
 
 // ResetPasswordRequest representa os dados de requisição para recuperação de senha
 type ResetPasswordRequest struct {
-	Token           string `json:"token" validate:"required"`
+	Token string `json:"token" validate:"required"`
+	// Phone identifica o dono do token quando ele é um código recebido por SMS ou WhatsApp
+	Phone           string `json:"phone"`
 	Password        string `json:"password" validate:"required"`
 	ConfirmPassword string `json:"confirm_password" validate:"required,eqfield=Password"`
+	ClientIP        string `json:"-"`
+	UserAgent       string `json:"-"`
+}
+
+// VerifyResetCodeRequest representa os dados de requisição para conferência do código de recuperação
+type VerifyResetCodeRequest struct {
+	Phone string `json:"phone" validate:"required"`
+	Code  string `json:"code" validate:"required"`
 }
 
 // ResetPasswordToken representa os dados de requisição para recuperação de senha
@@ -137,11 +290,13 @@ type TokenResponse struct {
 	ExpiresIn    int64  `json:"expires_in"`
 }
 
-// Register registra um novo usuário
+// Register registra um novo usuário.
+// No modo de privacidade um email ou telefone já cadastrado não gera erro: o dono
+// da conta existente é avisado por email e o usuário retornado é nil.
 func (s *AuthService) Register(req RegisterRequest) (*models.User, error) {
 	// Validamos a senha
-	if err := s.PasswordUtil.ValidatePasswordStrength(req.Password); err != nil {
-		return nil, ErrPasswordTooWeak
+	if err := s.validatePasswordPolicy(req.Password, req.Name, req.Email, req.Phone); err != nil {
+		return nil, err
 	}
 
 	// Verificamos se as senhas sao iguais
@@ -152,6 +307,10 @@ func (s *AuthService) Register(req RegisterRequest) (*models.User, error) {
 	// Geramos o hash da senha
 	hashedPassword, err := s.PasswordUtil.HashPassword(req.Password)
 	if err != nil {
+		// O algoritmo de hash pode recusar a senha com o limite dele anexado ao erro
+		if errors.Is(err, utils.ErrPasswordTooLong) {
+			return nil, ErrPasswordTooWeak
+		}
 		return nil, err
 	}
 
@@ -162,12 +321,19 @@ func (s *AuthService) Register(req RegisterRequest) (*models.User, error) {
 		Name:         req.Name,
 		PasswordHash: hashedPassword,
 		Role:         req.Role,
-		Status:       models.UserStatusActive,
+		Status:       models.UserStatusPending,
 		Timezone:     req.Timezone,
 	}
 
 	// Salvamos no banco de dados
 	if err := s.UserRepo.Create(user); err != nil {
+		// No modo de privacidade o conflito e resolvido por email ao dono da conta existente
+		if err == repositories.ErrUserAlreadyExists && s.Config.PrivacyMode {
+			s.dispatchInBackground("aviso de tentativa de cadastro", func() error {
+				return s.notifyRegistrationAttempt(req)
+			})
+			return nil, nil
+		}
 		return nil, err
 	}
 
@@ -185,15 +351,34 @@ func (s *AuthService) Register(req RegisterRequest) (*models.User, error) {
 		}
 	}
 
+	// A conta so e ativada apos a confirmacao do email. Uma falha no envio
+	// nao desfaz o cadastro, ja que o usuario pode solicitar o reenvio.
+	if s.Config.PrivacyMode {
+		// O envio tambem sai da requisicao para que o tempo de resposta nao denuncie o conflito
+		s.dispatchInBackground("email de verificação", func() error {
+			return s.sendVerificationEmail(user, "", "")
+		})
+	} else {
+		s.sendVerificationEmail(user, "", "")
+	}
+
 	return user, nil
 }
 
 // Login realiza o login de um usuário
 func (s *AuthService) Login(req LoginRequest) (*models.User, *TokenResponse, error) {
-	// Buscamos o usuario pelo email
-	user, err := s.UserRepo.FindByEmail(req.Email)
+	// Buscamos o usuario pelo email, incluindo contas ainda nao verificadas
+	user, err := s.UserRepo.FindByEmailAnyStatus(req.Email)
 	if err != nil {
 		if err == repositories.ErrUserNotFound {
+			// Registramos o email informado, ja que nao ha conta a que associar o evento
+			s.recordAuthEvent(&models.AuthEvent{
+				Type:      models.AuthEventLoginFailed,
+				Email:     req.Email,
+				Details:   loginFailureUnknownEmail,
+				IPAddress: req.ClientIP,
+				UserAgent: req.UserAgent,
+			})
 			// Retornamos erro generico para evitar enumeracao de usuarios
 			return nil, nil, ErrInvalidLogin
 		}
@@ -201,44 +386,108 @@ func (s *AuthService) Login(req LoginRequest) (*models.User, *TokenResponse, err
 	}
 
 	// Variuficamos se o usuario esta ativo
-	if user.Status != models.UserStatusActive {
+	if user.Status != models.UserStatusActive && user.Status != models.UserStatusPending {
 		// Para usuarios bloqueados, informamos explicitamente
 		if user.Status == models.UserStatusBlocked {
+			s.recordUserEvent(models.AuthEventLoginFailed, user, req.ClientIP, req.UserAgent, loginFailureLocked)
 			return nil, nil, ErrUserBlocked
 		}
+		s.recordUserEvent(models.AuthEventLoginFailed, user, req.ClientIP, req.UserAgent, loginFailureInactive)
 		return nil, nil, ErrUserInactive
 	}
 
 	// Verificamos se o usuario esta bloqueado por tentativas de login
-	if user.FailedLoginCount >= s.Config.MaxLoginAttempts {
+	if user.IsLocked() {
+		s.recordUserEvent(models.AuthEventLoginFailed, user, req.ClientIP, req.UserAgent, loginFailureLocked)
 		return nil, nil, ErrUserBlocked
 	}
 
 	// Verificamos a senha
 	if err := s.PasswordUtil.VerifyPassword(user.PasswordHash, req.Password); err != nil {
-		// Incrementamos o contador de falhas
-		s.UserRepo.IncrementFailedLoginCount(user.ID)
+		// Contabilizamos a falha, bloqueando a conta ao atingir o limite
+		s.registerFailedLogin(user, req.ClientIP, req.UserAgent, loginFailureInvalidPassword)
 		return nil, nil, ErrInvalidLogin
 	}
 
+	// Hashes de algoritmos ou parametros antigos sao atualizados enquanto conhecemos a senha
+	s.upgradePasswordHash(user, req.Password)
+
+	// O papel so e conferido depois da senha, para nao revelar a quem nao a conhece o tipo da conta,
+	// e antes de qualquer efeito do login, como a sessao, o desafio do segundo fator ou o alerta de novo dispositivo
+	if !roleAllowed(user, req.AllowedRoles) {
+		s.recordUserEvent(models.AuthEventLoginFailed, user, req.ClientIP, req.UserAgent, loginFailureRoleNotAllowed)
+		return nil, nil, ErrRoleNotAllowed
+	}
+
+	// So informamos a falta de verificacao para quem conhece a senha
+	if user.Status == models.UserStatusPending {
+		return nil, nil, ErrEmailNotVerified
+	}
+
+	// Depois de um aviso de login nao reconhecido, a senha precisa ser redefinida
+	if user.PasswordResetRequired {
+		return nil, nil, ErrPasswordResetRequired
+	}
+
+	// Com o segundo fator habilitado, devolvemos um desafio em vez dos tokens
+	if user.TOTPEnabled {
+		challenge, err := s.createMFAChallenge(user)
+		if err != nil {
+			return nil, nil, err
+		}
+		return user, nil, &MFARequiredError{Challenge: challenge}
+	}
+
+	tokenResponse, err := s.completeLogin(user, req.DeviceName, req.ClientIP, req.UserAgent)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return user, tokenResponse, nil
+}
+
+// roleAllowed informa se o papel do usuário está entre os aceitos; sem papéis informados todos são aceitos
+func roleAllowed(user *models.User, roles []models.UserRole) bool {
+	if len(roles) == 0 {
+		return true
+	}
+
+	for _, role := range roles {
+		if user.Role == role {
+			return true
+		}
+	}
+	return false
+}
+
+// completeLogin finaliza um login bem sucedido, abrindo a sessão e emitindo os tokens
+func (s *AuthService) completeLogin(user *models.User, deviceName, clientIP, userAgent string) (*TokenResponse, error) {
+	// Vale para todos os metodos de login, ja que a conta pode ter sido comprometida
+	if user.PasswordResetRequired {
+		return nil, ErrPasswordResetRequired
+	}
+
 	// Resetamos o contador de falhas e atualizamos o ultimo login
 	s.UserRepo.ResetFailedLoginCount(user.ID)
 	s.UserRepo.UpdateLastLogin(user.ID)
 
-	// Geramos o par de token
-	accessToken, refreshToken, err := s.JWTUtil.GenerateTokenPair(user.ID, user.Role)
+	// Cada login inicia uma nova sessao, que tambem e a familia dos refresh tokens
+	session, err := s.startSession(user, deviceName, clientIP, userAgent)
 	if err != nil {
-		return nil, nil, err
+		return nil, err
 	}
 
-	// Criamos a respota
-	tokenResponse := &TokenResponse{
-		AccessToken:  accessToken,
-		RefreshToken: refreshToken,
-		ExpiresIn:    int64(utils.TokenExpirationAccess.Seconds()),
+	tokenResponse, err := s.issueTokenPair(user, session, clientIP, userAgent, nil)
+	if err != nil {
+		return nil, err
 	}
 
-	return user, tokenResponse, nil
+	s.recordUserEvent(models.AuthEventLoginSucceeded, user, clientIP, userAgent, "")
+
+	// Logins de dispositivos desconhecidos geram um aviso ao usuario
+	s.trackLoginDevice(user, deviceName, clientIP, userAgent)
+
+	return tokenResponse, nil
 }
 
 // RefreshToken renova o token de acesso usando um refresh token
@@ -246,12 +495,59 @@ func (s *AuthService) RefreshToken(req RefreshTokenRequest) (*TokenResponse, err
 	// Validamos o refresh token
 	claims, err := s.JWTUtil.ValidateRefreshToken(req.RefreshToken)
 	if err != nil {
+		return nil, ErrInvalidToken
+	}
+
+	// Buscamos o registro do token pelo hash
+	stored, err := s.RefreshTokenRepo.FindByTokenHash(s.PasswordUtil.HashToken(req.RefreshToken))
+	if err != nil {
+		if err == repositories.ErrRefreshTokenNotFound {
+			return nil, ErrInvalidToken
+		}
 		return nil, err
 	}
 
+	if stored.UserID != claims.UserID {
+		return nil, ErrInvalidToken
+	}
+
+	// Um token ja rotacionado sendo reapresentado indica vazamento: encerramos a sessao e revogamos toda a familia,
+	// para que os tokens de acesso ja emitidos tambem deixem de valer
+	if stored.IsRotated() {
+		if err := s.revokeSession(stored.FamilyID); err != nil {
+			return nil, err
+		}
+		s.recordAuthEvent(&models.AuthEvent{
+			Type:      models.AuthEventRefreshTokenReused,
+			UserID:    &stored.UserID,
+			IPAddress: req.ClientIP,
+			UserAgent: req.UserAgent,
+		})
+		return nil, ErrRefreshTokenReused
+	}
+
+	if !stored.IsValid() {
+		return nil, ErrInvalidToken
+	}
+
+	// Sessoes encerradas nao podem mais ser renovadas
+	session, err := s.SessionRepo.FindByID(stored.FamilyID)
+	if err != nil {
+		if err == repositories.ErrSessionNotFound {
+			return nil, ErrInvalidToken
+		}
+		return nil, err
+	}
+	if !session.IsActive() {
+		return nil, ErrInvalidToken
+	}
+
 	// Buscamos o usuario
 	user, err := s.UserRepo.FindByID(claims.UserID)
 	if err != nil {
+		if err == repositories.ErrUserNotFound {
+			return nil, ErrInvalidToken
+		}
 		return nil, err
 	}
 
@@ -260,24 +556,31 @@ func (s *AuthService) RefreshToken(req RefreshTokenRequest) (*TokenResponse, err
 		return nil, ErrUserInactive
 	}
 
-	// Geramos o novo par de tokens
-	accessToken, refreshToken, err := s.JWTUtil.GenerateTokenPair(user.ID, user.Role)
+	// Geramos o novo par de tokens na mesma sessao
+	tokenResponse, err := s.issueTokenPair(user, session, req.ClientIP, req.UserAgent, stored)
 	if err != nil {
 		return nil, err
 	}
 
-	// Criamos a resposta
-	tokenResponse := &TokenResponse{
-		AccessToken:  accessToken,
-		RefreshToken: refreshToken,
-		ExpiresIn:    int64(utils.TokenExpirationAccess.Seconds()),
-	}
+	s.recordUserEvent(models.AuthEventTokenRefreshed, user, req.ClientIP, req.UserAgent, "")
 
 	return tokenResponse, nil
 }
 
-// ForgotPasswordEmail inicia o processo de recuperação de senha via email
+// ForgotPasswordEmail inicia o processo de recuperação de senha via email.
+// No modo de privacidade a solicitação é processada em segundo plano e nunca retorna erro.
 func (s *AuthService) ForgotPasswordEmail(req ForgotPasswordRequest) error {
+	if s.Config.PrivacyMode {
+		s.dispatchInBackground("recuperação de senha via email", func() error {
+			return s.forgotPasswordEmail(req)
+		})
+		return nil
+	}
+	return s.forgotPasswordEmail(req)
+}
+
+// forgotPasswordEmail busca o usuário, gera o token de recuperação e o envia via email
+func (s *AuthService) forgotPasswordEmail(req ForgotPasswordRequest) error {
 	// Buscamos o usuario pelo email
 	user, err := s.UserRepo.FindByEmail(req.Email)
 	if err != nil {
@@ -293,7 +596,7 @@ func (s *AuthService) ForgotPasswordEmail(req ForgotPasswordRequest) error {
 	}
 
 	// Verificamos o rate limit
-	count, err := s.TokenRepo.CountActiveTokensByUser(user.ID, s.Config.ResetTokenRateWindow)
+	count, err := s.TokenRepo.CountTokensByUserAndPurpose(user.ID, models.TokenPurposePasswordReset, s.Config.ResetTokenRateWindow)
 	if err != nil {
 		return nil
 	}
@@ -302,7 +605,7 @@ func (s *AuthService) ForgotPasswordEmail(req ForgotPasswordRequest) error {
 	}
 
 	// Invalidamos todos os tokens ativos do usuario
-	if err := s.TokenRepo.InvalidateAllUserTokens(user.ID); err != nil {
+	if err := s.TokenRepo.InvalidateUserTokensByPurpose(user.ID, models.TokenPurposePasswordReset); err != nil {
 		return err
 	}
 
@@ -315,8 +618,9 @@ func (s *AuthService) ForgotPasswordEmail(req ForgotPasswordRequest) error {
 	// Criamos o registro do token
 	token := &models.PasswordResetToken{
 		UserID:    user.ID,
-		Token:     resetToken,
+		TokenHash: s.PasswordUtil.HashOneTimeToken(resetToken),
 		Channel:   models.TokenChannelEmail,
+		Purpose:   models.TokenPurposePasswordReset,
 		Status:    models.TokenStatusActive,
 		ExpiresAt: time.Now().Add(s.Config.ResetTokenEmailExpiration),
 		IPAddress: req.ClientIP,
@@ -327,12 +631,26 @@ func (s *AuthService) ForgotPasswordEmail(req ForgotPasswordRequest) error {
 		return err
 	}
 
+	s.recordUserEvent(models.AuthEventPasswordResetRequested, user, req.ClientIP, req.UserAgent, "channel="+string(models.TokenChannelEmail))
+
 	// Enviamos o email com o token
 	return s.EmailService.SendPasswordResetEmail(user.Email, user.Name, resetToken)
 }
 
-// ForgotPasswordSMS inicia o processo de recuperação de senha via SMS
+// ForgotPasswordSMS inicia o processo de recuperação de senha via SMS.
+// No modo de privacidade a solicitação é processada em segundo plano e nunca retorna erro.
 func (s *AuthService) ForgotPasswordSMS(req ForgotPasswordRequest) error {
+	if s.Config.PrivacyMode {
+		s.dispatchInBackground("recuperação de senha via SMS", func() error {
+			return s.forgotPasswordSMS(req)
+		})
+		return nil
+	}
+	return s.forgotPasswordSMS(req)
+}
+
+// forgotPasswordSMS busca o usuário, gera o token de recuperação e o envia via SMS
+func (s *AuthService) forgotPasswordSMS(req ForgotPasswordRequest) error {
 	// Buscamos o usario pelo telefone
 	user, err := s.UserRepo.FindByPhone(req.Phone)
 	if err != nil {
@@ -347,8 +665,13 @@ func (s *AuthService) ForgotPasswordSMS(req ForgotPasswordRequest) error {
 		return ErrUserInactive
 	}
 
+	// Apenas telefones verificados podem receber codigos de recuperacao
+	if user.PhoneVerifiedAt == nil {
+		return ErrPhoneNotVerified
+	}
+
 	// Verificamos o rate limit
-	count, err := s.TokenRepo.CountActiveTokensByUser(user.ID, s.Config.ResetTokenEmailExpiration)
+	count, err := s.TokenRepo.CountTokensByUserAndPurpose(user.ID, models.TokenPurposePasswordReset, s.Config.ResetTokenRateWindow)
 	if err != nil {
 		return err
 	}
@@ -357,7 +680,7 @@ func (s *AuthService) ForgotPasswordSMS(req ForgotPasswordRequest) error {
 	}
 
 	// Invalidamos todos os tokens ativos do usuario
-	if err := s.TokenRepo.InvalidateAllUserTokens(user.ID); err != nil {
+	if err := s.TokenRepo.InvalidateUserTokensByPurpose(user.ID, models.TokenPurposePasswordReset); err != nil {
 		return nil
 	}
 
@@ -369,8 +692,9 @@ func (s *AuthService) ForgotPasswordSMS(req ForgotPasswordRequest) error {
 
 	token := &models.PasswordResetToken{
 		UserID:    user.ID,
-		Token:     code,
+		TokenHash: s.PasswordUtil.HashOneTimeToken(code),
 		Channel:   models.TokenChannelSMS,
+		Purpose:   models.TokenPurposePasswordReset,
 		Status:    models.TokenStatusActive,
 		ExpiresAt: time.Now().Add(s.Config.ResetTokenSMSExpiration),
 		IPAddress: req.ClientIP,
@@ -381,12 +705,26 @@ func (s *AuthService) ForgotPasswordSMS(req ForgotPasswordRequest) error {
 		return err
 	}
 
+	s.recordUserEvent(models.AuthEventPasswordResetRequested, user, req.ClientIP, req.UserAgent, "channel="+string(models.TokenChannelSMS))
+
 	// Enviamos o SMS com o codigo
 	return s.SMSService.SendPasswordResetSMS(user.Phone, code)
 }
 
-// ForgotPasswordWhatsApp inicia o processo de recuperação de senha via WhatsApp
+// ForgotPasswordWhatsApp inicia o processo de recuperação de senha via WhatsApp.
+// No modo de privacidade a solicitação é processada em segundo plano e nunca retorna erro.
 func (s *AuthService) ForgotPasswordWhatsApp(req ForgotPasswordRequest) error {
+	if s.Config.PrivacyMode {
+		s.dispatchInBackground("recuperação de senha via WhatsApp", func() error {
+			return s.forgotPasswordWhatsApp(req)
+		})
+		return nil
+	}
+	return s.forgotPasswordWhatsApp(req)
+}
+
+// forgotPasswordWhatsApp busca o usuário, gera o token de recuperação e o envia via WhatsApp
+func (s *AuthService) forgotPasswordWhatsApp(req ForgotPasswordRequest) error {
 	// Buscamos o usuário pelo telefone
 	user, err := s.UserRepo.FindByPhone(req.Phone)
 	if err != nil {
@@ -401,8 +739,13 @@ func (s *AuthService) ForgotPasswordWhatsApp(req ForgotPasswordRequest) error {
 		return ErrUserInactive
 	}
 
+	// Apenas telefones verificados podem receber códigos de recuperação
+	if user.PhoneVerifiedAt == nil {
+		return ErrPhoneNotVerified
+	}
+
 	// Verificamos o rate limit
-	count, err := s.TokenRepo.CountActiveTokensByUser(user.ID, s.Config.ResetTokenRateWindow)
+	count, err := s.TokenRepo.CountTokensByUserAndPurpose(user.ID, models.TokenPurposePasswordReset, s.Config.ResetTokenRateWindow)
 	if err != nil {
 		return err
 	}
@@ -411,7 +754,7 @@ func (s *AuthService) ForgotPasswordWhatsApp(req ForgotPasswordRequest) error {
 	}
 
 	// Invalidamos todos os tokens ativos do usuário
-	if err := s.TokenRepo.InvalidateAllUserTokens(user.ID); err != nil {
+	if err := s.TokenRepo.InvalidateUserTokensByPurpose(user.ID, models.TokenPurposePasswordReset); err != nil {
 		return err
 	}
 
@@ -424,8 +767,9 @@ func (s *AuthService) ForgotPasswordWhatsApp(req ForgotPasswordRequest) error {
 	// Criamos o registro do token
 	token := &models.PasswordResetToken{
 		UserID:    user.ID,
-		Token:     code,
+		TokenHash: s.PasswordUtil.HashOneTimeToken(code),
 		Channel:   models.TokenChannelWhatsApp,
+		Purpose:   models.TokenPurposePasswordReset,
 		Status:    models.TokenStatusActive,
 		ExpiresAt: time.Now().Add(s.Config.ResetTokenSMSExpiration),
 		IPAddress: req.ClientIP,
@@ -436,47 +780,64 @@ func (s *AuthService) ForgotPasswordWhatsApp(req ForgotPasswordRequest) error {
 		return err
 	}
 
+	s.recordUserEvent(models.AuthEventPasswordResetRequested, user, req.ClientIP, req.UserAgent, "channel="+string(models.TokenChannelWhatsApp))
+
 	// Enviamos a mensagem WhatsApp com o código
 	return s.WhatsAppService.SendPasswordResetWhatsApp(user.Phone, user.Name, code)
 }
 
-// ValidateResetToken valida um token de recuperação de senha
+// ValidateResetToken valida um token de recuperação de senha enviado por email
 func (s *AuthService) ValidateResetToken(token string) error {
-	// Buscamos o token no banco
-	tokenObj, err := s.TokenRepo.FindByToken(token)
-	if err != nil {
-		return ErrInvalidToken
+	_, err := s.findResetToken(token, "")
+	return err
+}
+
+// VerifyResetCode confere o código de recuperação enviado por SMS ou WhatsApp sem consumi-lo.
+// Após 5 códigos errados o token é revogado e uma nova solicitação é necessária.
+func (s *AuthService) VerifyResetCode(req VerifyResetCodeRequest) error {
+	_, err := s.findResetToken(req.Code, req.Phone)
+	return err
+}
+
+// findResetToken busca um token de recuperação válido.
+// Tokens de email são buscados pelo valor; códigos são buscados entre os do dono do telefone.
+func (s *AuthService) findResetToken(value, phone string) (*models.PasswordResetToken, error) {
+	if phone == "" {
+		token, err := s.findTokenByValue(value)
+		if err != nil {
+			return nil, err
+		}
+
+		// Codigos curtos so sao aceitos junto com o telefone
+		if !token.IsValid() || token.Purpose != models.TokenPurposePasswordReset || token.Channel != models.TokenChannelEmail {
+			return nil, ErrInvalidToken
+		}
+		return token, nil
 	}
 
-	// Verificamos se o token eh valido
-	if !tokenObj.IsValid() {
-		return ErrInvalidToken
+	// Buscamos o usuario pelo telefone
+	user, err := s.UserRepo.FindByPhone(phone)
+	if err != nil {
+		if err == repositories.ErrUserNotFound {
+			return nil, ErrInvalidToken
+		}
+		return nil, err
 	}
 
-	return nil
+	return s.verifyUserCode(user.ID, models.TokenPurposePasswordReset, value)
 }
 
 // ResetPassword redefine a senha de um usuário usando o token de recuperação
 func (s *AuthService) ResetPassword(req ResetPasswordRequest) error {
-	// Validamos a nova senha
-	if err := s.PasswordUtil.ValidatePasswordStrength(req.Password); err != nil {
-		return ErrPasswordTooWeak
-	}
-
 	// Verificamos se a senhas sao iguais
 	if req.Password != req.ConfirmPassword {
 		return ErrPasswordConfirmation
 	}
 
 	// Buscamos o token no banco
-	token, err := s.TokenRepo.FindByToken(req.Token)
+	token, err := s.findResetToken(req.Token, req.Phone)
 	if err != nil {
-		return ErrInvalidToken
-	}
-
-	// Verificamos se o token eh valido
-	if !token.IsValid() {
-		return ErrInvalidToken
+		return err
 	}
 
 	// Buscamos o usuario
@@ -490,21 +851,32 @@ func (s *AuthService) ResetPassword(req ResetPasswordRequest) error {
 		return ErrUserInactive
 	}
 
-	// Geramos o hash da nova senha
-	hashedPassword, err := s.PasswordUtil.HashPassword(req.Password)
-	if err != nil {
+	// Validamos a nova senha, que nao pode repetir as ultimas senhas do usuario
+	if err := s.setPassword(user, req.Password); err != nil {
+		return err
+	}
+
+	// Consumimos o token antes de gravar a nova senha
+	if err := s.consumeToken(token); err != nil {
 		return err
 	}
 
 	// Atualizamos a senha do usuario
-	user.PasswordHash = hashedPassword
 	user.FailedLoginCount = 0 // Resetamos o contador de falhas
-	if err := s.UserRepo.Update(user); err != nil {
+	user.LockoutCount = 0
+	user.LockedUntil = nil // Quem redefine a senha comprova a posse da conta
+	columns := passwordColumns(user)
+	columns["failed_login_count"] = 0
+	columns["lockout_count"] = 0
+	columns["locked_until"] = nil
+	if err := s.UserRepo.UpdateColumns(user.ID, columns); err != nil {
 		return err
 	}
 
-	// Marcamos o token como usado
-	if err := s.TokenRepo.MarkTokenAsUsed(token.ID); err != nil {
+	s.recordUserEvent(models.AuthEventPasswordResetCompleted, user, req.ClientIP, req.UserAgent, "channel="+string(token.Channel))
+
+	// Encerramos as sessoes existentes, ja que a senha anterior pode ter sido comprometida
+	if err := s.LogoutAll(user.ID); err != nil {
 		return err
 	}
 
@@ -514,19 +886,30 @@ func (s *AuthService) ResetPassword(req ResetPasswordRequest) error {
 
 // GetUserFromToken obtem os dados do usuario a partir de um token JWT
 func (s *AuthService) GetUserFromToken(tokenString string) (*models.User, error) {
+	user, _, err := s.AuthenticateAccessToken(tokenString)
+	return user, err
+}
+
+// AuthenticateAccessToken valida um token de acesso e a sessao a que ele pertence
+func (s *AuthService) AuthenticateAccessToken(tokenString string) (*models.User, *utils.Claims, error) {
 	// Validamos o token
 	claims, err := s.JWTUtil.ValidateAccessToken(tokenString)
 	if err != nil {
-		return nil, ErrInvalidToken
+		return nil, nil, ErrInvalidToken
+	}
+
+	// Verificamos se a sessao do token continua ativa
+	if err := s.checkSession(claims); err != nil {
+		return nil, nil, err
 	}
 
 	// Buscamos o usuario
 	user, err := s.UserRepo.FindByID(claims.UserID)
 	if err != nil {
-		return nil, err
+		return nil, nil, err
 	}
 
-	return user, nil
+	return user, claims, nil
 }
 
 // ExtractTokenFromRequest extrai o token JWT do cabecalho de Authorization
@@ -537,3 +920,8 @@ func (s *AuthService) ExtractTokenFromRequest(r *http.Request) string {
 	}
 	return ""
 }
+
+// JWKS retorna as chaves públicas usadas para verificar os tokens emitidos
+func (s *AuthService) JWKS() utils.JWKS {
+	return s.JWTUtil.JWKS()
+}
@@ -0,0 +1,205 @@
+package services
+
+import (
+	"time"
+
+	"github.com/Barba2k2/aurora_backend/src/models"
+	"github.com/Barba2k2/aurora_backend/src/repositories"
+	"github.com/Barba2k2/aurora_backend/src/utils"
+	"github.com/google/uuid"
+)
+
+// sessionTouchInterval evita uma escrita no banco a cada requisição autenticada
+const sessionTouchInterval = 5 * time.Minute
+
+// SessionResponse representa uma sessão ativa na listagem do usuário
+type SessionResponse struct {
+	*models.Session
+	Current bool `json:"current"`
+}
+
+// startSession cria uma nova sessão para o usuário
+func (s *AuthService) startSession(user *models.User, deviceName, clientIP, userAgent string) (*models.Session, error) {
+	session := &models.Session{
+		ID:         uuid.New(),
+		UserID:     user.ID,
+		DeviceName: deviceName,
+		IPAddress:  clientIP,
+		UserAgent:  userAgent,
+		ExpiresAt:  time.Now().Add(utils.TokenExpirationRefresh),
+	}
+
+	if err := s.SessionRepo.Create(session); err != nil {
+		return nil, err
+	}
+
+	return session, nil
+}
+
+// issueTokenPair gera um par de tokens para a sessão e persiste o hash do refresh token.
+// Se previous for informado, ele é marcado como rotacionado.
+func (s *AuthService) issueTokenPair(user *models.User, session *models.Session, clientIP, userAgent string, previous *models.RefreshToken) (*TokenResponse, error) {
+	// Geramos o par de token
+	accessToken, refreshToken, err := s.JWTUtil.GenerateTokenPair(user.ID, user.Role, session.ID, session.EstablishmentID)
+	if err != nil {
+		return nil, err
+	}
+
+	expiresAt := time.Now().Add(utils.TokenExpirationRefresh)
+	stored := &models.RefreshToken{
+		ID:        uuid.New(),
+		UserID:    user.ID,
+		FamilyID:  session.ID,
+		TokenHash: s.PasswordUtil.HashToken(refreshToken),
+		Status:    models.TokenStatusActive,
+		ExpiresAt: expiresAt,
+		IPAddress: clientIP,
+		UserAgent: userAgent,
+	}
+
+	// Rotacionamos o token anterior antes de persistir o novo; se outra requisicao
+	// ja o rotacionou, tratamos como reutilizacao
+	if previous != nil {
+		if err := s.RefreshTokenRepo.MarkAsRotated(previous.ID, stored.ID); err != nil {
+			if err == repositories.ErrRefreshTokenAlreadyRotated {
+				s.revokeSession(session.ID)
+				return nil, ErrRefreshTokenReused
+			}
+			return nil, err
+		}
+
+		// A renovação mantém a sessão viva
+		if err := s.SessionRepo.Touch(session.ID, clientIP, userAgent, expiresAt); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := s.RefreshTokenRepo.Create(stored); err != nil {
+		return nil, err
+	}
+
+	// Criamos a respota
+	return &TokenResponse{
+		AccessToken:  accessToken,
+		RefreshToken: refreshToken,
+		ExpiresIn:    int64(utils.TokenExpirationAccess.Seconds()),
+	}, nil
+}
+
+// checkSession verifica se a sessão referenciada pelo token continua ativa
+func (s *AuthService) checkSession(claims *utils.Claims) error {
+	if claims.SessionID == uuid.Nil {
+		return ErrInvalidToken
+	}
+
+	session, err := s.SessionRepo.FindByID(claims.SessionID)
+	if err != nil {
+		if err == repositories.ErrSessionNotFound {
+			return ErrInvalidToken
+		}
+		return err
+	}
+
+	if session.UserID != claims.UserID || !session.IsActive() {
+		return ErrInvalidToken
+	}
+
+	// Tokens de personificacao so valem na sessao aberta pelo mesmo administrador
+	if !sameImpersonator(session.ImpersonatorID, claims.ImpersonatorID) {
+		return ErrInvalidToken
+	}
+
+	// Atualizamos o ultimo acesso com no maximo uma escrita por intervalo
+	if time.Since(session.LastSeenAt) > sessionTouchInterval {
+		s.SessionRepo.Touch(session.ID, "", "", time.Time{})
+	}
+
+	return nil
+}
+
+// revokeSession encerra uma sessão e todos os refresh tokens emitidos para ela
+func (s *AuthService) revokeSession(sessionID uuid.UUID) error {
+	if err := s.SessionRepo.Revoke(sessionID); err != nil {
+		return err
+	}
+
+	return s.RefreshTokenRepo.RevokeFamily(sessionID)
+}
+
+// Logout encerra a sessão a que pertence o refresh token informado
+func (s *AuthService) Logout(req LogoutRequest) error {
+	// Validamos o refresh token
+	claims, err := s.JWTUtil.ValidateRefreshToken(req.RefreshToken)
+	if err != nil {
+		return ErrInvalidToken
+	}
+
+	// Buscamos o registro do token pelo hash
+	stored, err := s.RefreshTokenRepo.FindByTokenHash(s.PasswordUtil.HashToken(req.RefreshToken))
+	if err != nil {
+		if err == repositories.ErrRefreshTokenNotFound {
+			return ErrInvalidToken
+		}
+		return err
+	}
+
+	if stored.UserID != claims.UserID {
+		return ErrInvalidToken
+	}
+
+	return s.revokeSession(stored.FamilyID)
+}
+
+// LogoutAll encerra todas as sessões do usuário
+func (s *AuthService) LogoutAll(userID uuid.UUID) error {
+	if err := s.SessionRepo.RevokeAllByUser(userID, nil); err != nil {
+		return err
+	}
+
+	return s.RefreshTokenRepo.RevokeAllUserTokens(userID)
+}
+
+// ListSessions lista as sessões ativas do usuário, indicando a sessão atual
+func (s *AuthService) ListSessions(userID, currentSessionID uuid.UUID) ([]*SessionResponse, error) {
+	sessions, err := s.SessionRepo.FindActiveByUser(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	response := make([]*SessionResponse, 0, len(sessions))
+	for _, session := range sessions {
+		response = append(response, &SessionResponse{
+			Session: session,
+			Current: session.ID == currentSessionID,
+		})
+	}
+
+	return response, nil
+}
+
+// RevokeSession encerra uma sessão específica do usuário
+func (s *AuthService) RevokeSession(userID, sessionID uuid.UUID) error {
+	session, err := s.SessionRepo.FindByID(sessionID)
+	if err != nil {
+		if err == repositories.ErrSessionNotFound {
+			return ErrSessionNotFound
+		}
+		return err
+	}
+
+	// Nao revelamos a existencia de sessoes de outros usuarios
+	if session.UserID != userID {
+		return ErrSessionNotFound
+	}
+
+	return s.revokeSession(session.ID)
+}
+
+// RevokeOtherSessions encerra todas as sessões do usuário exceto a atual
+func (s *AuthService) RevokeOtherSessions(userID, currentSessionID uuid.UUID) error {
+	if err := s.SessionRepo.RevokeAllByUser(userID, &currentSessionID); err != nil {
+		return err
+	}
+
+	return s.RefreshTokenRepo.RevokeAllUserTokensExceptFamily(userID, currentSessionID)
+}
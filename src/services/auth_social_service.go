@@ -0,0 +1,295 @@
+package services
+
+import (
+	"time"
+
+	"github.com/Barba2k2/aurora_backend/src/models"
+	"github.com/Barba2k2/aurora_backend/src/repositories"
+	"github.com/Barba2k2/aurora_backend/src/utils"
+)
+
+// SocialLoginRequest representa os dados de requisição para login com um provedor de identidade
+type SocialLoginRequest struct {
+	IDToken    string `json:"id_token" validate:"required"`
+	Nonce      string `json:"nonce" validate:"required"`
+	Name       string `json:"name"`
+	DeviceName string `json:"device_name"`
+	ClientIP   string `json:"-"`
+	UserAgent  string `json:"-"`
+}
+
+// LinkProviderRequest representa os dados de requisição para vincular um provedor de identidade
+type LinkProviderRequest struct {
+	IDToken string `json:"id_token" validate:"required"`
+	Nonce   string `json:"nonce" validate:"required"`
+}
+
+// providerReauthMaxAge é a idade máxima do ID token aceito para confirmar a identidade em ações sensíveis
+const providerReauthMaxAge = 5 * time.Minute
+
+// verifyProviderToken valida o ID token emitido pelo provedor
+func (s *AuthService) verifyProviderToken(provider, idToken, nonce string) (*utils.OIDCIdentity, error) {
+	identity, err := s.OIDCUtil.VerifyIDToken(provider, idToken, nonce)
+	if err != nil {
+		if err == utils.ErrOIDCProviderNotConfigured {
+			return nil, ErrProviderNotSupported
+		}
+		return nil, ErrInvalidToken
+	}
+	return identity, nil
+}
+
+// LoginWithProvider autentica com um ID token do Google ou da Apple.
+// A identidade é vinculada a um cliente existente pelo email verificado ou gera um novo cliente;
+// outros papéis são recusados.
+func (s *AuthService) LoginWithProvider(provider string, req SocialLoginRequest) (*models.User, *TokenResponse, error) {
+	identity, err := s.verifyProviderToken(provider, req.IDToken, req.Nonce)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	user, err := s.findOrCreateProviderUser(provider, identity, req.Name)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	// Verificamos se o usuario esta ativo
+	if user.Status != models.UserStatusActive {
+		if user.Status == models.UserStatusBlocked {
+			return nil, nil, ErrUserBlocked
+		}
+		return nil, nil, ErrUserInactive
+	}
+
+	// Verificamos se o usuario esta bloqueado por tentativas de login
+	if user.IsLocked() {
+		return nil, nil, ErrUserBlocked
+	}
+
+	// Com o segundo fator habilitado, devolvemos um desafio em vez dos tokens
+	if user.TOTPEnabled {
+		challenge, err := s.createMFAChallenge(user)
+		if err != nil {
+			return nil, nil, err
+		}
+		return user, nil, &MFARequiredError{Challenge: challenge}
+	}
+
+	tokenResponse, err := s.completeLogin(user, req.DeviceName, req.ClientIP, req.UserAgent)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return user, tokenResponse, nil
+}
+
+// findOrCreateProviderUser resolve o usuário de uma identidade do provedor
+func (s *AuthService) findOrCreateProviderUser(provider string, identity *utils.OIDCIdentity, name string) (*models.User, error) {
+	// Identidade ja vinculada
+	account, err := s.LinkedAccountRepo.FindByProviderSubject(provider, identity.Subject)
+	if err == nil {
+		user, err := s.UserRepo.FindByIDAnyStatus(account.UserID)
+		if err != nil {
+			if err == repositories.ErrUserNotFound {
+				return nil, ErrInvalidToken
+			}
+			return nil, err
+		}
+		if user.Role != models.UserRoleClient {
+			return nil, ErrSocialLoginDenied
+		}
+		return user, nil
+	}
+	if err != repositories.ErrLinkedAccountNotFound {
+		return nil, err
+	}
+
+	// Sem vinculo, so confiamos no email se o provedor o tiver verificado
+	if identity.Email == "" || !identity.EmailVerified {
+		return nil, ErrProviderEmailMissing
+	}
+
+	user, err := s.UserRepo.FindByEmailAnyStatus(identity.Email)
+	if err != nil && err != repositories.ErrUserNotFound {
+		return nil, err
+	}
+
+	// So vinculamos clientes; contas de profissionais, equipe e administradores sao recusadas antes de qualquer escrita
+	if user != nil && user.Role != models.UserRoleClient {
+		return nil, ErrSocialLoginDenied
+	}
+
+	if user == nil {
+		user, err = s.createProviderUser(identity, name)
+		if err != nil {
+			return nil, err
+		}
+	} else if user.Status == models.UserStatusPending {
+		if err := s.activatePendingProviderUser(user); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := s.LinkedAccountRepo.Create(&models.LinkedAccount{
+		UserID:   user.ID,
+		Provider: provider,
+		Subject:  identity.Subject,
+		Email:    identity.Email,
+	}); err != nil {
+		if err == repositories.ErrLinkedAccountAlreadyExists {
+			return nil, ErrProviderAlreadyLinked
+		}
+		return nil, err
+	}
+
+	return user, nil
+}
+
+// activatePendingProviderUser ativa um cadastro pendente cujo email o provedor comprovou.
+// Quem fez o cadastro pode nao ser o dono do email, entao a senha escolhida nele e descartada
+// e os links pendentes sao invalidados; o dono pode definir uma senha pela recuperacao de senha.
+func (s *AuthService) activatePendingProviderUser(user *models.User) error {
+	hashedPassword, err := s.unusablePasswordHash()
+	if err != nil {
+		return err
+	}
+
+	now := time.Now()
+	user.PasswordHash = hashedPassword
+	user.EmailVerifiedAt = &now
+	user.Status = models.UserStatusActive
+	if err := s.UserRepo.UpdateColumns(user.ID, map[string]interface{}{
+		"password_hash":     user.PasswordHash,
+		"email_verified_at": user.EmailVerifiedAt,
+		"status":            user.Status,
+	}); err != nil {
+		return err
+	}
+
+	if err := s.TokenRepo.InvalidateAllUserTokens(user.ID); err != nil {
+		return err
+	}
+
+	return s.LogoutAll(user.ID)
+}
+
+// unusablePasswordHash gera o hash de uma senha aleatória que ninguém conhece
+func (s *AuthService) unusablePasswordHash() (string, error) {
+	randomPassword, err := s.PasswordUtil.GenerateRandomToken(32)
+	if err != nil {
+		return "", err
+	}
+	return s.PasswordUtil.HashPassword(randomPassword)
+}
+
+// createProviderUser cria um novo cliente a partir da identidade do provedor
+func (s *AuthService) createProviderUser(identity *utils.OIDCIdentity, name string) (*models.User, error) {
+	if name == "" {
+		name = identity.Name
+	}
+	if name == "" {
+		name = identity.Email
+	}
+
+	// A conta nao tem senha conhecida; o cliente pode definir uma pela recuperacao de senha
+	hashedPassword, err := s.unusablePasswordHash()
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	user := &models.User{
+		Email:           identity.Email,
+		Name:            name,
+		PasswordHash:    hashedPassword,
+		Role:            models.UserRoleClient,
+		Status:          models.UserStatusActive,
+		EmailVerifiedAt: &now,
+	}
+
+	if err := s.UserRepo.Create(user); err != nil {
+		return nil, err
+	}
+
+	return user, nil
+}
+
+// ListLinkedAccounts lista os provedores vinculados ao usuário
+func (s *AuthService) ListLinkedAccounts(user *models.User) ([]*models.LinkedAccount, error) {
+	return s.LinkedAccountRepo.FindByUser(user.ID)
+}
+
+// LinkProvider vincula a identidade de um provedor ao usuário autenticado
+func (s *AuthService) LinkProvider(user *models.User, provider string, req LinkProviderRequest) (*models.LinkedAccount, error) {
+	identity, err := s.verifyProviderToken(provider, req.IDToken, req.Nonce)
+	if err != nil {
+		return nil, err
+	}
+
+	account := &models.LinkedAccount{
+		UserID:   user.ID,
+		Provider: provider,
+		Subject:  identity.Subject,
+		Email:    identity.Email,
+	}
+
+	if err := s.LinkedAccountRepo.Create(account); err != nil {
+		if err == repositories.ErrLinkedAccountAlreadyExists {
+			return nil, ErrProviderAlreadyLinked
+		}
+		return nil, err
+	}
+
+	return account, nil
+}
+
+// UnlinkProvider remove o vínculo de um provedor com o usuário autenticado
+func (s *AuthService) UnlinkProvider(user *models.User, provider string) error {
+	if err := s.LinkedAccountRepo.DeleteByUserAndProvider(user.ID, provider); err != nil {
+		if err == repositories.ErrLinkedAccountNotFound {
+			return ErrProviderNotLinked
+		}
+		return err
+	}
+	return nil
+}
+
+// reauthenticate confirma a identidade do usuário antes de uma ação sensível, já que o token de acesso
+// pode ter sido obtido por terceiros. Vale a senha atual ou, para contas criadas pelo login social e sem
+// senha utilizável, um ID token recente de um provedor vinculado à conta.
+func (s *AuthService) reauthenticate(user *models.User, password, provider, idToken, nonce string) error {
+	if password != "" {
+		if err := s.PasswordUtil.VerifyPassword(user.PasswordHash, password); err != nil {
+			return ErrInvalidLogin
+		}
+		return nil
+	}
+
+	if idToken == "" {
+		return ErrInvalidLogin
+	}
+
+	identity, err := s.verifyProviderToken(provider, idToken, nonce)
+	if err != nil {
+		return ErrInvalidLogin
+	}
+
+	// Exigimos um login recente no provedor, e nao um ID token guardado de outro momento
+	if time.Since(identity.IssuedAt) > providerReauthMaxAge {
+		return ErrInvalidLogin
+	}
+
+	// A identidade precisa estar vinculada a esta conta
+	account, err := s.LinkedAccountRepo.FindByProviderSubject(provider, identity.Subject)
+	if err != nil {
+		if err == repositories.ErrLinkedAccountNotFound {
+			return ErrInvalidLogin
+		}
+		return err
+	}
+	if account.UserID != user.ID {
+		return ErrInvalidLogin
+	}
+
+	return nil
+}
@@ -0,0 +1,508 @@
+package services
+
+import (
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/Barba2k2/aurora_backend/src/models"
+	"github.com/Barba2k2/aurora_backend/src/repositories"
+	"github.com/Barba2k2/aurora_backend/src/utils"
+	"github.com/google/uuid"
+)
+
+// InviteStaffRequest representa os dados de requisição para convidar alguém para a equipe do estabelecimento.
+// O convite é enviado por email ou, sem email, por WhatsApp para o telefone informado.
+type InviteStaffRequest struct {
+	Name   string     `json:"name" validate:"max=255"`
+	Email  string     `json:"email" validate:"omitempty,email"`
+	Phone  string     `json:"phone" validate:"omitempty,phone"`
+	RoleID *uuid.UUID `json:"role_id"`
+}
+
+// AcceptStaffInvitationRequest representa os dados de requisição para aceitar um convite criando a conta.
+// O email é obrigatório quando o convite foi enviado para um telefone.
+type AcceptStaffInvitationRequest struct {
+	Token           string `json:"token" validate:"required"`
+	Name            string `json:"name" validate:"required"`
+	Email           string `json:"email" validate:"omitempty,email"`
+	Phone           string `json:"phone" validate:"omitempty,phone"`
+	Password        string `json:"password" validate:"required"`
+	ConfirmPassword string `json:"confirm_password" validate:"required,eqfield=Password"`
+	Timezone        string `json:"timezone"`
+	ClientIP        string `json:"-"`
+	UserAgent       string `json:"-"`
+}
+
+// JoinEstablishmentRequest representa os dados de requisição para um membro já cadastrado aceitar um convite
+type JoinEstablishmentRequest struct {
+	Token     string `json:"token" validate:"required"`
+	ClientIP  string `json:"-"`
+	UserAgent string `json:"-"`
+}
+
+// SwitchEstablishmentRequest representa os dados de requisição para troca do estabelecimento da sessão.
+// O refresh token atual é rotacionado, já que o novo par carrega o estabelecimento escolhido.
+type SwitchEstablishmentRequest struct {
+	EstablishmentID uuid.UUID `json:"establishment_id" validate:"required"`
+	RefreshToken    string    `json:"refresh_token" validate:"required"`
+	ClientIP        string    `json:"-"`
+	UserAgent       string    `json:"-"`
+}
+
+// MembershipResponse representa um estabelecimento de que o usuário faz parte
+type MembershipResponse struct {
+	Establishment *models.Establishment `json:"establishment"`
+	RoleID        *uuid.UUID            `json:"role_id,omitempty"`
+	JoinedAt      time.Time             `json:"joined_at"`
+	// Current indica o estabelecimento em que a sessão atua
+	Current bool `json:"current"`
+}
+
+// StaffMemberResponse representa um membro na listagem da equipe do estabelecimento
+type StaffMemberResponse struct {
+	*models.EstablishmentMember
+	Name  string `json:"name"`
+	Email string `json:"email"`
+	Phone string `json:"phone,omitempty"`
+}
+
+// InviteStaff convida alguém para a equipe do estabelecimento em que o responsável atua.
+// Um novo convite para o mesmo contato substitui os convites pendentes anteriores.
+func (s *AuthService) InviteStaff(inviter *models.User, actor *PermissionSet, req InviteStaffRequest) (*models.StaffInvitation, error) {
+	if actor.EstablishmentID == nil {
+		return nil, ErrEstablishmentNotFound
+	}
+
+	email := strings.ToLower(strings.TrimSpace(req.Email))
+	phone := strings.TrimSpace(req.Phone)
+	if email == "" && phone == "" {
+		return nil, ErrContactRequired
+	}
+
+	// O papel do convite segue as mesmas regras da atribuicao de papeis
+	if err := s.checkRoleGrant(actor, req.RoleID); err != nil {
+		return nil, err
+	}
+
+	establishment, err := s.UserRepo.FindEstablishmentByID(*actor.EstablishmentID)
+	if err != nil {
+		if err == repositories.ErrUserNotFound {
+			return nil, ErrEstablishmentNotFound
+		}
+		return nil, err
+	}
+
+	// Quem ja faz parte da equipe nao precisa de convite
+	if existing := s.findUserByContact(email, phone); existing != nil {
+		if _, err := s.EstablishmentMemberRepo.FindByEstablishmentAndUser(establishment.ID, existing.ID); err == nil {
+			return nil, ErrAlreadyMember
+		}
+	}
+
+	if err := s.StaffInvitationRepo.RevokePendingByContact(establishment.ID, email, phone); err != nil {
+		return nil, err
+	}
+
+	token, err := s.PasswordUtil.GenerateRandomToken(32)
+	if err != nil {
+		return nil, err
+	}
+
+	invitation := &models.StaffInvitation{
+		EstablishmentID: establishment.ID,
+		InvitedBy:       inviter.ID,
+		Name:            strings.TrimSpace(req.Name),
+		Email:           email,
+		Phone:           phone,
+		RoleID:          req.RoleID,
+		TokenHash:       s.PasswordUtil.HashOneTimeToken(token),
+		Status:          models.InvitationStatusPending,
+		ExpiresAt:       time.Now().Add(s.Config.StaffInvitationExpiration),
+	}
+	if err := s.StaffInvitationRepo.Create(invitation); err != nil {
+		return nil, err
+	}
+
+	// O email tem prioridade; o telefone so e usado em convites sem email
+	if email != "" {
+		err = s.EmailService.SendStaffInvitationEmail(email, invitation.Name, establishment.BussinessName, token, invitation.ExpiresAt)
+	} else {
+		err = s.WhatsAppService.SendStaffInvitationWhatsApp(phone, invitation.Name, establishment.BussinessName, token, invitation.ExpiresAt)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return invitation, nil
+}
+
+// ListStaffInvitations lista os convites pendentes do estabelecimento
+func (s *AuthService) ListStaffInvitations(actor *PermissionSet) ([]*models.StaffInvitation, error) {
+	if actor.EstablishmentID == nil {
+		return nil, ErrEstablishmentNotFound
+	}
+
+	return s.StaffInvitationRepo.FindPendingByEstablishment(*actor.EstablishmentID)
+}
+
+// RevokeStaffInvitation cancela um convite pendente do estabelecimento
+func (s *AuthService) RevokeStaffInvitation(actor *PermissionSet, invitationID uuid.UUID) error {
+	if actor.EstablishmentID == nil {
+		return ErrEstablishmentNotFound
+	}
+
+	invitation, err := s.StaffInvitationRepo.FindByID(*actor.EstablishmentID, invitationID)
+	if err != nil {
+		if err == repositories.ErrStaffInvitationNotFound {
+			return ErrInvitationNotFound
+		}
+		return err
+	}
+
+	if !invitation.IsValid() {
+		return ErrInvitationNotFound
+	}
+
+	return s.StaffInvitationRepo.Revoke(invitation.ID)
+}
+
+// AcceptStaffInvitation aceita um convite criando a conta do novo membro da equipe.
+// O link do convite comprova o contato para o qual foi enviado: convites por email
+// criam a conta já ativa, enquanto convites por telefone ainda exigem a confirmação do email.
+func (s *AuthService) AcceptStaffInvitation(req AcceptStaffInvitationRequest) (*models.User, error) {
+	invitation, err := s.findStaffInvitation(req.Token)
+	if err != nil {
+		return nil, err
+	}
+
+	email := invitation.Email
+	if email == "" {
+		email = strings.ToLower(strings.TrimSpace(req.Email))
+	}
+	if email == "" {
+		return nil, ErrEmailRequired
+	}
+	phone := invitation.Phone
+	if phone == "" {
+		phone = strings.TrimSpace(req.Phone)
+	}
+
+	// Contas existentes aceitam o convite depois do login
+	if existing := s.findUserByContact(email, phone); existing != nil {
+		if existing.Role == models.UserRoleStaff {
+			return nil, ErrInvitationNeedsLogin
+		}
+		return nil, ErrStaffRoleRequired
+	}
+
+	if err := s.validatePasswordPolicy(req.Password, req.Name, email, phone); err != nil {
+		return nil, err
+	}
+	if req.Password != req.ConfirmPassword {
+		return nil, ErrPasswordConfirmation
+	}
+
+	hashedPassword, err := s.PasswordUtil.HashPassword(req.Password)
+	if err != nil {
+		// O algoritmo de hash pode recusar a senha com o limite dele anexado ao erro
+		if errors.Is(err, utils.ErrPasswordTooLong) {
+			return nil, ErrPasswordTooWeak
+		}
+		return nil, err
+	}
+
+	now := time.Now()
+	user := &models.User{
+		ID:           uuid.New(),
+		Email:        email,
+		Phone:        phone,
+		Name:         req.Name,
+		PasswordHash: hashedPassword,
+		Role:         models.UserRoleStaff,
+		Status:       models.UserStatusPending,
+		Timezone:     req.Timezone,
+	}
+	if invitation.Email != "" {
+		user.Status = models.UserStatusActive
+		user.EmailVerifiedAt = &now
+	} else {
+		user.PhoneVerifiedAt = &now
+	}
+
+	// A conta, o vinculo e o aceite sao gravados juntos; uma falha nao consome o convite
+	if err := s.acceptInvitation(invitation, user, true, req.ClientIP, req.UserAgent); err != nil {
+		return nil, err
+	}
+
+	// Sem email comprovado a conta so e ativada apos a confirmacao
+	if user.Status == models.UserStatusPending {
+		s.sendVerificationEmail(user, req.ClientIP, req.UserAgent)
+	}
+
+	return user, nil
+}
+
+// AcceptStaffInvitationAsUser aceita um convite com uma conta de equipe já existente,
+// adicionando um novo estabelecimento aos vínculos do usuário
+func (s *AuthService) AcceptStaffInvitationAsUser(user *models.User, req JoinEstablishmentRequest) (*models.EstablishmentMember, error) {
+	if user.Role != models.UserRoleStaff {
+		return nil, ErrStaffRoleRequired
+	}
+
+	invitation, err := s.findStaffInvitation(req.Token)
+	if err != nil {
+		return nil, err
+	}
+
+	// O convite vale apenas para o contato a que foi enviado
+	if invitation.Email != "" && !strings.EqualFold(invitation.Email, user.Email) {
+		return nil, ErrInvitationMismatch
+	}
+	if invitation.Email == "" && invitation.Phone != user.Phone {
+		return nil, ErrInvitationMismatch
+	}
+
+	if _, err := s.EstablishmentMemberRepo.FindByEstablishmentAndUser(invitation.EstablishmentID, user.ID); err == nil {
+		return nil, ErrAlreadyMember
+	} else if err != repositories.ErrEstablishmentMemberNotFound {
+		return nil, err
+	}
+
+	if err := s.acceptInvitation(invitation, user, false, req.ClientIP, req.UserAgent); err != nil {
+		return nil, err
+	}
+
+	return s.EstablishmentMemberRepo.FindByEstablishmentAndUser(invitation.EstablishmentID, user.ID)
+}
+
+// findStaffInvitation busca um convite válido pelo token recebido no link
+func (s *AuthService) findStaffInvitation(token string) (*models.StaffInvitation, error) {
+	invitation, err := s.StaffInvitationRepo.FindByTokenHash(s.PasswordUtil.HashOneTimeToken(token))
+	if err != nil {
+		if err == repositories.ErrStaffInvitationNotFound {
+			return nil, ErrInvitationNotFound
+		}
+		return nil, err
+	}
+
+	if !invitation.IsValid() {
+		return nil, ErrInvitationNotFound
+	}
+
+	// Convites de estabelecimentos desativados deixam de valer
+	if _, err := s.UserRepo.FindEstablishmentByID(invitation.EstablishmentID); err != nil {
+		if err == repositories.ErrUserNotFound {
+			return nil, ErrInvitationNotFound
+		}
+		return nil, err
+	}
+
+	return invitation, nil
+}
+
+// acceptInvitation consome o convite e vincula o usuário ao estabelecimento com o papel escolhido por quem convidou,
+// em uma única transação. Com newUser a conta do convidado é criada na mesma transação.
+func (s *AuthService) acceptInvitation(invitation *models.StaffInvitation, user *models.User, newUser bool, clientIP, userAgent string) error {
+	member := &models.EstablishmentMember{
+		EstablishmentID: invitation.EstablishmentID,
+		UserID:          user.ID,
+		RoleID:          invitation.RoleID,
+	}
+
+	var createdUser *models.User
+	if newUser {
+		createdUser = user
+	}
+
+	if err := s.StaffInvitationRepo.Accept(invitation.ID, createdUser, member); err != nil {
+		switch err {
+		case repositories.ErrStaffInvitationNotFound:
+			return ErrInvitationNotFound
+		case repositories.ErrUserAlreadyExists:
+			return ErrEmailAlreadyInUse
+		case repositories.ErrEstablishmentMemberAlreadyExists:
+			return ErrAlreadyMember
+		}
+		return err
+	}
+
+	s.recordAuthEvent(&models.AuthEvent{
+		Type:      models.AuthEventMemberAdded,
+		UserID:    &user.ID,
+		ActorID:   &invitation.InvitedBy,
+		Email:     user.Email,
+		Details:   invitation.EstablishmentID.String(),
+		IPAddress: clientIP,
+		UserAgent: userAgent,
+	})
+
+	return nil
+}
+
+// findUserByContact busca uma conta pelo email ou, na falta dele, pelo telefone
+func (s *AuthService) findUserByContact(email, phone string) *models.User {
+	if email != "" {
+		if user, err := s.UserRepo.FindByEmailAnyStatus(email); err == nil {
+			return user
+		}
+	}
+	if phone != "" {
+		if user, err := s.UserRepo.FindByPhoneAnyStatus(phone); err == nil {
+			return user
+		}
+	}
+	return nil
+}
+
+// ListMemberships lista os estabelecimentos de que o usuário faz parte.
+// Sem estabelecimento escolhido na sessão, o primeiro vínculo é o atual.
+func (s *AuthService) ListMemberships(user *models.User, currentEstablishmentID *uuid.UUID) ([]*MembershipResponse, error) {
+	members, err := s.EstablishmentMemberRepo.FindAllByUser(user.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	response := make([]*MembershipResponse, 0, len(members))
+	for i, member := range members {
+		establishment, err := s.UserRepo.FindEstablishmentByID(member.EstablishmentID)
+		if err != nil {
+			// Estabelecimentos desativados nao aparecem na listagem
+			if err == repositories.ErrUserNotFound {
+				continue
+			}
+			return nil, err
+		}
+
+		current := i == 0
+		if currentEstablishmentID != nil {
+			current = member.EstablishmentID == *currentEstablishmentID
+		}
+
+		response = append(response, &MembershipResponse{
+			Establishment: establishment,
+			RoleID:        member.RoleID,
+			JoinedAt:      member.CreatedAt,
+			Current:       current,
+		})
+	}
+
+	return response, nil
+}
+
+// ListEstablishmentMembers lista a equipe do estabelecimento em que o responsável atua
+func (s *AuthService) ListEstablishmentMembers(actor *PermissionSet) ([]*StaffMemberResponse, error) {
+	if actor.EstablishmentID == nil {
+		return nil, ErrEstablishmentNotFound
+	}
+
+	members, err := s.EstablishmentMemberRepo.FindAllByEstablishment(*actor.EstablishmentID)
+	if err != nil {
+		return nil, err
+	}
+
+	response := make([]*StaffMemberResponse, 0, len(members))
+	for _, member := range members {
+		user, err := s.UserRepo.FindByIDAnyStatus(member.UserID)
+		if err != nil {
+			if err == repositories.ErrUserNotFound {
+				continue
+			}
+			return nil, err
+		}
+
+		response = append(response, &StaffMemberResponse{
+			EstablishmentMember: member,
+			Name:                user.Name,
+			Email:               user.Email,
+			Phone:               user.Phone,
+		})
+	}
+
+	return response, nil
+}
+
+// RemoveMember remove um membro da equipe do estabelecimento.
+// O responsável só remove membros cujas permissões ele também tem; a remoção vale na próxima requisição do membro.
+func (s *AuthService) RemoveMember(actorID uuid.UUID, actor *PermissionSet, userID uuid.UUID, clientIP, userAgent string) error {
+	if actor.EstablishmentID == nil {
+		return ErrEstablishmentNotFound
+	}
+
+	member, err := s.EstablishmentMemberRepo.FindByEstablishmentAndUser(*actor.EstablishmentID, userID)
+	if err != nil {
+		if err == repositories.ErrEstablishmentMemberNotFound {
+			return ErrMemberNotFound
+		}
+		return err
+	}
+
+	permissions, err := s.memberPermissions(member)
+	if err != nil {
+		return err
+	}
+	for _, permission := range permissions.List() {
+		if !actor.Has(permission) {
+			return ErrPermissionEscalation
+		}
+	}
+
+	if err := s.EstablishmentMemberRepo.Delete(member.ID); err != nil {
+		return err
+	}
+
+	s.recordAuthEvent(&models.AuthEvent{
+		Type:      models.AuthEventMemberRemoved,
+		UserID:    &member.UserID,
+		ActorID:   &actorID,
+		Details:   member.EstablishmentID.String(),
+		IPAddress: clientIP,
+		UserAgent: userAgent,
+	})
+
+	return nil
+}
+
+// SwitchEstablishment troca o estabelecimento em que a sessão atua e emite um novo par de tokens com ele
+func (s *AuthService) SwitchEstablishment(user *models.User, sessionID uuid.UUID, req SwitchEstablishmentRequest) (*TokenResponse, error) {
+	if _, err := s.EstablishmentMemberRepo.FindByEstablishmentAndUser(req.EstablishmentID, user.ID); err != nil {
+		if err == repositories.ErrEstablishmentMemberNotFound {
+			return nil, ErrMemberNotFound
+		}
+		return nil, err
+	}
+
+	if _, err := s.UserRepo.FindEstablishmentByID(req.EstablishmentID); err != nil {
+		if err == repositories.ErrUserNotFound {
+			return nil, ErrEstablishmentNotFound
+		}
+		return nil, err
+	}
+
+	// O refresh token precisa ser o atual da sessao do token de acesso
+	stored, err := s.RefreshTokenRepo.FindByTokenHash(s.PasswordUtil.HashToken(req.RefreshToken))
+	if err != nil {
+		if err == repositories.ErrRefreshTokenNotFound {
+			return nil, ErrInvalidToken
+		}
+		return nil, err
+	}
+	if stored.UserID != user.ID || stored.FamilyID != sessionID || !stored.IsValid() {
+		return nil, ErrInvalidToken
+	}
+
+	session, err := s.SessionRepo.FindByID(sessionID)
+	if err != nil {
+		if err == repositories.ErrSessionNotFound {
+			return nil, ErrSessionNotFound
+		}
+		return nil, err
+	}
+
+	if err := s.SessionRepo.UpdateEstablishment(session.ID, &req.EstablishmentID); err != nil {
+		return nil, err
+	}
+	session.EstablishmentID = &req.EstablishmentID
+
+	return s.issueTokenPair(user, session, req.ClientIP, req.UserAgent, stored)
+}
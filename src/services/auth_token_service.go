@@ -0,0 +1,59 @@
+package services
+
+import (
+	"crypto/subtle"
+
+	"github.com/Barba2k2/aurora_backend/src/models"
+	"github.com/Barba2k2/aurora_backend/src/repositories"
+	"github.com/google/uuid"
+)
+
+// findTokenByValue busca um token de uso único pelo valor recebido, que só existe como hash no banco.
+// Deve ser usada apenas para tokens aleatórios longos; códigos numéricos são buscados por usuário.
+func (s *AuthService) findTokenByValue(value string) (*models.PasswordResetToken, error) {
+	token, err := s.TokenRepo.FindByTokenHash(s.PasswordUtil.HashOneTimeToken(value))
+	if err != nil {
+		return nil, ErrInvalidToken
+	}
+	return token, nil
+}
+
+// consumeToken marca o token como usado antes de qualquer efeito da operação; entre requisições
+// concorrentes com o mesmo link ou código, só a primeira consegue consumi-lo
+func (s *AuthService) consumeToken(token *models.PasswordResetToken) error {
+	if err := s.TokenRepo.MarkTokenAsUsed(token.ID); err != nil {
+		if err == repositories.ErrTokenAlreadyUsed {
+			return ErrInvalidToken
+		}
+		return err
+	}
+	return nil
+}
+
+// verifyUserCode confere o código enviado por SMS ou WhatsApp com o código ativo do usuário.
+// Cada erro conta para o limite de tentativas do token, que é revogado ao atingi-lo.
+func (s *AuthService) verifyUserCode(userID uuid.UUID, purpose models.TokenPurpose, code string) (*models.PasswordResetToken, error) {
+	if code == "" {
+		return nil, ErrInvalidToken
+	}
+
+	// Buscamos o codigo ativo do usuario
+	token, err := s.TokenRepo.FindActiveByUserAndPurpose(userID, purpose)
+	if err != nil {
+		if err == repositories.ErrTokenNotFound {
+			return nil, ErrInvalidToken
+		}
+		return nil, err
+	}
+
+	// Codigos so sao enviados por telefone; tokens de email nunca sao aceitos como codigo
+	hash := s.PasswordUtil.HashOneTimeToken(code)
+	if token.Channel == models.TokenChannelEmail || subtle.ConstantTimeCompare([]byte(token.TokenHash), []byte(hash)) != 1 {
+		if err := s.TokenRepo.IncrementFailedAttempts(token.ID); err != nil {
+			return nil, err
+		}
+		return nil, ErrInvalidToken
+	}
+
+	return token, nil
+}
@@ -0,0 +1,136 @@
+package services
+
+import (
+	"time"
+
+	"github.com/Barba2k2/aurora_backend/src/models"
+	"github.com/Barba2k2/aurora_backend/src/repositories"
+	"github.com/google/uuid"
+)
+
+// VerifyEmailRequest representa os dados de requisição para confirmação de email
+type VerifyEmailRequest struct {
+	Token string `json:"token" validate:"required"`
+}
+
+// ResendVerificationRequest representa os dados de requisição para reenvio do link de verificação
+type ResendVerificationRequest struct {
+	Email     string `json:"email" validate:"required,email"`
+	ClientIP  string `json:"-"`
+	UserAgent string `json:"-"`
+}
+
+// VerifyEmail confirma o email de uma conta e a ativa
+func (s *AuthService) VerifyEmail(req VerifyEmailRequest) (*models.User, error) {
+	// Validamos a assinatura do link
+	claims, err := s.JWTUtil.ValidateEmailVerificationToken(req.Token)
+	if err != nil {
+		return nil, ErrInvalidToken
+	}
+
+	// O ID do token assinado corresponde ao registro de uso único
+	token, err := s.findTokenByValue(claims.Id)
+	if err != nil {
+		return nil, err
+	}
+
+	if !token.IsValid() || token.Purpose != models.TokenPurposeEmailVerification || token.UserID != claims.UserID {
+		return nil, ErrInvalidToken
+	}
+
+	// Buscamos o usuario, que ainda esta pendente
+	user, err := s.UserRepo.FindByIDAnyStatus(token.UserID)
+	if err != nil {
+		if err == repositories.ErrUserNotFound {
+			return nil, ErrInvalidToken
+		}
+		return nil, err
+	}
+
+	if user.Status != models.UserStatusPending && user.Status != models.UserStatusActive {
+		return nil, ErrUserInactive
+	}
+
+	// Consumimos o token antes de ativar a conta
+	if err := s.consumeToken(token); err != nil {
+		return nil, err
+	}
+
+	// Confirmamos o email e ativamos a conta
+	now := time.Now()
+	user.EmailVerifiedAt = &now
+	user.Status = models.UserStatusActive
+	if err := s.UserRepo.UpdateColumns(user.ID, map[string]interface{}{
+		"email_verified_at": user.EmailVerifiedAt,
+		"status":            user.Status,
+	}); err != nil {
+		return nil, err
+	}
+
+	return user, nil
+}
+
+// ResendVerificationEmail envia um novo link de verificação, invalidando os anteriores
+func (s *AuthService) ResendVerificationEmail(req ResendVerificationRequest) error {
+	// Buscamos o usuario pelo email
+	user, err := s.UserRepo.FindByEmailAnyStatus(req.Email)
+	if err != nil {
+		if err == repositories.ErrUserNotFound {
+			return ErrEmailNotFound
+		}
+		return err
+	}
+
+	// Apenas contas pendentes aguardam verificacao
+	if user.EmailVerifiedAt != nil || user.Status == models.UserStatusActive {
+		return ErrEmailAlreadyVerified
+	}
+	if user.Status != models.UserStatusPending {
+		return ErrUserInactive
+	}
+
+	// Verificamos o rate limit
+	count, err := s.TokenRepo.CountTokensByUserAndPurpose(user.ID, models.TokenPurposeEmailVerification, s.Config.ResetTokenRateWindow)
+	if err != nil {
+		return err
+	}
+	if count >= s.Config.ResetTokenRateLimit {
+		return ErrTooManyRequests
+	}
+
+	return s.sendVerificationEmail(user, req.ClientIP, req.UserAgent)
+}
+
+// sendVerificationEmail gera um novo link de verificação e o envia por email
+func (s *AuthService) sendVerificationEmail(user *models.User, clientIP, userAgent string) error {
+	// Invalidamos os links anteriores
+	if err := s.TokenRepo.InvalidateUserTokensByPurpose(user.ID, models.TokenPurposeEmailVerification); err != nil {
+		return err
+	}
+
+	// Criamos o registro do token, referenciado pelo ID do link assinado
+	tokenID := uuid.New()
+	expiresAt := time.Now().Add(s.Config.EmailVerificationExpiration)
+	token := &models.PasswordResetToken{
+		UserID:    user.ID,
+		TokenHash: s.PasswordUtil.HashOneTimeToken(tokenID.String()),
+		Channel:   models.TokenChannelEmail,
+		Purpose:   models.TokenPurposeEmailVerification,
+		Status:    models.TokenStatusActive,
+		ExpiresAt: expiresAt,
+		IPAddress: clientIP,
+		UserAgent: userAgent,
+	}
+
+	if err := s.TokenRepo.Create(token); err != nil {
+		return err
+	}
+
+	signedToken, err := s.JWTUtil.GenerateEmailVerificationToken(user.ID, tokenID, expiresAt)
+	if err != nil {
+		return err
+	}
+
+	// Enviamos o email com o link
+	return s.EmailService.SendVerificationEmail(user.Email, user.Name, signedToken)
+}
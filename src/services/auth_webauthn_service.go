@@ -0,0 +1,417 @@
+package services
+
+import (
+	"encoding/base64"
+	"time"
+
+	"github.com/Barba2k2/aurora_backend/src/models"
+	"github.com/Barba2k2/aurora_backend/src/repositories"
+	"github.com/Barba2k2/aurora_backend/src/utils"
+	"github.com/google/uuid"
+)
+
+// WebAuthnCredentialDescriptor identifica uma credencial nas opções enviadas ao navegador
+type WebAuthnCredentialDescriptor struct {
+	Type       string   `json:"type"`
+	ID         string   `json:"id"`
+	Transports []string `json:"transports,omitempty"`
+}
+
+// WebAuthnRelyingParty identifica o sistema nas opções de registro
+type WebAuthnRelyingParty struct {
+	ID   string `json:"id"`
+	Name string `json:"name"`
+}
+
+// WebAuthnUserEntity identifica o usuário nas opções de registro
+type WebAuthnUserEntity struct {
+	ID          string `json:"id"`
+	Name        string `json:"name"`
+	DisplayName string `json:"displayName"`
+}
+
+// WebAuthnCredentialParameter indica um algoritmo aceito para a credencial
+type WebAuthnCredentialParameter struct {
+	Type string `json:"type"`
+	Alg  int64  `json:"alg"`
+}
+
+// WebAuthnAuthenticatorSelection indica os requisitos do autenticador
+type WebAuthnAuthenticatorSelection struct {
+	ResidentKey      string `json:"residentKey"`
+	UserVerification string `json:"userVerification"`
+}
+
+// WebAuthnRegistrationOptions são as opções para navigator.credentials.create()
+type WebAuthnRegistrationOptions struct {
+	Challenge              string                         `json:"challenge"`
+	RP                     WebAuthnRelyingParty           `json:"rp"`
+	User                   WebAuthnUserEntity             `json:"user"`
+	PubKeyCredParams       []WebAuthnCredentialParameter  `json:"pubKeyCredParams"`
+	Timeout                int64                          `json:"timeout"`
+	Attestation            string                         `json:"attestation"`
+	ExcludeCredentials     []WebAuthnCredentialDescriptor `json:"excludeCredentials"`
+	AuthenticatorSelection WebAuthnAuthenticatorSelection `json:"authenticatorSelection"`
+}
+
+// WebAuthnLoginOptions são as opções para navigator.credentials.get()
+type WebAuthnLoginOptions struct {
+	Challenge        string                         `json:"challenge"`
+	RPID             string                         `json:"rpId"`
+	Timeout          int64                          `json:"timeout"`
+	AllowCredentials []WebAuthnCredentialDescriptor `json:"allowCredentials"`
+	UserVerification string                         `json:"userVerification"`
+}
+
+// WebAuthnAttestationResponse é a resposta do autenticador ao registro (campos em base64url)
+type WebAuthnAttestationResponse struct {
+	ClientDataJSON    string   `json:"clientDataJSON"`
+	AttestationObject string   `json:"attestationObject"`
+	Transports        []string `json:"transports"`
+}
+
+// WebAuthnAttestationCredential é a credencial retornada por navigator.credentials.create()
+type WebAuthnAttestationCredential struct {
+	ID       string                      `json:"id"`
+	RawID    string                      `json:"rawId"`
+	Type     string                      `json:"type"`
+	Response WebAuthnAttestationResponse `json:"response"`
+}
+
+// WebAuthnRegistrationRequest representa os dados de requisição para concluir o registro de uma passkey
+type WebAuthnRegistrationRequest struct {
+	Name       string                        `json:"name"`
+	Credential WebAuthnAttestationCredential `json:"credential" validate:"required"`
+}
+
+// WebAuthnAssertionResponse é a resposta do autenticador ao login (campos em base64url)
+type WebAuthnAssertionResponse struct {
+	ClientDataJSON    string `json:"clientDataJSON"`
+	AuthenticatorData string `json:"authenticatorData"`
+	Signature         string `json:"signature"`
+	UserHandle        string `json:"userHandle"`
+}
+
+// WebAuthnAssertionCredential é a credencial retornada por navigator.credentials.get()
+type WebAuthnAssertionCredential struct {
+	ID       string                    `json:"id"`
+	RawID    string                    `json:"rawId"`
+	Type     string                    `json:"type"`
+	Response WebAuthnAssertionResponse `json:"response"`
+}
+
+// WebAuthnLoginBeginRequest representa os dados de requisição para iniciar o login com passkey
+type WebAuthnLoginBeginRequest struct {
+	Email string `json:"email" validate:"omitempty,email"`
+}
+
+// WebAuthnLoginFinishRequest representa os dados de requisição para concluir o login com passkey
+type WebAuthnLoginFinishRequest struct {
+	Credential WebAuthnAssertionCredential `json:"credential" validate:"required"`
+	DeviceName string                      `json:"device_name"`
+	ClientIP   string                      `json:"-"`
+	UserAgent  string                      `json:"-"`
+	// AllowedRoles restringe o login aos papéis do portal; vazio aceita todos
+	AllowedRoles []models.UserRole `json:"-"`
+}
+
+// webAuthnTimeoutMillis retorna o tempo limite da cerimônia no formato esperado pelo navegador
+func (s *AuthService) webAuthnTimeoutMillis() int64 {
+	return s.WebAuthnUtil.Config.Timeout.Milliseconds()
+}
+
+// webAuthnUserVerification indica ao navegador se a verificação do usuário (biometria ou PIN) é exigida
+func (s *AuthService) webAuthnUserVerification() string {
+	if s.WebAuthnUtil.Config.RequireUserVerification {
+		return "required"
+	}
+	return "preferred"
+}
+
+// createWebAuthnChallenge gera e persiste o desafio de uma cerimônia
+func (s *AuthService) createWebAuthnChallenge(userID *uuid.UUID, ceremony models.WebAuthnCeremony) (string, error) {
+	challenge, err := s.WebAuthnUtil.NewChallenge()
+	if err != nil {
+		return "", err
+	}
+
+	record := &models.WebAuthnChallenge{
+		UserID:    userID,
+		Challenge: challenge,
+		Ceremony:  ceremony,
+		ExpiresAt: time.Now().Add(s.WebAuthnUtil.Config.Timeout),
+	}
+	if err := s.WebAuthnRepo.CreateChallenge(record); err != nil {
+		return "", err
+	}
+
+	return challenge, nil
+}
+
+// credentialDescriptors converte as credenciais do usuário para as listas de opções
+func credentialDescriptors(credentials []*models.WebAuthnCredential) []WebAuthnCredentialDescriptor {
+	descriptors := make([]WebAuthnCredentialDescriptor, 0, len(credentials))
+	for _, credential := range credentials {
+		descriptors = append(descriptors, WebAuthnCredentialDescriptor{
+			Type:       "public-key",
+			ID:         credential.CredentialID,
+			Transports: credential.Transports,
+		})
+	}
+	return descriptors
+}
+
+// BeginWebAuthnRegistration gera as opções para o registro de uma nova passkey
+func (s *AuthService) BeginWebAuthnRegistration(user *models.User) (*WebAuthnRegistrationOptions, error) {
+	credentials, err := s.WebAuthnRepo.FindCredentialsByUser(user.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	challenge, err := s.createWebAuthnChallenge(&user.ID, models.WebAuthnCeremonyRegistration)
+	if err != nil {
+		return nil, err
+	}
+
+	return &WebAuthnRegistrationOptions{
+		Challenge: challenge,
+		RP: WebAuthnRelyingParty{
+			ID:   s.WebAuthnUtil.Config.RPID,
+			Name: s.WebAuthnUtil.Config.RPName,
+		},
+		User: WebAuthnUserEntity{
+			ID:          base64.RawURLEncoding.EncodeToString(user.ID[:]),
+			Name:        user.Email,
+			DisplayName: user.Name,
+		},
+		PubKeyCredParams: []WebAuthnCredentialParameter{
+			{Type: "public-key", Alg: utils.COSEAlgES256},
+			{Type: "public-key", Alg: utils.COSEAlgEdDSA},
+			{Type: "public-key", Alg: utils.COSEAlgRS256},
+		},
+		Timeout:     s.webAuthnTimeoutMillis(),
+		Attestation: "none",
+		// Evitamos registrar duas vezes o mesmo autenticador
+		ExcludeCredentials: credentialDescriptors(credentials),
+		AuthenticatorSelection: WebAuthnAuthenticatorSelection{
+			ResidentKey:      "preferred",
+			UserVerification: s.webAuthnUserVerification(),
+		},
+	}, nil
+}
+
+// FinishWebAuthnRegistration valida a resposta do autenticador e salva a nova passkey
+func (s *AuthService) FinishWebAuthnRegistration(user *models.User, req WebAuthnRegistrationRequest) (*models.WebAuthnCredential, error) {
+	clientDataJSON, err := utils.DecodeBase64URL(req.Credential.Response.ClientDataJSON)
+	if err != nil {
+		return nil, ErrWebAuthnFailed
+	}
+	attestationObject, err := utils.DecodeBase64URL(req.Credential.Response.AttestationObject)
+	if err != nil {
+		return nil, ErrWebAuthnFailed
+	}
+
+	// Buscamos a cerimônia pelo desafio assinado pelo navegador
+	challenge, err := s.WebAuthnUtil.ClientDataChallenge(clientDataJSON)
+	if err != nil {
+		return nil, ErrWebAuthnFailed
+	}
+	record, err := s.WebAuthnRepo.ConsumeChallenge(challenge, models.WebAuthnCeremonyRegistration)
+	if err != nil {
+		if err == repositories.ErrChallengeNotFound {
+			return nil, ErrWebAuthnFailed
+		}
+		return nil, err
+	}
+	if record.UserID == nil || *record.UserID != user.ID {
+		return nil, ErrWebAuthnFailed
+	}
+
+	data, err := s.WebAuthnUtil.VerifyRegistration(challenge, clientDataJSON, attestationObject)
+	if err != nil {
+		return nil, ErrWebAuthnFailed
+	}
+
+	credential := &models.WebAuthnCredential{
+		UserID:       user.ID,
+		CredentialID: base64.RawURLEncoding.EncodeToString(data.CredentialID),
+		PublicKey:    data.PublicKey,
+		Algorithm:    data.Algorithm,
+		SignCount:    int64(data.SignCount),
+		Transports:   req.Credential.Response.Transports,
+		Name:         req.Name,
+	}
+	if aaguid, err := uuid.FromBytes(data.AAGUID); err == nil {
+		credential.AAGUID = aaguid.String()
+	}
+
+	if err := s.WebAuthnRepo.CreateCredential(credential); err != nil {
+		if err == repositories.ErrCredentialAlreadyExists {
+			return nil, ErrPasskeyAlreadyExists
+		}
+		return nil, err
+	}
+
+	return credential, nil
+}
+
+// BeginWebAuthnLogin gera as opções para o login com passkey.
+// Sem email, o navegador oferece as passkeys descobríveis do domínio.
+func (s *AuthService) BeginWebAuthnLogin(req WebAuthnLoginBeginRequest) (*WebAuthnLoginOptions, error) {
+	var userID *uuid.UUID
+	allowCredentials := []WebAuthnCredentialDescriptor{}
+
+	if req.Email != "" {
+		// Um email desconhecido gera as mesmas opções, sem revelar se a conta existe
+		user, err := s.UserRepo.FindByEmail(req.Email)
+		if err != nil && err != repositories.ErrUserNotFound {
+			return nil, err
+		}
+		if user != nil {
+			credentials, err := s.WebAuthnRepo.FindCredentialsByUser(user.ID)
+			if err != nil {
+				return nil, err
+			}
+			userID = &user.ID
+			allowCredentials = credentialDescriptors(credentials)
+		}
+	}
+
+	challenge, err := s.createWebAuthnChallenge(userID, models.WebAuthnCeremonyAuthentication)
+	if err != nil {
+		return nil, err
+	}
+
+	return &WebAuthnLoginOptions{
+		Challenge:        challenge,
+		RPID:             s.WebAuthnUtil.Config.RPID,
+		Timeout:          s.webAuthnTimeoutMillis(),
+		AllowCredentials: allowCredentials,
+		UserVerification: s.webAuthnUserVerification(),
+	}, nil
+}
+
+// FinishWebAuthnLogin valida a asserção do autenticador e realiza o login
+func (s *AuthService) FinishWebAuthnLogin(req WebAuthnLoginFinishRequest) (*models.User, *TokenResponse, error) {
+	response := req.Credential.Response
+
+	clientDataJSON, err := utils.DecodeBase64URL(response.ClientDataJSON)
+	if err != nil {
+		return nil, nil, ErrWebAuthnFailed
+	}
+	authenticatorData, err := utils.DecodeBase64URL(response.AuthenticatorData)
+	if err != nil {
+		return nil, nil, ErrWebAuthnFailed
+	}
+	signature, err := utils.DecodeBase64URL(response.Signature)
+	if err != nil {
+		return nil, nil, ErrWebAuthnFailed
+	}
+	rawID, err := utils.DecodeBase64URL(req.Credential.RawID)
+	if err != nil || len(rawID) == 0 {
+		return nil, nil, ErrWebAuthnFailed
+	}
+
+	// Buscamos a cerimônia pelo desafio assinado pelo navegador
+	challenge, err := s.WebAuthnUtil.ClientDataChallenge(clientDataJSON)
+	if err != nil {
+		return nil, nil, ErrWebAuthnFailed
+	}
+	record, err := s.WebAuthnRepo.ConsumeChallenge(challenge, models.WebAuthnCeremonyAuthentication)
+	if err != nil {
+		if err == repositories.ErrChallengeNotFound {
+			return nil, nil, ErrWebAuthnFailed
+		}
+		return nil, nil, err
+	}
+
+	credential, err := s.WebAuthnRepo.FindCredentialByCredentialID(base64.RawURLEncoding.EncodeToString(rawID))
+	if err != nil {
+		if err == repositories.ErrCredentialNotFound {
+			return nil, nil, ErrWebAuthnFailed
+		}
+		return nil, nil, err
+	}
+
+	// O desafio emitido para um usuário só vale para as credenciais dele
+	if record.UserID != nil && *record.UserID != credential.UserID {
+		return nil, nil, ErrWebAuthnFailed
+	}
+
+	// Passkeys descobríveis informam o usuário a que pertencem
+	if err := s.WebAuthnUtil.VerifyUserHandle(response.UserHandle, credential.UserID[:]); err != nil {
+		return nil, nil, ErrWebAuthnFailed
+	}
+
+	// Buscamos o usuario
+	user, err := s.UserRepo.FindByID(credential.UserID)
+	if err != nil {
+		if err == repositories.ErrUserNotFound {
+			return nil, nil, ErrWebAuthnFailed
+		}
+		return nil, nil, err
+	}
+
+	// Verificamos se o usuario esta ativo
+	if user.Status != models.UserStatusActive {
+		if user.Status == models.UserStatusBlocked {
+			return nil, nil, ErrUserBlocked
+		}
+		return nil, nil, ErrUserInactive
+	}
+
+	if user.IsLocked() {
+		return nil, nil, ErrUserBlocked
+	}
+
+	result, err := s.WebAuthnUtil.VerifyAssertion(challenge, credential.PublicKey, uint32(credential.SignCount), clientDataJSON, authenticatorData, signature)
+	if err != nil {
+		return nil, nil, ErrWebAuthnFailed
+	}
+
+	if err := s.WebAuthnRepo.UpdateSignCount(credential.ID, credential.SignCount, int64(result.SignCount)); err != nil {
+		if err == repositories.ErrCredentialSignCountRaised {
+			return nil, nil, ErrWebAuthnFailed
+		}
+		return nil, nil, err
+	}
+
+	// O papel e conferido antes de abrir a sessao
+	if !roleAllowed(user, req.AllowedRoles) {
+		s.recordUserEvent(models.AuthEventLoginFailed, user, req.ClientIP, req.UserAgent, loginFailureRoleNotAllowed)
+		return nil, nil, ErrRoleNotAllowed
+	}
+
+	// Uma passkey com verificação do usuário já combina dois fatores; com apenas a presença
+	// do usuário, quem tem o TOTP habilitado ainda precisa informar o código
+	if user.TOTPEnabled && !result.UserVerified {
+		challenge, err := s.createMFAChallenge(user)
+		if err != nil {
+			return nil, nil, err
+		}
+		return user, nil, &MFARequiredError{Challenge: challenge}
+	}
+
+	tokenResponse, err := s.completeLogin(user, req.DeviceName, req.ClientIP, req.UserAgent)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return user, tokenResponse, nil
+}
+
+// ListWebAuthnCredentials lista as passkeys do usuário
+func (s *AuthService) ListWebAuthnCredentials(userID uuid.UUID) ([]*models.WebAuthnCredential, error) {
+	return s.WebAuthnRepo.FindCredentialsByUser(userID)
+}
+
+// DeleteWebAuthnCredential remove uma passkey do usuário
+func (s *AuthService) DeleteWebAuthnCredential(userID, credentialID uuid.UUID) error {
+	if err := s.WebAuthnRepo.DeleteCredential(credentialID, userID); err != nil {
+		if err == repositories.ErrCredentialNotFound {
+			return ErrPasskeyNotFound
+		}
+		return err
+	}
+	return nil
+}
@@ -7,6 +7,7 @@ import (
 	"net/http"
 	"net/smtp"
 	"text/template"
+	"time"
 )
 
 // EmailService implements the EmailServiceInterface
@@ -171,3 +172,252 @@ func (s *EmailService) SendPasswordResetEmail(email, name, token string) error {
 	// Send the email
 	return s.SendGenericEmail(email, subject, body.String())
 }
+
+// SendVerificationEmail sends the link to confirm the email address of a new account
+func (s *EmailService) SendVerificationEmail(email, name, token string) error {
+	subject := "Confirm your email - Scheduling System"
+
+	// Data for the template
+	data := map[string]interface{}{
+		"Name":      name,
+		"Token":     token,
+		"VerifyURL": fmt.Sprintf("%s/verify-email?token=%s", s.Config.AppURL, token),
+	}
+
+	// Load the template
+	tmpl, err := template.ParseFiles(s.Config.TemplatesDir + "/email_verification.html")
+	if err != nil {
+		return ErrInvalidTemplate
+	}
+
+	// Fill the template
+	var body bytes.Buffer
+	if err := tmpl.Execute(&body, data); err != nil {
+		return ErrInvalidTemplate
+	}
+
+	// Send the email
+	return s.SendGenericEmail(email, subject, body.String())
+}
+
+// SendMagicLinkEmail sends a one-time link for passwordless login
+func (s *EmailService) SendMagicLinkEmail(email, name, token string) error {
+	subject := "Your login link - Scheduling System"
+
+	// Data for the template
+	data := map[string]interface{}{
+		"Name":     name,
+		"Token":    token,
+		"LoginURL": fmt.Sprintf("%s/magic-login?token=%s", s.Config.AppURL, token),
+	}
+
+	// Load the template
+	tmpl, err := template.ParseFiles(s.Config.TemplatesDir + "/magic_link.html")
+	if err != nil {
+		return ErrInvalidTemplate
+	}
+
+	// Fill the template
+	var body bytes.Buffer
+	if err := tmpl.Execute(&body, data); err != nil {
+		return ErrInvalidTemplate
+	}
+
+	// Send the email
+	return s.SendGenericEmail(email, subject, body.String())
+}
+
+// SendAccountLockedEmail warns the user that the account was locked and sends a link to unlock it
+func (s *EmailService) SendAccountLockedEmail(email, name, token string, lockedUntil time.Time) error {
+	subject := "Your account was locked - Scheduling System"
+
+	// Data for the template
+	data := map[string]interface{}{
+		"Name":        name,
+		"Token":       token,
+		"LockedUntil": lockedUntil.Format("02/01/2006 15:04"),
+		"UnlockURL":   fmt.Sprintf("%s/unlock-account?token=%s", s.Config.AppURL, token),
+	}
+
+	// Load the template
+	tmpl, err := template.ParseFiles(s.Config.TemplatesDir + "/account_locked.html")
+	if err != nil {
+		return ErrInvalidTemplate
+	}
+
+	// Fill the template
+	var body bytes.Buffer
+	if err := tmpl.Execute(&body, data); err != nil {
+		return ErrInvalidTemplate
+	}
+
+	// Send the email
+	return s.SendGenericEmail(email, subject, body.String())
+}
+
+// SendRegistrationAttemptEmail warns the owner of an existing account that someone tried to sign up with their data
+func (s *EmailService) SendRegistrationAttemptEmail(email, name string) error {
+	subject := "Sign up attempt with your email - Scheduling System"
+
+	// Data for the template
+	data := map[string]interface{}{
+		"Name":     name,
+		"LoginURL": fmt.Sprintf("%s/login", s.Config.AppURL),
+		"ResetURL": fmt.Sprintf("%s/forgot-password", s.Config.AppURL),
+	}
+
+	// Load the template
+	tmpl, err := template.ParseFiles(s.Config.TemplatesDir + "/registration_attempt.html")
+	if err != nil {
+		return ErrInvalidTemplate
+	}
+
+	// Fill the template
+	var body bytes.Buffer
+	if err := tmpl.Execute(&body, data); err != nil {
+		return ErrInvalidTemplate
+	}
+
+	// Send the email
+	return s.SendGenericEmail(email, subject, body.String())
+}
+
+// SendEmailChangeEmail sends the link to confirm a new email address to that address
+func (s *EmailService) SendEmailChangeEmail(email, name, token string) error {
+	subject := "Confirm your new email - Scheduling System"
+
+	// Data for the template
+	data := map[string]interface{}{
+		"Name":       name,
+		"Token":      token,
+		"ConfirmURL": fmt.Sprintf("%s/confirm-email-change?token=%s", s.Config.AppURL, token),
+	}
+
+	// Load the template
+	tmpl, err := template.ParseFiles(s.Config.TemplatesDir + "/email_change.html")
+	if err != nil {
+		return ErrInvalidTemplate
+	}
+
+	// Fill the template
+	var body bytes.Buffer
+	if err := tmpl.Execute(&body, data); err != nil {
+		return ErrInvalidTemplate
+	}
+
+	// Send the email
+	return s.SendGenericEmail(email, subject, body.String())
+}
+
+// SendEmailChangeNoticeEmail warns the current address that a change to another email was requested
+func (s *EmailService) SendEmailChangeNoticeEmail(email, name, newEmail string) error {
+	subject := "Email change requested - Scheduling System"
+
+	// Data for the template
+	data := map[string]interface{}{
+		"Name":     name,
+		"NewEmail": newEmail,
+		"ResetURL": fmt.Sprintf("%s/forgot-password", s.Config.AppURL),
+	}
+
+	// Load the template
+	tmpl, err := template.ParseFiles(s.Config.TemplatesDir + "/email_change_notice.html")
+	if err != nil {
+		return ErrInvalidTemplate
+	}
+
+	// Fill the template
+	var body bytes.Buffer
+	if err := tmpl.Execute(&body, data); err != nil {
+		return ErrInvalidTemplate
+	}
+
+	// Send the email
+	return s.SendGenericEmail(email, subject, body.String())
+}
+
+// SendNewDeviceLoginEmail warns the user about a sign-in from a device that was not seen before,
+// with a link to secure the account if it was not them
+func (s *EmailService) SendNewDeviceLoginEmail(email, name, token, device, ipAddress string, loginAt time.Time) error {
+	subject := "New sign-in to your account - Scheduling System"
+
+	// Data for the template
+	data := map[string]interface{}{
+		"Name":      name,
+		"Token":     token,
+		"Device":    device,
+		"IPAddress": ipAddress,
+		"LoginAt":   loginAt.Format("02/01/2006 15:04"),
+		"SecureURL": fmt.Sprintf("%s/secure-account?token=%s", s.Config.AppURL, token),
+	}
+
+	// Load the template
+	tmpl, err := template.ParseFiles(s.Config.TemplatesDir + "/new_device_login.html")
+	if err != nil {
+		return ErrInvalidTemplate
+	}
+
+	// Fill the template
+	var body bytes.Buffer
+	if err := tmpl.Execute(&body, data); err != nil {
+		return ErrInvalidTemplate
+	}
+
+	// Send the email
+	return s.SendGenericEmail(email, subject, body.String())
+}
+
+// SendStaffInvitationEmail sends the link to join the staff of an establishment
+func (s *EmailService) SendStaffInvitationEmail(email, name, establishmentName, token string, expiresAt time.Time) error {
+	subject := fmt.Sprintf("Invitation to join %s - Scheduling System", establishmentName)
+
+	// Data for the template
+	data := map[string]interface{}{
+		"Name":              name,
+		"EstablishmentName": establishmentName,
+		"Token":             token,
+		"ExpiresAt":         expiresAt.Format("02/01/2006 15:04"),
+		"AcceptURL":         fmt.Sprintf("%s/accept-invitation?token=%s", s.Config.AppURL, token),
+	}
+
+	// Load the template
+	tmpl, err := template.ParseFiles(s.Config.TemplatesDir + "/staff_invitation.html")
+	if err != nil {
+		return ErrInvalidTemplate
+	}
+
+	// Fill the template
+	var body bytes.Buffer
+	if err := tmpl.Execute(&body, data); err != nil {
+		return ErrInvalidTemplate
+	}
+
+	// Send the email
+	return s.SendGenericEmail(email, subject, body.String())
+}
+
+// SendErasureScheduledEmail confirms that the account was deleted and when its personal data will be erased
+func (s *EmailService) SendErasureScheduledEmail(email, name string, erasureAt time.Time) error {
+	subject := "Your account was deleted - Scheduling System"
+
+	// Data for the template
+	data := map[string]interface{}{
+		"Name":      name,
+		"ErasureAt": erasureAt.Format("02/01/2006"),
+	}
+
+	// Load the template
+	tmpl, err := template.ParseFiles(s.Config.TemplatesDir + "/erasure_scheduled.html")
+	if err != nil {
+		return ErrInvalidTemplate
+	}
+
+	// Fill the template
+	var body bytes.Buffer
+	if err := tmpl.Execute(&body, data); err != nil {
+		return ErrInvalidTemplate
+	}
+
+	// Send the email
+	return s.SendGenericEmail(email, subject, body.String())
+}
@@ -2,6 +2,7 @@ package services
 
 import (
 	"errors"
+	"time"
 )
 
 // Common errors for notification services
@@ -16,18 +17,33 @@ var (
 // EmailServiceInterface defines the interface for the email service
 type EmailServiceInterface interface {
 	SendPasswordResetEmail(email, name, token string) error
+	SendVerificationEmail(email, name, token string) error
+	SendMagicLinkEmail(email, name, token string) error
+	SendAccountLockedEmail(email, name, token string, lockedUntil time.Time) error
+	SendRegistrationAttemptEmail(email, name string) error
+	SendEmailChangeEmail(email, name, token string) error
+	SendEmailChangeNoticeEmail(email, name, newEmail string) error
+	SendNewDeviceLoginEmail(email, name, token, device, ipAddress string, loginAt time.Time) error
+	SendStaffInvitationEmail(email, name, establishmentName, token string, expiresAt time.Time) error
+	SendErasureScheduledEmail(email, name string, erasureAt time.Time) error
 	SendGenericEmail(email, subject, body string) error
 }
 
 // SMSServiceInterface defines the interface for the SMS service
 type SMSServiceInterface interface {
 	SendPasswordResetSMS(phone, code string) error
+	SendPhoneVerificationSMS(phone, code string) error
+	SendLoginCodeSMS(phone, code string) error
 	SendGenericSMS(phone, message string) error
 }
 
 // WhatsAppServiceInterface defines the interface for the WhatsApp service
 type WhatsAppServiceInterface interface {
 	SendPasswordResetWhatsApp(phone, name, code string) error
+	SendPhoneVerificationWhatsApp(phone, name, code string) error
+	SendLoginCodeWhatsApp(phone, name, code string) error
+	SendNewDeviceLoginWhatsApp(phone, name, token, device, ipAddress string, loginAt time.Time) error
+	SendStaffInvitationWhatsApp(phone, name, establishmentName, token string, expiresAt time.Time) error
 	SendGenericWhatsApp(phone, message string) error
 }
 
@@ -43,6 +59,7 @@ type EmailConfig struct {
 	IsSMTP       bool
 	ServiceType  string // "smtp", "sendgrid", "aws_ses"
 	APIKey       string // For SendGrid or other API-based services
+	AppURL       string // Base URL of the frontend, used to build links
 }
 
 // SMSConfig contains the settings for the SMS service
@@ -63,4 +80,5 @@ type WhatsAppConfig struct {
 	AccountSID    string // For Twilio
 	AuthToken     string // For Twilio
 	FromNumber    string // Source number (with WhatsApp)
+	AppURL        string // Base URL of the frontend, used to build links
 }
\ No newline at end of file
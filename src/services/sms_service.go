@@ -25,6 +25,18 @@ func (s *SMSService) SendPasswordResetSMS(phone, code string) error {
 	return s.SendGenericSMS(phone, message)
 }
 
+// SendPhoneVerificationSMS sends the code used to confirm ownership of a phone number
+func (s *SMSService) SendPhoneVerificationSMS(phone, code string) error {
+	message := fmt.Sprintf("Your phone verification code is: %s. Valid for 5 minutes.", code)
+	return s.SendGenericSMS(phone, message)
+}
+
+// SendLoginCodeSMS sends a one-time code for passwordless login
+func (s *SMSService) SendLoginCodeSMS(phone, code string) error {
+	message := fmt.Sprintf("Your login code is: %s. Valid for 5 minutes. Do not share it with anyone.", code)
+	return s.SendGenericSMS(phone, message)
+}
+
 // SendGenericSMS sends a generic SMS
 func (s *SMSService) SendGenericSMS(phone, message string) error {
 	switch s.Config.Provider {
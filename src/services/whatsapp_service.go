@@ -5,6 +5,7 @@ import (
 	"encoding/json"
 	"fmt"
 	"net/http"
+	"time"
 )
 
 // WhatsAppService implements the WhatsAppServiceInterface
@@ -25,6 +26,35 @@ func (s *WhatsAppService) SendPasswordResetWhatsApp(phone, name, code string) er
 	return s.SendGenericWhatsApp(phone, message)
 }
 
+// SendPhoneVerificationWhatsApp sends the code used to confirm ownership of a phone number
+func (s *WhatsAppService) SendPhoneVerificationWhatsApp(phone, name, code string) error {
+	message := fmt.Sprintf("Hello %s, your phone verification code is: %s. Valid for 5 minutes.", name, code)
+	return s.SendGenericWhatsApp(phone, message)
+}
+
+// SendLoginCodeWhatsApp sends a one-time code for passwordless login
+func (s *WhatsAppService) SendLoginCodeWhatsApp(phone, name, code string) error {
+	message := fmt.Sprintf("Hello %s, your login code is: %s. Valid for 5 minutes. Do not share it with anyone.", name, code)
+	return s.SendGenericWhatsApp(phone, message)
+}
+
+// SendNewDeviceLoginWhatsApp warns the user about a sign-in from a device that was not seen before,
+// with a link to secure the account if it was not them
+func (s *WhatsAppService) SendNewDeviceLoginWhatsApp(phone, name, token, device, ipAddress string, loginAt time.Time) error {
+	secureURL := fmt.Sprintf("%s/secure-account?token=%s", s.Config.AppURL, token)
+	message := fmt.Sprintf("Hello %s, a new sign-in to your account was made on %s from %s (IP %s). If it was not you, secure your account: %s",
+		name, loginAt.Format("02/01/2006 15:04"), device, ipAddress, secureURL)
+	return s.SendGenericWhatsApp(phone, message)
+}
+
+// SendStaffInvitationWhatsApp sends the link to join the staff of an establishment
+func (s *WhatsAppService) SendStaffInvitationWhatsApp(phone, name, establishmentName, token string, expiresAt time.Time) error {
+	acceptURL := fmt.Sprintf("%s/accept-invitation?token=%s", s.Config.AppURL, token)
+	message := fmt.Sprintf("Hello %s, you were invited to join the staff of %s. Accept the invitation until %s: %s",
+		name, establishmentName, expiresAt.Format("02/01/2006 15:04"), acceptURL)
+	return s.SendGenericWhatsApp(phone, message)
+}
+
 // SendGenericWhatsApp sends a generic WhatsApp message
 func (s *WhatsAppService) SendGenericWhatsApp(phone, message string) error {
 	switch s.Config.Provider {
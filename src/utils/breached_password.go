@@ -0,0 +1,238 @@
+package utils
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/sha1"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+const (
+	// breachedHashLength is the length of a hex encoded SHA-1 hash
+	breachedHashLength = 40
+	// breachedRangePrefixLength is how many hex digits name each file of the range layout
+	breachedRangePrefixLength = 5
+	// breachedMaxLineLength bounds a "SHA1:COUNT" line, leaving room for the count and a CRLF
+	breachedMaxLineLength = 128
+)
+
+// ErrBreachedListFormat indicates that the breached password list does not follow a supported layout
+var ErrBreachedListFormat = errors.New("invalid breached password list")
+
+// BreachedPasswordList looks up passwords in a local copy of the Have I Been Pwned corpus, so the check
+// works offline and never sends password hashes to a third party. The corpus stays on disk and each
+// lookup reads only a few blocks of it.
+type BreachedPasswordList struct {
+	// file and size are set for the single sorted file layout
+	file *os.File
+	size int64
+	// dir and rangeExt are set for the range layout
+	dir      string
+	rangeExt string
+}
+
+// LoadBreachedPasswordList opens a breached password list in one of the Have I Been Pwned layouts:
+//   - a single file ordered by hash, one "SHA1:COUNT" per line, searched with a binary search;
+//   - a directory in the range layout, with one file per 5 hex digit prefix (named "ABCDE" or
+//     "ABCDE.txt") holding the remaining 35 hex digits of each hash as "SUFFIX:COUNT" lines.
+//
+// The count is optional in both layouts. Hashes are compared without regard to case.
+func LoadBreachedPasswordList(path string) (*BreachedPasswordList, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, err
+	}
+
+	if info.IsDir() {
+		return loadBreachedRangeDir(path)
+	}
+	return loadBreachedSortedFile(path, info.Size())
+}
+
+// loadBreachedSortedFile opens a single file ordered by hash and checks its first line
+func loadBreachedSortedFile(path string, size int64) (*BreachedPasswordList, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+
+	list := &BreachedPasswordList{file: file, size: size}
+
+	line, err := list.lineAt(0)
+	if err != nil {
+		file.Close()
+		return nil, err
+	}
+	if hash := string(breachedLineHash(line)); len(hash) != breachedHashLength || !isHex(hash) {
+		file.Close()
+		return nil, fmt.Errorf("%w: %s does not start with a SHA-1 hash", ErrBreachedListFormat, path)
+	}
+
+	return list, nil
+}
+
+// loadBreachedRangeDir finds out how the files of the range layout are named
+func loadBreachedRangeDir(path string) (*BreachedPasswordList, error) {
+	dir, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer dir.Close()
+
+	// A few entries are enough to tell the naming apart, without listing a million files
+	names, err := dir.Readdirnames(16)
+	if err != nil && err != io.EOF {
+		return nil, err
+	}
+
+	for _, name := range names {
+		prefix, ext := name, filepath.Ext(name)
+		if ext == ".txt" {
+			prefix = strings.TrimSuffix(name, ext)
+		} else {
+			ext = ""
+		}
+		if len(prefix) == breachedRangePrefixLength && isHex(prefix) {
+			return &BreachedPasswordList{dir: path, rangeExt: ext}, nil
+		}
+	}
+
+	return nil, fmt.Errorf("%w: %s has no range files", ErrBreachedListFormat, path)
+}
+
+// Contains reports whether the password appears in the list
+func (l *BreachedPasswordList) Contains(password string) (bool, error) {
+	sum := sha1.Sum([]byte(password))
+	hash := strings.ToUpper(hex.EncodeToString(sum[:]))
+
+	if l.dir != "" {
+		return l.containsInRange(hash)
+	}
+	return l.containsInSortedFile(hash)
+}
+
+// containsInRange scans the file of the hash prefix; each file holds only a few hundred hashes
+func (l *BreachedPasswordList) containsInRange(hash string) (bool, error) {
+	file, err := os.Open(filepath.Join(l.dir, hash[:breachedRangePrefixLength]+l.rangeExt))
+	if err != nil {
+		if os.IsNotExist(err) {
+			// Partial copies of the corpus may lack some prefixes
+			return false, nil
+		}
+		return false, err
+	}
+	defer file.Close()
+
+	suffix := hash[breachedRangePrefixLength:]
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		line := scanner.Bytes()
+		if !strings.EqualFold(string(breachedLineHash(line)), suffix) {
+			continue
+		}
+		// Entries with a zero count are padding added by the range API
+		return !bytes.HasSuffix(bytes.TrimSpace(line), []byte(":0")), nil
+	}
+	return false, scanner.Err()
+}
+
+// containsInSortedFile binary searches the file for the first line whose hash is not less than the
+// given one. Offsets are searched instead of lines, since lines differ in length; each step reads
+// the first full line that starts at or after the offset.
+func (l *BreachedPasswordList) containsInSortedFile(hash string) (bool, error) {
+	low, high := int64(0), l.size
+	for low < high {
+		mid := low + (high-low)/2
+
+		line, err := l.lineAt(mid)
+		if err != nil {
+			return false, err
+		}
+		if line == nil || compareBreachedHash(line, hash) >= 0 {
+			high = mid
+		} else {
+			low = mid + 1
+		}
+	}
+
+	line, err := l.lineAt(low)
+	if err != nil || line == nil {
+		return false, err
+	}
+	return compareBreachedHash(line, hash) == 0, nil
+}
+
+// lineAt returns the first full line that starts at or after offset, without its line break;
+// the line is nil when there is none
+func (l *BreachedPasswordList) lineAt(offset int64) ([]byte, error) {
+	// Reading from the byte before the offset tells whether a line starts exactly at it
+	start := offset
+	if offset > 0 {
+		start = offset - 1
+	}
+
+	buffer := make([]byte, 2*breachedMaxLineLength)
+	n, err := l.file.ReadAt(buffer, start)
+	if err != nil && err != io.EOF {
+		return nil, err
+	}
+	buffer = buffer[:n]
+
+	if offset > 0 {
+		i := bytes.IndexByte(buffer, '\n')
+		if i < 0 {
+			if len(buffer) == 2*breachedMaxLineLength {
+				return nil, fmt.Errorf("%w: line too long near offset %d", ErrBreachedListFormat, offset)
+			}
+			return nil, nil
+		}
+		buffer = buffer[i+1:]
+		start += int64(i + 1)
+	}
+
+	if len(buffer) == 0 {
+		return nil, nil
+	}
+
+	if i := bytes.IndexByte(buffer, '\n'); i >= 0 {
+		buffer = buffer[:i]
+	} else if start+int64(len(buffer)) < l.size {
+		return nil, fmt.Errorf("%w: line too long near offset %d", ErrBreachedListFormat, start)
+	}
+
+	// The downloads of the corpus use CRLF line breaks
+	if n := len(buffer); n > 0 && buffer[n-1] == '\r' {
+		buffer = buffer[:n-1]
+	}
+	return buffer, nil
+}
+
+// breachedLineHash returns the hash part of a "HASH:COUNT" line
+func breachedLineHash(line []byte) []byte {
+	if i := bytes.IndexByte(line, ':'); i >= 0 {
+		line = line[:i]
+	}
+	return bytes.TrimSpace(line)
+}
+
+// compareBreachedHash compares the hash of the line with an uppercase hash
+func compareBreachedHash(line []byte, hash string) int {
+	return strings.Compare(strings.ToUpper(string(breachedLineHash(line))), hash)
+}
+
+// isHex reports whether value holds only hex digits
+func isHex(value string) bool {
+	for i := 0; i < len(value); i++ {
+		c := value[i]
+		if !('0' <= c && c <= '9' || 'a' <= c && c <= 'f' || 'A' <= c && c <= 'F') {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,118 @@
+package utils
+
+import (
+	"crypto/sha1"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+)
+
+// breachedTestHash returns the uppercase SHA-1 of a password, as written in the corpus
+func breachedTestHash(password string) string {
+	sum := sha1.Sum([]byte(password))
+	return strings.ToUpper(hex.EncodeToString(sum[:]))
+}
+
+// breachedTestPasswords builds a corpus large enough for the binary search to take many steps
+func breachedTestPasswords() []string {
+	passwords := []string{"password", "123456", "Tr0ub4dor&3"}
+	for i := 0; i < 2000; i++ {
+		passwords = append(passwords, fmt.Sprintf("leaked-%d", i))
+	}
+	return passwords
+}
+
+func TestBreachedPasswordListSortedFile(t *testing.T) {
+	passwords := breachedTestPasswords()
+
+	var lines []string
+	for i, password := range passwords {
+		lines = append(lines, fmt.Sprintf("%s:%d", breachedTestHash(password), i+1))
+	}
+	sort.Strings(lines)
+
+	path := filepath.Join(t.TempDir(), "pwned-passwords-sha1-ordered-by-hash.txt")
+	if err := os.WriteFile(path, []byte(strings.Join(lines, "\r\n")+"\r\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	list, err := LoadBreachedPasswordList(path)
+	if err != nil {
+		t.Fatalf("LoadBreachedPasswordList: %v", err)
+	}
+
+	for _, password := range passwords {
+		breached, err := list.Contains(password)
+		if err != nil || !breached {
+			t.Fatalf("Contains(%q) = %v, %v; want true", password, breached, err)
+		}
+	}
+
+	for _, password := range []string{"not-leaked", "leaked-2000", "", "Correct Horse Battery Staple"} {
+		breached, err := list.Contains(password)
+		if err != nil || breached {
+			t.Fatalf("Contains(%q) = %v, %v; want false", password, breached, err)
+		}
+	}
+}
+
+func TestBreachedPasswordListRangeDir(t *testing.T) {
+	for _, ext := range []string{"", ".txt"} {
+		t.Run("ext="+ext, func(t *testing.T) {
+			dir := t.TempDir()
+
+			ranges := make(map[string][]string)
+			for _, password := range []string{"password", "123456"} {
+				hash := breachedTestHash(password)
+				ranges[hash[:5]] = append(ranges[hash[:5]], strings.ToLower(hash[5:])+":10")
+			}
+			// A padding entry with the suffix of a password that is not breached
+			padded := breachedTestHash("padded-password")
+			ranges[padded[:5]] = append(ranges[padded[:5]], padded[5:]+":0")
+
+			for prefix, lines := range ranges {
+				if err := os.WriteFile(filepath.Join(dir, prefix+ext), []byte(strings.Join(lines, "\r\n")), 0o600); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			list, err := LoadBreachedPasswordList(dir)
+			if err != nil {
+				t.Fatalf("LoadBreachedPasswordList: %v", err)
+			}
+
+			tests := map[string]bool{
+				"password":        true,
+				"123456":          true,
+				"padded-password": false,
+				"not-leaked":      false,
+			}
+			for password, want := range tests {
+				breached, err := list.Contains(password)
+				if err != nil || breached != want {
+					t.Fatalf("Contains(%q) = %v, %v; want %v", password, breached, err, want)
+				}
+			}
+		})
+	}
+}
+
+func TestLoadBreachedPasswordListRejectsUnknownLayout(t *testing.T) {
+	dir := t.TempDir()
+
+	path := filepath.Join(dir, "words.txt")
+	if err := os.WriteFile(path, []byte("password\n123456\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := LoadBreachedPasswordList(path); err == nil {
+		t.Fatal("expected an error for a file without SHA-1 hashes")
+	}
+
+	if _, err := LoadBreachedPasswordList(dir); err == nil {
+		t.Fatal("expected an error for a directory without range files")
+	}
+}
@@ -0,0 +1,146 @@
+package utils
+
+import (
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// Supported CAPTCHA providers
+const (
+	CaptchaProviderHCaptcha  = "hcaptcha"
+	CaptchaProviderTurnstile = "turnstile"
+	CaptchaProviderReCaptcha = "recaptcha"
+	// CaptchaProviderTest accepts a fixed token, for local development and automated tests
+	CaptchaProviderTest = "test"
+)
+
+// Verification endpoints of the CAPTCHA providers
+const (
+	hCaptchaVerifyURL  = "https://api.hcaptcha.com/siteverify"
+	turnstileVerifyURL = "https://challenges.cloudflare.com/turnstile/v0/siteverify"
+	reCaptchaVerifyURL = "https://www.google.com/recaptcha/api/siteverify"
+)
+
+var (
+	// ErrCaptchaFailed indicates that the challenge response was missing, invalid, expired or scored too low
+	ErrCaptchaFailed = errors.New("captcha verification failed")
+	// ErrCaptchaProviderNotSupported indicates an unknown CAPTCHA provider name
+	ErrCaptchaProviderNotSupported = errors.New("captcha provider not supported")
+)
+
+// CaptchaVerifier checks the response token produced by a CAPTCHA widget on the client.
+// Verify returns ErrCaptchaFailed when the token is rejected and another error when the provider could not be reached.
+type CaptchaVerifier interface {
+	Verify(token, remoteIP string) error
+}
+
+// NewCaptchaVerifier creates the verifier of a provider.
+// minScore only applies to reCAPTCHA v3, whose responses carry a score between 0 and 1; zero disables the check.
+// For the test provider, secret is the token that is accepted.
+func NewCaptchaVerifier(provider, secret string, minScore float64) (CaptchaVerifier, error) {
+	if secret == "" {
+		return nil, errors.New("captcha secret is required")
+	}
+
+	switch strings.ToLower(provider) {
+	case CaptchaProviderHCaptcha:
+		return NewSiteVerifyCaptcha(hCaptchaVerifyURL, secret, 0), nil
+	case CaptchaProviderTurnstile:
+		return NewSiteVerifyCaptcha(turnstileVerifyURL, secret, 0), nil
+	case CaptchaProviderReCaptcha:
+		return NewSiteVerifyCaptcha(reCaptchaVerifyURL, secret, minScore), nil
+	case CaptchaProviderTest:
+		return NewTestCaptcha(secret), nil
+	default:
+		return nil, ErrCaptchaProviderNotSupported
+	}
+}
+
+// SiteVerifyCaptcha verifies tokens with the "siteverify" API shared by hCaptcha, Turnstile and reCAPTCHA
+type SiteVerifyCaptcha struct {
+	VerifyURL  string
+	Secret     string
+	MinScore   float64
+	HTTPClient *http.Client
+}
+
+// NewSiteVerifyCaptcha creates a new instance of SiteVerifyCaptcha
+func NewSiteVerifyCaptcha(verifyURL, secret string, minScore float64) *SiteVerifyCaptcha {
+	return &SiteVerifyCaptcha{
+		VerifyURL:  verifyURL,
+		Secret:     secret,
+		MinScore:   minScore,
+		HTTPClient: &http.Client{Timeout: 5 * time.Second},
+	}
+}
+
+// siteVerifyResponse is the answer of the siteverify API; score is only sent by reCAPTCHA v3
+type siteVerifyResponse struct {
+	Success    bool     `json:"success"`
+	Score      *float64 `json:"score"`
+	ErrorCodes []string `json:"error-codes"`
+}
+
+// Verify sends the token to the provider and checks the answer
+func (c *SiteVerifyCaptcha) Verify(token, remoteIP string) error {
+	if token == "" {
+		return ErrCaptchaFailed
+	}
+
+	form := url.Values{}
+	form.Set("secret", c.Secret)
+	form.Set("response", token)
+	if remoteIP != "" {
+		form.Set("remoteip", remoteIP)
+	}
+
+	resp, err := c.HTTPClient.PostForm(c.VerifyURL, form)
+	if err != nil {
+		return fmt.Errorf("captcha verification request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("captcha verification returned status %d", resp.StatusCode)
+	}
+
+	var result siteVerifyResponse
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return fmt.Errorf("invalid captcha verification response: %w", err)
+	}
+
+	if !result.Success {
+		return ErrCaptchaFailed
+	}
+
+	if c.MinScore > 0 && result.Score != nil && *result.Score < c.MinScore {
+		return ErrCaptchaFailed
+	}
+
+	return nil
+}
+
+// TestCaptcha accepts a single fixed token without calling any provider.
+// It must only be configured in development and automated tests.
+type TestCaptcha struct {
+	Token string
+}
+
+// NewTestCaptcha creates a new instance of TestCaptcha
+func NewTestCaptcha(token string) *TestCaptcha {
+	return &TestCaptcha{Token: token}
+}
+
+// Verify accepts the configured token
+func (c *TestCaptcha) Verify(token, remoteIP string) error {
+	if token == "" || subtle.ConstantTimeCompare([]byte(token), []byte(c.Token)) != 1 {
+		return ErrCaptchaFailed
+	}
+	return nil
+}
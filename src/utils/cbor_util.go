@@ -0,0 +1,156 @@
+package utils
+
+import (
+	"encoding/binary"
+	"errors"
+	"math"
+)
+
+// CBOR limits used when decoding data sent by authenticators
+const (
+	// cborMaxDepth limits nesting to protect against deeply nested payloads
+	cborMaxDepth = 16
+)
+
+var (
+	// ErrInvalidCBOR indicates malformed or unsupported CBOR data
+	ErrInvalidCBOR = errors.New("invalid CBOR data")
+)
+
+// DecodeCBOR decodes the first CBOR item of data (RFC 8949) and returns it along
+// with the number of bytes consumed. Only the subset used by WebAuthn is supported:
+// integers are returned as int64, byte strings as []byte, text as string, arrays as
+// []interface{}, maps as map[interface{}]interface{}, plus booleans and null.
+// Indefinite-length items and floats are rejected.
+func DecodeCBOR(data []byte) (interface{}, int, error) {
+	return decodeCBORItem(data, 0)
+}
+
+func decodeCBORItem(data []byte, depth int) (interface{}, int, error) {
+	if depth > cborMaxDepth || len(data) == 0 {
+		return nil, 0, ErrInvalidCBOR
+	}
+
+	major := data[0] >> 5
+	info := data[0] & 0x1f
+
+	// Simple values (major type 7)
+	if major == 7 {
+		switch info {
+		case 20:
+			return false, 1, nil
+		case 21:
+			return true, 1, nil
+		case 22:
+			return nil, 1, nil
+		default:
+			return nil, 0, ErrInvalidCBOR
+		}
+	}
+
+	arg, offset, err := readCBORArgument(data, info)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	switch major {
+	case 0:
+		if arg > math.MaxInt64 {
+			return nil, 0, ErrInvalidCBOR
+		}
+		return int64(arg), offset, nil
+	case 1:
+		if arg > math.MaxInt64 {
+			return nil, 0, ErrInvalidCBOR
+		}
+		return -1 - int64(arg), offset, nil
+	case 2, 3:
+		if arg > uint64(len(data)-offset) {
+			return nil, 0, ErrInvalidCBOR
+		}
+		end := offset + int(arg)
+		if major == 2 {
+			value := make([]byte, arg)
+			copy(value, data[offset:end])
+			return value, end, nil
+		}
+		return string(data[offset:end]), end, nil
+	case 4:
+		// Each element takes at least one byte
+		if arg > uint64(len(data)-offset) {
+			return nil, 0, ErrInvalidCBOR
+		}
+		items := make([]interface{}, 0, arg)
+		for i := uint64(0); i < arg; i++ {
+			item, n, err := decodeCBORItem(data[offset:], depth+1)
+			if err != nil {
+				return nil, 0, err
+			}
+			items = append(items, item)
+			offset += n
+		}
+		return items, offset, nil
+	case 5:
+		// Each pair takes at least two bytes
+		if arg > uint64(len(data)-offset)/2 {
+			return nil, 0, ErrInvalidCBOR
+		}
+		items := make(map[interface{}]interface{}, arg)
+		for i := uint64(0); i < arg; i++ {
+			key, n, err := decodeCBORItem(data[offset:], depth+1)
+			if err != nil {
+				return nil, 0, err
+			}
+			offset += n
+
+			switch key.(type) {
+			case int64, string:
+			default:
+				return nil, 0, ErrInvalidCBOR
+			}
+
+			value, n, err := decodeCBORItem(data[offset:], depth+1)
+			if err != nil {
+				return nil, 0, err
+			}
+			offset += n
+
+			items[key] = value
+		}
+		return items, offset, nil
+	default:
+		// Tags (major type 6) are not used by WebAuthn
+		return nil, 0, ErrInvalidCBOR
+	}
+}
+
+// readCBORArgument reads the argument that follows the initial byte of an item
+func readCBORArgument(data []byte, info byte) (uint64, int, error) {
+	switch {
+	case info < 24:
+		return uint64(info), 1, nil
+	case info == 24:
+		if len(data) < 2 {
+			return 0, 0, ErrInvalidCBOR
+		}
+		return uint64(data[1]), 2, nil
+	case info == 25:
+		if len(data) < 3 {
+			return 0, 0, ErrInvalidCBOR
+		}
+		return uint64(binary.BigEndian.Uint16(data[1:3])), 3, nil
+	case info == 26:
+		if len(data) < 5 {
+			return 0, 0, ErrInvalidCBOR
+		}
+		return uint64(binary.BigEndian.Uint32(data[1:5])), 5, nil
+	case info == 27:
+		if len(data) < 9 {
+			return 0, 0, ErrInvalidCBOR
+		}
+		return binary.BigEndian.Uint64(data[1:9]), 9, nil
+	default:
+		// Indefinite lengths and reserved values
+		return 0, 0, ErrInvalidCBOR
+	}
+}
@@ -0,0 +1,197 @@
+package utils
+
+import (
+	"bytes"
+	"encoding/hex"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// cborPair is a map entry for encodeCBOR; maps are written as []cborPair to keep the order of the entries
+type cborPair struct {
+	Key   interface{}
+	Value interface{}
+}
+
+// cborHead encodes the initial byte and argument of an item
+func cborHead(major byte, arg uint64) []byte {
+	switch {
+	case arg < 24:
+		return []byte{major<<5 | byte(arg)}
+	case arg <= 0xff:
+		return []byte{major<<5 | 24, byte(arg)}
+	case arg <= 0xffff:
+		return []byte{major<<5 | 25, byte(arg >> 8), byte(arg)}
+	default:
+		return []byte{major<<5 | 26, byte(arg >> 24), byte(arg >> 16), byte(arg >> 8), byte(arg)}
+	}
+}
+
+// encodeCBOR encodes the subset of CBOR produced by authenticators, used to build test fixtures
+func encodeCBOR(value interface{}) []byte {
+	switch v := value.(type) {
+	case int:
+		if v < 0 {
+			return cborHead(1, uint64(-1-v))
+		}
+		return cborHead(0, uint64(v))
+	case []byte:
+		return append(cborHead(2, uint64(len(v))), v...)
+	case string:
+		return append(cborHead(3, uint64(len(v))), v...)
+	case []interface{}:
+		out := cborHead(4, uint64(len(v)))
+		for _, item := range v {
+			out = append(out, encodeCBOR(item)...)
+		}
+		return out
+	case []cborPair:
+		out := cborHead(5, uint64(len(v)))
+		for _, pair := range v {
+			out = append(out, encodeCBOR(pair.Key)...)
+			out = append(out, encodeCBOR(pair.Value)...)
+		}
+		return out
+	case bool:
+		if v {
+			return []byte{0xf5}
+		}
+		return []byte{0xf4}
+	case nil:
+		return []byte{0xf6}
+	}
+	panic("unsupported CBOR test value")
+}
+
+func TestDecodeCBOR(t *testing.T) {
+	tests := []struct {
+		name string
+		data string
+		want interface{}
+	}{
+		{"small unsigned", "17", int64(23)},
+		{"one byte unsigned", "1818", int64(24)},
+		{"two byte unsigned", "190100", int64(256)},
+		{"four byte unsigned", "1a000f4240", int64(1000000)},
+		{"eight byte unsigned", "1b000000e8d4a51000", int64(1000000000000)},
+		{"negative", "26", int64(-7)},
+		{"two byte negative", "390100", int64(-257)},
+		{"byte string", "4401020304", []byte{1, 2, 3, 4}},
+		{"text", "6449455446", "IETF"},
+		{"array", "83010203", []interface{}{int64(1), int64(2), int64(3)}},
+		{"nested array", "8201820203", []interface{}{int64(1), []interface{}{int64(2), int64(3)}}},
+		{"map", "a201020304", map[interface{}]interface{}{int64(1): int64(2), int64(3): int64(4)}},
+		{"text keys", "a26161016162820203", map[interface{}]interface{}{"a": int64(1), "b": []interface{}{int64(2), int64(3)}}},
+		{"false", "f4", false},
+		{"true", "f5", true},
+		{"null", "f6", nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			data, _ := hex.DecodeString(tt.data)
+			got, n, err := DecodeCBOR(data)
+			if err != nil {
+				t.Fatalf("DecodeCBOR: %v", err)
+			}
+			if n != len(data) {
+				t.Fatalf("consumed %d bytes, want %d", n, len(data))
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Fatalf("got %#v, want %#v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDecodeCBORConsumesFirstItemOnly(t *testing.T) {
+	// A COSE key in the authenticator data may be followed by the extensions
+	data := append(encodeCBOR([]cborPair{{1, 2}}), encodeCBOR([]cborPair{{"credProtect", 1}})...)
+
+	_, n, err := DecodeCBOR(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n != 3 {
+		t.Fatalf("consumed %d bytes, want 3", n)
+	}
+}
+
+func TestDecodeCBORRejectsInvalidData(t *testing.T) {
+	deep := bytes.Repeat([]byte{0x81}, cborMaxDepth+2)
+	deep = append(deep, 0x01)
+
+	tests := []struct {
+		name string
+		data string
+	}{
+		{"empty", ""},
+		{"truncated argument", "19 01"},
+		{"truncated byte string", "44 0102"},
+		{"truncated array", "83 0102"},
+		{"indefinite byte string", "5f 4101 ff"},
+		{"indefinite array", "9f 01 ff"},
+		{"half float", "f9 3c00"},
+		{"double float", "fb 3ff199999999999a"},
+		{"undefined", "f7"},
+		{"tag", "c0 74 323031332d30332d32315432303a30343a30305a"},
+		{"reserved argument", "1c"},
+		{"unsigned overflow", "1b ffffffffffffffff"},
+		{"negative overflow", "3b ffffffffffffffff"},
+		{"byte string key", "a1 4101 01"},
+		{"array key", "a1 8101 01"},
+		{"huge byte string length", "5b 7fffffffffffffff"},
+		{"huge array length", "9b 7fffffffffffffff"},
+		{"huge map length", "bb 7fffffffffffffff"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			data, err := hex.DecodeString(strings.ReplaceAll(tt.data, " ", ""))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if _, _, err := DecodeCBOR(data); err != ErrInvalidCBOR {
+				t.Fatalf("DecodeCBOR(%s) = %v, want ErrInvalidCBOR", tt.data, err)
+			}
+		})
+	}
+
+	t.Run("too deep", func(t *testing.T) {
+		if _, _, err := DecodeCBOR(deep); err != ErrInvalidCBOR {
+			t.Fatalf("DecodeCBOR = %v, want ErrInvalidCBOR", err)
+		}
+	})
+}
+
+func TestEncodeCBORRoundTrip(t *testing.T) {
+	value := []cborPair{
+		{1, 2},
+		{3, -7},
+		{-1, 1},
+		{-2, bytes.Repeat([]byte{0xab}, 32)},
+		{"fmt", "none"},
+		{"list", []interface{}{true, false, nil}},
+	}
+
+	got, n, err := DecodeCBOR(encodeCBOR(value))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n != len(encodeCBOR(value)) {
+		t.Fatalf("consumed %d bytes", n)
+	}
+
+	want := map[interface{}]interface{}{
+		int64(1):  int64(2),
+		int64(3):  int64(-7),
+		int64(-1): int64(1),
+		int64(-2): bytes.Repeat([]byte{0xab}, 32),
+		"fmt":     "none",
+		"list":    []interface{}{true, false, nil},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("got %#v, want %#v", got, want)
+	}
+}
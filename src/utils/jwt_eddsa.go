@@ -0,0 +1,56 @@
+package utils
+
+import (
+	"crypto/ed25519"
+	"errors"
+
+	"github.com/dgrijalva/jwt-go"
+)
+
+// ErrEd25519Verification indicates that an EdDSA signature is invalid
+var ErrEd25519Verification = errors.New("ed25519: verification error")
+
+// SigningMethodEd25519 implements the EdDSA (Ed25519) signing method, which jwt-go v3 lacks
+type SigningMethodEd25519 struct{}
+
+// SigningMethodEdDSA is the EdDSA signing method instance
+var SigningMethodEdDSA = &SigningMethodEd25519{}
+
+func init() {
+	jwt.RegisterSigningMethod(SigningMethodEdDSA.Alg(), func() jwt.SigningMethod {
+		return SigningMethodEdDSA
+	})
+}
+
+// Alg returns the JWS algorithm name
+func (m *SigningMethodEd25519) Alg() string {
+	return JWTAlgorithmEdDSA
+}
+
+// Verify checks the signature of the signing string with an ed25519.PublicKey
+func (m *SigningMethodEd25519) Verify(signingString, signature string, key interface{}) error {
+	publicKey, ok := key.(ed25519.PublicKey)
+	if !ok || len(publicKey) != ed25519.PublicKeySize {
+		return jwt.ErrInvalidKeyType
+	}
+
+	sig, err := jwt.DecodeSegment(signature)
+	if err != nil {
+		return err
+	}
+
+	if !ed25519.Verify(publicKey, []byte(signingString), sig) {
+		return ErrEd25519Verification
+	}
+	return nil
+}
+
+// Sign signs the signing string with an ed25519.PrivateKey
+func (m *SigningMethodEd25519) Sign(signingString string, key interface{}) (string, error) {
+	privateKey, ok := key.(ed25519.PrivateKey)
+	if !ok || len(privateKey) != ed25519.PrivateKeySize {
+		return "", jwt.ErrInvalidKeyType
+	}
+
+	return jwt.EncodeSegment(ed25519.Sign(privateKey, []byte(signingString))), nil
+}
@@ -0,0 +1,352 @@
+package utils
+
+import (
+	"crypto"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"math/big"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/dgrijalva/jwt-go"
+)
+
+// JWT signing algorithms supported by the key ring
+const (
+	JWTAlgorithmRS256 = "RS256"
+	JWTAlgorithmEdDSA = "EdDSA"
+	// jwtRSAKeyBits is the size of generated RSA keys
+	jwtRSAKeyBits = 2048
+)
+
+var (
+	// ErrJWTKeyNotFound indicates that no key in the ring has the requested ID
+	ErrJWTKeyNotFound = errors.New("JWT key not found")
+	// ErrJWTNoSigningKey indicates that the key ring has no key selected for signing
+	ErrJWTNoSigningKey = errors.New("JWT key ring has no signing key")
+	// ErrJWTUnsupportedAlgorithm indicates an algorithm other than RS256 or EdDSA
+	ErrJWTUnsupportedAlgorithm = errors.New("unsupported JWT algorithm")
+)
+
+// JWTKey is an asymmetric key used to sign and verify tokens
+type JWTKey struct {
+	ID         string
+	Algorithm  string
+	PrivateKey crypto.Signer
+	CreatedAt  time.Time
+	// RetiredAt is set once the key stops signing; it is kept to verify tokens issued before the rotation
+	RetiredAt *time.Time
+}
+
+// PublicKey returns the public half of the key
+func (k *JWTKey) PublicKey() crypto.PublicKey {
+	return k.PrivateKey.Public()
+}
+
+// signingMethod returns the jwt-go signing method of the key
+func (k *JWTKey) signingMethod() jwt.SigningMethod {
+	if k.Algorithm == JWTAlgorithmEdDSA {
+		return SigningMethodEdDSA
+	}
+	return jwt.SigningMethodRS256
+}
+
+// JWTKeyRing holds the key used to sign new tokens and every key still accepted for verification.
+// The ring is read-only while the server runs; rotations are done offline with cmd/jwtkeys.
+type JWTKeyRing struct {
+	SigningKeyID string
+	Keys         []*JWTKey
+}
+
+// GenerateJWTKey generates a new key for the given algorithm
+func GenerateJWTKey(algorithm string) (*JWTKey, error) {
+	var privateKey crypto.Signer
+	var err error
+
+	switch algorithm {
+	case JWTAlgorithmRS256:
+		privateKey, err = rsa.GenerateKey(rand.Reader, jwtRSAKeyBits)
+	case JWTAlgorithmEdDSA:
+		_, privateKey, err = ed25519.GenerateKey(rand.Reader)
+	default:
+		return nil, ErrJWTUnsupportedAlgorithm
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	id, err := jwtKeyID(privateKey.Public())
+	if err != nil {
+		return nil, err
+	}
+
+	return &JWTKey{
+		ID:         id,
+		Algorithm:  algorithm,
+		PrivateKey: privateKey,
+		CreatedAt:  time.Now().UTC(),
+	}, nil
+}
+
+// NewJWTKeyRing creates a key ring with a single new signing key
+func NewJWTKeyRing(algorithm string) (*JWTKeyRing, error) {
+	key, err := GenerateJWTKey(algorithm)
+	if err != nil {
+		return nil, err
+	}
+
+	return &JWTKeyRing{
+		SigningKeyID: key.ID,
+		Keys:         []*JWTKey{key},
+	}, nil
+}
+
+// SigningKey returns the key used to sign new tokens
+func (r *JWTKeyRing) SigningKey() (*JWTKey, error) {
+	key, err := r.Key(r.SigningKeyID)
+	if err != nil {
+		return nil, ErrJWTNoSigningKey
+	}
+	return key, nil
+}
+
+// Key returns the key with the given ID
+func (r *JWTKeyRing) Key(id string) (*JWTKey, error) {
+	for _, key := range r.Keys {
+		if key.ID == id {
+			return key, nil
+		}
+	}
+	return nil, ErrJWTKeyNotFound
+}
+
+// Add adds a key that is published and accepted for verification but does not sign yet.
+// Staging the next key before promoting it lets every verifier fetch it before it is used.
+func (r *JWTKeyRing) Add(key *JWTKey) {
+	r.Keys = append(r.Keys, key)
+}
+
+// Promote makes the key with the given ID the signing key, retiring the current one
+func (r *JWTKeyRing) Promote(id string) error {
+	key, err := r.Key(id)
+	if err != nil {
+		return err
+	}
+
+	if current, err := r.Key(r.SigningKeyID); err == nil && current.ID != key.ID {
+		now := time.Now().UTC()
+		current.RetiredAt = &now
+	}
+
+	key.RetiredAt = nil
+	r.SigningKeyID = key.ID
+	return nil
+}
+
+// Prune removes the keys retired for longer than the retention period and returns how many were removed.
+// The retention must outlive the longest token signed with a retired key.
+func (r *JWTKeyRing) Prune(retention time.Duration) int {
+	cutoff := time.Now().Add(-retention)
+	kept := r.Keys[:0]
+	removed := 0
+
+	for _, key := range r.Keys {
+		if key.ID != r.SigningKeyID && key.RetiredAt != nil && key.RetiredAt.Before(cutoff) {
+			removed++
+			continue
+		}
+		kept = append(kept, key)
+	}
+
+	r.Keys = kept
+	return removed
+}
+
+// JWK is the public representation of a key in a JWKS document
+type JWK struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Use string `json:"use"`
+	Alg string `json:"alg"`
+	N   string `json:"n,omitempty"`
+	E   string `json:"e,omitempty"`
+	Crv string `json:"crv,omitempty"`
+	X   string `json:"x,omitempty"`
+}
+
+// JWKS is a JSON Web Key Set document
+type JWKS struct {
+	Keys []JWK `json:"keys"`
+}
+
+// JWKS returns the public keys of the ring, including the retired ones still accepted for verification
+func (r *JWTKeyRing) JWKS() JWKS {
+	document := JWKS{Keys: []JWK{}}
+
+	for _, key := range r.Keys {
+		jwk := JWK{
+			Kid: key.ID,
+			Use: "sig",
+			Alg: key.Algorithm,
+		}
+
+		switch publicKey := key.PublicKey().(type) {
+		case *rsa.PublicKey:
+			jwk.Kty = "RSA"
+			jwk.N = base64.RawURLEncoding.EncodeToString(publicKey.N.Bytes())
+			jwk.E = base64.RawURLEncoding.EncodeToString(big.NewInt(int64(publicKey.E)).Bytes())
+		case ed25519.PublicKey:
+			jwk.Kty = "OKP"
+			jwk.Crv = "Ed25519"
+			jwk.X = base64.RawURLEncoding.EncodeToString(publicKey)
+		default:
+			continue
+		}
+
+		document.Keys = append(document.Keys, jwk)
+	}
+
+	return document
+}
+
+// jwtKeyRingFile is the on-disk format of the key ring
+type jwtKeyRingFile struct {
+	SigningKeyID string           `json:"signing_key_id"`
+	Keys         []jwtKeyFileItem `json:"keys"`
+}
+
+// jwtKeyFileItem is a key stored in the key ring file, with the private key as a PKCS#8 PEM block
+type jwtKeyFileItem struct {
+	ID         string     `json:"kid"`
+	Algorithm  string     `json:"alg"`
+	PrivateKey string     `json:"private_key"`
+	CreatedAt  time.Time  `json:"created_at"`
+	RetiredAt  *time.Time `json:"retired_at,omitempty"`
+}
+
+// LoadJWTKeyRing reads a key ring file
+func LoadJWTKeyRing(path string) (*JWTKeyRing, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("reading JWT key ring: %w", err)
+	}
+
+	var file jwtKeyRingFile
+	if err := json.Unmarshal(data, &file); err != nil {
+		return nil, fmt.Errorf("decoding JWT key ring: %w", err)
+	}
+
+	ring := &JWTKeyRing{SigningKeyID: file.SigningKeyID}
+	for _, item := range file.Keys {
+		key, err := item.decode()
+		if err != nil {
+			return nil, fmt.Errorf("decoding JWT key %q: %w", item.ID, err)
+		}
+		ring.Keys = append(ring.Keys, key)
+	}
+
+	if _, err := ring.SigningKey(); err != nil {
+		return nil, err
+	}
+
+	return ring, nil
+}
+
+// Save writes the key ring to a file readable only by its owner
+func (r *JWTKeyRing) Save(path string) error {
+	file := jwtKeyRingFile{SigningKeyID: r.SigningKeyID}
+	for _, key := range r.Keys {
+		der, err := x509.MarshalPKCS8PrivateKey(key.PrivateKey)
+		if err != nil {
+			return err
+		}
+		file.Keys = append(file.Keys, jwtKeyFileItem{
+			ID:         key.ID,
+			Algorithm:  key.Algorithm,
+			PrivateKey: string(pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der})),
+			CreatedAt:  key.CreatedAt,
+			RetiredAt:  key.RetiredAt,
+		})
+	}
+
+	data, err := json.MarshalIndent(file, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	// We write to a temporary file first so a failure never leaves a truncated key ring behind
+	tmp, err := os.CreateTemp(filepath.Dir(path), ".jwt-keys-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	if err := os.Chmod(tmp.Name(), 0600); err != nil {
+		return err
+	}
+
+	return os.Rename(tmp.Name(), path)
+}
+
+// decode parses a stored key, checking that it matches the declared algorithm
+func (item jwtKeyFileItem) decode() (*JWTKey, error) {
+	block, _ := pem.Decode([]byte(item.PrivateKey))
+	if block == nil {
+		return nil, errors.New("invalid PEM block")
+	}
+
+	parsed, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil {
+		return nil, err
+	}
+
+	var privateKey crypto.Signer
+	switch k := parsed.(type) {
+	case *rsa.PrivateKey:
+		if item.Algorithm != JWTAlgorithmRS256 {
+			return nil, ErrJWTUnsupportedAlgorithm
+		}
+		privateKey = k
+	case ed25519.PrivateKey:
+		if item.Algorithm != JWTAlgorithmEdDSA {
+			return nil, ErrJWTUnsupportedAlgorithm
+		}
+		privateKey = k
+	default:
+		return nil, ErrJWTUnsupportedAlgorithm
+	}
+
+	return &JWTKey{
+		ID:         item.ID,
+		Algorithm:  item.Algorithm,
+		PrivateKey: privateKey,
+		CreatedAt:  item.CreatedAt,
+		RetiredAt:  item.RetiredAt,
+	}, nil
+}
+
+// jwtKeyID derives a stable key ID from the public key
+func jwtKeyID(publicKey crypto.PublicKey) (string, error) {
+	der, err := x509.MarshalPKIXPublicKey(publicKey)
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256(der)
+	return base64.RawURLEncoding.EncodeToString(sum[:12]), nil
+}
@@ -22,13 +22,20 @@ const (
 	TokenExpirationAccess = 15 * time.Minute
 	// TokenExpirationRefresh is the duration of the refresh token (7 days)
 	TokenExpirationRefresh = 7 * 24 * time.Hour
+	// TokenExpirationMFA is the duration of the MFA challenge token issued after the password step (5 minutes)
+	TokenExpirationMFA = 5 * time.Minute
+	// TokenExpirationImpersonation is the duration of the access token an administrator uses to act as another user (30 minutes)
+	TokenExpirationImpersonation = 30 * time.Minute
 )
 
 // JWTConfig contains the configuration for JWT
 type JWTConfig struct {
-	AccessSecret  string
-	RefreshSecret string
-	Issuer        string
+	// KeyRing holds the asymmetric keys used to sign and verify every token type
+	KeyRing *JWTKeyRing
+	Issuer  string
+	// Audience is the aud claim of access tokens, checked by the API and by services verifying them with the JWKS.
+	// The other token types are addressed to the issuer, so they are never accepted as access tokens.
+	Audience string
 }
 
 type JWTUtil struct {
@@ -44,21 +51,30 @@ func NewJWTUtil(config JWTConfig) *JWTUtil {
 
 // Claims represents the data included in the JWT
 type Claims struct {
-	UserID uuid.UUID       `json:"user_id"`
-	Role   models.UserRole `json:"role"`
-	Type   string          `json:"type"`
+	UserID    uuid.UUID       `json:"user_id"`
+	Role      models.UserRole `json:"role"`
+	Type      string          `json:"type"`
+	SessionID uuid.UUID       `json:"sid"`
+	// EstablishmentID is the establishment the session is acting on, for staff of several establishments
+	EstablishmentID *uuid.UUID `json:"eid,omitempty"`
+	// ImpersonatorID is the administrator acting as UserID in impersonation tokens
+	ImpersonatorID *uuid.UUID `json:"imp,omitempty"`
+	// Email carries the new address in email change tokens
+	Email string `json:"email,omitempty"`
 	jwt.StandardClaims
 }
 
 // GenerateAccessToken generates a new JWT access token
-func (j *JWTUtil) GenerateAccessToken(userID uuid.UUID, role models.UserRole) (string, error) {
+func (j *JWTUtil) GenerateAccessToken(userID uuid.UUID, role models.UserRole, sessionID uuid.UUID, establishmentID *uuid.UUID) (string, error) {
 	now := time.Now()
 	expirationTime := now.Add(TokenExpirationAccess)
 
 	claims := Claims{
-		UserID: userID,
-		Role:   role,
-		Type:   "access",
+		UserID:          userID,
+		Role:            role,
+		Type:            "access",
+		SessionID:       sessionID,
+		EstablishmentID: establishmentID,
 		StandardClaims: jwt.StandardClaims{
 			ExpiresAt: expirationTime.Unix(),
 			IssuedAt:  now.Unix(),
@@ -66,49 +82,113 @@ func (j *JWTUtil) GenerateAccessToken(userID uuid.UUID, role models.UserRole) (s
 		},
 	}
 
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
-	return token.SignedString([]byte(j.Config.AccessSecret))
+	return j.signToken(claims)
 }
 
 // GenerateRefreshToken generates a new JWT refresh token
-func (j *JWTUtil) GenerateRefreshToken(userID uuid.UUID, role models.UserRole) (string, error) {
+func (j *JWTUtil) GenerateRefreshToken(userID uuid.UUID, role models.UserRole, sessionID uuid.UUID, establishmentID *uuid.UUID) (string, error) {
 	now := time.Now()
 	expirationTime := now.Add(TokenExpirationRefresh)
 
 	claims := Claims{
-		UserID: userID,
-		Role:   role,
-		Type:   "refresh",
+		UserID:          userID,
+		Role:            role,
+		Type:            "refresh",
+		SessionID:       sessionID,
+		EstablishmentID: establishmentID,
 		StandardClaims: jwt.StandardClaims{
+			// The ID keeps tokens issued in the same second distinct, since they are stored by hash
+			Id:        uuid.New().String(),
 			ExpiresAt: expirationTime.Unix(),
 			IssuedAt:  now.Unix(),
 			Issuer:    j.Config.Issuer,
 		},
 	}
 
-	token := jwt.NewWithClaims(jwt.SigningMethodES256, claims)
-	return token.SignedString([]byte(j.Config.RefreshSecret))
+	return j.signToken(claims)
+}
+
+// GenerateImpersonationToken generates a short-lived access token for an administrator acting as another user.
+// It is an access token for the target user that also carries the administrator's ID; no refresh token is issued.
+func (j *JWTUtil) GenerateImpersonationToken(adminID, userID uuid.UUID, role models.UserRole, sessionID uuid.UUID, expiresAt time.Time) (string, error) {
+	claims := Claims{
+		UserID:         userID,
+		Role:           role,
+		Type:           "access",
+		SessionID:      sessionID,
+		ImpersonatorID: &adminID,
+		StandardClaims: jwt.StandardClaims{
+			Id:        uuid.New().String(),
+			ExpiresAt: expiresAt.Unix(),
+			IssuedAt:  time.Now().Unix(),
+			Issuer:    j.Config.Issuer,
+		},
+	}
+
+	return j.signToken(claims)
 }
 
 // ValidateAccessToken validates an access token
 func (j *JWTUtil) ValidateAccessToken(tokenString string) (*Claims, error) {
-	return j.validateToken(tokenString, j.Config.AccessSecret, "access")
+	return j.validateToken(tokenString, "access")
 }
 
 // ValidateRefreshToken validates a refresh token
 func (j *JWTUtil) ValidateRefreshToken(tokenString string) (*Claims, error) {
-	return j.validateToken(tokenString, j.Config.RefreshSecret, "refresh")
+	return j.validateToken(tokenString, "refresh")
+}
+
+// tokenTypeHeader returns the typ header of a token type. Access tokens use the at+jwt type of RFC 9068,
+// so verifiers that only have the JWKS can tell them apart from the other tokens signed with the same keys.
+func tokenTypeHeader(tokenType string) string {
+	if tokenType == "access" {
+		return "at+jwt"
+	}
+	return tokenType + "+jwt"
+}
+
+// audience returns the aud claim of a token type
+func (j *JWTUtil) audience(tokenType string) string {
+	if tokenType == "access" {
+		return j.Config.Audience
+	}
+	return j.Config.Issuer
+}
+
+// signToken signs the claims with the current signing key, identified by the kid header.
+// The typ header and the audience are set from the token type.
+func (j *JWTUtil) signToken(claims Claims) (string, error) {
+	key, err := j.Config.KeyRing.SigningKey()
+	if err != nil {
+		return "", err
+	}
+
+	claims.Audience = j.audience(claims.Type)
+
+	token := jwt.NewWithClaims(key.signingMethod(), claims)
+	token.Header["kid"] = key.ID
+	token.Header["typ"] = tokenTypeHeader(claims.Type)
+	return token.SignedString(key.PrivateKey)
 }
 
 // validateToken validates a JWT token
-func (j *JWTUtil) validateToken(tokenString, secret, tokenType string) (*Claims, error) {
+func (j *JWTUtil) validateToken(tokenString, tokenType string) (*Claims, error) {
 	claims := &Claims{}
 
 	token, err := jwt.ParseWithClaims(tokenString, claims, func(token *jwt.Token) (interface{}, error) {
-		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
+		kid, _ := token.Header["kid"].(string)
+		key, err := j.Config.KeyRing.Key(kid)
+		if err != nil {
+			return nil, ErrInvalidToken
+		}
+		// The algorithm comes from the key, never from the token header
+		if token.Method.Alg() != key.Algorithm {
+			return nil, ErrInvalidToken
+		}
+		if typ, _ := token.Header["typ"].(string); typ != tokenTypeHeader(tokenType) {
 			return nil, ErrInvalidToken
 		}
-		return []byte(secret), nil
+		return key.PublicKey(), nil
 	})
 
 	if err != nil {
@@ -130,17 +210,96 @@ func (j *JWTUtil) validateToken(tokenString, secret, tokenType string) (*Claims,
 		return nil, ErrInvalidToken
 	}
 
+	if !claims.VerifyIssuer(j.Config.Issuer, true) || !claims.VerifyAudience(j.audience(tokenType), true) {
+		return nil, ErrInvalidToken
+	}
+
 	return claims, nil
 }
 
+// JWKS returns the public keys used to verify the tokens
+func (j *JWTUtil) JWKS() JWKS {
+	return j.Config.KeyRing.JWKS()
+}
+
+// GenerateMFAToken generates a short-lived token proving that the password step of the login succeeded
+func (j *JWTUtil) GenerateMFAToken(userID uuid.UUID, role models.UserRole) (string, error) {
+	now := time.Now()
+	expirationTime := now.Add(TokenExpirationMFA)
+
+	claims := Claims{
+		UserID: userID,
+		Role:   role,
+		Type:   "mfa",
+		StandardClaims: jwt.StandardClaims{
+			Id:        uuid.New().String(),
+			ExpiresAt: expirationTime.Unix(),
+			IssuedAt:  now.Unix(),
+			Issuer:    j.Config.Issuer,
+		},
+	}
+
+	return j.signToken(claims)
+}
+
+// ValidateMFAToken validates an MFA challenge token
+func (j *JWTUtil) ValidateMFAToken(tokenString string) (*Claims, error) {
+	return j.validateToken(tokenString, "mfa")
+}
+
+// GenerateEmailVerificationToken generates the signed token sent in the email verification link.
+// The token ID matches the stored one-time token, so the link can only be used once.
+func (j *JWTUtil) GenerateEmailVerificationToken(userID, tokenID uuid.UUID, expiresAt time.Time) (string, error) {
+	claims := Claims{
+		UserID: userID,
+		Type:   "email_verification",
+		StandardClaims: jwt.StandardClaims{
+			Id:        tokenID.String(),
+			ExpiresAt: expiresAt.Unix(),
+			IssuedAt:  time.Now().Unix(),
+			Issuer:    j.Config.Issuer,
+		},
+	}
+
+	return j.signToken(claims)
+}
+
+// ValidateEmailVerificationToken validates an email verification token
+func (j *JWTUtil) ValidateEmailVerificationToken(tokenString string) (*Claims, error) {
+	return j.validateToken(tokenString, "email_verification")
+}
+
+// GenerateEmailChangeToken generates the signed token sent to the new address when a user changes their email.
+// The new address travels in the claims, so it is only committed once the link is confirmed.
+func (j *JWTUtil) GenerateEmailChangeToken(userID, tokenID uuid.UUID, newEmail string, expiresAt time.Time) (string, error) {
+	claims := Claims{
+		UserID: userID,
+		Type:   "email_change",
+		Email:  newEmail,
+		StandardClaims: jwt.StandardClaims{
+			Id:        tokenID.String(),
+			ExpiresAt: expiresAt.Unix(),
+			IssuedAt:  time.Now().Unix(),
+			Issuer:    j.Config.Issuer,
+		},
+	}
+
+	return j.signToken(claims)
+}
+
+// ValidateEmailChangeToken validates an email change token
+func (j *JWTUtil) ValidateEmailChangeToken(tokenString string) (*Claims, error) {
+	return j.validateToken(tokenString, "email_change")
+}
+
 // GenerateTokenPair generates a pair of tokens (access and refresh)
-func (j *JWTUtil) GenerateTokenPair(userID uuid.UUID, role models.UserRole) (accessToken, refreshToken string, err error) {
-	accessToken, err = j.GenerateAccessToken(userID, role)
+func (j *JWTUtil) GenerateTokenPair(userID uuid.UUID, role models.UserRole, sessionID uuid.UUID, establishmentID *uuid.UUID) (accessToken, refreshToken string, err error) {
+	accessToken, err = j.GenerateAccessToken(userID, role, sessionID, establishmentID)
 	if err != nil {
 		return "", "", err
 	}
 
-	refreshToken, err = j.GenerateRefreshToken(userID, role)
+	refreshToken, err = j.GenerateRefreshToken(userID, role, sessionID, establishmentID)
 	if err != nil {
 		return "", "", err
 	}
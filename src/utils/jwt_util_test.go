@@ -0,0 +1,114 @@
+package utils
+
+import (
+	"testing"
+	"time"
+
+	"github.com/Barba2k2/aurora_backend/src/models"
+	"github.com/dgrijalva/jwt-go"
+	"github.com/google/uuid"
+)
+
+// newTestJWTUtil creates a JWTUtil with a fresh key ring
+func newTestJWTUtil(t *testing.T) *JWTUtil {
+	t.Helper()
+
+	keyRing, err := NewJWTKeyRing(JWTAlgorithmEdDSA)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return NewJWTUtil(JWTConfig{KeyRing: keyRing, Issuer: "aurora_backend", Audience: "aurora_api"})
+}
+
+func TestAccessTokenHeaderAndAudience(t *testing.T) {
+	j := newTestJWTUtil(t)
+
+	token, err := j.GenerateAccessToken(uuid.New(), models.UserRoleClient, uuid.New(), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	claims, err := j.ValidateAccessToken(token)
+	if err != nil {
+		t.Fatalf("ValidateAccessToken: %v", err)
+	}
+	if claims.Audience != "aurora_api" {
+		t.Fatalf("aud = %q, want aurora_api", claims.Audience)
+	}
+
+	parsed, _, err := new(jwt.Parser).ParseUnverified(token, &Claims{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if typ := parsed.Header["typ"]; typ != "at+jwt" {
+		t.Fatalf("typ = %v, want at+jwt", typ)
+	}
+}
+
+func TestOtherTokenTypesAreNotAccessTokens(t *testing.T) {
+	j := newTestJWTUtil(t)
+	userID, sessionID := uuid.New(), uuid.New()
+	expiresAt := time.Now().Add(time.Hour)
+
+	refresh, err := j.GenerateRefreshToken(userID, models.UserRoleClient, sessionID, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	mfa, err := j.GenerateMFAToken(userID, models.UserRoleClient)
+	if err != nil {
+		t.Fatal(err)
+	}
+	verification, err := j.GenerateEmailVerificationToken(userID, uuid.New(), expiresAt)
+	if err != nil {
+		t.Fatal(err)
+	}
+	emailChange, err := j.GenerateEmailChangeToken(userID, uuid.New(), "new@example.com", expiresAt)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for name, token := range map[string]string{
+		"refresh":            refresh,
+		"mfa":                mfa,
+		"email_verification": verification,
+		"email_change":       emailChange,
+	} {
+		if _, err := j.ValidateAccessToken(token); err == nil {
+			t.Errorf("%s token accepted as access token", name)
+		}
+
+		claims := &Claims{}
+		if _, _, err := new(jwt.Parser).ParseUnverified(token, claims); err != nil {
+			t.Fatal(err)
+		}
+		if claims.Audience == "aurora_api" {
+			t.Errorf("%s token is addressed to the API", name)
+		}
+	}
+
+	if _, err := j.ValidateRefreshToken(refresh); err != nil {
+		t.Errorf("ValidateRefreshToken: %v", err)
+	}
+	if _, err := j.ValidateMFAToken(mfa); err != nil {
+		t.Errorf("ValidateMFAToken: %v", err)
+	}
+}
+
+func TestValidateTokenChecksIssuerAndAudience(t *testing.T) {
+	j := newTestJWTUtil(t)
+
+	token, err := j.GenerateAccessToken(uuid.New(), models.UserRoleClient, uuid.New(), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	otherAudience := NewJWTUtil(JWTConfig{KeyRing: j.Config.KeyRing, Issuer: "aurora_backend", Audience: "other_api"})
+	if _, err := otherAudience.ValidateAccessToken(token); err != ErrInvalidToken {
+		t.Errorf("token accepted for another audience: %v", err)
+	}
+
+	otherIssuer := NewJWTUtil(JWTConfig{KeyRing: j.Config.KeyRing, Issuer: "other_issuer", Audience: "aurora_api"})
+	if _, err := otherIssuer.ValidateAccessToken(token); err != ErrInvalidToken {
+		t.Errorf("token accepted for another issuer: %v", err)
+	}
+}
@@ -0,0 +1,222 @@
+package utils
+
+import (
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/dgrijalva/jwt-go"
+)
+
+// OIDC defaults
+const (
+	// OIDCJWKSCacheTTL is how long a fetched key set is trusted before being refreshed
+	OIDCJWKSCacheTTL = 1 * time.Hour
+	// oidcJWKSMinRefresh limits refreshes triggered by unknown key IDs
+	oidcJWKSMinRefresh = 1 * time.Minute
+)
+
+var (
+	// ErrOIDCProviderNotConfigured indicates that the provider is unknown or has no client IDs
+	ErrOIDCProviderNotConfigured = errors.New("OIDC provider not configured")
+	// ErrOIDCInvalidToken indicates that the ID token failed verification
+	ErrOIDCInvalidToken = errors.New("invalid OIDC ID token")
+	// ErrOIDCKeyNotFound indicates that the token was signed with a key missing from the JWKS
+	ErrOIDCKeyNotFound = errors.New("OIDC signing key not found")
+)
+
+// OIDCProviderConfig contains the settings used to verify the ID tokens of a provider
+type OIDCProviderConfig struct {
+	// Issuers lists the accepted values for the iss claim
+	Issuers []string
+	// ClientIDs lists the accepted values for the aud claim (web, iOS, Android...)
+	ClientIDs []string
+	// JWKSURL is where the provider publishes its signing keys
+	JWKSURL string
+}
+
+// OIDCIdentity contains the verified claims of an ID token
+type OIDCIdentity struct {
+	Subject       string
+	Email         string
+	EmailVerified bool
+	Name          string
+	// IssuedAt is when the provider issued the token, used to require a recent sign-in
+	IssuedAt time.Time
+}
+
+// oidcKeySet is a cached JWKS
+type oidcKeySet struct {
+	keys      map[string]*rsa.PublicKey
+	fetchedAt time.Time
+}
+
+// OIDCUtil verifies ID tokens issued by OpenID Connect providers
+type OIDCUtil struct {
+	Providers  map[string]OIDCProviderConfig
+	HTTPClient *http.Client
+
+	mu    sync.Mutex
+	cache map[string]*oidcKeySet
+}
+
+// NewOIDCUtil creates a new instance of OIDCUtil
+func NewOIDCUtil(providers map[string]OIDCProviderConfig) *OIDCUtil {
+	return &OIDCUtil{
+		Providers:  providers,
+		HTTPClient: &http.Client{Timeout: 10 * time.Second},
+		cache:      make(map[string]*oidcKeySet),
+	}
+}
+
+// oidcClaims are the ID token claims used for verification
+type oidcClaims struct {
+	Email         string      `json:"email"`
+	EmailVerified interface{} `json:"email_verified"`
+	Name          string      `json:"name"`
+	Nonce         string      `json:"nonce"`
+	jwt.StandardClaims
+}
+
+// VerifyIDToken verifies the signature, issuer, audience, expiry and nonce of an ID token.
+// The nonce is mandatory, so a token obtained for another request cannot be replayed.
+func (o *OIDCUtil) VerifyIDToken(provider, idToken, nonce string) (*OIDCIdentity, error) {
+	config, ok := o.Providers[provider]
+	if !ok || len(config.ClientIDs) == 0 {
+		return nil, ErrOIDCProviderNotConfigured
+	}
+
+	claims := &oidcClaims{}
+	token, err := jwt.ParseWithClaims(idToken, claims, func(token *jwt.Token) (interface{}, error) {
+		if token.Method != jwt.SigningMethodRS256 {
+			return nil, ErrOIDCInvalidToken
+		}
+		kid, _ := token.Header["kid"].(string)
+		return o.publicKey(config.JWKSURL, kid)
+	})
+	if err != nil || !token.Valid {
+		return nil, ErrOIDCInvalidToken
+	}
+
+	if !containsString(config.Issuers, claims.Issuer) || !containsString(config.ClientIDs, claims.Audience) {
+		return nil, ErrOIDCInvalidToken
+	}
+	if claims.Subject == "" || claims.ExpiresAt == 0 {
+		return nil, ErrOIDCInvalidToken
+	}
+	if nonce == "" || claims.Nonce != nonce {
+		return nil, ErrOIDCInvalidToken
+	}
+
+	return &OIDCIdentity{
+		Subject:       claims.Subject,
+		Email:         claims.Email,
+		EmailVerified: isTrueClaim(claims.EmailVerified),
+		Name:          claims.Name,
+		IssuedAt:      time.Unix(claims.IssuedAt, 0),
+	}, nil
+}
+
+// publicKey returns the key with the given ID, refreshing the cached JWKS when needed
+func (o *OIDCUtil) publicKey(jwksURL, kid string) (*rsa.PublicKey, error) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	set := o.cache[jwksURL]
+	if set != nil && time.Since(set.fetchedAt) < OIDCJWKSCacheTTL {
+		if key, ok := set.keys[kid]; ok {
+			return key, nil
+		}
+		// Providers rotate keys, so an unknown ID may only mean the cache is stale
+		if time.Since(set.fetchedAt) < oidcJWKSMinRefresh {
+			return nil, ErrOIDCKeyNotFound
+		}
+	}
+
+	keys, err := o.fetchJWKS(jwksURL)
+	if err != nil {
+		return nil, err
+	}
+	o.cache[jwksURL] = &oidcKeySet{keys: keys, fetchedAt: time.Now()}
+
+	key, ok := keys[kid]
+	if !ok {
+		return nil, ErrOIDCKeyNotFound
+	}
+	return key, nil
+}
+
+// fetchJWKS downloads and parses the RSA keys of a JWKS document
+func (o *OIDCUtil) fetchJWKS(jwksURL string) (map[string]*rsa.PublicKey, error) {
+	resp, err := o.HTTPClient.Get(jwksURL)
+	if err != nil {
+		return nil, fmt.Errorf("fetching JWKS: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("fetching JWKS: status code %d", resp.StatusCode)
+	}
+
+	var document struct {
+		Keys []struct {
+			Kty string `json:"kty"`
+			Kid string `json:"kid"`
+			Use string `json:"use"`
+			N   string `json:"n"`
+			E   string `json:"e"`
+		} `json:"keys"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&document); err != nil {
+		return nil, fmt.Errorf("decoding JWKS: %w", err)
+	}
+
+	keys := make(map[string]*rsa.PublicKey)
+	for _, jwk := range document.Keys {
+		if jwk.Kty != "RSA" || (jwk.Use != "" && jwk.Use != "sig") {
+			continue
+		}
+		n, err := base64.RawURLEncoding.DecodeString(jwk.N)
+		if err != nil {
+			continue
+		}
+		e, err := base64.RawURLEncoding.DecodeString(jwk.E)
+		if err != nil || len(e) == 0 || len(e) > 4 {
+			continue
+		}
+		keys[jwk.Kid] = &rsa.PublicKey{
+			N: new(big.Int).SetBytes(n),
+			E: int(new(big.Int).SetBytes(e).Int64()),
+		}
+	}
+
+	return keys, nil
+}
+
+// isTrueClaim reads a boolean claim that some providers (e.g. Apple) send as a string
+func isTrueClaim(value interface{}) bool {
+	switch v := value.(type) {
+	case bool:
+		return v
+	case string:
+		return v == "true"
+	default:
+		return false
+	}
+}
+
+// containsString reports whether value is in list
+func containsString(list []string, value string) bool {
+	for _, item := range list {
+		if item == value {
+			return true
+		}
+	}
+	return false
+}
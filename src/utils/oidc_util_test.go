@@ -0,0 +1,284 @@
+package utils
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/dgrijalva/jwt-go"
+)
+
+const (
+	testOIDCIssuer   = "https://accounts.example.com"
+	testOIDCClientID = "aurora-web"
+)
+
+// testOIDCProvider serves a JWKS with httptest and signs ID tokens with its keys
+type testOIDCProvider struct {
+	server *httptest.Server
+
+	mu       sync.Mutex
+	keys     map[string]*rsa.PrivateKey
+	requests int
+}
+
+func newTestOIDCProvider(t *testing.T) *testOIDCProvider {
+	t.Helper()
+
+	provider := &testOIDCProvider{keys: make(map[string]*rsa.PrivateKey)}
+	provider.addKey(t, "key-1")
+
+	provider.server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		provider.mu.Lock()
+		defer provider.mu.Unlock()
+		provider.requests++
+
+		keys := []map[string]string{}
+		for kid, key := range provider.keys {
+			keys = append(keys, map[string]string{
+				"kty": "RSA",
+				"kid": kid,
+				"use": "sig",
+				"alg": "RS256",
+				"n":   base64.RawURLEncoding.EncodeToString(key.PublicKey.N.Bytes()),
+				"e":   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(key.PublicKey.E)).Bytes()),
+			})
+		}
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string]interface{}{"keys": keys})
+	}))
+	t.Cleanup(provider.server.Close)
+
+	return provider
+}
+
+// addKey generates a key and publishes it in the JWKS
+func (p *testOIDCProvider) addKey(t *testing.T, kid string) *rsa.PrivateKey {
+	t.Helper()
+
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	p.mu.Lock()
+	p.keys[kid] = key
+	p.mu.Unlock()
+	return key
+}
+
+// key returns a published key
+func (p *testOIDCProvider) key(kid string) *rsa.PrivateKey {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.keys[kid]
+}
+
+// requestCount returns how many times the JWKS was fetched
+func (p *testOIDCProvider) requestCount() int {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.requests
+}
+
+// util returns an OIDCUtil configured for the provider
+func (p *testOIDCProvider) util() *OIDCUtil {
+	return NewOIDCUtil(map[string]OIDCProviderConfig{
+		"test": {
+			Issuers:   []string{testOIDCIssuer},
+			ClientIDs: []string{testOIDCClientID},
+			JWKSURL:   p.server.URL,
+		},
+	})
+}
+
+// validIDTokenClaims returns the claims of an ID token that passes verification
+func validIDTokenClaims() jwt.MapClaims {
+	now := time.Now()
+	return jwt.MapClaims{
+		"iss":            testOIDCIssuer,
+		"aud":            testOIDCClientID,
+		"sub":            "provider-subject",
+		"iat":            now.Unix(),
+		"exp":            now.Add(time.Hour).Unix(),
+		"nonce":          "nonce-123",
+		"email":          "client@example.com",
+		"email_verified": true,
+		"name":           "Client",
+	}
+}
+
+// signIDToken signs the claims with RS256 and the given kid
+func signIDToken(t *testing.T, key *rsa.PrivateKey, kid string, claims jwt.MapClaims) string {
+	t.Helper()
+
+	token := jwt.NewWithClaims(jwt.SigningMethodRS256, claims)
+	token.Header["kid"] = kid
+	signed, err := token.SignedString(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return signed
+}
+
+func TestVerifyIDToken(t *testing.T) {
+	provider := newTestOIDCProvider(t)
+	o := provider.util()
+	strangerKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name    string
+		claims  func(claims jwt.MapClaims)
+		key     *rsa.PrivateKey
+		kid     string
+		nonce   string
+		wantErr error
+	}{
+		{name: "valid", nonce: "nonce-123"},
+		{name: "nonce not sent", nonce: "", wantErr: ErrOIDCInvalidToken},
+		{name: "no nonce on either side", claims: func(c jwt.MapClaims) { delete(c, "nonce") }, nonce: "", wantErr: ErrOIDCInvalidToken},
+		{name: "email_verified as string", claims: func(c jwt.MapClaims) { c["email_verified"] = "true" }, nonce: "nonce-123"},
+		{name: "wrong issuer", claims: func(c jwt.MapClaims) { c["iss"] = "https://evil.example.com" }, nonce: "nonce-123", wantErr: ErrOIDCInvalidToken},
+		{name: "missing issuer", claims: func(c jwt.MapClaims) { delete(c, "iss") }, nonce: "nonce-123", wantErr: ErrOIDCInvalidToken},
+		{name: "wrong audience", claims: func(c jwt.MapClaims) { c["aud"] = "another-client" }, nonce: "nonce-123", wantErr: ErrOIDCInvalidToken},
+		{name: "missing audience", claims: func(c jwt.MapClaims) { delete(c, "aud") }, nonce: "nonce-123", wantErr: ErrOIDCInvalidToken},
+		{name: "expired", claims: func(c jwt.MapClaims) { c["exp"] = time.Now().Add(-time.Minute).Unix() }, nonce: "nonce-123", wantErr: ErrOIDCInvalidToken},
+		{name: "missing expiry", claims: func(c jwt.MapClaims) { delete(c, "exp") }, nonce: "nonce-123", wantErr: ErrOIDCInvalidToken},
+		{name: "issued in the future", claims: func(c jwt.MapClaims) { c["iat"] = time.Now().Add(time.Hour).Unix() }, nonce: "nonce-123", wantErr: ErrOIDCInvalidToken},
+		{name: "missing subject", claims: func(c jwt.MapClaims) { delete(c, "sub") }, nonce: "nonce-123", wantErr: ErrOIDCInvalidToken},
+		{name: "wrong nonce", nonce: "another-nonce", wantErr: ErrOIDCInvalidToken},
+		{name: "missing nonce", claims: func(c jwt.MapClaims) { delete(c, "nonce") }, nonce: "nonce-123", wantErr: ErrOIDCInvalidToken},
+		{name: "unknown kid", kid: "key-unknown", nonce: "nonce-123", wantErr: ErrOIDCInvalidToken},
+		{name: "kid of another key", key: strangerKey, nonce: "nonce-123", wantErr: ErrOIDCInvalidToken},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			claims := validIDTokenClaims()
+			if tt.claims != nil {
+				tt.claims(claims)
+			}
+			key, kid := tt.key, tt.kid
+			if key == nil {
+				key = provider.key("key-1")
+			}
+			if kid == "" {
+				kid = "key-1"
+			}
+
+			identity, err := o.VerifyIDToken("test", signIDToken(t, key, kid, claims), tt.nonce)
+			if err != tt.wantErr {
+				t.Fatalf("VerifyIDToken = %v, want %v", err, tt.wantErr)
+			}
+			if err != nil {
+				return
+			}
+
+			if identity.Subject != "provider-subject" || identity.Email != "client@example.com" || !identity.EmailVerified || identity.Name != "Client" {
+				t.Fatalf("unexpected identity %+v", identity)
+			}
+		})
+	}
+}
+
+func TestVerifyIDTokenRejectsOtherAlgorithms(t *testing.T) {
+	provider := newTestOIDCProvider(t)
+	o := provider.util()
+
+	// HS256 signed with the public modulus, the classic algorithm confusion attack
+	hmacToken := jwt.NewWithClaims(jwt.SigningMethodHS256, validIDTokenClaims())
+	hmacToken.Header["kid"] = "key-1"
+	signed, err := hmacToken.SignedString(provider.key("key-1").PublicKey.N.Bytes())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := o.VerifyIDToken("test", signed, "nonce-123"); err != ErrOIDCInvalidToken {
+		t.Fatalf("HS256 token: VerifyIDToken = %v, want ErrOIDCInvalidToken", err)
+	}
+
+	noneToken := jwt.NewWithClaims(jwt.SigningMethodNone, validIDTokenClaims())
+	noneToken.Header["kid"] = "key-1"
+	signed, err = noneToken.SignedString(jwt.UnsafeAllowNoneSignatureType)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := o.VerifyIDToken("test", signed, "nonce-123"); err != ErrOIDCInvalidToken {
+		t.Fatalf("unsigned token: VerifyIDToken = %v, want ErrOIDCInvalidToken", err)
+	}
+}
+
+func TestVerifyIDTokenUnknownProvider(t *testing.T) {
+	provider := newTestOIDCProvider(t)
+	o := provider.util()
+	token := signIDToken(t, provider.key("key-1"), "key-1", validIDTokenClaims())
+
+	if _, err := o.VerifyIDToken("other", token, "nonce-123"); err != ErrOIDCProviderNotConfigured {
+		t.Fatalf("VerifyIDToken = %v, want ErrOIDCProviderNotConfigured", err)
+	}
+
+	o.Providers["test"] = OIDCProviderConfig{Issuers: []string{testOIDCIssuer}, JWKSURL: provider.server.URL}
+	if _, err := o.VerifyIDToken("test", token, "nonce-123"); err != ErrOIDCProviderNotConfigured {
+		t.Fatalf("provider without client IDs: VerifyIDToken = %v, want ErrOIDCProviderNotConfigured", err)
+	}
+}
+
+func TestVerifyIDTokenKeyRotation(t *testing.T) {
+	provider := newTestOIDCProvider(t)
+	o := provider.util()
+
+	if _, err := o.VerifyIDToken("test", signIDToken(t, provider.key("key-1"), "key-1", validIDTokenClaims()), "nonce-123"); err != nil {
+		t.Fatalf("VerifyIDToken: %v", err)
+	}
+	if provider.requestCount() != 1 {
+		t.Fatalf("JWKS fetched %d times, want 1", provider.requestCount())
+	}
+
+	// Cached keys are reused
+	if _, err := o.VerifyIDToken("test", signIDToken(t, provider.key("key-1"), "key-1", validIDTokenClaims()), "nonce-123"); err != nil {
+		t.Fatalf("VerifyIDToken: %v", err)
+	}
+	if provider.requestCount() != 1 {
+		t.Fatalf("JWKS fetched %d times, want 1", provider.requestCount())
+	}
+
+	// A key published after the last fetch is not looked up again right away
+	rotated := signIDToken(t, provider.addKey(t, "key-2"), "key-2", validIDTokenClaims())
+	if _, err := o.VerifyIDToken("test", rotated, "nonce-123"); err != ErrOIDCInvalidToken {
+		t.Fatalf("VerifyIDToken = %v, want ErrOIDCInvalidToken", err)
+	}
+	if provider.requestCount() != 1 {
+		t.Fatalf("JWKS fetched %d times, want 1", provider.requestCount())
+	}
+
+	// Once the minimum refresh interval passes, an unknown kid refreshes the JWKS
+	o.mu.Lock()
+	o.cache[provider.server.URL].fetchedAt = time.Now().Add(-2 * oidcJWKSMinRefresh)
+	o.mu.Unlock()
+
+	if _, err := o.VerifyIDToken("test", rotated, "nonce-123"); err != nil {
+		t.Fatalf("VerifyIDToken after rotation: %v", err)
+	}
+	if provider.requestCount() != 2 {
+		t.Fatalf("JWKS fetched %d times, want 2", provider.requestCount())
+	}
+}
+
+func TestVerifyIDTokenJWKSUnavailable(t *testing.T) {
+	provider := newTestOIDCProvider(t)
+	token := signIDToken(t, provider.key("key-1"), "key-1", validIDTokenClaims())
+	o := provider.util()
+	provider.server.Close()
+
+	if _, err := o.VerifyIDToken("test", token, "nonce-123"); err != ErrOIDCInvalidToken {
+		t.Fatalf("VerifyIDToken = %v, want ErrOIDCInvalidToken", err)
+	}
+}
@@ -0,0 +1,211 @@
+package utils
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strings"
+
+	"golang.org/x/crypto/argon2"
+	"golang.org/x/crypto/bcrypt"
+)
+
+var (
+	// ErrPasswordMismatch indicates that the password does not match the hash
+	ErrPasswordMismatch = errors.New("password does not match")
+	// ErrUnknownHashFormat indicates that no configured hasher recognizes the stored hash
+	ErrUnknownHashFormat = errors.New("unknown password hash format")
+)
+
+// Password hashing algorithms
+const (
+	PasswordHasherArgon2id = "argon2id"
+	PasswordHasherBcrypt   = "bcrypt"
+)
+
+// bcryptMaxPasswordLength is the number of bytes bcrypt takes into account; longer passwords are rejected
+const bcryptMaxPasswordLength = 72
+
+// PasswordHasher hashes and verifies passwords with a single algorithm
+type PasswordHasher interface {
+	// Hash returns the encoded hash of the password, including algorithm and parameters
+	Hash(password string) (string, error)
+	// Verify checks the password against a hash produced by this hasher
+	Verify(hash, password string) error
+	// Recognizes reports whether the hash was produced by this algorithm
+	Recognizes(hash string) bool
+	// NeedsRehash reports whether a hash of this algorithm uses outdated parameters
+	NeedsRehash(hash string) bool
+	// MaxPasswordLength is the longest password, in bytes, the algorithm hashes without truncating
+	MaxPasswordLength() int
+}
+
+// Argon2idHasher hashes passwords with argon2id, encoding them in the PHC string format:
+// $argon2id$v=19$m=<memory>,t=<iterations>,p=<parallelism>$<salt>$<hash>
+type Argon2idHasher struct {
+	// Memory is the memory cost in KiB
+	Memory uint32
+	// Iterations is the time cost
+	Iterations uint32
+	// Parallelism is the number of lanes
+	Parallelism uint8
+	SaltLength  uint32
+	KeyLength   uint32
+}
+
+// NewArgon2idHasher creates an argon2id hasher with the parameters recommended by OWASP (19 MiB, 2 iterations)
+func NewArgon2idHasher() *Argon2idHasher {
+	return &Argon2idHasher{
+		Memory:      19 * 1024,
+		Iterations:  2,
+		Parallelism: 1,
+		SaltLength:  16,
+		KeyLength:   32,
+	}
+}
+
+// argon2idParams holds the parameters decoded from a PHC string
+type argon2idParams struct {
+	memory      uint32
+	iterations  uint32
+	parallelism uint8
+	salt        []byte
+	key         []byte
+}
+
+// Hash generates an argon2id hash for the password
+func (h *Argon2idHasher) Hash(password string) (string, error) {
+	salt := make([]byte, h.SaltLength)
+	if _, err := rand.Read(salt); err != nil {
+		return "", err
+	}
+
+	key := argon2.IDKey([]byte(password), salt, h.Iterations, h.Memory, h.Parallelism, h.KeyLength)
+
+	return fmt.Sprintf("$argon2id$v=%d$m=%d,t=%d,p=%d$%s$%s",
+		argon2.Version, h.Memory, h.Iterations, h.Parallelism,
+		base64.RawStdEncoding.EncodeToString(salt),
+		base64.RawStdEncoding.EncodeToString(key),
+	), nil
+}
+
+// Verify checks the password against an argon2id hash, using the parameters stored in the hash
+func (h *Argon2idHasher) Verify(hash, password string) error {
+	params, err := decodeArgon2idHash(hash)
+	if err != nil {
+		return err
+	}
+
+	key := argon2.IDKey([]byte(password), params.salt, params.iterations, params.memory, params.parallelism, uint32(len(params.key)))
+	if subtle.ConstantTimeCompare(key, params.key) != 1 {
+		return ErrPasswordMismatch
+	}
+
+	return nil
+}
+
+// Recognizes reports whether the hash is an argon2id PHC string
+func (h *Argon2idHasher) Recognizes(hash string) bool {
+	return strings.HasPrefix(hash, "$argon2id$")
+}
+
+// NeedsRehash reports whether the hash was generated with parameters different from the current ones
+func (h *Argon2idHasher) NeedsRehash(hash string) bool {
+	params, err := decodeArgon2idHash(hash)
+	if err != nil {
+		return true
+	}
+
+	return params.memory != h.Memory ||
+		params.iterations != h.Iterations ||
+		params.parallelism != h.Parallelism ||
+		uint32(len(params.salt)) != h.SaltLength ||
+		uint32(len(params.key)) != h.KeyLength
+}
+
+// MaxPasswordLength returns the general limit, since argon2id takes the whole password into account
+func (h *Argon2idHasher) MaxPasswordLength() int {
+	return MaxPasswordLength
+}
+
+// decodeArgon2idHash parses an argon2id PHC string
+func decodeArgon2idHash(hash string) (*argon2idParams, error) {
+	parts := strings.Split(hash, "$")
+	if len(parts) != 6 || parts[1] != "argon2id" {
+		return nil, ErrUnknownHashFormat
+	}
+
+	var version int
+	if _, err := fmt.Sscanf(parts[2], "v=%d", &version); err != nil || version != argon2.Version {
+		return nil, ErrUnknownHashFormat
+	}
+
+	params := &argon2idParams{}
+	if _, err := fmt.Sscanf(parts[3], "m=%d,t=%d,p=%d", &params.memory, &params.iterations, &params.parallelism); err != nil {
+		return nil, ErrUnknownHashFormat
+	}
+
+	var err error
+	if params.salt, err = base64.RawStdEncoding.DecodeString(parts[4]); err != nil {
+		return nil, ErrUnknownHashFormat
+	}
+	if params.key, err = base64.RawStdEncoding.DecodeString(parts[5]); err != nil || len(params.key) == 0 {
+		return nil, ErrUnknownHashFormat
+	}
+
+	return params, nil
+}
+
+// BcryptHasher hashes passwords with bcrypt. It is kept to verify legacy hashes.
+type BcryptHasher struct {
+	Cost int
+}
+
+// NewBcryptHasher creates a bcrypt hasher with the given cost
+func NewBcryptHasher(cost int) *BcryptHasher {
+	if cost <= 0 {
+		cost = DefaultBcryptCost
+	}
+	return &BcryptHasher{Cost: cost}
+}
+
+// Hash generates a bcrypt hash for the password.
+// bcrypt ignores everything after 72 bytes, so longer passwords are rejected instead of truncated.
+func (h *BcryptHasher) Hash(password string) (string, error) {
+	if len(password) > bcryptMaxPasswordLength {
+		return "", fmt.Errorf("%w: bcrypt accepts at most %d bytes", ErrPasswordTooLong, bcryptMaxPasswordLength)
+	}
+
+	hash, err := bcrypt.GenerateFromPassword([]byte(password), h.Cost)
+	if err != nil {
+		return "", err
+	}
+
+	return string(hash), nil
+}
+
+// Verify checks the password against a bcrypt hash
+func (h *BcryptHasher) Verify(hash, password string) error {
+	if err := bcrypt.CompareHashAndPassword([]byte(hash), []byte(password)); err != nil {
+		return ErrPasswordMismatch
+	}
+	return nil
+}
+
+// Recognizes reports whether the hash is a bcrypt hash
+func (h *BcryptHasher) Recognizes(hash string) bool {
+	return strings.HasPrefix(hash, "$2a$") || strings.HasPrefix(hash, "$2b$") || strings.HasPrefix(hash, "$2y$")
+}
+
+// NeedsRehash reports whether the hash was generated with a different cost
+func (h *BcryptHasher) NeedsRehash(hash string) bool {
+	cost, err := bcrypt.Cost([]byte(hash))
+	return err != nil || cost != h.Cost
+}
+
+// MaxPasswordLength returns the 72 bytes bcrypt takes into account
+func (h *BcryptHasher) MaxPasswordLength() int {
+	return bcryptMaxPasswordLength
+}
@@ -0,0 +1,223 @@
+package utils
+
+import (
+	"bufio"
+	"errors"
+	"os"
+	"strings"
+	"unicode"
+)
+
+var (
+	// ErrPasswordRepeatedChars indicates that the password repeats the same character too many times in a row
+	ErrPasswordRepeatedChars = errors.New("password repeats the same character too many times")
+	// ErrPasswordCommon indicates that the password is a common word or password
+	ErrPasswordCommon = errors.New("password is too common")
+	// ErrPasswordContainsUserInfo indicates that the password contains the user's name, email or phone
+	ErrPasswordContainsUserInfo = errors.New("password must not contain personal information")
+	// ErrPasswordBreached indicates that the password appears in a known data breach
+	ErrPasswordBreached = errors.New("password appears in a known data breach")
+)
+
+// minUserInfoLength is the shortest piece of user information checked against the password
+const minUserInfoLength = 4
+
+// defaultPasswordDictionary holds common passwords rejected even without a dictionary file
+var defaultPasswordDictionary = []string{
+	"password", "senha", "123456", "12345678", "123456789", "qwerty", "qwertyuiop",
+	"abc123", "admin", "administrator", "letmein", "welcome", "iloveyou", "monkey",
+	"dragon", "football", "futebol", "baseball", "master", "sunshine", "princess",
+	"changeme", "passw0rd", "brasil", "mudar", "aurora",
+}
+
+// leetReplacer undoes common character substitutions before the dictionary check
+var leetReplacer = strings.NewReplacer("@", "a", "4", "a", "0", "o", "1", "i", "!", "i", "3", "e", "$", "s", "5", "s", "7", "t")
+
+// PasswordPolicy defines the rules a new password must follow
+type PasswordPolicy struct {
+	// MinLength is the minimum password length
+	MinLength int
+	// MinCharacterClasses is how many of uppercase, lowercase, numbers and special characters are required
+	MinCharacterClasses int
+	// MaxRepeatedChars is the longest run of the same character allowed (0 disables the check)
+	MaxRepeatedChars int
+	// Dictionary holds the lowercase common words and passwords that cannot be used
+	Dictionary map[string]struct{}
+	// CheckUserInfo rejects passwords containing the user's name, email or phone
+	CheckUserInfo bool
+	// Breached is the list of known breached passwords (nil disables the check)
+	Breached *BreachedPasswordList
+}
+
+// DefaultPasswordPolicy returns the default password policy, without the breached password check
+func DefaultPasswordPolicy() PasswordPolicy {
+	return PasswordPolicy{
+		MinLength:           MinPasswordLength,
+		MinCharacterClasses: 3,
+		MaxRepeatedChars:    3,
+		Dictionary:          NewPasswordDictionary(defaultPasswordDictionary),
+		CheckUserInfo:       true,
+	}
+}
+
+// NewPasswordDictionary builds a dictionary from a list of words
+func NewPasswordDictionary(words []string) map[string]struct{} {
+	dictionary := make(map[string]struct{}, len(words))
+	for _, word := range words {
+		if word = strings.ToLower(strings.TrimSpace(word)); word != "" {
+			dictionary[word] = struct{}{}
+		}
+	}
+	return dictionary
+}
+
+// LoadPasswordDictionary loads a dictionary file with one word per line and merges it with the default words
+func LoadPasswordDictionary(path string) (map[string]struct{}, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	words := append([]string{}, defaultPasswordDictionary...)
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		words = append(words, scanner.Text())
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	return NewPasswordDictionary(words), nil
+}
+
+// Validate checks the password against the policy.
+// userInputs are the user's name, email and phone, rejected as part of the password when CheckUserInfo is set.
+// Errors reading the breached password list are returned as they are.
+func (p PasswordPolicy) Validate(password string, userInputs ...string) error {
+	if len(password) < p.MinLength {
+		return ErrPasswordTooShort
+	}
+	if len(password) > MaxPasswordLength {
+		return ErrPasswordTooLong
+	}
+
+	if characterClasses(password) < p.MinCharacterClasses {
+		return ErrPasswordTooWeak
+	}
+
+	if p.MaxRepeatedChars > 0 && longestRun(password) > p.MaxRepeatedChars {
+		return ErrPasswordRepeatedChars
+	}
+
+	if p.isCommon(password) {
+		return ErrPasswordCommon
+	}
+
+	if p.CheckUserInfo && containsUserInfo(password, userInputs) {
+		return ErrPasswordContainsUserInfo
+	}
+
+	if p.Breached != nil {
+		breached, err := p.Breached.Contains(password)
+		if err != nil {
+			return err
+		}
+		if breached {
+			return ErrPasswordBreached
+		}
+	}
+
+	return nil
+}
+
+// characterClasses counts how many of uppercase, lowercase, numbers and special characters the password uses
+func characterClasses(password string) int {
+	var hasUpper, hasLower, hasNumber, hasSpecial bool
+
+	for _, char := range password {
+		switch {
+		case unicode.IsUpper(char):
+			hasUpper = true
+		case unicode.IsLower(char):
+			hasLower = true
+		case unicode.IsDigit(char):
+			hasNumber = true
+		case !unicode.IsSpace(char):
+			hasSpecial = true
+		}
+	}
+
+	classes := 0
+	for _, has := range []bool{hasUpper, hasLower, hasNumber, hasSpecial} {
+		if has {
+			classes++
+		}
+	}
+	return classes
+}
+
+// longestRun returns the longest sequence of the same character in the password
+func longestRun(password string) int {
+	longest, current := 0, 0
+	var previous rune
+	for i, char := range password {
+		if i > 0 && char == previous {
+			current++
+		} else {
+			current = 1
+		}
+		if current > longest {
+			longest = current
+		}
+		previous = char
+	}
+	return longest
+}
+
+// isCommon reports whether the password, ignoring case, common substitutions and
+// leading or trailing digits and symbols, is a dictionary word
+func (p PasswordPolicy) isCommon(password string) bool {
+	if len(p.Dictionary) == 0 {
+		return false
+	}
+
+	lower := strings.ToLower(password)
+	if _, found := p.Dictionary[lower]; found {
+		return true
+	}
+
+	base := strings.TrimFunc(lower, func(char rune) bool {
+		return !unicode.IsLetter(char)
+	})
+	for _, candidate := range []string{base, leetReplacer.Replace(lower), leetReplacer.Replace(base)} {
+		if _, found := p.Dictionary[candidate]; found {
+			return true
+		}
+	}
+
+	return false
+}
+
+// containsUserInfo reports whether the password contains a meaningful piece of the user's information
+func containsUserInfo(password string, userInputs []string) bool {
+	lower := strings.ToLower(password)
+
+	for _, input := range userInputs {
+		parts := strings.FieldsFunc(strings.ToLower(input), func(char rune) bool {
+			return !unicode.IsLetter(char) && !unicode.IsDigit(char)
+		})
+
+		for _, part := range parts {
+			// The local part of a phone number is enough to identify it
+			if len(part) > 8 && strings.Trim(part, "0123456789") == "" {
+				part = part[len(part)-8:]
+			}
+			if len(part) >= minUserInfoLength && strings.Contains(lower, part) {
+				return true
+			}
+		}
+	}
+
+	return false
+}
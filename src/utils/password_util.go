@@ -1,14 +1,15 @@
 package utils
 
 import (
+	"crypto/hmac"
 	"crypto/rand"
+	"crypto/sha256"
 	"encoding/base64"
+	"encoding/hex"
 	"errors"
 	"fmt"
 	"math/big"
 	"strings"
-
-	"golang.org/x/crypto/bcrypt"
 )
 
 // Definition of constants for passwords
@@ -17,8 +18,8 @@ const (
 	DefaultBcryptCost = 12
 	// MinPasswordLength is the minimum password length
 	MinPasswordLength = 8
-	// MaxPasswordLength is the maximum password length
-	MaxPasswordLength = 72
+	// MaxPasswordLength is the maximum password length, which bounds the hashing cost
+	MaxPasswordLength = 128
 	// NumericCodeLength is the default length for numeric codes (SMS, WhatsApp)
 	NumericCodeLength = 6
 )
@@ -34,16 +35,26 @@ var (
 
 // PasswordUtil provides functions for working with passwords
 type PasswordUtil struct {
-	BcryptCost int
+	// Hasher hashes new passwords; hashes from other algorithms are upgraded on login
+	Hasher PasswordHasher
+	// LegacyHashers verify hashes produced by previously used algorithms
+	LegacyHashers []PasswordHasher
+	// TokenHashKey is the secret key used to hash one-time tokens and codes at rest
+	TokenHashKey []byte
+	// Policy holds the rules new passwords must follow
+	Policy PasswordPolicy
 }
 
 // NewPasswordUtil creates a new instance of PasswordUtil
-func NewPasswordUtil(bcryptCost int) *PasswordUtil {
-	if bcryptCost <= 0 {
-		bcryptCost = DefaultBcryptCost
+func NewPasswordUtil(hasher PasswordHasher, legacyHashers []PasswordHasher, tokenHashKey []byte, policy PasswordPolicy) *PasswordUtil {
+	if hasher == nil {
+		hasher = NewArgon2idHasher()
 	}
 	return &PasswordUtil{
-		BcryptCost: bcryptCost,
+		Hasher:        hasher,
+		LegacyHashers: legacyHashers,
+		TokenHashKey:  tokenHashKey,
+		Policy:        policy,
 	}
 }
 
@@ -52,78 +63,69 @@ func (p *PasswordUtil) ValidatePasswordLength(password string) error {
 	if len(password) < MinPasswordLength {
 		return ErrPasswordTooShort
 	}
-	if len(password) > MaxPasswordLength {
+	if len(password) > p.maxPasswordLength() {
 		return ErrPasswordTooLong
 	}
 	return nil
 }
 
-// HashPassword generates a bcrypt hash for the password
+// maxPasswordLength is the general limit or the one of the current hasher, whichever is lower
+func (p *PasswordUtil) maxPasswordLength() int {
+	if limit := p.Hasher.MaxPasswordLength(); limit < MaxPasswordLength {
+		return limit
+	}
+	return MaxPasswordLength
+}
+
+// HashPassword hashes the password with the current hasher
 func (p *PasswordUtil) HashPassword(password string) (string, error) {
 	// We validate the password length
 	if err := p.ValidatePasswordLength(password); err != nil {
 		return "", err
 	}
 
-	hash, err := bcrypt.GenerateFromPassword([]byte(password), p.BcryptCost)
-	if err != nil {
-		return "", err
-	}
-
-	return string(hash), nil
+	return p.Hasher.Hash(password)
 }
 
-// VerifyPassword checks if the password matches the hash
+// VerifyPassword checks if the password matches the hash, whichever configured algorithm produced it
 func (p *PasswordUtil) VerifyPassword(hashedPassword, password string) error {
-	return bcrypt.CompareHashAndPassword([]byte(hashedPassword), []byte(password))
+	hasher := p.hasherFor(hashedPassword)
+	if hasher == nil {
+		return ErrUnknownHashFormat
+	}
+	return hasher.Verify(hashedPassword, password)
 }
 
-// ValidatePasswordStrength checks if the password is strong enough
-func (p *PasswordUtil) ValidatePasswordStrength(password string) error {
-	if err := p.ValidatePasswordLength(password); err != nil {
-		return err
-	}
-
-	var (
-		hasUpper   bool
-		hasLower   bool
-		hasNumber  bool
-		hasSpecial bool
-	)
-
-	for _, char := range password {
-		switch {
-		case 'A' <= char && char <= 'Z':
-			hasUpper = true
-		case 'a' <= char && char <= 'z':
-			hasLower = true
-		case '0' <= char && char <= '9':
-			hasNumber = true
-		case strings.ContainsRune("!@#$%^&*()-_[]{}|;:,.<>?/", char):
-			hasSpecial = true
-		}
+// NeedsRehash reports whether the hash should be replaced by one from the current hasher,
+// because it uses another algorithm or outdated parameters
+func (p *PasswordUtil) NeedsRehash(hashedPassword string) bool {
+	if !p.Hasher.Recognizes(hashedPassword) {
+		return true
 	}
+	return p.Hasher.NeedsRehash(hashedPassword)
+}
 
-	// We require at least 3 of the 4 character types
-	score := 0
-	if hasUpper {
-		score++
-	}
-	if hasLower {
-		score++
+// hasherFor finds the hasher that produced the hash
+func (p *PasswordUtil) hasherFor(hashedPassword string) PasswordHasher {
+	if p.Hasher.Recognizes(hashedPassword) {
+		return p.Hasher
 	}
-	if hasNumber {
-		score++
-	}
-	if hasSpecial {
-		score++
+	for _, hasher := range p.LegacyHashers {
+		if hasher.Recognizes(hashedPassword) {
+			return hasher
+		}
 	}
+	return nil
+}
 
-	if score < 3 {
-		return ErrPasswordTooWeak
+// ValidatePasswordStrength checks the password against the configured policy.
+// userInputs are the user's name, email and phone, which the password must not contain.
+func (p *PasswordUtil) ValidatePasswordStrength(password string, userInputs ...string) error {
+	// The password must also fit in the algorithm that hashes it
+	if len(password) > p.maxPasswordLength() {
+		return ErrPasswordTooLong
 	}
-
-	return nil
+	return p.Policy.Validate(password, userInputs...)
 }
 
 // GenerateRandomToken generates a random token
@@ -171,3 +173,17 @@ func (p *PasswordUtil) GenerateNumericCode(length int) (string, error) {
 
 	return sb.String(), nil
 }
+
+// HashToken returns the SHA-256 hex digest of a token, used to store tokens at rest
+func (p *PasswordUtil) HashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+// HashOneTimeToken returns the keyed HMAC-SHA256 hex digest of a one-time token or code.
+// Unlike HashToken, the key keeps short numeric codes from being brute-forced out of a leaked database.
+func (p *PasswordUtil) HashOneTimeToken(token string) string {
+	mac := hmac.New(sha256.New, p.TokenHashKey)
+	mac.Write([]byte(token))
+	return hex.EncodeToString(mac.Sum(nil))
+}
@@ -0,0 +1,37 @@
+package utils
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestPasswordLengthFollowsHasherLimit(t *testing.T) {
+	policy := PasswordPolicy{MinLength: MinPasswordLength}
+
+	tests := []struct {
+		name    string
+		hasher  PasswordHasher
+		length  int
+		wantErr error
+	}{
+		{"argon2id at the general limit", NewArgon2idHasher(), MaxPasswordLength, nil},
+		{"argon2id over the general limit", NewArgon2idHasher(), MaxPasswordLength + 1, ErrPasswordTooLong},
+		{"bcrypt at its limit", NewBcryptHasher(4), bcryptMaxPasswordLength, nil},
+		{"bcrypt over its limit", NewBcryptHasher(4), bcryptMaxPasswordLength + 1, ErrPasswordTooLong},
+		{"bcrypt at the general limit", NewBcryptHasher(4), MaxPasswordLength, ErrPasswordTooLong},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := NewPasswordUtil(tt.hasher, nil, []byte("token-hash-key"), policy)
+			password := strings.Repeat("a", tt.length)
+
+			if err := p.ValidatePasswordStrength(password); err != tt.wantErr {
+				t.Fatalf("ValidatePasswordStrength = %v, want %v", err, tt.wantErr)
+			}
+			if _, err := p.HashPassword(password); err != tt.wantErr {
+				t.Fatalf("HashPassword = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+}
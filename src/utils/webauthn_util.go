@@ -0,0 +1,452 @@
+package utils
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/subtle"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"math/big"
+	"strings"
+	"time"
+)
+
+// WebAuthn constants
+const (
+	// DefaultWebAuthnTimeout is how long a ceremony challenge remains valid
+	DefaultWebAuthnTimeout = 5 * time.Minute
+	// WebAuthnChallengeSize is the size in bytes of generated challenges
+	WebAuthnChallengeSize = 32
+
+	// COSE algorithm identifiers supported for credentials
+	COSEAlgES256 = -7
+	COSEAlgEdDSA = -8
+	COSEAlgRS256 = -257
+
+	// Authenticator data flags
+	webAuthnFlagUserPresent  = 0x01
+	webAuthnFlagUserVerified = 0x04
+	webAuthnFlagAttestedData = 0x40
+
+	webAuthnTypeCreate = "webauthn.create"
+	webAuthnTypeGet    = "webauthn.get"
+)
+
+var (
+	// ErrWebAuthnInvalidClientData indicates a clientDataJSON with wrong type, challenge or origin
+	ErrWebAuthnInvalidClientData = errors.New("invalid WebAuthn client data")
+	// ErrWebAuthnInvalidAuthData indicates malformed authenticator data or a wrong RP ID
+	ErrWebAuthnInvalidAuthData = errors.New("invalid WebAuthn authenticator data")
+	// ErrWebAuthnUserNotPresent indicates that the user presence flag was not set
+	ErrWebAuthnUserNotPresent = errors.New("WebAuthn user presence not confirmed")
+	// ErrWebAuthnUserNotVerified indicates that user verification was required but not performed
+	ErrWebAuthnUserNotVerified = errors.New("WebAuthn user verification not performed")
+	// ErrWebAuthnUnsupportedAttestation indicates an attestation format that is not supported
+	ErrWebAuthnUnsupportedAttestation = errors.New("unsupported WebAuthn attestation format")
+	// ErrWebAuthnInvalidAttestation indicates an attestation statement that does not verify
+	ErrWebAuthnInvalidAttestation = errors.New("invalid WebAuthn attestation")
+	// ErrWebAuthnUnsupportedKey indicates a COSE key type or algorithm that is not supported
+	ErrWebAuthnUnsupportedKey = errors.New("unsupported WebAuthn public key")
+	// ErrWebAuthnInvalidSignature indicates an assertion signature that does not verify
+	ErrWebAuthnInvalidSignature = errors.New("invalid WebAuthn signature")
+	// ErrWebAuthnSignCount indicates a signature counter that did not increase (possible cloned authenticator)
+	ErrWebAuthnSignCount = errors.New("WebAuthn signature counter did not increase")
+	// ErrWebAuthnUserHandle indicates an assertion whose user handle is not the owner of the credential
+	ErrWebAuthnUserHandle = errors.New("WebAuthn user handle does not match the credential owner")
+)
+
+// WebAuthnConfig contains the relying party settings
+type WebAuthnConfig struct {
+	RPID                    string
+	RPName                  string
+	RPOrigins               []string
+	Timeout                 time.Duration
+	RequireUserVerification bool
+}
+
+// WebAuthnUtil verifies WebAuthn registration and assertion ceremonies.
+// It holds no state, so ceremonies can be verified against recorded
+// software authenticator fixtures.
+type WebAuthnUtil struct {
+	Config WebAuthnConfig
+}
+
+// NewWebAuthnUtil creates a new instance of WebAuthnUtil
+func NewWebAuthnUtil(config WebAuthnConfig) *WebAuthnUtil {
+	if config.Timeout <= 0 {
+		config.Timeout = DefaultWebAuthnTimeout
+	}
+	return &WebAuthnUtil{
+		Config: config,
+	}
+}
+
+// WebAuthnCredentialData is the credential extracted from a verified registration
+type WebAuthnCredentialData struct {
+	CredentialID []byte
+	PublicKey    []byte // COSE encoded
+	Algorithm    int64
+	SignCount    uint32
+	AAGUID       []byte
+	UserVerified bool
+}
+
+// WebAuthnAssertionResult is the outcome of a verified assertion
+type WebAuthnAssertionResult struct {
+	SignCount    uint32
+	UserVerified bool
+}
+
+// webAuthnClientData is the subset of CollectedClientData that is verified
+type webAuthnClientData struct {
+	Type      string `json:"type"`
+	Challenge string `json:"challenge"`
+	Origin    string `json:"origin"`
+}
+
+// webAuthnAuthData is the parsed authenticator data
+type webAuthnAuthData struct {
+	RPIDHash     []byte
+	Flags        byte
+	SignCount    uint32
+	AAGUID       []byte
+	CredentialID []byte
+	PublicKey    []byte
+}
+
+// NewChallenge generates a random base64url encoded challenge
+func (w *WebAuthnUtil) NewChallenge() (string, error) {
+	b := make([]byte, WebAuthnChallengeSize)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+// DecodeBase64URL decodes base64url data with or without padding, as sent by browsers
+func DecodeBase64URL(value string) ([]byte, error) {
+	return base64.RawURLEncoding.DecodeString(strings.TrimRight(value, "="))
+}
+
+// ClientDataChallenge extracts the challenge from a clientDataJSON without verifying it,
+// so the caller can look up the stored ceremony before the full verification
+func (w *WebAuthnUtil) ClientDataChallenge(clientDataJSON []byte) (string, error) {
+	var clientData webAuthnClientData
+	if err := json.Unmarshal(clientDataJSON, &clientData); err != nil || clientData.Challenge == "" {
+		return "", ErrWebAuthnInvalidClientData
+	}
+	return clientData.Challenge, nil
+}
+
+// VerifyRegistration verifies the response of navigator.credentials.create()
+func (w *WebAuthnUtil) VerifyRegistration(challenge string, clientDataJSON, attestationObject []byte) (*WebAuthnCredentialData, error) {
+	if err := w.verifyClientData(clientDataJSON, webAuthnTypeCreate, challenge); err != nil {
+		return nil, err
+	}
+
+	decoded, _, err := DecodeCBOR(attestationObject)
+	if err != nil {
+		return nil, ErrWebAuthnInvalidAttestation
+	}
+	attestation, ok := decoded.(map[interface{}]interface{})
+	if !ok {
+		return nil, ErrWebAuthnInvalidAttestation
+	}
+
+	format, _ := attestation["fmt"].(string)
+	statement, _ := attestation["attStmt"].(map[interface{}]interface{})
+	rawAuthData, _ := attestation["authData"].([]byte)
+	if rawAuthData == nil || statement == nil {
+		return nil, ErrWebAuthnInvalidAttestation
+	}
+
+	authData, err := w.parseAuthData(rawAuthData)
+	if err != nil {
+		return nil, err
+	}
+	if authData.Flags&webAuthnFlagAttestedData == 0 || authData.PublicKey == nil {
+		return nil, ErrWebAuthnInvalidAuthData
+	}
+
+	algorithm, publicKey, err := ParseCOSEKey(authData.PublicKey)
+	if err != nil {
+		return nil, err
+	}
+
+	clientDataHash := sha256.Sum256(clientDataJSON)
+	signed := append(append([]byte{}, rawAuthData...), clientDataHash[:]...)
+
+	switch format {
+	case "none":
+		if len(statement) != 0 {
+			return nil, ErrWebAuthnInvalidAttestation
+		}
+	case "packed":
+		if err := verifyPackedAttestation(statement, signed, algorithm, publicKey); err != nil {
+			return nil, err
+		}
+	default:
+		return nil, ErrWebAuthnUnsupportedAttestation
+	}
+
+	return &WebAuthnCredentialData{
+		CredentialID: authData.CredentialID,
+		PublicKey:    authData.PublicKey,
+		Algorithm:    algorithm,
+		SignCount:    authData.SignCount,
+		AAGUID:       authData.AAGUID,
+		UserVerified: authData.Flags&webAuthnFlagUserVerified != 0,
+	}, nil
+}
+
+// VerifyAssertion verifies the response of navigator.credentials.get() against a stored credential
+func (w *WebAuthnUtil) VerifyAssertion(challenge string, storedPublicKey []byte, storedSignCount uint32, clientDataJSON, rawAuthData, signature []byte) (*WebAuthnAssertionResult, error) {
+	if err := w.verifyClientData(clientDataJSON, webAuthnTypeGet, challenge); err != nil {
+		return nil, err
+	}
+
+	authData, err := w.parseAuthData(rawAuthData)
+	if err != nil {
+		return nil, err
+	}
+
+	algorithm, publicKey, err := ParseCOSEKey(storedPublicKey)
+	if err != nil {
+		return nil, err
+	}
+
+	clientDataHash := sha256.Sum256(clientDataJSON)
+	signed := append(append([]byte{}, rawAuthData...), clientDataHash[:]...)
+	if err := verifyWebAuthnSignature(algorithm, publicKey, signed, signature); err != nil {
+		return nil, err
+	}
+
+	// Authenticators that do not implement a counter always report zero
+	if (authData.SignCount != 0 || storedSignCount != 0) && authData.SignCount <= storedSignCount {
+		return nil, ErrWebAuthnSignCount
+	}
+
+	return &WebAuthnAssertionResult{
+		SignCount:    authData.SignCount,
+		UserVerified: authData.Flags&webAuthnFlagUserVerified != 0,
+	}, nil
+}
+
+// VerifyUserHandle checks the base64url user handle of an assertion against the owner of the credential.
+// Only discoverable credentials return a user handle, so an empty one is accepted.
+func (w *WebAuthnUtil) VerifyUserHandle(userHandle string, ownerID []byte) error {
+	if userHandle == "" {
+		return nil
+	}
+
+	decoded, err := DecodeBase64URL(userHandle)
+	if err != nil || !bytes.Equal(decoded, ownerID) {
+		return ErrWebAuthnUserHandle
+	}
+	return nil
+}
+
+// verifyClientData checks the ceremony type, challenge and origin of a clientDataJSON
+func (w *WebAuthnUtil) verifyClientData(clientDataJSON []byte, ceremonyType, challenge string) error {
+	var clientData webAuthnClientData
+	if err := json.Unmarshal(clientDataJSON, &clientData); err != nil {
+		return ErrWebAuthnInvalidClientData
+	}
+
+	if clientData.Type != ceremonyType {
+		return ErrWebAuthnInvalidClientData
+	}
+
+	if subtle.ConstantTimeCompare([]byte(strings.TrimRight(clientData.Challenge, "=")), []byte(challenge)) != 1 {
+		return ErrWebAuthnInvalidClientData
+	}
+
+	for _, origin := range w.Config.RPOrigins {
+		if clientData.Origin == origin {
+			return nil
+		}
+	}
+
+	return ErrWebAuthnInvalidClientData
+}
+
+// parseAuthData parses authenticator data and checks the RP ID hash and user flags
+func (w *WebAuthnUtil) parseAuthData(data []byte) (*webAuthnAuthData, error) {
+	if len(data) < 37 {
+		return nil, ErrWebAuthnInvalidAuthData
+	}
+
+	authData := &webAuthnAuthData{
+		RPIDHash:  data[:32],
+		Flags:     data[32],
+		SignCount: binary.BigEndian.Uint32(data[33:37]),
+	}
+
+	rpIDHash := sha256.Sum256([]byte(w.Config.RPID))
+	if !bytes.Equal(authData.RPIDHash, rpIDHash[:]) {
+		return nil, ErrWebAuthnInvalidAuthData
+	}
+
+	if authData.Flags&webAuthnFlagUserPresent == 0 {
+		return nil, ErrWebAuthnUserNotPresent
+	}
+	if w.Config.RequireUserVerification && authData.Flags&webAuthnFlagUserVerified == 0 {
+		return nil, ErrWebAuthnUserNotVerified
+	}
+
+	if authData.Flags&webAuthnFlagAttestedData != 0 {
+		rest := data[37:]
+		if len(rest) < 18 {
+			return nil, ErrWebAuthnInvalidAuthData
+		}
+		authData.AAGUID = rest[:16]
+
+		idLength := int(binary.BigEndian.Uint16(rest[16:18]))
+		rest = rest[18:]
+		if len(rest) < idLength {
+			return nil, ErrWebAuthnInvalidAuthData
+		}
+		authData.CredentialID = rest[:idLength]
+		rest = rest[idLength:]
+
+		// The public key is a CBOR item, possibly followed by extensions
+		_, keyLength, err := DecodeCBOR(rest)
+		if err != nil {
+			return nil, ErrWebAuthnInvalidAuthData
+		}
+		authData.PublicKey = rest[:keyLength]
+	}
+
+	return authData, nil
+}
+
+// ParseCOSEKey decodes a COSE_Key and returns its algorithm and Go public key
+func ParseCOSEKey(data []byte) (int64, crypto.PublicKey, error) {
+	decoded, _, err := DecodeCBOR(data)
+	if err != nil {
+		return 0, nil, ErrWebAuthnUnsupportedKey
+	}
+	key, ok := decoded.(map[interface{}]interface{})
+	if !ok {
+		return 0, nil, ErrWebAuthnUnsupportedKey
+	}
+
+	keyType, _ := key[int64(1)].(int64)
+	algorithm, _ := key[int64(3)].(int64)
+
+	switch {
+	case keyType == 2 && algorithm == COSEAlgES256:
+		curve, _ := key[int64(-1)].(int64)
+		x, _ := key[int64(-2)].([]byte)
+		y, _ := key[int64(-3)].([]byte)
+		if curve != 1 || len(x) != 32 || len(y) != 32 {
+			return 0, nil, ErrWebAuthnUnsupportedKey
+		}
+		publicKey := &ecdsa.PublicKey{
+			Curve: elliptic.P256(),
+			X:     new(big.Int).SetBytes(x),
+			Y:     new(big.Int).SetBytes(y),
+		}
+		if !publicKey.Curve.IsOnCurve(publicKey.X, publicKey.Y) {
+			return 0, nil, ErrWebAuthnUnsupportedKey
+		}
+		return algorithm, publicKey, nil
+	case keyType == 1 && algorithm == COSEAlgEdDSA:
+		curve, _ := key[int64(-1)].(int64)
+		x, _ := key[int64(-2)].([]byte)
+		if curve != 6 || len(x) != ed25519.PublicKeySize {
+			return 0, nil, ErrWebAuthnUnsupportedKey
+		}
+		return algorithm, ed25519.PublicKey(x), nil
+	case keyType == 3 && algorithm == COSEAlgRS256:
+		n, _ := key[int64(-1)].([]byte)
+		e, _ := key[int64(-2)].([]byte)
+		if len(n) < 256 || len(e) == 0 || len(e) > 4 {
+			return 0, nil, ErrWebAuthnUnsupportedKey
+		}
+		return algorithm, &rsa.PublicKey{
+			N: new(big.Int).SetBytes(n),
+			E: int(new(big.Int).SetBytes(e).Int64()),
+		}, nil
+	default:
+		return 0, nil, ErrWebAuthnUnsupportedKey
+	}
+}
+
+// verifyPackedAttestation verifies a "packed" attestation statement, either self
+// attestation or basic attestation with a certificate (the chain is not checked)
+func verifyPackedAttestation(statement map[interface{}]interface{}, signed []byte, credentialAlgorithm int64, credentialKey crypto.PublicKey) error {
+	algorithm, _ := statement["alg"].(int64)
+	signature, _ := statement["sig"].([]byte)
+	if signature == nil {
+		return ErrWebAuthnInvalidAttestation
+	}
+
+	x5c, hasCertificate := statement["x5c"].([]interface{})
+	if !hasCertificate {
+		// Self attestation is signed by the credential key itself
+		if algorithm != credentialAlgorithm {
+			return ErrWebAuthnInvalidAttestation
+		}
+		if err := verifyWebAuthnSignature(algorithm, credentialKey, signed, signature); err != nil {
+			return ErrWebAuthnInvalidAttestation
+		}
+		return nil
+	}
+
+	if len(x5c) == 0 {
+		return ErrWebAuthnInvalidAttestation
+	}
+	rawCertificate, _ := x5c[0].([]byte)
+	certificate, err := x509.ParseCertificate(rawCertificate)
+	if err != nil {
+		return ErrWebAuthnInvalidAttestation
+	}
+	if err := verifyWebAuthnSignature(algorithm, certificate.PublicKey, signed, signature); err != nil {
+		return ErrWebAuthnInvalidAttestation
+	}
+
+	return nil
+}
+
+// verifyWebAuthnSignature verifies a signature with one of the supported COSE algorithms
+func verifyWebAuthnSignature(algorithm int64, publicKey crypto.PublicKey, signed, signature []byte) error {
+	switch algorithm {
+	case COSEAlgES256:
+		key, ok := publicKey.(*ecdsa.PublicKey)
+		if !ok {
+			return ErrWebAuthnInvalidSignature
+		}
+		digest := sha256.Sum256(signed)
+		if !ecdsa.VerifyASN1(key, digest[:], signature) {
+			return ErrWebAuthnInvalidSignature
+		}
+	case COSEAlgEdDSA:
+		key, ok := publicKey.(ed25519.PublicKey)
+		if !ok || !ed25519.Verify(key, signed, signature) {
+			return ErrWebAuthnInvalidSignature
+		}
+	case COSEAlgRS256:
+		key, ok := publicKey.(*rsa.PublicKey)
+		if !ok {
+			return ErrWebAuthnInvalidSignature
+		}
+		digest := sha256.Sum256(signed)
+		if err := rsa.VerifyPKCS1v15(key, crypto.SHA256, digest[:], signature); err != nil {
+			return ErrWebAuthnInvalidSignature
+		}
+	default:
+		return ErrWebAuthnUnsupportedKey
+	}
+
+	return nil
+}
@@ -0,0 +1,423 @@
+package utils
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"testing"
+)
+
+const (
+	testRPID   = "aurora.example"
+	testOrigin = "https://app.aurora.example"
+)
+
+// softAuthenticator is a software authenticator with an ES256 credential, used to build
+// registration and assertion responses the way a browser would send them
+type softAuthenticator struct {
+	key          *ecdsa.PrivateKey
+	credentialID []byte
+	aaguid       []byte
+}
+
+func newSoftAuthenticator(t *testing.T) *softAuthenticator {
+	t.Helper()
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	credentialID := make([]byte, 16)
+	if _, err := rand.Read(credentialID); err != nil {
+		t.Fatal(err)
+	}
+	return &softAuthenticator{key: key, credentialID: credentialID, aaguid: make([]byte, 16)}
+}
+
+// coseKey encodes the credential public key as an EC2 COSE_Key
+func (a *softAuthenticator) coseKey() []byte {
+	x := make([]byte, 32)
+	y := make([]byte, 32)
+	a.key.PublicKey.X.FillBytes(x)
+	a.key.PublicKey.Y.FillBytes(y)
+
+	return encodeCBOR([]cborPair{
+		{1, 2},
+		{3, COSEAlgES256},
+		{-1, 1},
+		{-2, x},
+		{-3, y},
+	})
+}
+
+// authData builds the authenticator data; the attested credential is included when attested is set
+func (a *softAuthenticator) authData(rpID string, flags byte, signCount uint32, attested bool) []byte {
+	rpIDHash := sha256.Sum256([]byte(rpID))
+
+	data := append([]byte{}, rpIDHash[:]...)
+	if attested {
+		flags |= webAuthnFlagAttestedData
+	}
+	data = append(data, flags)
+	data = binary.BigEndian.AppendUint32(data, signCount)
+
+	if attested {
+		data = append(data, a.aaguid...)
+		data = binary.BigEndian.AppendUint16(data, uint16(len(a.credentialID)))
+		data = append(data, a.credentialID...)
+		data = append(data, a.coseKey()...)
+	}
+	return data
+}
+
+// sign signs the authenticator data and the client data hash, as in both ceremonies
+func (a *softAuthenticator) sign(t *testing.T, authData, clientDataJSON []byte) []byte {
+	t.Helper()
+
+	clientDataHash := sha256.Sum256(clientDataJSON)
+	digest := sha256.Sum256(append(append([]byte{}, authData...), clientDataHash[:]...))
+	signature, err := ecdsa.SignASN1(rand.Reader, a.key, digest[:])
+	if err != nil {
+		t.Fatal(err)
+	}
+	return signature
+}
+
+// testClientData builds a clientDataJSON
+func testClientData(ceremonyType, challenge, origin string) []byte {
+	data, _ := json.Marshal(map[string]interface{}{
+		"type":        ceremonyType,
+		"challenge":   challenge,
+		"origin":      origin,
+		"crossOrigin": false,
+	})
+	return data
+}
+
+func newTestWebAuthnUtil(requireUserVerification bool) *WebAuthnUtil {
+	return NewWebAuthnUtil(WebAuthnConfig{
+		RPID:                    testRPID,
+		RPName:                  "Aurora",
+		RPOrigins:               []string{testOrigin},
+		RequireUserVerification: requireUserVerification,
+	})
+}
+
+func TestVerifyRegistration(t *testing.T) {
+	authenticator := newSoftAuthenticator(t)
+	w := newTestWebAuthnUtil(false)
+
+	challenge, err := w.NewChallenge()
+	if err != nil {
+		t.Fatal(err)
+	}
+	otherChallenge, err := w.NewChallenge()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	type registration struct {
+		clientData []byte
+		authData   []byte
+		format     string
+		statement  func(authData, clientData []byte) []cborPair
+	}
+
+	valid := func() registration {
+		return registration{
+			clientData: testClientData(webAuthnTypeCreate, challenge, testOrigin),
+			authData:   authenticator.authData(testRPID, webAuthnFlagUserPresent|webAuthnFlagUserVerified, 0, true),
+			format:     "none",
+		}
+	}
+	packedSelf := func(authData, clientData []byte) []cborPair {
+		return []cborPair{{"alg", COSEAlgES256}, {"sig", authenticator.sign(t, authData, clientData)}}
+	}
+
+	tests := []struct {
+		name    string
+		modify  func(r *registration)
+		require bool
+		wantErr error
+	}{
+		{"none attestation", func(r *registration) {}, false, nil},
+		{"packed self attestation", func(r *registration) { r.format = "packed"; r.statement = packedSelf }, false, nil},
+		{"wrong rpIdHash", func(r *registration) {
+			r.authData = authenticator.authData("evil.example", webAuthnFlagUserPresent, 0, true)
+		}, false, ErrWebAuthnInvalidAuthData},
+		{"wrong origin", func(r *registration) {
+			r.clientData = testClientData(webAuthnTypeCreate, challenge, "https://evil.example")
+		}, false, ErrWebAuthnInvalidClientData},
+		{"wrong challenge", func(r *registration) {
+			r.clientData = testClientData(webAuthnTypeCreate, otherChallenge, testOrigin)
+		}, false, ErrWebAuthnInvalidClientData},
+		{"assertion client data", func(r *registration) {
+			r.clientData = testClientData(webAuthnTypeGet, challenge, testOrigin)
+		}, false, ErrWebAuthnInvalidClientData},
+		{"user not present", func(r *registration) {
+			r.authData = authenticator.authData(testRPID, 0, 0, true)
+		}, false, ErrWebAuthnUserNotPresent},
+		{"user not verified", func(r *registration) {
+			r.authData = authenticator.authData(testRPID, webAuthnFlagUserPresent, 0, true)
+		}, true, ErrWebAuthnUserNotVerified},
+		{"no attested credential", func(r *registration) {
+			r.authData = authenticator.authData(testRPID, webAuthnFlagUserPresent, 0, false)
+		}, false, ErrWebAuthnInvalidAuthData},
+		{"packed signature over other client data", func(r *registration) {
+			r.format = "packed"
+			r.statement = func(authData, _ []byte) []cborPair {
+				return packedSelf(authData, testClientData(webAuthnTypeCreate, otherChallenge, testOrigin))
+			}
+		}, false, ErrWebAuthnInvalidAttestation},
+		{"none with statement", func(r *registration) {
+			r.statement = func(authData, clientData []byte) []cborPair { return []cborPair{{"alg", COSEAlgES256}} }
+		}, false, ErrWebAuthnInvalidAttestation},
+		{"unsupported format", func(r *registration) { r.format = "fido-u2f" }, false, ErrWebAuthnUnsupportedAttestation},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := valid()
+			tt.modify(&r)
+
+			statement := []cborPair{}
+			if r.statement != nil {
+				statement = r.statement(r.authData, r.clientData)
+			}
+			attestationObject := encodeCBOR([]cborPair{
+				{"fmt", r.format},
+				{"attStmt", statement},
+				{"authData", r.authData},
+			})
+
+			credential, err := newTestWebAuthnUtil(tt.require).VerifyRegistration(challenge, r.clientData, attestationObject)
+			if err != tt.wantErr {
+				t.Fatalf("VerifyRegistration = %v, want %v", err, tt.wantErr)
+			}
+			if err != nil {
+				return
+			}
+
+			if string(credential.CredentialID) != string(authenticator.credentialID) {
+				t.Fatal("credential ID does not match the authenticator")
+			}
+			if credential.Algorithm != COSEAlgES256 || string(credential.PublicKey) != string(authenticator.coseKey()) {
+				t.Fatal("credential public key does not match the authenticator")
+			}
+			if !credential.UserVerified {
+				t.Fatal("user verification flag was lost")
+			}
+		})
+	}
+}
+
+func TestVerifyRegistrationRejectsMalformedAttestation(t *testing.T) {
+	w := newTestWebAuthnUtil(false)
+	challenge, _ := w.NewChallenge()
+	clientData := testClientData(webAuthnTypeCreate, challenge, testOrigin)
+
+	for name, attestationObject := range map[string][]byte{
+		"not CBOR":         {0xff},
+		"not a map":        encodeCBOR([]interface{}{1}),
+		"missing authData": encodeCBOR([]cborPair{{"fmt", "none"}, {"attStmt", []cborPair{}}}),
+		"short authData":   encodeCBOR([]cborPair{{"fmt", "none"}, {"attStmt", []cborPair{}}, {"authData", []byte{1, 2, 3}}}),
+	} {
+		if _, err := w.VerifyRegistration(challenge, clientData, attestationObject); err == nil {
+			t.Errorf("%s: registration accepted", name)
+		}
+	}
+}
+
+func TestVerifyAssertion(t *testing.T) {
+	authenticator := newSoftAuthenticator(t)
+	w := newTestWebAuthnUtil(false)
+
+	challenge, err := w.NewChallenge()
+	if err != nil {
+		t.Fatal(err)
+	}
+	otherChallenge, err := w.NewChallenge()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	type assertion struct {
+		clientData      []byte
+		authData        []byte
+		signature       []byte
+		storedSignCount uint32
+	}
+
+	build := func(clientData, authData []byte, storedSignCount uint32) assertion {
+		return assertion{
+			clientData:      clientData,
+			authData:        authData,
+			signature:       authenticator.sign(t, authData, clientData),
+			storedSignCount: storedSignCount,
+		}
+	}
+	validClientData := testClientData(webAuthnTypeGet, challenge, testOrigin)
+	validAuthData := authenticator.authData(testRPID, webAuthnFlagUserPresent, 11, false)
+
+	tests := []struct {
+		name          string
+		assertion     assertion
+		wantErr       error
+		wantSignCount uint32
+	}{
+		{"valid", build(validClientData, validAuthData, 10), nil, 11},
+		{"authenticator without counter", build(validClientData, authenticator.authData(testRPID, webAuthnFlagUserPresent, 0, false), 0), nil, 0},
+		{"wrong rpIdHash", build(validClientData, authenticator.authData("evil.example", webAuthnFlagUserPresent, 11, false), 10), ErrWebAuthnInvalidAuthData, 0},
+		{"wrong origin", build(testClientData(webAuthnTypeGet, challenge, "https://evil.example"), validAuthData, 10), ErrWebAuthnInvalidClientData, 0},
+		{"origin of another scheme", build(testClientData(webAuthnTypeGet, challenge, "http://app.aurora.example"), validAuthData, 10), ErrWebAuthnInvalidClientData, 0},
+		// An assertion signed for an earlier ceremony cannot answer a new challenge
+		{"challenge of another ceremony", build(testClientData(webAuthnTypeGet, otherChallenge, testOrigin), validAuthData, 10), ErrWebAuthnInvalidClientData, 0},
+		{"registration client data", build(testClientData(webAuthnTypeCreate, challenge, testOrigin), validAuthData, 10), ErrWebAuthnInvalidClientData, 0},
+		{"sign count regression", build(validClientData, authenticator.authData(testRPID, webAuthnFlagUserPresent, 5, false), 10), ErrWebAuthnSignCount, 0},
+		{"sign count not increased", build(validClientData, authenticator.authData(testRPID, webAuthnFlagUserPresent, 10, false), 10), ErrWebAuthnSignCount, 0},
+		{"counter reset to zero", build(validClientData, authenticator.authData(testRPID, webAuthnFlagUserPresent, 0, false), 10), ErrWebAuthnSignCount, 0},
+		{"user not present", build(validClientData, authenticator.authData(testRPID, 0, 11, false), 10), ErrWebAuthnUserNotPresent, 0},
+		{"tampered authenticator data", func() assertion {
+			a := build(validClientData, validAuthData, 10)
+			a.authData = authenticator.authData(testRPID, webAuthnFlagUserPresent|webAuthnFlagUserVerified, 11, false)
+			return a
+		}(), ErrWebAuthnInvalidSignature, 0},
+		{"tampered client data", func() assertion {
+			a := build(validClientData, validAuthData, 10)
+			a.clientData = testClientData(webAuthnTypeGet, challenge, testOrigin+"/")
+			return a
+		}(), ErrWebAuthnInvalidClientData, 0},
+		{"signature of another key", func() assertion {
+			a := build(validClientData, validAuthData, 10)
+			a.signature = newSoftAuthenticator(t).sign(t, validAuthData, validClientData)
+			return a
+		}(), ErrWebAuthnInvalidSignature, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			a := tt.assertion
+			result, err := w.VerifyAssertion(challenge, authenticator.coseKey(), a.storedSignCount, a.clientData, a.authData, a.signature)
+			if err != tt.wantErr {
+				t.Fatalf("VerifyAssertion = %v, want %v", err, tt.wantErr)
+			}
+			if err == nil && result.SignCount != tt.wantSignCount {
+				t.Fatalf("sign count = %d, want %d", result.SignCount, tt.wantSignCount)
+			}
+		})
+	}
+}
+
+func TestVerifyAssertionRequiresUserVerification(t *testing.T) {
+	authenticator := newSoftAuthenticator(t)
+	w := newTestWebAuthnUtil(true)
+	challenge, _ := w.NewChallenge()
+	clientData := testClientData(webAuthnTypeGet, challenge, testOrigin)
+
+	authData := authenticator.authData(testRPID, webAuthnFlagUserPresent, 1, false)
+	_, err := w.VerifyAssertion(challenge, authenticator.coseKey(), 0, clientData, authData, authenticator.sign(t, authData, clientData))
+	if err != ErrWebAuthnUserNotVerified {
+		t.Fatalf("VerifyAssertion = %v, want ErrWebAuthnUserNotVerified", err)
+	}
+
+	authData = authenticator.authData(testRPID, webAuthnFlagUserPresent|webAuthnFlagUserVerified, 1, false)
+	result, err := w.VerifyAssertion(challenge, authenticator.coseKey(), 0, clientData, authData, authenticator.sign(t, authData, clientData))
+	if err != nil || !result.UserVerified {
+		t.Fatalf("VerifyAssertion = %+v, %v; want a verified user", result, err)
+	}
+}
+
+// TestVerifyAssertionReplay replays an accepted assertion. The challenge itself is single use through
+// WebAuthnRepository.ConsumeChallenge; here the stored counter rejects the replay on its own.
+func TestVerifyAssertionReplay(t *testing.T) {
+	authenticator := newSoftAuthenticator(t)
+	w := newTestWebAuthnUtil(false)
+	challenge, _ := w.NewChallenge()
+	clientData := testClientData(webAuthnTypeGet, challenge, testOrigin)
+
+	authData := authenticator.authData(testRPID, webAuthnFlagUserPresent, 1, false)
+	signature := authenticator.sign(t, authData, clientData)
+
+	result, err := w.VerifyAssertion(challenge, authenticator.coseKey(), 0, clientData, authData, signature)
+	if err != nil {
+		t.Fatalf("first assertion: %v", err)
+	}
+
+	if _, err := w.VerifyAssertion(challenge, authenticator.coseKey(), result.SignCount, clientData, authData, signature); err != ErrWebAuthnSignCount {
+		t.Fatalf("replayed assertion = %v, want ErrWebAuthnSignCount", err)
+	}
+}
+
+func TestClientDataChallenge(t *testing.T) {
+	w := newTestWebAuthnUtil(false)
+
+	challenge, err := w.ClientDataChallenge(testClientData(webAuthnTypeGet, "abc", testOrigin))
+	if err != nil || challenge != "abc" {
+		t.Fatalf("ClientDataChallenge = %q, %v", challenge, err)
+	}
+
+	for name, clientData := range map[string][]byte{
+		"not JSON":          []byte("{"),
+		"missing challenge": []byte(`{"type":"webauthn.get","origin":"` + testOrigin + `"}`),
+	} {
+		if _, err := w.ClientDataChallenge(clientData); err != ErrWebAuthnInvalidClientData {
+			t.Errorf("%s: ClientDataChallenge = %v, want ErrWebAuthnInvalidClientData", name, err)
+		}
+	}
+}
+
+func TestVerifyUserHandle(t *testing.T) {
+	w := newTestWebAuthnUtil(false)
+	ownerID := []byte("0123456789abcdef")
+	otherID := []byte("fedcba9876543210")
+
+	tests := []struct {
+		name       string
+		userHandle string
+		wantErr    error
+	}{
+		{"owner", base64.RawURLEncoding.EncodeToString(ownerID), nil},
+		{"owner with padding", base64.URLEncoding.EncodeToString(ownerID), nil},
+		{"omitted by non-discoverable credential", "", nil},
+		{"another user", base64.RawURLEncoding.EncodeToString(otherID), ErrWebAuthnUserHandle},
+		{"prefix of the owner", base64.RawURLEncoding.EncodeToString(ownerID[:8]), ErrWebAuthnUserHandle},
+		{"not base64url", "not base64!", ErrWebAuthnUserHandle},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := w.VerifyUserHandle(tt.userHandle, ownerID); err != tt.wantErr {
+				t.Fatalf("VerifyUserHandle = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestParseCOSEKeyRejectsInvalidKeys(t *testing.T) {
+	authenticator := newSoftAuthenticator(t)
+	x := make([]byte, 32)
+	authenticator.key.PublicKey.X.FillBytes(x)
+
+	for name, key := range map[string][]byte{
+		"not CBOR":          {0xff},
+		"not a map":         encodeCBOR([]interface{}{1}),
+		"unknown algorithm": encodeCBOR([]cborPair{{1, 2}, {3, -35}, {-1, 1}, {-2, x}, {-3, x}}),
+		"wrong curve":       encodeCBOR([]cborPair{{1, 2}, {3, COSEAlgES256}, {-1, 2}, {-2, x}, {-3, x}}),
+		"point off curve":   encodeCBOR([]cborPair{{1, 2}, {3, COSEAlgES256}, {-1, 1}, {-2, x}, {-3, x}}),
+		"short coordinate":  encodeCBOR([]cborPair{{1, 2}, {3, COSEAlgES256}, {-1, 1}, {-2, x[:31]}, {-3, x}}),
+		"short RSA modulus": encodeCBOR([]cborPair{{1, 3}, {3, COSEAlgRS256}, {-1, make([]byte, 128)}, {-2, []byte{1, 0, 1}}}),
+	} {
+		if _, _, err := ParseCOSEKey(key); err != ErrWebAuthnUnsupportedKey {
+			t.Errorf("%s: ParseCOSEKey = %v, want ErrWebAuthnUnsupportedKey", name, err)
+		}
+	}
+
+	algorithm, _, err := ParseCOSEKey(authenticator.coseKey())
+	if err != nil || algorithm != COSEAlgES256 {
+		t.Fatalf("ParseCOSEKey = %d, %v", algorithm, err)
+	}
+}